@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/dap"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+func main() {
+
+	var libPath string
+	flag.StringVar(&libPath, "lib", "", "library search path, separated by the OS path list separator (default the directory of the program)")
+
+	flag.Parse()
+
+	var rtOpts []builtins.OptionRuntime
+	if libPath != "" {
+		rtOpts = append(rtOpts, builtins.WithLibraryPath(filepath.SplitList(libPath)...))
+	}
+
+	// stdout carries the protocol; errors are logged to stderr
+	server := dap.NewServer(os.Stdin, os.Stdout, rtOpts...)
+	if err := server.Serve(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+}
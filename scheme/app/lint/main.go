@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/lint"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+var (
+	jsonOutput = flag.Bool("json", false, "print the findings as a JSON array")
+)
+
+// exitCode is set to 1 once a finding was reported, and to 2 once an error was.
+var exitCode = 0
+
+func main() {
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: lint [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	globals := builtins.NewRuntime()
+	findings := []lint.Finding{}
+	check := func(filename string, in io.Reader) {
+		found, err := lint.Source(in, filename, globals)
+		if err != nil {
+			report(fmt.Errorf("%s: %w", filename, err))
+			return
+		}
+		findings = append(findings, found...)
+	}
+
+	if flag.NArg() == 0 {
+		check("<standard input>", os.Stdin)
+	}
+	for _, path := range flag.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			report(err)
+			continue
+		}
+		if !info.IsDir() {
+			checkFile(path, check)
+			continue
+		}
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				report(err)
+				return nil
+			}
+			if !d.IsDir() && filepath.Ext(path) == ".scm" {
+				checkFile(path, check)
+			}
+			return nil
+		})
+		if err != nil {
+			report(err)
+		}
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(findings); err != nil {
+			report(err)
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	}
+	if len(findings) > 0 && exitCode == 0 {
+		exitCode = 1
+	}
+	os.Exit(exitCode)
+
+}
+
+func checkFile(path string, check func(string, io.Reader)) {
+	f, err := os.Open(path)
+	if err != nil {
+		report(err)
+		return
+	}
+	defer f.Close()
+	check(path, f)
+}
+
+func report(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	exitCode = 2
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/lsp"
+)
+
+func main() {
+
+	// stdout carries the protocol; errors are logged to stderr
+	server := lsp.NewServer(os.Stdin, os.Stdout)
+	if err := server.Serve(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+}
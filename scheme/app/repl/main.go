@@ -5,6 +5,7 @@ import (
 	"flag"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/lexer"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser"
@@ -17,12 +18,18 @@ func main() {
 		srcPath    string
 		dstPath    string
 		prompt     string
+		libPath    string
 		debugLevel int
+		bytecode   bool
+		analysis   bool
 	)
 	flag.StringVar(&srcPath, "src", "", "source file")
 	flag.StringVar(&dstPath, "dst", "", "destination file")
 	flag.StringVar(&prompt, "prompt", "go-scheme> ", "prompt")
+	flag.StringVar(&libPath, "lib", ".", "library search path, separated by the OS path list separator")
 	flag.IntVar(&debugLevel, "debug", int(parser.Info), "debug level")
+	flag.BoolVar(&bytecode, "bytecode", false, "compile expressions to bytecode run by a virtual machine")
+	flag.BoolVar(&analysis, "analyze", false, "analyze expressions once, resolving their variables, before evaluating them")
 
 	flag.Parse()
 
@@ -52,8 +59,12 @@ func main() {
 		lexer.New(in),
 		parser.WithPrompt(prompt),
 		parser.WithShowExpressionCount(true),
+		parser.WithBytecode(bytecode),
+		parser.WithAnalysis(analysis),
 		parser.WithVerbose(parser.VerboseLevel(debugLevel)))
 
-	p.Repl(builtins.WithEvaluatorCallback(parser.DefaultExpressionEvaluator()))
+	p.Repl(
+		builtins.WithEvaluatorCallback(parser.DefaultExpressionEvaluator()),
+		builtins.WithLibraryPath(filepath.SplitList(libPath)...))
 
 }
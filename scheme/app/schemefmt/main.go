@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/pretty"
+)
+
+var (
+	write = flag.Bool("w", false, "write the result to the source file instead of stdout")
+	diff  = flag.Bool("d", false, "display diffs instead of rewriting files")
+	list  = flag.Bool("l", false, "list files whose formatting differs from schemefmt's")
+)
+
+// exitCode is set to 2 once an error was reported.
+var exitCode = 0
+
+func main() {
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: schemefmt [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "schemefmt: cannot use -w with standard input")
+			os.Exit(2)
+		}
+		if err := process("<standard input>", os.Stdin, os.Stdout); err != nil {
+			report(err)
+		}
+		os.Exit(exitCode)
+	}
+
+	for _, path := range flag.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			report(err)
+			continue
+		}
+		if !info.IsDir() {
+			processFile(path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				report(err)
+				return nil
+			}
+			if !d.IsDir() && filepath.Ext(path) == ".scm" {
+				processFile(path)
+			}
+			return nil
+		})
+		if err != nil {
+			report(err)
+		}
+	}
+	os.Exit(exitCode)
+
+}
+
+func processFile(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		report(err)
+		return
+	}
+	defer f.Close()
+	if err := process(path, f, os.Stdout); err != nil {
+		report(err)
+	}
+}
+
+// process formats the source read from in, named filename, and writes the result, its
+// diff or its name to out, or rewrites the file, as the flags select.
+func process(filename string, in io.Reader, out io.Writer) error {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	res, err := pretty.Source(src)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	changed := !bytes.Equal(src, res)
+	if *list && changed {
+		fmt.Fprintln(out, filename)
+	}
+	if *write && changed {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filename, res, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if *diff && changed {
+		_, err := out.Write(pretty.Diff(filename+".orig", src, filename, res))
+		return err
+	}
+	if !*list && !*write && !*diff {
+		_, err := out.Write(res)
+		return err
+	}
+	return nil
+}
+
+func report(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	exitCode = 2
+}
@@ -0,0 +1,810 @@
+// Package analyze converts Scheme expressions once into trees of Go closures, in the
+// manner of the analyze procedure of SICP, so that evaluating them again, as the body of
+// a procedure is, does not repeat the work of the tree-walking evaluator of the parser.
+//
+// The analysis resolves the variables bound by lambda, let and internal definitions to
+// slots of frames, addressed by the number of frames out and the slot within, so that
+// local variables are not looked up by name. Globals are looked up in the environment the
+// expression was analyzed for, and cached until a binding of it changes. Literals and
+// quotations are converted once, an if or cond whose test is a literal keeps the branch
+// taken only, and calls of arithmetic and comparison builtins with literal arguments are
+// folded to their value, which is used while the builtins are bound to their names.
+//
+// The special forms quote, if, define, set!, lambda, begin, let and cond are analyzed.
+// Other special forms are evaluated by the evaluator of the runtime when they neither
+// refer to local variables nor define names within a body; Analyze returns
+// ErrNotAnalyzable for expressions holding any other form, which are left to the
+// evaluator. Analyzed code keeps proper tail calls, records its calls on the call stack
+// of its runtime and is accounted for by its step and depth limits, but does not run the
+// hook of the runtime, so a debugger does not stop in it.
+package analyze
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"text/scanner"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+var (
+	ErrNotAnalyzable = errors.New("not analyzable")
+)
+
+// exec evaluates an analyzed expression in the frame of a call.
+type exec func(f *frame) (values.Interface, error)
+
+// node is an analyzed expression. value is its value when it is known before it runs:
+// that of a literal or quotation, which always holds, or that of a folded call, which
+// holds while the globals indexed by guards are bound to their builtins.
+type node struct {
+	exec   exec
+	value  values.Interface
+	guards []int
+}
+
+// constant reports whether the expression always evaluates to its known value, without
+// effects.
+func (n node) constant() bool {
+	return n.value != nil && len(n.guards) == 0
+}
+
+func constant(v values.Interface) node {
+	return node{exec: func(*frame) (values.Interface, error) { return v, nil }, value: v}
+}
+
+// layout names the slots of the frames of a procedure, or of an expression analyzed at top level.
+type layout struct {
+	slots []string
+	top   bool
+}
+
+func (l *layout) slot(name string) int {
+	l.slots = append(l.slots, name)
+	return len(l.slots) - 1
+}
+
+// scope is a region of analyzed code in which names are bound to slots of the frames of
+// frame, together with the scope enclosing it.
+type scope struct {
+	parent *scope
+	frame  *layout
+	names  map[string]int
+}
+
+func newScope(parent *scope, frame *layout) *scope {
+	return &scope{parent: parent, frame: frame, names: make(map[string]int)}
+}
+
+// resolve returns the frame, counted outwards from that of sc, and the slot of the
+// local variable name, or false for a global.
+func (sc *scope) resolve(name string) (depth, slot int, ok bool) {
+	for s := sc; s != nil; s = s.parent {
+		if slot, ok := s.names[name]; ok {
+			return depth, slot, true
+		}
+		if s.parent != nil && s.parent.frame != s.frame {
+			depth++
+		}
+	}
+	return 0, 0, false
+}
+
+// top reports whether sc is the outermost scope of an expression, where definitions are global.
+func (sc *scope) top() bool {
+	return sc.parent == nil && sc.frame.top
+}
+
+// analyzer converts expressions, resolving special forms against the global environment
+// of its runtime.
+type analyzer struct {
+	rt *builtins.Runtime
+	// names are the globals referred to by the code analyzed, indexed by its closures
+	names []string
+}
+
+func (a *analyzer) global(name string) int {
+	if i := slices.Index(a.names, name); i >= 0 {
+		return i
+	}
+	a.names = append(a.names, name)
+	return len(a.names) - 1
+}
+
+// notAnalyzable reports a form the analyzer does not convert.
+func notAnalyzable(form values.Interface) error {
+	return fmt.Errorf("%w: %s", ErrNotAnalyzable, form.WriteString())
+}
+
+func symbolName(v values.Interface) (string, bool) {
+	switch sym := v.(type) {
+	case values.Operator:
+		return sym.GetName(), true
+	case values.Identifier:
+		return sym.GetName(), true
+	}
+	return "", false
+}
+
+// special returns the name of the special form expr is an application of, or "" when
+// it is not one. A special form shadowed by a local variable is not special.
+func (a *analyzer) special(expr values.Interface, sc *scope) string {
+	pair, ok := expr.(values.Pair)
+	if !ok {
+		return ""
+	}
+	name, ok := symbolName(pair.Car())
+	if !ok {
+		return ""
+	}
+	if _, _, ok := sc.resolve(name); ok {
+		return ""
+	}
+	if v, ok := a.rt.Env.Lookup(name); ok {
+		if syntax, ok := v.(builtins.Syntax); ok {
+			return syntax.Name
+		}
+	}
+	return ""
+}
+
+// analyze converts expr for evaluation in sc. A call in tail position of a procedure
+// body returns a tail call for the procedure to make instead of making it.
+func (a *analyzer) analyze(expr values.Interface, sc *scope, tail bool) (node, error) {
+	switch v := expr.(type) {
+	case values.Operator:
+		return a.reference(v.GetName(), true, sc), nil
+	case values.Identifier:
+		return a.reference(v.GetName(), false, sc), nil
+	case values.Pair:
+		return a.analyzePair(v, sc, tail)
+	}
+	// literals evaluate to themselves
+	return constant(expr), nil
+}
+
+// reference converts a reference to the variable name, which must be bound to a
+// procedure when it is an operator.
+func (a *analyzer) reference(name string, operator bool, sc *scope) node {
+	if depth, slot, ok := sc.resolve(name); ok {
+		return node{exec: func(f *frame) (values.Interface, error) {
+			for range depth {
+				f = f.parent
+			}
+			v := f.slots[slot]
+			if v == nil {
+				// an internal definition referred to before it is evaluated
+				return nil, fmt.Errorf("%w: %s", builtins.ErrUndefinedIdent, name)
+			}
+			return v, nil
+		}}
+	}
+	i := a.global(name)
+	return node{exec: func(f *frame) (values.Interface, error) {
+		b, ok := f.globals.lookup(i)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", builtins.ErrUndefinedIdent, name)
+		}
+		if _, ok := b.value.(builtins.Lambda); operator && !ok {
+			return nil, builtins.ErrOperatorIsNotAProcedure
+		}
+		return b.value, nil
+	}}
+}
+
+func (a *analyzer) analyzePair(form values.Pair, sc *scope, tail bool) (node, error) {
+	operands, err := values.ToSlice(form.Cdr())
+	if err != nil {
+		return node{}, notAnalyzable(form)
+	}
+	switch name := a.special(form, sc); name {
+	case "":
+		return a.analyzeCall(form, operands, sc, tail)
+	case "quote":
+		if len(operands) != 1 {
+			return node{}, notAnalyzable(form)
+		}
+		return constant(values.NewQuot(operands[0])), nil
+	case "if":
+		return a.analyzeIf(form, operands, sc, tail)
+	case "define":
+		return a.analyzeDefine(form, operands, sc)
+	case "set!":
+		return a.analyzeSet(form, operands, sc)
+	case "lambda":
+		if len(operands) < 2 {
+			return node{}, notAnalyzable(form)
+		}
+		return a.analyzeLambda("", form, operands[0], operands[1:], sc)
+	case "begin":
+		if len(operands) == 0 {
+			return constant(values.NewVoidType()), nil
+		}
+		return a.analyzeSequence(operands, sc, tail)
+	case "let":
+		return a.analyzeLet(form, operands, sc, tail)
+	case "cond":
+		return a.analyzeCond(form, operands, sc, tail)
+	default:
+		return a.delegate(form, name, sc)
+	}
+}
+
+// definitions are the special forms binding names in the environment they are evaluated in.
+var definitions = map[string]bool{
+	"define":             true,
+	"define-record-type": true,
+	"define-library":     true,
+	"import":             true,
+}
+
+// delegate converts a special form the analyzer does not convert to its evaluation by
+// the evaluator of the runtime. That evaluation sees the global environment only, so the
+// form may neither refer to local variables nor define names within a body.
+func (a *analyzer) delegate(form values.Interface, name string, sc *scope) (node, error) {
+	if !sc.top() && (definitions[name] || a.refersToLocals(form, sc)) {
+		return node{}, notAnalyzable(form)
+	}
+	return node{exec: func(f *frame) (values.Interface, error) {
+		return f.rt.WithEnvironment(f.globals.env).Evaluate(form)
+	}}, nil
+}
+
+// refersToLocals reports whether expr, including its quoted data, holds a symbol naming
+// a local variable of sc.
+func (a *analyzer) refersToLocals(expr values.Interface, sc *scope) bool {
+	for expr.Type() == types.Pair {
+		if a.refersToLocals(values.Car(expr), sc) {
+			return true
+		}
+		expr = values.Cdr(expr)
+	}
+	if name, ok := symbolName(expr); ok {
+		_, _, local := sc.resolve(name)
+		return local
+	}
+	if v, ok := expr.(values.Vector); ok {
+		for _, item := range v.Items() {
+			if a.refersToLocals(item, sc) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// foldable are the builtins whose calls with literal arguments are folded. They have no
+// effects, and their value depends on their arguments only.
+var foldable = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true,
+	"<": true, "<=": true, ">": true, ">=": true, "=": true,
+	"not": true,
+}
+
+// analyzeCall converts the call of the procedure form applies to operands.
+func (a *analyzer) analyzeCall(form values.Pair, operands []values.Interface, sc *scope, tail bool) (node, error) {
+	operator, err := a.analyze(form.Car(), sc, false)
+	if err != nil {
+		return node{}, err
+	}
+	args := make([]node, len(operands))
+	for i, operand := range operands {
+		if args[i], err = a.analyze(operand, sc, false); err != nil {
+			return node{}, err
+		}
+	}
+	pos, _ := values.SourcePosition(form)
+	n := node{exec: call(operator.exec, execs(args), pos, tail)}
+	if name, ok := symbolName(form.Car()); ok && builtins.PrimitiveCall(name, len(args)) {
+		if _, _, local := sc.resolve(name); !local {
+			n.exec = primitiveCall(a.global(name), execs(args), pos, tail)
+		}
+	}
+	if folded, ok := a.fold(form, args, sc); ok {
+		guards := folded.guards
+		n.value, n.guards = folded.value, guards
+		unfolded := n.exec
+		n.exec = func(f *frame) (values.Interface, error) {
+			for _, i := range guards {
+				if b, ok := f.globals.lookup(i); !ok || !b.builtin {
+					return unfolded(f)
+				}
+			}
+			return folded.value, nil
+		}
+	}
+	return n, nil
+}
+
+// fold returns the value of the call form of a foldable builtin with args, when their
+// values are known numbers or booleans, guarded by the builtin and those of the folded
+// calls among args.
+func (a *analyzer) fold(form values.Pair, args []node, sc *scope) (node, bool) {
+	name, ok := symbolName(form.Car())
+	if !ok || !foldable[name] {
+		return node{}, false
+	}
+	if _, _, local := sc.resolve(name); local {
+		return node{}, false
+	}
+	proc, ok := a.rt.Env.Lookup(name)
+	if !ok || !builtins.IsBuiltin(name, proc) {
+		return node{}, false
+	}
+	guards := []int{a.global(name)}
+	known := make([]values.Interface, len(args))
+	for i, arg := range args {
+		if arg.value == nil {
+			return node{}, false
+		}
+		v := values.Unquote(arg.value)
+		if !builtins.ArithmeticAllowedGate(v.Type()) && v.Type() != types.Bool {
+			return node{}, false
+		}
+		known[i] = v
+		guards = append(guards, arg.guards...)
+	}
+	v, err := a.rt.Trampoline(proc.(builtins.Procedure).Call(values.List(known...), a.rt))
+	if err != nil {
+		// the error is left to be raised when the call is made
+		return node{}, false
+	}
+	return node{value: v, guards: guards}, true
+}
+
+func execs(nodes []node) []exec {
+	execs := make([]exec, len(nodes))
+	for i, n := range nodes {
+		execs[i] = n.exec
+	}
+	return execs
+}
+
+// analyzeIf converts (if test consequent [alternate]).
+func (a *analyzer) analyzeIf(form values.Interface, operands []values.Interface, sc *scope, tail bool) (node, error) {
+	if len(operands) < 2 || len(operands) > 3 {
+		return node{}, notAnalyzable(form)
+	}
+	test, err := a.analyze(operands[0], sc, false)
+	if err != nil {
+		return node{}, err
+	}
+	consequent, err := a.analyze(operands[1], sc, tail)
+	if err != nil {
+		return node{}, err
+	}
+	alternate := constant(values.NewVoidType())
+	if len(operands) == 3 {
+		if alternate, err = a.analyze(operands[2], sc, tail); err != nil {
+			return node{}, err
+		}
+	}
+	if test.constant() {
+		if values.Unquote(test.value).IsTruthy() {
+			return consequent, nil
+		}
+		return alternate, nil
+	}
+	return node{exec: func(f *frame) (values.Interface, error) {
+		v, err := test.exec(f)
+		if err != nil {
+			return nil, err
+		}
+		if v.IsTruthy() {
+			return consequent.exec(f)
+		}
+		return alternate.exec(f)
+	}}, nil
+}
+
+// analyzeDefine converts (define name [expr]) and (define (name . formals) body ...). At
+// top level they define globals, and within a body they store the value in the slot
+// declared for name by analyzeBody.
+func (a *analyzer) analyzeDefine(form values.Interface, operands []values.Interface, sc *scope) (node, error) {
+	if len(operands) == 0 {
+		return node{}, notAnalyzable(form)
+	}
+	var (
+		name  string
+		value node
+		err   error
+	)
+	if target, ok := operands[0].(values.Pair); ok {
+		var named bool
+		if name, named = symbolName(target.Car()); !named || len(operands) < 2 {
+			return node{}, notAnalyzable(form)
+		}
+		if value, err = a.analyzeLambda(name, form, target.Cdr(), operands[1:], sc); err != nil {
+			return node{}, err
+		}
+	} else {
+		var named bool
+		if name, named = symbolName(operands[0]); !named || len(operands) > 2 {
+			return node{}, notAnalyzable(form)
+		}
+		value = constant(values.NewVoidType())
+		if len(operands) == 2 {
+			if value, err = a.analyzeNamed(name, operands[1], sc); err != nil {
+				return node{}, err
+			}
+		}
+	}
+	if sc.top() {
+		return node{exec: func(f *frame) (values.Interface, error) {
+			v, err := value.exec(f)
+			if err != nil {
+				return nil, err
+			}
+			f.globals.env.Define(name, named(name, values.Unquote(v)))
+			return values.NewVoidType(), nil
+		}}, nil
+	}
+	slot, ok := sc.names[name]
+	if !ok {
+		return node{}, notAnalyzable(form)
+	}
+	return node{exec: func(f *frame) (values.Interface, error) {
+		v, err := value.exec(f)
+		if err != nil {
+			return nil, err
+		}
+		f.slots[slot] = values.Unquote(v)
+		return values.NewVoidType(), nil
+	}}, nil
+}
+
+// analyzeNamed converts expr, the value of a definition of name, naming it after the
+// definition when it is a lambda expression.
+func (a *analyzer) analyzeNamed(name string, expr values.Interface, sc *scope) (node, error) {
+	if a.special(expr, sc) == "lambda" {
+		operands, err := values.ToSlice(values.Cdr(expr))
+		if err == nil && len(operands) >= 2 {
+			return a.analyzeLambda(name, expr, operands[0], operands[1:], sc)
+		}
+	}
+	return a.analyze(expr, sc, false)
+}
+
+// analyzeSet converts (set! name expr).
+func (a *analyzer) analyzeSet(form values.Interface, operands []values.Interface, sc *scope) (node, error) {
+	if len(operands) != 2 {
+		return node{}, notAnalyzable(form)
+	}
+	name, ok := symbolName(operands[0])
+	if !ok {
+		return node{}, notAnalyzable(form)
+	}
+	value, err := a.analyze(operands[1], sc, false)
+	if err != nil {
+		return node{}, err
+	}
+	if depth, slot, ok := sc.resolve(name); ok {
+		return node{exec: func(f *frame) (values.Interface, error) {
+			v, err := value.exec(f)
+			if err != nil {
+				return nil, err
+			}
+			for range depth {
+				f = f.parent
+			}
+			f.slots[slot] = values.Unquote(v)
+			return values.NewVoidType(), nil
+		}}, nil
+	}
+	return node{exec: func(f *frame) (values.Interface, error) {
+		v, err := value.exec(f)
+		if err != nil {
+			return nil, err
+		}
+		if !f.globals.env.Set(name, values.Unquote(v)) {
+			return nil, fmt.Errorf("%w: %s", builtins.ErrUndefinedIdent, name)
+		}
+		return values.NewVoidType(), nil
+	}}, nil
+}
+
+// analyzeLambda converts the lambda expression of a procedure named name, defined by
+// form, taking formals and running body.
+func (a *analyzer) analyzeLambda(name string, form, formals values.Interface, body []values.Interface, sc *scope) (node, error) {
+	l := &lambda{name: name, anonymous: "anonymous procedure"}
+	if pos, ok := values.SourcePosition(form); ok {
+		l.source = pos
+		l.anonymous += " at " + values.Location(pos)
+	}
+	slots := &layout{}
+	inner := newScope(sc, slots)
+	for formals.Type() == types.Pair {
+		formal, ok := symbolName(values.Car(formals))
+		if !ok {
+			return node{}, notAnalyzable(form)
+		}
+		inner.names[formal] = slots.slot(formal)
+		l.params++
+		formals = values.Cdr(formals)
+	}
+	l.arity = builtins.Exactly(l.params)
+	if formals.Type() != types.Nil {
+		rest, ok := symbolName(formals)
+		if !ok {
+			return node{}, notAnalyzable(form)
+		}
+		inner.names[rest] = slots.slot(rest)
+		l.rest = true
+		l.arity = builtins.AtLeast(l.params)
+	}
+	n, err := a.analyzeBody(body, inner, true)
+	if err != nil {
+		return node{}, err
+	}
+	l.body = n.exec
+	l.size = len(slots.slots)
+	return node{exec: func(f *frame) (values.Interface, error) {
+		return &Procedure{lambda: l, parent: f, globals: f.globals, rt: f.rt, name: l.name}, nil
+	}}, nil
+}
+
+// analyzeBody converts the expressions of a body in sc, after declaring slots in sc for
+// its internal definitions.
+func (a *analyzer) analyzeBody(body []values.Interface, sc *scope, tail bool) (node, error) {
+	if len(body) == 0 {
+		return node{}, ErrNotAnalyzable
+	}
+	a.declare(body, sc)
+	return a.analyzeSequence(body, sc, tail)
+}
+
+// declare declares slots for the names defined by the forms of a body, including those
+// within begin, so that they can be referred to before their definition.
+func (a *analyzer) declare(forms []values.Interface, sc *scope) {
+	for _, form := range forms {
+		switch a.special(form, sc) {
+		case "begin":
+			if inner, err := values.ToSlice(values.Cdr(form)); err == nil {
+				a.declare(inner, sc)
+			}
+		case "define":
+			target := values.Cdr(form)
+			if target.Type() != types.Pair {
+				continue
+			}
+			target = values.Car(target)
+			if target.Type() == types.Pair {
+				target = values.Car(target)
+			}
+			if name, ok := symbolName(target); ok {
+				if _, declared := sc.names[name]; !declared {
+					sc.names[name] = sc.frame.slot(name)
+				}
+			}
+		}
+	}
+}
+
+// analyzeSequence converts expressions evaluated in order, keeping the value of the last
+// one. Constants other than the last one are dropped, as they have no effect.
+func (a *analyzer) analyzeSequence(exprs []values.Interface, sc *scope, tail bool) (node, error) {
+	var nodes []node
+	for i, expr := range exprs {
+		last := i == len(exprs)-1
+		n, err := a.analyze(expr, sc, tail && last)
+		if err != nil {
+			return node{}, err
+		}
+		if last || !n.constant() {
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	init, last := execs(nodes[:len(nodes)-1]), nodes[len(nodes)-1].exec
+	return node{exec: func(f *frame) (values.Interface, error) {
+		for _, e := range init {
+			if _, err := e(f); err != nil {
+				return nil, err
+			}
+		}
+		return last(f)
+	}}, nil
+}
+
+// analyzeLet converts (let ((name expr) ...) body ...), whose variables take slots of
+// the frames of the enclosing procedure, and the named let (let loop ((name expr) ...)
+// body ...), which calls a procedure bound to loop.
+func (a *analyzer) analyzeLet(form values.Interface, operands []values.Interface, sc *scope, tail bool) (node, error) {
+	if len(operands) < 2 {
+		return node{}, notAnalyzable(form)
+	}
+	loop, isNamed := symbolName(operands[0])
+	if isNamed {
+		operands = operands[1:]
+		if len(operands) < 2 {
+			return node{}, notAnalyzable(form)
+		}
+	}
+	bindings, err := values.ToSlice(operands[0])
+	if err != nil {
+		return node{}, notAnalyzable(form)
+	}
+	var (
+		formals = make([]values.Interface, 0, len(bindings))
+		inits   = make([]node, 0, len(bindings))
+	)
+	for _, binding := range bindings {
+		parts, err := values.ToSlice(binding)
+		if err != nil || len(parts) < 1 || len(parts) > 2 {
+			return node{}, notAnalyzable(form)
+		}
+		if _, ok := symbolName(parts[0]); !ok {
+			return node{}, notAnalyzable(form)
+		}
+		formals = append(formals, parts[0])
+		init := constant(values.NewVoidType())
+		if len(parts) == 2 {
+			if init, err = a.analyze(parts[1], sc, false); err != nil {
+				return node{}, err
+			}
+		}
+		inits = append(inits, init)
+	}
+	if isNamed {
+		loopScope := newScope(sc, sc.frame)
+		slot := sc.frame.slot(loop)
+		loopScope.names[loop] = slot
+		proc, err := a.analyzeLambda(loop, values.Cdr(form), values.List(formals...), operands[1:], loopScope)
+		if err != nil {
+			return node{}, err
+		}
+		pos, _ := values.SourcePosition(form)
+		start := call(func(f *frame) (values.Interface, error) {
+			v, err := proc.exec(f)
+			if err != nil {
+				return nil, err
+			}
+			f.slots[slot] = v
+			return v, nil
+		}, execs(inits), pos, tail)
+		return node{exec: start}, nil
+	}
+	inner := newScope(sc, sc.frame)
+	slots := make([]int, len(formals))
+	for i, formal := range formals {
+		name, _ := symbolName(formal)
+		slots[i] = sc.frame.slot(name)
+		inner.names[name] = slots[i]
+	}
+	body, err := a.analyzeBody(operands[1:], inner, tail)
+	if err != nil {
+		return node{}, err
+	}
+	initExecs := execs(inits)
+	return node{exec: func(f *frame) (values.Interface, error) {
+		// the inits are evaluated before any variable is bound, as they may refer to
+		// variables of enclosing scopes with the same names
+		vs := make([]values.Interface, len(initExecs))
+		for i, init := range initExecs {
+			v, err := init(f)
+			if err != nil {
+				return nil, err
+			}
+			vs[i] = values.Unquote(v)
+		}
+		for i, v := range vs {
+			f.slots[slots[i]] = v
+		}
+		return body.exec(f)
+	}}, nil
+}
+
+// clause is an analyzed clause of a cond.
+type clause struct {
+	test node
+	// body is nil for a clause (test), which yields the value of test
+	body *node
+	// receiver is set for a clause (test => receiver), which calls it with the value of test
+	receiver *node
+}
+
+// analyzeCond converts (cond (test expr ...) ... [(else expr ...)]), where a clause
+// (test) yields the value of test and (test => receiver) calls receiver with it.
+func (a *analyzer) analyzeCond(form values.Interface, operands []values.Interface, sc *scope, tail bool) (node, error) {
+	var clauses []clause
+	otherwise := constant(values.NewVoidType())
+	for _, operand := range operands {
+		parts, err := values.ToSlice(operand)
+		if err != nil || len(parts) == 0 {
+			return node{}, notAnalyzable(form)
+		}
+		if name, ok := symbolName(parts[0]); ok && name == "else" {
+			if len(parts) > 1 {
+				if otherwise, err = a.analyzeSequence(parts[1:], sc, tail); err != nil {
+					return node{}, err
+				}
+			}
+			break
+		}
+		test, err := a.analyze(parts[0], sc, false)
+		if err != nil {
+			return node{}, err
+		}
+		c := clause{test: test}
+		switch name, _ := symbolName(parts[min(1, len(parts)-1)]); {
+		case len(parts) == 1:
+		case name == "=>":
+			if len(parts) != 3 {
+				return node{}, notAnalyzable(form)
+			}
+			receiver, err := a.analyze(parts[2], sc, false)
+			if err != nil {
+				return node{}, err
+			}
+			c.receiver = &receiver
+		default:
+			body, err := a.analyzeSequence(parts[1:], sc, tail)
+			if err != nil {
+				return node{}, err
+			}
+			c.body = &body
+		}
+		clauses = append(clauses, c)
+	}
+	n := otherwise
+	for _, c := range slices.Backward(clauses) {
+		n = c.analyze(n, tail)
+	}
+	return n, nil
+}
+
+// analyze converts the clause, continuing with next when its test is false.
+func (c clause) analyze(next node, tail bool) node {
+	test := c.test
+	switch {
+	case c.body != nil && test.constant():
+		if values.Unquote(test.value).IsTruthy() {
+			return *c.body
+		}
+		return next
+	case c.body != nil:
+		body := c.body.exec
+		return node{exec: func(f *frame) (values.Interface, error) {
+			v, err := test.exec(f)
+			if err != nil {
+				return nil, err
+			}
+			if v.IsTruthy() {
+				return body(f)
+			}
+			return next.exec(f)
+		}}
+	case c.receiver != nil:
+		receiver := c.receiver.exec
+		return node{exec: func(f *frame) (values.Interface, error) {
+			v, err := test.exec(f)
+			if err != nil {
+				return nil, err
+			}
+			if !v.IsTruthy() {
+				return next.exec(f)
+			}
+			proc, err := receiver(f)
+			if err != nil {
+				return nil, err
+			}
+			// the receiver is called without a call position, as by the evaluator
+			return dispatch(f, proc, []values.Interface{values.Unquote(v)}, scanner.Position{}, tail)
+		}}
+	}
+	return node{exec: func(f *frame) (values.Interface, error) {
+		v, err := test.exec(f)
+		if err != nil {
+			return nil, err
+		}
+		if v.IsTruthy() {
+			return v, nil
+		}
+		return next.exec(f)
+	}}
+}
@@ -0,0 +1,373 @@
+package analyze
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/scanner"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Expression is an analyzed top level expression.
+type Expression struct {
+	body  exec
+	size  int
+	names []string
+}
+
+// Analyze converts expr for evaluation in the global environment of rt, in which its
+// special forms are recognised.
+func Analyze(expr values.Interface, rt *builtins.Runtime) (*Expression, error) {
+	a := &analyzer{rt: rt}
+	slots := &layout{top: true}
+	n, err := a.analyze(expr, newScope(nil, slots), false)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{body: n.exec, size: len(slots.slots), names: a.names}, nil
+}
+
+// Eval evaluates the expression in the global environment of rt.
+func (e *Expression) Eval(rt *builtins.Runtime) (values.Interface, error) {
+	if err := rt.Enter(); err != nil {
+		return values.NewVoidType(), err
+	}
+	defer rt.Leave()
+	v, err := e.body(newFrame(e.size, nil, rt, newGlobals(rt.Env, e.names)))
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return v, nil
+}
+
+// Eval analyzes and evaluates expr in rt, or evaluates it with the evaluator of rt when
+// it cannot be analyzed.
+func Eval(expr values.Interface, rt *builtins.Runtime) (values.Interface, error) {
+	e, err := Analyze(expr, rt)
+	if err != nil {
+		return rt.Evaluate(expr)
+	}
+	return e.Eval(rt)
+}
+
+// frame holds the formals and local variables of a call of an analyzed procedure, or of
+// the evaluation of a top level expression, together with the runtime and globals it
+// runs with. Frames of up to four slots keep them inline.
+type frame struct {
+	slots   []values.Interface
+	parent  *frame
+	rt      *builtins.Runtime
+	globals *globals
+	inline  [4]values.Interface
+}
+
+func newFrame(size int, parent *frame, rt *builtins.Runtime, g *globals) *frame {
+	f := &frame{parent: parent, rt: rt, globals: g}
+	if size <= len(f.inline) {
+		f.slots = f.inline[:size]
+	} else {
+		f.slots = make([]values.Interface, size)
+	}
+	return f
+}
+
+// lambda is an analyzed lambda expression.
+type lambda struct {
+	name      string
+	source    scanner.Position
+	anonymous string
+	params    int
+	rest      bool
+	arity     builtins.Arity
+	size      int
+	body      exec
+}
+
+// Procedure is an analyzed procedure with the frame it was created in.
+type Procedure struct {
+	lambda  *lambda
+	parent  *frame
+	globals *globals
+	rt      *builtins.Runtime
+	name    string
+}
+
+func (p *Procedure) Equal(v values.Interface) bool {
+	other, ok := v.(*Procedure)
+	return ok && other == p
+}
+
+func (p *Procedure) Type() types.Type {
+	return types.Lambda
+}
+
+func (p *Procedure) IsTruthy() bool {
+	return true
+}
+
+// WriteString returns the printed form of the procedure, such as #<procedure fact at lib.scm:12>.
+func (p *Procedure) WriteString() string {
+	var sb strings.Builder
+	sb.WriteString("#<procedure")
+	if p.name != "" {
+		sb.WriteString(" " + p.name)
+	}
+	if p.lambda.source.IsValid() {
+		sb.WriteString(" at " + values.Location(p.lambda.source))
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
+
+func (p *Procedure) DisplayString() string {
+	return p.WriteString()
+}
+
+func (p *Procedure) String() string {
+	return p.WriteString()
+}
+
+// ProcedureName describes the procedure in stack traces.
+func (p *Procedure) ProcedureName() string {
+	if p.name != "" {
+		return p.name
+	}
+	return p.lambda.anonymous
+}
+
+// Arity returns the number of arguments the procedure accepts.
+func (p *Procedure) Arity() builtins.Arity {
+	return p.lambda.arity
+}
+
+// Apply calls the procedure in the runtime it was created in.
+func (p *Procedure) Apply(args values.Interface) (values.Interface, error) {
+	return p.Call(args, p.rt)
+}
+
+// Call calls the procedure in rt, the runtime of the call site.
+func (p *Procedure) Call(args values.Interface, rt *builtins.Runtime) (values.Interface, error) {
+	items, err := values.ToSlice(args)
+	if err != nil {
+		return values.NewVoidType(), builtins.ErrInvalidFormat
+	}
+	v, err := p.run(items, rt)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return v, nil
+}
+
+// ApplyContext calls the procedure from Go as a new evaluation bounded by ctx.
+func (p *Procedure) ApplyContext(ctx context.Context, args values.Interface) (values.Interface, error) {
+	return p.rt.NewEvaluation(ctx).Apply(p, args)
+}
+
+// named returns v called name when it is an anonymous procedure being defined.
+func named(name string, v values.Interface) values.Interface {
+	switch proc := v.(type) {
+	case *Procedure:
+		if proc.name == "" {
+			named := *proc
+			named.name = name
+			return &named
+		}
+	case builtins.LambdaExpr:
+		if proc.Name == "" {
+			proc.Name = name
+			return proc
+		}
+	}
+	return v
+}
+
+// bind returns the frame of a call of the procedure with args in rt.
+func (p *Procedure) bind(args []values.Interface, rt *builtins.Runtime) (*frame, error) {
+	l := p.lambda
+	if !l.arity.Accepts(len(args)) {
+		err := fmt.Errorf("%w: expected %s, got %d", builtins.ErrWrongNumberOfArguments, l.arity, len(args))
+		if p.name != "" {
+			err = fmt.Errorf("%s: %w", p.name, err)
+		}
+		return nil, err
+	}
+	f := newFrame(l.size, p.parent, rt, p.globals)
+	copy(f.slots, args[:l.params])
+	if l.rest {
+		f.slots[l.params] = values.List(args[l.params:]...)
+	}
+	return f, nil
+}
+
+// run calls the procedure with args in rt, making the tail calls of its body in turn
+// until one returns a value. The tail calls of analyzed procedures replace its call on
+// the call stack of rt.
+func (p *Procedure) run(args []values.Interface, rt *builtins.Runtime) (values.Interface, error) {
+	if err := rt.Enter(); err != nil {
+		return nil, err
+	}
+	defer rt.Leave()
+	for {
+		f, err := p.bind(args, rt)
+		if err != nil {
+			return nil, err
+		}
+		v, err := p.lambda.body(f)
+		if err != nil {
+			return nil, err
+		}
+		tail, ok := v.(*tailCall)
+		if !ok {
+			return v, nil
+		}
+		if err := rt.Step(); err != nil {
+			return nil, err
+		}
+		call := values.Frame{Procedure: procedureName(tail.proc), Call: tail.call}
+		next, ok := tail.proc.(*Procedure)
+		if !ok {
+			// a builtin is called on top of the call, as by the evaluator
+			depth := rt.PushCall(call)
+			v, err := apply(rt, tail.proc, tail.args)
+			if err != nil {
+				err = rt.Traced(err)
+			}
+			rt.Unwind(depth)
+			return v, err
+		}
+		rt.TailCall(call)
+		p, args = next, tail.args
+	}
+}
+
+// tailCall is returned by the body of a procedure for the procedure to call proc with
+// args in its place. It never escapes Procedure.run.
+type tailCall struct {
+	proc values.Interface
+	args []values.Interface
+	call scanner.Position
+}
+
+func (t *tailCall) Equal(values.Interface) bool {
+	return false
+}
+
+func (t *tailCall) Type() types.Type {
+	return types.Void
+}
+
+func (t *tailCall) IsTruthy() bool {
+	return true
+}
+
+func (t *tailCall) DisplayString() string {
+	return "#<tail call " + procedureName(t.proc) + ">"
+}
+
+func (t *tailCall) WriteString() string {
+	return t.DisplayString()
+}
+
+// call returns the evaluation of the call at pos of the procedure operator evaluates to,
+// with the values of operands. The operator is evaluated first, then the operands in order.
+func call(operator exec, operands []exec, pos scanner.Position, tail bool) exec {
+	return func(f *frame) (values.Interface, error) {
+		proc, err := operator(f)
+		if err != nil {
+			return nil, err
+		}
+		args := make([]values.Interface, len(operands))
+		for i, operand := range operands {
+			v, err := operand(f)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = values.Unquote(v)
+		}
+		return dispatch(f, proc, args, pos, tail)
+	}
+}
+
+// primitiveCall returns the evaluation of the call at pos of the global indexed by
+// global, with the values of operands, which is computed by its primitive when it is one
+// and the values are ones the primitive handles.
+func primitiveCall(global int, operands []exec, pos scanner.Position, tail bool) exec {
+	return func(f *frame) (values.Interface, error) {
+		b, ok := f.globals.lookup(global)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", builtins.ErrUndefinedIdent, f.globals.names[global])
+		}
+		var buf [2]values.Interface
+		args := buf[:len(operands)]
+		for i, operand := range operands {
+			v, err := operand(f)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = values.Unquote(v)
+		}
+		if b.primitive != builtins.NoPrimitive {
+			if v, ok := b.primitive.Apply(args); ok {
+				if err := f.rt.Step(); err != nil {
+					return nil, err
+				}
+				return v, nil
+			}
+		}
+		return dispatch(f, b.value, slices.Clone(args), pos, tail)
+	}
+}
+
+// dispatch calls proc with args from f, or returns the tail call for the procedure f
+// belongs to to make.
+func dispatch(f *frame, proc values.Interface, args []values.Interface, pos scanner.Position, tail bool) (values.Interface, error) {
+	if tail {
+		return &tailCall{proc: proc, args: args, call: pos}, nil
+	}
+	rt := f.rt
+	if err := rt.Step(); err != nil {
+		return nil, err
+	}
+	depth := rt.PushCall(values.Frame{Procedure: procedureName(proc), Call: pos})
+	var (
+		v   values.Interface
+		err error
+	)
+	if p, ok := proc.(*Procedure); ok {
+		v, err = p.run(args, rt)
+	} else {
+		v, err = apply(rt, proc, args)
+	}
+	if err != nil {
+		err = rt.Traced(err)
+	}
+	rt.Unwind(depth)
+	return v, err
+}
+
+// apply calls proc, which is not an analyzed procedure, with args.
+func apply(rt *builtins.Runtime, proc values.Interface, args []values.Interface) (values.Interface, error) {
+	switch p := proc.(type) {
+	case builtins.Procedure:
+		return rt.Trampoline(p.Call(values.List(args...), rt))
+	case builtins.Lambda:
+		return p.Apply(values.List(args...))
+	}
+	// a value in operator position without operands resolves to itself, e.g. (1234)
+	if len(args) == 0 {
+		return proc, nil
+	}
+	return nil, fmt.Errorf("%w: %s", builtins.ErrOperatorIsNotAProcedure, proc.WriteString())
+}
+
+// procedureName describes proc in stack traces.
+func procedureName(proc values.Interface) string {
+	if p, ok := proc.(interface{ ProcedureName() string }); ok {
+		return p.ProcedureName()
+	}
+	return proc.WriteString()
+}
@@ -0,0 +1,404 @@
+// Package cst reads Scheme source into a concrete syntax tree, which keeps everything
+// the reader of the parser discards: comments, whitespace and the exact spelling of
+// every literal. Printing a tree reproduces its source byte for byte, so that tools
+// such as formatters, linters and refactorings can inspect and rewrite source without
+// losing what they do not change.
+//
+// Comments and whitespace are trivia attached to the nodes around them. The trivia
+// following a node up to the end of its line, such as a comment after a definition,
+// trails the node; the trivia on the lines before a node leads it.
+package cst
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/scanner"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/lexer"
+)
+
+var (
+	ErrSyntax = errors.New("syntax error")
+)
+
+type Kind int
+
+const (
+	// Atom is an identifier, number, string, character or boolean
+	Atom Kind = iota
+	// List is a parenthesised or bracketed list
+	List
+	// Vector is a vector literal
+	Vector
+	// Quote is a quoted datum, such as 'x
+	Quote
+	// Dot separates the last element of a dotted list from the others
+	Dot
+)
+
+// Trivia is a comment or run of whitespace, with the position it starts at.
+type Trivia struct {
+	Token lexer.Token
+	Pos   scanner.Position
+}
+
+// IsComment reports whether t is a comment. A #; datum comment is a single trivia
+// spelling out the datum it comments out.
+func (t Trivia) IsComment() bool {
+	switch t.Token.Type {
+	case lexer.TokenLineComment, lexer.TokenBlockComment, lexer.TokenDatumComment:
+		return true
+	}
+	return false
+}
+
+// Newlines returns the number of line breaks in t.
+func (t Trivia) Newlines() int {
+	return strings.Count(t.Token.Literal, "\n")
+}
+
+// Node is a datum of a source. Token is the atom, the dot, or the opening parenthesis
+// or quote of the datum. The elements of a list or vector, and the datum of a quote,
+// are its children; a dotted list has a Dot node before its last child.
+type Node struct {
+	Kind     Kind
+	Token    lexer.Token
+	Children []*Node
+	// Close is the closing parenthesis or bracket of a list or vector, and Inner the
+	// trivia between its last element and Close
+	Close lexer.Token
+	Inner []Trivia
+
+	Leading  []Trivia
+	Trailing []Trivia
+	// Pos is the position of the first token of the node, and End that just after its last
+	Pos, End scanner.Position
+}
+
+// Identifier returns the name of an identifier, or false for any other node.
+func (n *Node) Identifier() (string, bool) {
+	if n.Kind != Atom {
+		return "", false
+	}
+	switch n.Token.Type {
+	case lexer.TokenIdent, lexer.TokenRelationalOperator, lexer.TokenArithmeticOperator:
+		return n.Token.Literal, true
+	}
+	return "", false
+}
+
+// Comments returns the comments leading and trailing n.
+func (n *Node) Comments() []string {
+	var comments []string
+	for _, trivia := range [][]Trivia{n.Leading, n.Trailing} {
+		for _, t := range trivia {
+			if t.IsComment() {
+				comments = append(comments, t.Token.Literal)
+			}
+		}
+	}
+	return comments
+}
+
+// Text returns the source of n, without its leading and trailing trivia.
+func (n *Node) Text() string {
+	var sb strings.Builder
+	n.write(&sb, false)
+	return sb.String()
+}
+
+// String returns the source of n together with its leading and trailing trivia.
+func (n *Node) String() string {
+	var sb strings.Builder
+	n.write(&sb, true)
+	return sb.String()
+}
+
+func (n *Node) write(sb *strings.Builder, trivia bool) {
+	if trivia {
+		writeTrivia(sb, n.Leading)
+	}
+	sb.WriteString(n.Token.Literal)
+	for _, child := range n.Children {
+		child.write(sb, true)
+	}
+	if n.Kind == List || n.Kind == Vector {
+		writeTrivia(sb, n.Inner)
+		sb.WriteString(n.Close.Literal)
+	}
+	if trivia {
+		writeTrivia(sb, n.Trailing)
+	}
+}
+
+func writeTrivia(sb *strings.Builder, trivia []Trivia) {
+	for _, t := range trivia {
+		sb.WriteString(t.Token.Literal)
+	}
+}
+
+// File is the tree of a source: its top level datums, and the trivia after the last one.
+type File struct {
+	Nodes    []*Node
+	Trailing []Trivia
+}
+
+// String returns the source of the file.
+func (f *File) String() string {
+	var sb strings.Builder
+	for _, n := range f.Nodes {
+		n.write(&sb, true)
+	}
+	writeTrivia(&sb, f.Trailing)
+	return sb.String()
+}
+
+// Error is a syntax error of a source, with the range of the code it is about.
+type Error struct {
+	Msg string
+	// Pos is the position of the start of the code, and End that just after its end
+	Pos, End scanner.Position
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %s at %v", ErrSyntax, e.Msg, e.Pos)
+}
+
+// Unwrap returns ErrSyntax.
+func (e *Error) Unwrap() error {
+	return ErrSyntax
+}
+
+// Read reads the tree of the source src, named filename in the positions of its nodes.
+// It returns the first syntax error of src, an *Error wrapping ErrSyntax, for invalid
+// tokens, unbalanced parentheses and misplaced dots.
+func Read(src io.Reader, filename string) (*File, error) {
+	f, errs := ReadTolerant(src, filename)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return f, nil
+}
+
+// ReadTolerant reads the tree of the source src like Read, but reads past its syntax
+// errors so that tools such as editors can still work with the rest of the source. It
+// returns the tree together with every error, in the order of the source. Invalid tokens
+// are read as atoms, a list closed by the wrong kind of parenthesis or left open at the
+// end of the source is taken as closed, and misplaced dots and closing parentheses are
+// skipped, so the tree of a source with errors does not print back to the source.
+func ReadTolerant(src io.Reader, filename string) (*File, []*Error) {
+	r := &reader{scan: lexer.NewNamed(src, filename).KeepTrivia()}
+	f := &File{}
+	for {
+		tok := r.next()
+		if tok.Type == lexer.TokenEOF {
+			f.Trailing = tok.leading
+			return f, r.errs
+		}
+		if n := r.datum(tok); n != nil {
+			f.Nodes = append(f.Nodes, n)
+		}
+	}
+}
+
+// reader builds the nodes of a tree from the tokens of a scanner that keeps trivia.
+type reader struct {
+	scan *lexer.Scanner
+	// pending is the trivia read for the next token, and peeked that token when it was
+	// read while collecting the trailing trivia of a node
+	pending []Trivia
+	peeked  *token
+	errs    []*Error
+}
+
+// token is a token other than trivia, with the trivia leading it.
+type token struct {
+	lexer.Token
+	pos     scanner.Position
+	leading []Trivia
+}
+
+// next returns the next token that is not trivia.
+func (r *reader) next() token {
+	for {
+		var tok token
+		if r.peeked != nil {
+			tok, r.peeked = *r.peeked, nil
+		} else {
+			tok = token{Token: r.scan.NextToken()}
+			tok.pos = r.scan.Position()
+		}
+		switch {
+		case isTrivia(tok.Token):
+			r.pending = append(r.pending, Trivia{Token: tok.Token, Pos: tok.pos})
+		case tok.Type == lexer.TokenDatumComment:
+			r.datumComment(tok)
+		default:
+			tok.leading, r.pending = r.pending, nil
+			return tok
+		}
+	}
+}
+
+// datumComment reads the datum commented out by the #; comment tok, and adds the comment
+// to the pending trivia as a single trivia spelling out the comment and the datum. The
+// trivia trailing the datum follows the comment.
+func (r *reader) datumComment(tok token) {
+	pending := r.pending
+	r.pending = nil
+	next := r.next()
+	switch next.Type {
+	case lexer.TokenEOF, lexer.TokenRParen, lexer.TokenRBracket, lexer.TokenDot:
+		r.errorf(tok, "missing datum after #;")
+		r.pending = append(append(pending, Trivia{Token: tok.Token, Pos: tok.pos}), next.leading...)
+		next.leading = nil
+		r.peeked = &next
+		return
+	}
+	n := r.datum(next)
+	var sb strings.Builder
+	sb.WriteString(tok.Literal)
+	var trailing []Trivia
+	if n != nil {
+		writeTrivia(&sb, n.Leading)
+		n.write(&sb, false)
+		trailing = n.Trailing
+	}
+	pending = append(pending, Trivia{Token: lexer.Token{Type: lexer.TokenDatumComment, Literal: sb.String()}, Pos: tok.pos})
+	r.pending = append(append(pending, trailing...), r.pending...)
+}
+
+// unread puts tok back, so that next returns it again.
+func (r *reader) unread(tok token) {
+	r.pending, r.peeked = tok.leading, &tok
+}
+
+// trailing returns the trivia following a node up to the end of its line. The trivia
+// from there up to the next token is kept for that token.
+func (r *reader) trailing() []Trivia {
+	var trailing []Trivia
+	for {
+		tok := r.scan.NextToken()
+		if !isTrivia(tok) {
+			r.peeked = &token{Token: tok, pos: r.scan.Position()}
+			return trailing
+		}
+		t := Trivia{Token: tok, Pos: r.scan.Position()}
+		if t.Newlines() > 0 || len(r.pending) > 0 {
+			r.pending = append(r.pending, t)
+			continue
+		}
+		trailing = append(trailing, t)
+	}
+}
+
+func isTrivia(tok lexer.Token) bool {
+	switch tok.Type {
+	case lexer.TokenWhitespace, lexer.TokenLineComment, lexer.TokenBlockComment:
+		return true
+	}
+	return false
+}
+
+// errorf records a syntax error about tok.
+func (r *reader) errorf(tok token, format string, args ...any) {
+	r.errs = append(r.errs, &Error{Msg: fmt.Sprintf(format, args...), Pos: tok.pos, End: end(tok.pos, tok.Literal)})
+}
+
+// datum reads the datum starting with tok. It returns nil when tok cannot start one.
+func (r *reader) datum(tok token) *Node {
+	n := &Node{Token: tok.Token, Leading: tok.leading, Pos: tok.pos, End: end(tok.pos, tok.Literal)}
+	switch tok.Type {
+	case lexer.TokenLParen, lexer.TokenVectorStart:
+		return r.list(n, lexer.TokenRParen)
+	case lexer.TokenLBracket:
+		return r.list(n, lexer.TokenRBracket)
+	case lexer.TokenQuot:
+		n.Kind = Quote
+		next := r.next()
+		if next.Type == lexer.TokenEOF {
+			r.errorf(tok, "quote at end of input")
+			r.unread(next)
+			return nil
+		}
+		quoted := r.datum(next)
+		if quoted == nil {
+			return nil
+		}
+		// the trivia after the datum trails the quote
+		n.Children, n.End = []*Node{quoted}, quoted.End
+		n.Trailing, quoted.Trailing = quoted.Trailing, nil
+		return n
+	case lexer.TokenRParen, lexer.TokenRBracket, lexer.TokenDot:
+		r.errorf(tok, "unexpected %s", tok.Literal)
+		return nil
+	case lexer.TokenError:
+		r.errorf(tok, "%s: %s", tok.Error.Message, tok.Literal)
+	}
+	n.Trailing = r.trailing()
+	return n
+}
+
+// list reads the elements of the list or vector n up to its closing token. A list closed
+// by the wrong kind of parenthesis is taken as closed, and so is a list left open at the
+// end of the source.
+func (r *reader) list(n *Node, closing lexer.TokenType) *Node {
+	n.Kind = List
+	if n.Token.Type == lexer.TokenVectorStart {
+		n.Kind = Vector
+	}
+	n.Trailing = r.trailing()
+	// the trivia after the opening parenthesis leads the first element
+	r.pending, n.Trailing = append(n.Trailing, r.pending...), nil
+	// dot is the index of the dot of a dotted list, or -1
+	dot := -1
+	for {
+		tok := r.next()
+		switch tok.Type {
+		case closing, lexer.TokenRParen, lexer.TokenRBracket:
+			if tok.Type != closing {
+				r.errorf(tok, "%s does not match %s on line %d", tok.Literal, n.Token.Literal, n.Pos.Line)
+			} else if dot >= 0 && dot == len(n.Children)-1 {
+				r.errorf(tok, "missing datum after the dot of a dotted list")
+			}
+			n.Close, n.Inner, n.End = tok.Token, tok.leading, end(tok.pos, tok.Literal)
+			n.Trailing = r.trailing()
+			return n
+		case lexer.TokenEOF:
+			r.errorf(token{Token: n.Token, pos: n.Pos}, "unbalanced %s: missing %s", n.Token.Literal, closing)
+			n.End = tok.pos
+			r.unread(tok)
+			return n
+		case lexer.TokenDot:
+			if n.Kind != List || len(n.Children) == 0 || dot >= 0 {
+				r.errorf(tok, "unexpected %s", tok.Literal)
+				continue
+			}
+			dot = len(n.Children)
+			n.Children = append(n.Children, &Node{Kind: Dot, Token: tok.Token, Leading: tok.leading, Pos: tok.pos, End: end(tok.pos, tok.Literal), Trailing: r.trailing()})
+			continue
+		}
+		if dot >= 0 && len(n.Children) > dot+1 {
+			r.errorf(tok, "more than one datum after the dot of a dotted list")
+		}
+		if child := r.datum(tok); child != nil {
+			n.Children = append(n.Children, child)
+		}
+	}
+}
+
+// end returns the position just after text when it starts at pos.
+func end(pos scanner.Position, text string) scanner.Position {
+	pos.Offset += len(text)
+	for _, r := range text {
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+			continue
+		}
+		pos.Column++
+	}
+	return pos
+}
@@ -0,0 +1,212 @@
+package cst
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "empty", src: ""},
+		{name: "only trivia", src: "  ; nothing here\n\n"},
+		{
+			name: "definitions and comments",
+			src: `;;; factorial
+(define (fact n)   ; recursive
+  ;; base case
+  (if (= n 0)
+      1
+      (* n (fact (- n 1)))))   
+
+
+(fact 5) ; 120
+`,
+		},
+		{name: "literals", src: "(list #true #\\x41 #\\space \"a\\tb\\\"\" +5 .5 -1.50 #(1 2) [a . b] :key '( x ) ' y)"},
+		{name: "trivia inside lists", src: "( ; open\n a\n\n ; before close\n )\r\n"},
+		{name: "no trailing newline", src: "x"},
+		{name: "block and datum comments", src: "#| a #| b |# |#\n(f #;(g 1) x #; y ; z\n #| w |#)\n#;\n(h)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Read(strings.NewReader(tt.src), "test.scm")
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if got := f.String(); got != tt.src {
+				t.Errorf("String() = %q, want %q", got, tt.src)
+			}
+		})
+	}
+}
+
+func TestTrivia(t *testing.T) {
+	src := `;; leading
+(define x 1) ; trailing
+
+;; second
+(f 'a ; after a
+   b)
+`
+	f, err := Read(strings.NewReader(src), "test.scm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Nodes) != 2 {
+		t.Fatalf("Read() nodes = %d, want 2", len(f.Nodes))
+	}
+	define, call := f.Nodes[0], f.Nodes[1]
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"comments of a definition", define.Comments(), []string{";; leading", "; trailing"}},
+		{"text of a definition", define.Text(), "(define x 1)"},
+		{"comments of the next definition", call.Comments(), []string{";; second"}},
+		{"blank line before the next definition", call.Leading[0].Newlines(), 2},
+		{"comment trailing a quoted datum", call.Children[1].Comments(), []string{"; after a"}},
+		{"datum of the quote", call.Children[1].Children[0].Text(), "a"},
+		{"position", [2]int{call.Children[2].Pos.Line, call.Children[2].Pos.Column}, [2]int{6, 4}},
+		{"end", [2]int{call.End.Line, call.End.Column}, [2]int{6, 6}},
+		{"trivia after the last node", len(f.Trailing), 1},
+		{"identifier", call.Children[0].Token.Literal, "f"},
+		{"datum comment", comments(t, "#| a |# (f #; (g) x)"), []string{"#| a |#", "#; (g)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+// comments returns the comments of the nodes of src, and of their children.
+func comments(t *testing.T, src string) []string {
+	t.Helper()
+	f, err := Read(strings.NewReader(src), "test.scm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all []string
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		all = append(all, n.Comments()...)
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	for _, n := range f.Nodes {
+		walk(n)
+	}
+	return all
+}
+
+func TestRename(t *testing.T) {
+	src := "(define (sq x) ; squares\n  (* x x))\n(sq 2)\n"
+	f, err := Read(strings.NewReader(src), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rename func(n *Node)
+	rename = func(n *Node) {
+		if name, ok := n.Identifier(); ok && name == "sq" {
+			n.Token.Literal = "square"
+		}
+		for _, child := range n.Children {
+			rename(child)
+		}
+	}
+	for _, n := range f.Nodes {
+		rename(n)
+	}
+	want := "(define (square x) ; squares\n  (* x x))\n(square 2)\n"
+	if got := f.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"unbalanced", "(define x\n  1", "syntax error: unbalanced (: missing ) at f.scm:1:1"},
+		{"unexpected", "x)", "syntax error: unexpected ) at f.scm:1:2"},
+		{"mismatched", "(a\n b]", "syntax error: ] does not match ( on line 1 at f.scm:2:3"},
+		{"invalid token", "(f #x)", "syntax error: invalid boolean literal: #x at f.scm:1:4"},
+		{"quote at end", "'", "syntax error: quote at end of input at f.scm:1:1"},
+		{"leading dot", "(. a)", "syntax error: unexpected . at f.scm:1:2"},
+		{"dot in a vector", "#(a . b)", "syntax error: unexpected . at f.scm:1:5"},
+		{"two datums after a dot", "(a . b c)", "syntax error: more than one datum after the dot of a dotted list at f.scm:1:8"},
+		{"no datum after a dot", "(a .)", "syntax error: missing datum after the dot of a dotted list at f.scm:1:5"},
+		{"datum comment at end", "(a #;)", "syntax error: missing datum after #; at f.scm:1:4"},
+		{"unterminated block comment", "#| a", "syntax error: unterminated block comment: #| a at f.scm:1:1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.src), "f.scm")
+			if !errors.Is(err, ErrSyntax) || err.Error() != tt.want {
+				t.Errorf("Read() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadTolerant(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		wantText []string
+		wantErrs []string
+	}{
+		{
+			name:     "well formed",
+			src:      "(a . b) 'c",
+			wantText: []string{"(a . b)", "'c"},
+		},
+		{
+			name:     "stray close",
+			src:      "(f)) g",
+			wantText: []string{"(f)", "g"},
+			wantErrs: []string{"unexpected ) at t.scm:1:4"},
+		},
+		{
+			name:     "mismatched close is taken as closing",
+			src:      "[g) h",
+			wantText: []string{"[g)", "h"},
+			wantErrs: []string{") does not match [ on line 1 at t.scm:1:3"},
+		},
+		{
+			name:     "unbalanced list is closed at the end",
+			src:      "(define (f x)\n  (+ x #x)",
+			wantText: []string{"(define (f x)\n  (+ x #x)"},
+			wantErrs: []string{"invalid boolean literal: #x at t.scm:2:8", "unbalanced (: missing ) at t.scm:1:1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, errs := ReadTolerant(strings.NewReader(tt.src), "t.scm")
+			var text, msgs []string
+			for _, n := range f.Nodes {
+				text = append(text, n.Text())
+			}
+			for _, err := range errs {
+				msgs = append(msgs, err.Msg+" at "+err.Pos.String())
+			}
+			if !reflect.DeepEqual(text, tt.wantText) {
+				t.Errorf("ReadTolerant() nodes = %q, want %q", text, tt.wantText)
+			}
+			if !reflect.DeepEqual(msgs, tt.wantErrs) {
+				t.Errorf("ReadTolerant() errors = %q, want %q", msgs, tt.wantErrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,488 @@
+// Package dap serves the Debug Adapter Protocol, so that editors can run Scheme programs
+// under the debugger, set breakpoints, step through evaluations and inspect variables.
+// The program runs as a single thread, whose pauses are reported to the client as
+// stopped events. While it is paused its stack, scopes and variables can be requested,
+// and expressions evaluated in its environment.
+package dap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/debugger"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+var (
+	ErrProtocol  = errors.New("protocol error")
+	ErrNotPaused = errors.New("the program is not paused")
+)
+
+// threadID identifies the only thread the server reports, the thread running the program.
+const threadID = 1
+
+// Server is a debug adapter reading requests from one stream and writing responses
+// and events to another.
+type Server struct {
+	in     *bufio.Reader
+	out    io.Writer
+	rtOpts []builtins.OptionRuntime
+
+	// mu guards writing messages and their sequence numbers
+	mu  sync.Mutex
+	seq int
+
+	debugger *debugger.Debugger
+	program  string
+	entry    bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	running  bool
+	done     chan struct{}
+	resume   chan debugger.Action
+	evals    chan evaluation
+
+	// state guards the fields below, which are shared with the goroutine of the program
+	state       sync.Mutex
+	stop        *debugger.Stop
+	scopes      []debugger.Scope
+	breakpoints map[string][]int
+	functions   []int
+}
+
+// evaluation is a request to evaluate an expression in the paused program.
+type evaluation struct {
+	expression string
+	reply      chan evaluateResult
+}
+
+type evaluateResult struct {
+	value values.Interface
+	err   error
+}
+
+// NewServer returns a server reading requests from in and writing to out. The runtime
+// of the programs it launches is made with rtOpts.
+func NewServer(in io.Reader, out io.Writer, rtOpts ...builtins.OptionRuntime) *Server {
+	s := &Server{
+		in:          bufio.NewReader(in),
+		out:         out,
+		rtOpts:      rtOpts,
+		resume:      make(chan debugger.Action),
+		evals:       make(chan evaluation),
+		breakpoints: make(map[string][]int),
+	}
+	s.debugger = debugger.New(s.paused)
+	return s
+}
+
+// Serve handles requests until the client disconnects or the input ends, and aborts the
+// program if it is still running.
+func (s *Server) Serve(ctx context.Context) error {
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	defer s.cancel()
+	defer s.end()
+	for {
+		content, err := readMessage(s.in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil {
+			return fmt.Errorf("%w: %v", ErrProtocol, err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+		if s.handle(req) {
+			return nil
+		}
+	}
+}
+
+// handle answers req, and reports whether the client disconnected.
+func (s *Server) handle(req request) bool {
+	var (
+		body any
+		err  error
+	)
+	switch req.Command {
+	case "initialize":
+		s.respond(req, capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsFunctionBreakpoints:      true,
+			SupportsTerminateRequest:         true,
+		}, nil)
+		s.send(event{Type: "event", Event: "initialized"})
+		return false
+	case "launch":
+		err = s.launch(req.Arguments)
+	case "setBreakpoints":
+		body, err = s.setBreakpoints(req.Arguments)
+	case "setFunctionBreakpoints":
+		body, err = s.setFunctionBreakpoints(req.Arguments)
+	case "configurationDone":
+		s.respond(req, nil, nil)
+		s.start()
+		return false
+	case "threads":
+		body = threadsBody{Threads: []thread{{ID: threadID, Name: "main"}}}
+	case "stackTrace":
+		body, err = s.stackTrace()
+	case "scopes":
+		body, err = s.scopesOf(req.Arguments)
+	case "variables":
+		body, err = s.variables(req.Arguments)
+	case "evaluate":
+		body, err = s.evaluate(req.Arguments)
+	case "continue":
+		return s.resumeWith(req, debugger.Continue, continueBody{AllThreadsContinued: true})
+	case "next":
+		return s.resumeWith(req, debugger.StepOver, nil)
+	case "stepIn":
+		return s.resumeWith(req, debugger.StepIn, nil)
+	case "stepOut":
+		return s.resumeWith(req, debugger.StepOut, nil)
+	case "terminate":
+		s.end()
+	case "disconnect":
+		s.end()
+		s.respond(req, nil, nil)
+		return true
+	default:
+		err = fmt.Errorf("unsupported request %s", req.Command)
+	}
+	s.respond(req, body, err)
+	return false
+}
+
+// launch prepares the program to run once the client is done configuring breakpoints.
+func (s *Server) launch(arguments json.RawMessage) error {
+	var args launchArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	if args.Program == "" {
+		return fmt.Errorf("%w: no program to launch", ErrProtocol)
+	}
+	s.program, s.entry = args.Program, args.StopOnEntry
+	s.debugger.SetEnabled(!args.NoDebug)
+	return nil
+}
+
+// start runs the launched program in a new goroutine.
+func (s *Server) start() {
+	if s.program == "" || s.running {
+		return
+	}
+	s.running = true
+	s.done = make(chan struct{})
+	if s.entry {
+		s.debugger.Step()
+	}
+	rt := builtins.NewRuntime(append([]builtins.OptionRuntime{
+		builtins.WithEvaluatorCallback(parser.DefaultExpressionEvaluator()),
+		builtins.WithDatumReader(parser.DefaultDatumReader()),
+		builtins.WithOut(outputWriter{s, "stdout"}),
+		builtins.WithErr(outputWriter{s, "stderr"}),
+		builtins.WithIn(bytes.NewReader(nil)),
+		builtins.WithHook(s.debugger),
+		builtins.WithLibraryPath(filepath.Dir(s.program)),
+	}, s.rtOpts...)...)
+	go func() {
+		defer close(s.done)
+		exitCode := 0
+		if err := s.run(rt); err != nil {
+			parser.ReportError(rt.Err, err)
+			exitCode = 1
+		}
+		s.send(event{Type: "event", Event: "exited", Body: exitedBody{ExitCode: exitCode}})
+		s.send(event{Type: "event", Event: "terminated"})
+	}()
+}
+
+// run evaluates every datum of the program as a single evaluation, stopping at the first error.
+func (s *Server) run(rt *builtins.Runtime) error {
+	src, err := os.Open(s.program)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	datums, err := parser.DefaultDatumReader()(src, s.program, rt.WithContext(s.ctx))
+	if err != nil {
+		return err
+	}
+	rt = rt.NewEvaluation(s.ctx)
+	for _, datum := range datums {
+		if _, err := parser.DefaultExpressionEvaluator()(datum, rt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// end aborts the program if it is running and waits until it has ended.
+func (s *Server) end() {
+	if !s.running {
+		return
+	}
+	// a paused program is aborted once its context is canceled
+	s.cancel()
+	<-s.done
+	s.running = false
+}
+
+// paused is the debugger front end. It reports the stop to the client and serves
+// evaluations in the paused program until a request resumes it.
+func (s *Server) paused(stop *debugger.Stop) debugger.Action {
+	reason := "step"
+	if stop.Reason == debugger.AtBreakpoint {
+		reason = "breakpoint"
+	}
+	s.state.Lock()
+	if s.entry {
+		reason, s.entry = "entry", false
+	}
+	s.stop, s.scopes = stop, stop.Scopes()
+	s.state.Unlock()
+	body := stoppedBody{Reason: reason, Description: stop.String(), ThreadID: threadID, AllThreadsStopped: true}
+	if stop.Reason == debugger.AtBreakpoint {
+		body.HitBreakpointIDs = []int{stop.Breakpoint.ID}
+	}
+	s.send(event{Type: "event", Event: "stopped", Body: body})
+	for {
+		select {
+		case action := <-s.resume:
+			return action
+		case e := <-s.evals:
+			e.reply <- s.evaluateIn(stop, e.expression)
+		case <-s.ctx.Done():
+			return debugger.Abort
+		}
+	}
+}
+
+// evaluateIn evaluates every datum of expression in the paused program stop.
+func (s *Server) evaluateIn(stop *debugger.Stop, expression string) evaluateResult {
+	datums, err := parser.DefaultDatumReader()(bytes.NewBufferString(expression), "", stop.Runtime)
+	if err != nil {
+		return evaluateResult{err: err}
+	}
+	result := evaluateResult{value: values.NewVoidType()}
+	for _, datum := range datums {
+		if result.value, result.err = stop.Evaluate(datum); result.err != nil {
+			break
+		}
+	}
+	return result
+}
+
+// resumeWith answers req and resumes the paused program with action.
+func (s *Server) resumeWith(req request, action debugger.Action, body any) bool {
+	s.state.Lock()
+	paused := s.stop != nil
+	s.stop, s.scopes = nil, nil
+	s.state.Unlock()
+	if !paused {
+		s.respond(req, nil, ErrNotPaused)
+		return false
+	}
+	s.respond(req, body, nil)
+	s.resume <- action
+	return false
+}
+
+// setBreakpoints replaces the line breakpoints of a source file.
+func (s *Server) setBreakpoints(arguments json.RawMessage) (any, error) {
+	var args setBreakpointsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	s.state.Lock()
+	defer s.state.Unlock()
+	for _, id := range s.breakpoints[args.Source.Path] {
+		s.debugger.Delete(id)
+	}
+	body := breakpointsBody{Breakpoints: []breakpoint{}}
+	var ids []int
+	for _, sb := range args.Breakpoints {
+		b := s.debugger.Add(debugger.Breakpoint{File: args.Source.Path, Line: sb.Line})
+		ids = append(ids, b.ID)
+		body.Breakpoints = append(body.Breakpoints, breakpoint{ID: b.ID, Verified: true, Line: b.Line})
+	}
+	s.breakpoints[args.Source.Path] = ids
+	return body, nil
+}
+
+// setFunctionBreakpoints replaces the breakpoints on procedure names.
+func (s *Server) setFunctionBreakpoints(arguments json.RawMessage) (any, error) {
+	var args setFunctionBreakpointsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	s.state.Lock()
+	defer s.state.Unlock()
+	for _, id := range s.functions {
+		s.debugger.Delete(id)
+	}
+	s.functions = nil
+	body := breakpointsBody{Breakpoints: []breakpoint{}}
+	for _, fb := range args.Breakpoints {
+		b := s.debugger.Add(debugger.Breakpoint{Procedure: fb.Name})
+		s.functions = append(s.functions, b.ID)
+		body.Breakpoints = append(body.Breakpoints, breakpoint{ID: b.ID, Verified: true})
+	}
+	return body, nil
+}
+
+// stackTrace returns the frames of the paused program, innermost first. The innermost
+// frame is at the paused expression, and each other frame at the call it is making.
+func (s *Server) stackTrace() (any, error) {
+	s.state.Lock()
+	stop := s.stop
+	s.state.Unlock()
+	if stop == nil {
+		return nil, ErrNotPaused
+	}
+	calls := stop.Backtrace()
+	at := stop.Position
+	body := stackTraceBody{StackFrames: make([]stackFrame, 0, len(calls)+1)}
+	for i := 0; i <= len(calls); i++ {
+		name := "top level"
+		if i < len(calls) {
+			name = calls[i].Procedure
+		}
+		frame := stackFrame{ID: i, Name: name}
+		if at.IsValid() {
+			frame.Source = &source{Name: filepath.Base(at.Filename), Path: at.Filename}
+			frame.Line, frame.Column = at.Line, at.Column
+		}
+		body.StackFrames = append(body.StackFrames, frame)
+		if i < len(calls) {
+			at = calls[i].Call
+		}
+	}
+	body.TotalFrames = len(body.StackFrames)
+	return body, nil
+}
+
+// scopesOf returns the environment frames of a stack frame. Only the innermost stack
+// frame has them; its variables are referenced by the position of the environment
+// frame, counting from 1.
+func (s *Server) scopesOf(arguments json.RawMessage) (any, error) {
+	var args frameArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	s.state.Lock()
+	defer s.state.Unlock()
+	if s.stop == nil {
+		return nil, ErrNotPaused
+	}
+	body := scopesBody{Scopes: []scope{}}
+	if args.FrameID != 0 {
+		return body, nil
+	}
+	for i, sc := range s.scopes {
+		name := "Closure"
+		switch {
+		case sc.Global:
+			name = "Globals"
+		case i == 0:
+			name = "Locals"
+		}
+		body.Scopes = append(body.Scopes, scope{Name: name, VariablesReference: i + 1, Expensive: sc.Global})
+	}
+	return body, nil
+}
+
+// variables returns the bindings of an environment frame of the paused program.
+func (s *Server) variables(arguments json.RawMessage) (any, error) {
+	var args variablesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	s.state.Lock()
+	defer s.state.Unlock()
+	if s.stop == nil {
+		return nil, ErrNotPaused
+	}
+	if args.VariablesReference < 1 || args.VariablesReference > len(s.scopes) {
+		return nil, fmt.Errorf("%w: unknown variables reference %d", ErrProtocol, args.VariablesReference)
+	}
+	body := variablesBody{Variables: []variable{}}
+	for _, b := range s.scopes[args.VariablesReference-1].Bindings {
+		body.Variables = append(body.Variables, variable{Name: b.Name, Value: b.Value.WriteString()})
+	}
+	return body, nil
+}
+
+// evaluate evaluates an expression on the goroutine of the paused program.
+func (s *Server) evaluate(arguments json.RawMessage) (any, error) {
+	var args evaluateArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProtocol, err)
+	}
+	s.state.Lock()
+	paused := s.stop != nil
+	s.state.Unlock()
+	if !paused {
+		return nil, ErrNotPaused
+	}
+	e := evaluation{expression: args.Expression, reply: make(chan evaluateResult)}
+	s.evals <- e
+	result := <-e.reply
+	if result.err != nil {
+		return nil, result.err
+	}
+	return evaluateBody{Result: result.value.WriteString()}, nil
+}
+
+// respond answers req with body, or with err when it failed.
+func (s *Server) respond(req request, body any, err error) {
+	resp := response{Type: "response", RequestSeq: req.Seq, Success: err == nil, Command: req.Command, Body: body}
+	if err != nil {
+		resp.Message, resp.Body = err.Error(), nil
+	}
+	s.send(resp)
+}
+
+// send writes msg with the next sequence number.
+func (s *Server) send(msg any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	switch m := msg.(type) {
+	case response:
+		m.Seq = s.seq
+		msg = m
+	case event:
+		m.Seq = s.seq
+		msg = m
+	}
+	_ = writeMessage(s.out, msg)
+}
+
+// outputWriter sends what the program writes to the client as output events.
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	w.server.send(event{Type: "event", Event: "output", Body: outputBody{Category: w.category, Output: string(p)}})
+	return len(p), nil
+}
@@ -0,0 +1,123 @@
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTranscripts replays the recorded sessions in testdata/*.dap. Each line of a
+// transcript is a message sent to the server, prefixed with ->, or a message the
+// server is expected to send next, prefixed with <-. Lines starting with # are comments.
+func TestTranscripts(t *testing.T) {
+	transcripts, err := filepath.Glob("testdata/*.dap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, transcript := range transcripts {
+		t.Run(strings.TrimSuffix(filepath.Base(transcript), ".dap"), func(t *testing.T) {
+			data, err := os.ReadFile(transcript)
+			if err != nil {
+				t.Fatal(err)
+			}
+			replay(t, string(data))
+		})
+	}
+}
+
+func replay(t *testing.T, transcript string) {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	served := make(chan error, 1)
+	go func() {
+		served <- NewServer(serverIn, serverOut).Serve(context.Background())
+		_ = serverOut.Close()
+	}()
+	messages := make(chan []byte)
+	go func() {
+		defer close(messages)
+		r := bufio.NewReader(clientIn)
+		for {
+			content, err := readMessage(r)
+			if err != nil {
+				return
+			}
+			messages <- content
+		}
+	}()
+
+	for n, line := range strings.Split(transcript, "\n") {
+		direction, msg, _ := strings.Cut(line, " ")
+		var want any
+		switch direction {
+		case "->", "<-":
+			if err := json.Unmarshal([]byte(msg), &want); err != nil {
+				t.Fatalf("line %d: %v", n+1, err)
+			}
+		default:
+			continue
+		}
+		if direction == "->" {
+			if err := writeMessage(clientOut, want); err != nil {
+				t.Fatalf("line %d: %v", n+1, err)
+			}
+			continue
+		}
+		select {
+		case content, ok := <-messages:
+			if !ok {
+				t.Fatalf("line %d: the server closed the connection, want %s", n+1, msg)
+			}
+			var got any
+			if err := json.Unmarshal(content, &got); err != nil {
+				t.Fatalf("line %d: %v", n+1, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("line %d: got %s, want %s", n+1, content, msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("line %d: timed out waiting for %s", n+1, msg)
+		}
+	}
+	_ = clientOut.Close()
+	if err := <-served; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+	if content, ok := <-messages; ok {
+		t.Errorf("unexpected message %s", content)
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr error
+	}{
+		{name: "content", in: "Content-Length: 2\r\n\r\n{}", want: "{}"},
+		{name: "invalid length", in: "Content-Length: x\r\n\r\n", wantErr: ErrProtocol},
+		{name: "negative length", in: "Content-Length: -1\r\n\r\n", wantErr: ErrProtocol},
+		{name: "length above the limit", in: "Content-Length: 1000000000\r\n\r\n", wantErr: ErrProtocol},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readMessage(bufio.NewReader(strings.NewReader(tt.in)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("readMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("readMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+(define (first xs) (car xs))
+(display "start")
+(newline)
+(first '())
+(display "unreachable")
@@ -0,0 +1,326 @@
+// Package debugger pauses Scheme evaluations at breakpoints and steps through them.
+// A Debugger is installed in a runtime as its builtins.Hook and reports every pause to a
+// front end, such as the REPL's ,debug mode, which inspects the paused evaluation through
+// the Stop it is given and decides how the evaluation resumes.
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/scanner"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// ErrAborted is the error of an evaluation the front end aborted while it was paused.
+var ErrAborted = errors.New("evaluation aborted")
+
+// Action is how a paused evaluation resumes.
+type Action int
+
+const (
+	// Continue runs until the next breakpoint.
+	Continue Action = iota
+	// StepIn pauses at the next expression evaluated, including those in procedures called.
+	StepIn
+	// StepOver pauses at the next expression evaluated after the current one, without
+	// pausing in the procedures it calls.
+	StepOver
+	// StepOut pauses at the next expression evaluated once the current procedure returns.
+	StepOut
+	// Abort ends the evaluation with ErrAborted.
+	Abort
+)
+
+// Frontend is called on the goroutine of the evaluation whenever it pauses, and returns
+// how it resumes. The evaluation stays paused until Frontend returns.
+type Frontend func(stop *Stop) Action
+
+// Debugger is a builtins.Hook pausing evaluations at its breakpoints and while stepping.
+// Breakpoints may be changed at any time, including by the front end during a pause.
+// Pauses of concurrent threads are reported to the front end one at a time.
+type Debugger struct {
+	frontend Frontend
+	enabled  atomic.Bool
+	pause    sync.Mutex
+
+	mu          sync.Mutex
+	breakpoints []Breakpoint
+	nextID      int
+	action      Action
+	from        level
+	last        scanner.Position
+	suspended   bool
+}
+
+// level is how deep an evaluation is: the procedure calls in progress, and the nested
+// evaluations of the innermost one. Steps compare the level of an expression with the
+// level of the stop they were requested at.
+type level struct {
+	calls int
+	depth int
+}
+
+// within reports whether l is at or above the level m, in the same call or one of its callers.
+func (l level) within(m level) bool {
+	return l.calls < m.calls || l.calls == m.calls && l.depth <= m.depth
+}
+
+// New returns an enabled debugger reporting pauses to frontend.
+func New(frontend Frontend) *Debugger {
+	d := &Debugger{frontend: frontend, nextID: 1}
+	d.enabled.Store(true)
+	return d
+}
+
+// SetEnabled turns the debugger on or off. A debugger that is off never pauses.
+func (d *Debugger) SetEnabled(enabled bool) {
+	d.enabled.Store(enabled)
+}
+
+// Enabled reports whether the debugger is on.
+func (d *Debugger) Enabled() bool {
+	return d.enabled.Load()
+}
+
+// Break adds the breakpoint described by spec, as parsed by ParseBreakpoint.
+func (d *Debugger) Break(spec string) (Breakpoint, error) {
+	b, err := ParseBreakpoint(spec)
+	if err != nil {
+		return b, err
+	}
+	return d.Add(b), nil
+}
+
+// Add adds the breakpoint b, returning it with its identifier.
+func (d *Debugger) Add(b Breakpoint) Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	b.ID = d.nextID
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, b)
+	return b
+}
+
+// Delete removes the breakpoint with the identifier id, and reports whether there was one.
+func (d *Debugger) Delete(id int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := len(d.breakpoints)
+	d.breakpoints = slices.DeleteFunc(d.breakpoints, func(b Breakpoint) bool { return b.ID == id })
+	return len(d.breakpoints) < n
+}
+
+// Breakpoints returns the breakpoints in the order they were added.
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.breakpoints)
+}
+
+// Step makes the next evaluation pause at its first expression.
+func (d *Debugger) Step() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.action = StepIn
+}
+
+// Finish ends the step in progress, once the evaluation it was made in completes, so that
+// it does not continue into the next evaluation.
+func (d *Debugger) Finish() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.action = Continue
+	d.last = scanner.Position{}
+}
+
+// Evaluating implements builtins.Hook. It pauses before expr when a step ends there or
+// when expr starts a line with a breakpoint. A line breakpoint pauses the evaluation
+// when it reaches the line from another line, not again for each expression on it.
+func (d *Debugger) Evaluating(expr values.Interface, rt *builtins.Runtime) error {
+	if !d.enabled.Load() {
+		return nil
+	}
+	pos, _ := values.SourcePosition(expr)
+	at := level{calls: rt.StackDepth(), depth: rt.Depth()}
+	d.mu.Lock()
+	if d.suspended {
+		d.mu.Unlock()
+		return nil
+	}
+	stop := &Stop{Reason: Step, Expr: expr, Position: pos, Runtime: rt, level: at, debugger: d}
+	stepped := d.stepEnds(at)
+	hit, ok := Breakpoint{}, false
+	if pos.IsValid() {
+		if pos.Line != d.last.Line || pos.Filename != d.last.Filename {
+			hit, ok = d.lineBreakpoint(pos)
+		}
+		d.last = pos
+	}
+	d.mu.Unlock()
+	switch {
+	case ok:
+		stop.Reason, stop.Breakpoint = AtBreakpoint, hit
+	case !stepped:
+		return nil
+	}
+	return d.stop(stop)
+}
+
+// Calling implements builtins.Hook. It pauses before calling a procedure implemented in
+// Go with a breakpoint. Procedures defined in Scheme pause once they are Entered.
+func (d *Debugger) Calling(proc values.Interface, args values.Interface, rt *builtins.Runtime) error {
+	if l, ok := proc.(builtins.LambdaExpr); ok && l.Compound() {
+		return nil
+	}
+	return d.procedureStop(proc, args, rt)
+}
+
+// Entered implements builtins.Hook. It pauses a procedure defined in Scheme with a
+// breakpoint once its arguments are bound, so that the paused evaluation sees them.
+func (d *Debugger) Entered(proc values.Interface, args values.Interface, rt *builtins.Runtime) error {
+	return d.procedureStop(proc, args, rt)
+}
+
+// procedureStop pauses the call of proc with args in rt if proc has a breakpoint.
+func (d *Debugger) procedureStop(proc values.Interface, args values.Interface, rt *builtins.Runtime) error {
+	if !d.enabled.Load() {
+		return nil
+	}
+	name := procedureName(proc)
+	if name == "" {
+		return nil
+	}
+	d.mu.Lock()
+	hit, ok := d.procedureBreakpoint(name)
+	if d.suspended || !ok {
+		d.mu.Unlock()
+		return nil
+	}
+	d.mu.Unlock()
+	stop := &Stop{
+		Reason:     AtBreakpoint,
+		Breakpoint: hit,
+		Procedure:  proc,
+		Args:       args,
+		Runtime:    rt,
+		// the call is made from the level of its call site, which steps start from
+		level:    level{calls: rt.StackDepth() - 1, depth: rt.Depth()},
+		debugger: d,
+	}
+	if stack := rt.Stack(); len(stack) > 0 {
+		stop.Position = stack[0].Call
+	}
+	return d.stop(stop)
+}
+
+// stepEnds reports whether the step in progress ends at the level at. d must be locked.
+func (d *Debugger) stepEnds(at level) bool {
+	switch d.action {
+	case StepIn:
+		return true
+	case StepOver:
+		return at.within(d.from)
+	case StepOut:
+		return at.calls < d.from.calls
+	}
+	return false
+}
+
+func (d *Debugger) lineBreakpoint(pos scanner.Position) (Breakpoint, bool) {
+	for _, b := range d.breakpoints {
+		if b.Procedure == "" && b.matches(pos) {
+			return b, true
+		}
+	}
+	return Breakpoint{}, false
+}
+
+func (d *Debugger) procedureBreakpoint(name string) (Breakpoint, bool) {
+	for _, b := range d.breakpoints {
+		if b.Procedure != "" && b.Procedure == name {
+			return b, true
+		}
+	}
+	return Breakpoint{}, false
+}
+
+// stop reports stop to the front end and prepares the evaluation to resume as it asks.
+func (d *Debugger) stop(stop *Stop) error {
+	d.pause.Lock()
+	defer d.pause.Unlock()
+	action := d.frontend(stop)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.action, d.from = action, stop.level
+	if action == Abort {
+		d.action = Continue
+		return ErrAborted
+	}
+	return nil
+}
+
+// procedureName returns the name proc was defined with, if any.
+func procedureName(proc values.Interface) string {
+	if l, ok := proc.(builtins.LambdaExpr); ok {
+		return l.Name
+	}
+	return ""
+}
+
+// Breakpoint pauses evaluations calling a procedure or reaching a line of source code.
+type Breakpoint struct {
+	ID int
+	// Procedure is the name of the procedure whose calls pause, or "" for a line breakpoint.
+	Procedure string
+	// File is the file of a line breakpoint, or "" for a line of any file. It matches
+	// files by their name or by a trailing part of their path.
+	File string
+	Line int
+}
+
+// ParseBreakpoint parses a breakpoint on a procedure name, such as fact, on a line of
+// a file, such as lib.scm:12, or on a line of any file, such as 12.
+func ParseBreakpoint(spec string) (Breakpoint, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Breakpoint{}, fmt.Errorf("%w: empty breakpoint", builtins.ErrBadArgument)
+	}
+	file, line := "", spec
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		file, line = spec[:i], spec[i+1:]
+	}
+	n, err := strconv.Atoi(line)
+	switch {
+	case err != nil:
+		return Breakpoint{Procedure: spec}, nil
+	case n < 1:
+		return Breakpoint{}, fmt.Errorf("%w: invalid line in breakpoint %s", builtins.ErrBadArgument, spec)
+	}
+	return Breakpoint{File: file, Line: n}, nil
+}
+
+func (b Breakpoint) matches(pos scanner.Position) bool {
+	if pos.Line != b.Line {
+		return false
+	}
+	return b.File == "" || b.File == pos.Filename || filepath.Base(pos.Filename) == b.File ||
+		strings.HasSuffix(filepath.ToSlash(pos.Filename), "/"+strings.TrimPrefix(filepath.ToSlash(b.File), "./"))
+}
+
+func (b Breakpoint) String() string {
+	switch {
+	case b.Procedure != "":
+		return b.Procedure
+	case b.File != "":
+		return fmt.Sprintf("%s:%d", b.File, b.Line)
+	}
+	return fmt.Sprintf("line %d", b.Line)
+}
@@ -0,0 +1,111 @@
+package debugger
+
+import (
+	"fmt"
+	"slices"
+	"text/scanner"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Reason is why an evaluation paused.
+type Reason int
+
+const (
+	// Step is a pause at the end of a step.
+	Step Reason = iota
+	// AtBreakpoint is a pause at a breakpoint.
+	AtBreakpoint
+)
+
+// Stop is a paused evaluation. It is about to evaluate Expr, or, at a procedure
+// breakpoint, to run Procedure called with Args. A procedure defined in Scheme pauses
+// with its arguments bound in the environment of Runtime. A Stop is only valid until
+// the front end it was reported to returns.
+type Stop struct {
+	Reason     Reason
+	Breakpoint Breakpoint
+	Expr       values.Interface
+	Procedure  values.Interface
+	Args       values.Interface
+	// Position is the position of Expr, or of the call of Procedure, in source code.
+	Position scanner.Position
+	// Runtime is the runtime the evaluation paused in.
+	Runtime *builtins.Runtime
+
+	level    level
+	debugger *Debugger
+}
+
+// Scope is an environment frame of a paused evaluation.
+type Scope struct {
+	Bindings []Binding
+	// Global is set for the outermost frame, holding the global definitions.
+	Global bool
+}
+
+// Binding is a variable of an environment frame.
+type Binding struct {
+	Name  string
+	Value values.Interface
+}
+
+// Scopes returns the environment frames visible from the paused evaluation, innermost
+// first, with their bindings sorted by name.
+func (s *Stop) Scopes() []Scope {
+	var scopes []Scope
+	env, ok := s.Runtime.Env, true
+	for ok {
+		names := env.Names()
+		slices.Sort(names)
+		scope := Scope{Bindings: make([]Binding, 0, len(names))}
+		for _, name := range names {
+			if v, found := env.Lookup(name); found {
+				scope.Bindings = append(scope.Bindings, Binding{Name: name, Value: v})
+			}
+		}
+		env, ok = env.Parent()
+		scope.Global = !ok
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// Backtrace returns the procedure calls in progress, innermost first.
+func (s *Stop) Backtrace() []values.Frame {
+	return s.Runtime.Stack()
+}
+
+// Evaluate evaluates expr in the environment of the paused evaluation. Breakpoints do not
+// pause it. It must be called on the goroutine of the paused evaluation, by its front end.
+func (s *Stop) Evaluate(expr values.Interface) (values.Interface, error) {
+	d := s.debugger
+	d.mu.Lock()
+	d.suspended = true
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		d.suspended = false
+		d.mu.Unlock()
+	}()
+	return s.Runtime.Evaluate(expr)
+}
+
+// String describes the stop, such as "breakpoint 1, fact called with (3) at line 5".
+func (s *Stop) String() string {
+	var what string
+	switch {
+	case s.Procedure != nil:
+		what = fmt.Sprintf("%s called with %s", procedureName(s.Procedure), s.Args.WriteString())
+	case s.Expr != nil:
+		what = s.Expr.WriteString()
+	}
+	if s.Reason == AtBreakpoint {
+		what = fmt.Sprintf("breakpoint %d, %s", s.Breakpoint.ID, what)
+	}
+	if s.Position.IsValid() {
+		what += " at " + values.Location(s.Position)
+	}
+	return what
+}
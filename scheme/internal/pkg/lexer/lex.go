@@ -23,6 +23,7 @@ const (
 	TokenError              TokenType = "error"
 	TokenNumber             TokenType = "number"
 	TokenInt                TokenType = "int"
+	TokenFloat              TokenType = "float"
 	TokenString             TokenType = "string"
 	TokenBoolean            TokenType = "boolean"
 	TokenRune               TokenType = "rune"
@@ -31,6 +32,7 @@ const (
 	TokenIdent              TokenType = "ident"
 	TokenColonIdent         TokenType = "colon_ident"
 	TokenLParen             TokenType = "("
+	TokenVectorStart        TokenType = "#("
 	TokenRParen             TokenType = ")"
 	TokenLBracket           TokenType = "["
 	TokenRBracket           TokenType = "]"
@@ -38,7 +40,11 @@ const (
 	TokenRBrace             TokenType = "}"
 	TokenSemiColon          TokenType = ";"
 	TokenQuot               TokenType = "'"
+	TokenDot                TokenType = "."
 	TokenLineComment        TokenType = "line_comment"
+	TokenBlockComment       TokenType = "block_comment"
+	TokenDatumComment       TokenType = "#;"
+	TokenWhitespace         TokenType = "whitespace"
 	TokenRelationalOperator TokenType = "relationalOperator"
 	TokenArithmeticOperator TokenType = "arithmeticOperator"
 )
@@ -59,37 +65,66 @@ func (t Token) String() string {
 }
 
 type Scanner struct {
-	scan scanner.Scanner
+	scan   scanner.Scanner
+	pos    scanner.Position
+	trivia bool
 }
 
+// New creates a Scanner reading r. Its positions report the name of r as their source
+// when r is a file.
 func New(r io.Reader) *Scanner {
 	s := &Scanner{
-		scanner.Scanner{},
+		scan: scanner.Scanner{},
 	}
 	s.scan.Init(r)
+	if f, ok := r.(interface{ Name() string }); ok {
+		s.scan.Filename = f.Name()
+	}
+	return s
+}
+
+// NewNamed creates a Scanner whose positions report filename as their source.
+func NewNamed(r io.Reader, filename string) *Scanner {
+	s := New(r)
+	s.scan.Filename = filename
 	return s
 }
 
+// KeepTrivia makes the scanner return each run of whitespace as a TokenWhitespace token
+// instead of skipping it, so that the literals of the tokens it returns spell out its
+// input exactly. It returns s.
+func (s *Scanner) KeepTrivia() *Scanner {
+	s.trivia = true
+	return s
+}
+
+// Position returns the source position at which the most recently returned token starts.
+func (s *Scanner) Position() scanner.Position {
+	return s.pos
+}
+
 // NextToken extract the next token from the input stream
 func (s *Scanner) NextToken() (tok Token) {
 
 	for ch := s.scan.Peek(); ch != scanner.EOF; ch = s.scan.Peek() {
-		if unicode.IsDigit(ch) {
-			return s.consumeNumber()
-		}
-		if unicode.IsLetter(ch) {
-			return s.consumeIdentifier()
+		if unicode.IsSpace(ch) && s.trivia {
+			s.pos = s.scan.Pos()
+			return s.consumeWhitespace()
 		}
 		if unicode.IsSpace(ch) {
 			s.scan.Next()
+			continue
 		}
+		s.pos = s.scan.Pos()
 		switch ch {
 		case '[':
+			_ = s.scan.Next()
 			return Token{
 				Type:    TokenLBracket,
 				Literal: "[",
 			}
 		case ']':
+			_ = s.scan.Next()
 			return Token{
 				Type:    TokenRBracket,
 				Literal: "]",
@@ -98,61 +133,94 @@ func (s *Scanner) NextToken() (tok Token) {
 			return s.consumeLParen()
 		case ')':
 			return s.consumeRParen()
+		case ';':
+			return s.consumeLineComment()
 		case ':':
 			return s.consumeColonIdent()
 		case '"':
 			return s.consumeString()
 		case '\'':
 			return s.consumeQuot()
-		case '<', '>', '=':
-			return s.consumeRelationalOperator()
-		case '+', '-', '*', '/', '%':
-			return s.consumeArithmeticOperatorOrNumber()
 		case '#':
 			return s.consumeLiteral()
 		}
+		if unicode.IsDigit(ch) {
+			return s.consumeNumber()
+		}
+		return s.consumeAtom()
 	}
-
+	s.pos = s.scan.Pos()
 	return Token{Type: TokenEOF}
 }
 
 var (
-	startNumberFunc    = boolean.AnyFunc(onlyRunes([]rune{'-'}), isNumberChar)
-	continueNumberFunc = boolean.NotFunc(boolean.AnyFunc(unicode.IsDigit, onlyRunes([]rune{'.'})))
+	startNumberFunc    = boolean.AnyFunc(onlyRunes([]rune{'-', '+'}), isNumberChar)
+	continueNumberFunc = isDelimiter
 )
 
 func (s *Scanner) consumeNumber() (_ Token) {
 	itxt := s.collectRunes(startNumberFunc, continueNumberFunc)
-	itxt = strings.TrimSpace(itxt)
-	intval, err := strconv.ParseInt(itxt, 10, 64)
-	if err != nil {
+	return s.classifyNumber(itxt)
+}
+
+// classifyNumber converts the text of a numeric literal into an integer or float token.
+func (s *Scanner) classifyNumber(itxt string) Token {
+	if intval, err := strconv.ParseInt(itxt, 10, 64); err == nil {
 		return Token{
-			Type:    TokenError,
+			Type:    TokenNumber,
 			Literal: itxt,
+			Int:     intval,
+		}
+	}
+	if floatval, err := strconv.ParseFloat(itxt, 64); err == nil {
+		return Token{
+			Type:    TokenFloat,
+			Literal: itxt,
+			Float:   floatval,
 		}
 	}
-
 	return Token{
-		Type:    TokenInt,
+		Type:    TokenError,
 		Literal: itxt,
-		Int:     intval,
+		Error: LexError{
+			Position: s.pos,
+			Message:  "invalid number literal",
+		},
 	}
 }
 
 var (
-	startIdentifierFunc    = unicode.IsLetter
-	continueIdentifierFunc = boolean.NotFunc(isIdentifierChar)
+	startIdentifierFunc    = boolean.NotFunc(isDelimiter)
+	continueIdentifierFunc = isDelimiter
 )
 
-func (s *Scanner) consumeIdentifier() (_ Token) {
-
-	sb := strings.Builder{}
-	sb.WriteString(s.collectRunes(startIdentifierFunc, continueIdentifierFunc))
-
+// consumeAtom consumes a run of non-delimiter runes and classifies it as an
+// operator, a signed number, the dot of a dotted pair or an identifier.
+func (s *Scanner) consumeAtom() (_ Token) {
+	txt := s.collectRunes(startIdentifierFunc, continueIdentifierFunc)
+	switch {
+	case txt == ".":
+		return Token{
+			Type:    TokenDot,
+			Literal: txt,
+		}
+	case slices.Contains(relationalOperators, txt):
+		return Token{
+			Type:    TokenRelationalOperator,
+			Literal: txt,
+		}
+	case slices.Contains(arithmeticOperators, txt):
+		return Token{
+			Type:    TokenArithmeticOperator,
+			Literal: txt,
+		}
+	case looksNumeric(txt):
+		return s.classifyNumber(txt)
+	}
 	return Token{
 		Type:    TokenIdent,
-		Literal: sb.String(),
-		Ident:   sb.String(),
+		Literal: txt,
+		Ident:   txt,
 	}
 }
 
@@ -163,27 +231,45 @@ func (s *Scanner) consumeString() (_ Token) {
 		quotChar = s.scan.Next()
 	)
 
-	if quotChar == scanner.String {
-		quotChar = '"'
-	}
-	//sb.WriteRune(quotChar)
-
-	for ch := s.scan.Peek(); ch != scanner.EOF && ch != scanner.String; ch = s.scan.Peek() {
+	sb.WriteRune(quotChar)
+	for ch := s.scan.Peek(); ch != scanner.EOF; ch = s.scan.Peek() {
 		if ch == quotChar {
-			s.scan.Next()
+			sb.WriteRune(s.scan.Next())
+			return Token{
+				Type:    TokenString,
+				Literal: sb.String(),
+				Text:    content.String(),
+			}
+		}
+		sb.WriteRune(s.scan.Next())
+		if ch != '\\' {
+			content.WriteRune(ch)
+			continue
+		}
+		escaped := s.scan.Next()
+		if escaped == scanner.EOF {
 			break
 		}
-		content.WriteRune(s.scan.Next())
-		sb.WriteRune(ch)
+		sb.WriteRune(escaped)
+		content.WriteRune(unescape(escaped))
 	}
-	//sb.WriteRune(quotChar)
 
 	return Token{
-		Type:    TokenString,
+		Type:    TokenError,
 		Literal: sb.String(),
-		Text:    content.String(),
+		Error: LexError{
+			Position: s.pos,
+			Message:  "unterminated string literal",
+		},
 	}
+}
 
+func (s *Scanner) consumeWhitespace() Token {
+	txt := s.collectRunes(unicode.IsSpace, boolean.NotFunc(unicode.IsSpace))
+	return Token{
+		Type:    TokenWhitespace,
+		Literal: txt,
+	}
 }
 
 func (s *Scanner) consumeLParen() (tok Token) {
@@ -203,9 +289,8 @@ func (s *Scanner) consumeRParen() (tok Token) {
 }
 
 var (
-	relationalOperRunes      = list.New('<', '>', '=')
-	relationalOperStartsWith = boolean.AnyFunc(onlyRunes(relationalOperRunes))
-	relationalOperEndToken   = boolean.NotFunc(relationalOperStartsWith)
+	relationalOperators = list.New("<", ">", "=", "<=", ">=")
+	arithmeticOperators = list.New("+", "-", "*", "/", "%")
 )
 
 func (s *Scanner) consumeColonIdent() Token {
@@ -218,18 +303,13 @@ func (s *Scanner) consumeColonIdent() Token {
 	}
 }
 
-// line-comments start with ";;" followed by any text to end of the line
-func (s *Scanner) consumeSemiColonOrLineComment() Token {
-	s.scan.Next()
-	if s.scan.Peek() != ';' {
-		return Token{
-			Type:    TokenSemiColon,
-			Literal: ";",
-		}
-	}
-	_ = s.collectRunes(unicode.IsGraphic, anyBut([]rune{'\n'}))
+// line-comments start with ";" followed by any text to end of the line
+func (s *Scanner) consumeLineComment() Token {
+	txt := s.collectRunes(onlyRunes([]rune{';'}), onlyRunes([]rune{'\n'}))
 	return Token{
-		Type: TokenLineComment,
+		Type:    TokenLineComment,
+		Literal: txt,
+		Text:    strings.TrimSpace(strings.TrimLeft(txt, ";")),
 	}
 }
 
@@ -241,63 +321,144 @@ func (s *Scanner) consumeQuot() Token {
 	}
 }
 
-func (s *Scanner) consumeRelationalOperator() Token {
-	start := s.scan.Next()
-	if slices.Contains(list.New('<', '>'), start) && '=' == s.scan.Peek() {
-		next := s.scan.Next()
+func (s *Scanner) consumeLiteral() Token {
+	_ = s.scan.Next() //#
+	if s.scan.Peek() == '(' {
+		_ = s.scan.Next()
 		return Token{
-			Type:    TokenRelationalOperator,
-			Literal: string(start) + string(next),
+			Type:    TokenVectorStart,
+			Literal: "#(",
+		}
+	}
+	if s.scan.Peek() == '\\' {
+		_ = s.scan.Next() //consume the '\'
+		return s.consumeChar()
+	}
+	if s.scan.Peek() == '|' {
+		return s.consumeBlockComment()
+	}
+	if s.scan.Peek() == ';' {
+		_ = s.scan.Next()
+		return Token{
+			Type:    TokenDatumComment,
+			Literal: "#;",
+		}
+	}
+	txt := s.collectRunes(startIdentifierFunc, continueIdentifierFunc)
+	switch txt {
+	case "t", "true":
+		return Token{
+			Type:    TokenBoolean,
+			Literal: "#" + txt,
+			Bool:    true,
+		}
+	case "f", "false":
+		return Token{
+			Type:    TokenBoolean,
+			Literal: "#" + txt,
+			Bool:    false,
 		}
 	}
 	return Token{
-		Type:    TokenRelationalOperator,
-		Literal: string(start),
+		Type:    TokenError,
+		Literal: "#" + txt,
+		Error: LexError{
+			Position: s.pos,
+			Message:  "invalid boolean literal",
+		},
 	}
 }
 
-func (s *Scanner) consumeArithmeticOperatorOrNumber() Token {
-	oper := s.scan.Next()
-	if oper == '-' && unicode.IsDigit(s.scan.Peek()) {
-		//this is a number not ([operator]  .... )
-		number := s.consumeNumber()
-		number.Literal = fmt.Sprintf("%c%s", oper, number)
-		number.Int = -number.Int
-		number.Float = -number.Float
-		return number
+// consumeBlockComment consumes a #| |# comment, after its #. Block comments nest.
+func (s *Scanner) consumeBlockComment() Token {
+	var sb strings.Builder
+	sb.WriteRune('#')
+	sb.WriteRune(s.scan.Next())
+	depth := 1
+	for ch := s.scan.Next(); ch != scanner.EOF; ch = s.scan.Next() {
+		sb.WriteRune(ch)
+		switch {
+		case ch == '|' && s.scan.Peek() == '#':
+			sb.WriteRune(s.scan.Next())
+			if depth--; depth == 0 {
+				txt := sb.String()
+				return Token{
+					Type:    TokenBlockComment,
+					Literal: txt,
+					Text:    strings.TrimSpace(txt[2 : len(txt)-2]),
+				}
+			}
+		case ch == '#' && s.scan.Peek() == '|':
+			sb.WriteRune(s.scan.Next())
+			depth++
+		}
 	}
 	return Token{
-		Type:    TokenArithmeticOperator,
-		Literal: string(oper),
+		Type:    TokenError,
+		Literal: sb.String(),
+		Error: LexError{
+			Position: s.pos,
+			Message:  "unterminated block comment",
+		},
 	}
 }
 
-func (s *Scanner) consumeLiteral() Token {
-	_ = s.scan.Next() //#
-	if slices.Contains(list.New('t', 'f'), s.scan.Peek()) {
-		val := s.scan.Next()
+var charNames = map[string]rune{
+	"alarm":     '\a',
+	"backspace": '\b',
+	"delete":    0x7f,
+	"escape":    0x1b,
+	"newline":   '\n',
+	"null":      0,
+	"nul":       0,
+	"return":    '\r',
+	"space":     ' ',
+	"tab":       '\t',
+}
+
+// consumeChar consumes the body of a #\ character literal, which is either a
+// single rune, a character name such as space or a hex scalar value such as x41.
+func (s *Scanner) consumeChar() Token {
+	charLiteral := s.scan.Next()
+	if charLiteral == scanner.EOF {
 		return Token{
-			Type:    TokenBoolean,
-			Literal: "#" + string(val),
-			Bool:    val == 't',
+			Type:    TokenError,
+			Literal: "#\\",
+			Error: LexError{
+				Position: s.pos,
+				Message:  "unterminated character literal",
+			},
+		}
+	}
+	if unicode.IsLetter(charLiteral) && !isDelimiter(s.scan.Peek()) {
+		name := string(charLiteral) + s.collectRunes(boolean.NotFunc(isDelimiter), isDelimiter)
+		if r, ok := charNames[name]; ok {
+			charLiteral = r
+		} else if code, err := strconv.ParseInt(name[1:], 16, 32); err == nil && name[0] == 'x' {
+			charLiteral = rune(code)
+		} else {
+			return Token{
+				Type:    TokenError,
+				Literal: "#\\" + name,
+				Error: LexError{
+					Position: s.pos,
+					Message:  "invalid character literal",
+				},
+			}
 		}
-	} else if s.scan.Peek() == '\\' {
-		_ = s.scan.Next() //consume the '\'
-		charLiteral := s.scan.Next()
-
 		return Token{
 			Type:    TokenRune,
-			Literal: fmt.Sprintf("#\\%c", charLiteral),
+			Literal: "#\\" + name,
 			Text:    string(charLiteral),
 			Int:     int64(charLiteral),
 		}
 	}
+
 	return Token{
-		Type:    TokenError,
-		Literal: "#" + string(s.scan.Peek()),
-		Error: LexError{
-			Message: "invalid boolean literal",
-		},
+		Type:    TokenRune,
+		Literal: fmt.Sprintf("#\\%c", charLiteral),
+		Text:    string(charLiteral),
+		Int:     int64(charLiteral),
 	}
 }
 
@@ -319,24 +480,6 @@ tokenComplete:
 	return sb.String()
 }
 
-func isTerm(termChar rune) types.RuneClassifier {
-	return func(r rune) bool {
-		return termChar == r
-	}
-}
-
-func anyStart(rune) bool { return true }
-
-func anyBut(notAllowed []rune) types.RuneClassifier {
-	return func(r rune) bool {
-		for _, a := range notAllowed {
-			if a == r {
-				return true
-			}
-		}
-		return false
-	}
-}
 func onlyRunes(allowed []rune) types.RuneClassifier {
 	return func(r rune) bool {
 		for _, a := range allowed {
@@ -348,10 +491,34 @@ func onlyRunes(allowed []rune) types.RuneClassifier {
 	}
 }
 
-func isIdentifierChar(c rune) bool {
-	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
+// isDelimiter reports whether r terminates an identifier or number.
+func isDelimiter(r rune) bool {
+	return r == scanner.EOF || unicode.IsSpace(r) || strings.ContainsRune("()[]\"';", r)
 }
 
 func isNumberChar(c rune) bool {
 	return unicode.IsDigit(c) || c == '.'
 }
+
+// looksNumeric reports whether txt starts like a number, e.g. -1, +2 or .5
+func looksNumeric(txt string) bool {
+	txt = strings.TrimLeft(txt, "+-")
+	txt = strings.TrimPrefix(txt, ".")
+	return txt != "" && unicode.IsDigit(rune(txt[0]))
+}
+
+func unescape(r rune) rune {
+	switch r {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case 'a':
+		return '\a'
+	case '0':
+		return 0
+	}
+	return r
+}
@@ -141,3 +141,61 @@ func TestScanner_NextToken(t *testing.T) {
 		})
 	}
 }
+
+func TestScanner_KeepTrivia(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		wantTypes []TokenType
+	}{
+		{
+			name:      "whitespace and comments",
+			src:       "  (f\t1) ; one\n\n'x",
+			wantTypes: []TokenType{TokenWhitespace, TokenLParen, TokenIdent, TokenWhitespace, TokenNumber, TokenRParen, TokenWhitespace, TokenLineComment, TokenWhitespace, TokenQuot, TokenIdent},
+		},
+		{
+			name:      "literals keep their spelling",
+			src:       "#true #\\x41 #\\space \"a\\tb\" +5 .5 #(1) [a . b] :key",
+			wantTypes: []TokenType{TokenBoolean, TokenWhitespace, TokenRune, TokenWhitespace, TokenRune, TokenWhitespace, TokenString, TokenWhitespace, TokenNumber, TokenWhitespace, TokenFloat, TokenWhitespace, TokenVectorStart, TokenNumber, TokenRParen, TokenWhitespace, TokenLBracket, TokenIdent, TokenWhitespace, TokenDot, TokenWhitespace, TokenIdent, TokenRBracket, TokenWhitespace, TokenColonIdent},
+		},
+		{
+			name:      "block and datum comments",
+			src:       "#| a #| nested |# b |# 1 #;(x) 2",
+			wantTypes: []TokenType{TokenBlockComment, TokenWhitespace, TokenNumber, TokenWhitespace, TokenDatumComment, TokenLParen, TokenIdent, TokenRParen, TokenWhitespace, TokenNumber},
+		},
+		{
+			name:      "unterminated block comment",
+			src:       "#| a #| b |#",
+			wantTypes: []TokenType{TokenError},
+		},
+		{
+			name:      "errors",
+			src:       "#x \"open",
+			wantTypes: []TokenType{TokenError, TokenWhitespace, TokenError},
+		},
+		{
+			name:      "character at end of input",
+			src:       "#\\",
+			wantTypes: []TokenType{TokenError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(bytes.NewBufferString(tt.src)).KeepTrivia()
+			var (
+				types []TokenType
+				text  bytes.Buffer
+			)
+			for tok := s.NextToken(); tok.Type != TokenEOF; tok = s.NextToken() {
+				types = append(types, tok.Type)
+				text.WriteString(tok.Literal)
+			}
+			if !reflect.DeepEqual(types, tt.wantTypes) {
+				t.Errorf("token types = %v, want %v", types, tt.wantTypes)
+			}
+			if text.String() != tt.src {
+				t.Errorf("literals spell %q, want %q", text.String(), tt.src)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/cst"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/resolve"
+)
+
+// checker records the findings of a source from the resolution of its identifiers.
+type checker struct {
+	filename string
+	globals  *builtins.Runtime
+	findings []Finding
+}
+
+func newChecker(filename string, globals *builtins.Runtime) *checker {
+	return &checker{filename: filename, globals: globals}
+}
+
+// check resolves the identifiers of f and records the findings of every check.
+func (c *checker) check(f *cst.File) {
+	res := resolve.File(f, c.globals)
+	if !res.Opaque {
+		for _, id := range res.Unbound {
+			c.report(id, CheckUnbound, "undefined identifier: %s", id.Token.Literal)
+		}
+	}
+	for _, b := range res.Bindings {
+		c.checkBinding(b)
+	}
+	for _, v := range res.Values {
+		if v.Special == "if" && len(v.Node.Children) == 3 {
+			c.report(v.Node, CheckIfWithoutElse, "if without else used as a value")
+		}
+	}
+	for _, call := range res.Calls {
+		c.checkCall(call, res.Assigned)
+	}
+}
+
+func (c *checker) report(n *cst.Node, check, format string, args ...any) {
+	c.findings = append(c.findings, Finding{
+		File:      c.filename,
+		Line:      n.Pos.Line,
+		Column:    n.Pos.Column,
+		EndLine:   n.End.Line,
+		EndColumn: n.End.Column,
+		Check:     check,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// definition reports whether b is bound by define or define-record-type.
+func definition(b *resolve.Binding) bool {
+	return b.Kind == resolve.Define || b.Kind == resolve.RecordType
+}
+
+// checkBinding reports a definition that repeats a definition of the same body, a binding
+// that shadows a builtin, and let bindings and parameters that are never referred to.
+func (c *checker) checkBinding(b *resolve.Binding) {
+	if prev := b.Replaces; prev != nil && definition(b) && definition(prev) {
+		c.report(b.ID, CheckDuplicateDefine, "duplicate definition: %s (first defined at line %d)", b.Name, prev.ID.Pos.Line)
+	} else if _, ok := c.globals.Env.Lookup(b.Name); ok {
+		c.report(b.ID, CheckShadow, "binding shadows builtin: %s", b.Name)
+	}
+	if b.Used || b.Name[0] == '_' {
+		return
+	}
+	switch b.Kind {
+	case resolve.Parameter:
+		c.report(b.ID, CheckUnused, "unused parameter: %s", b.Name)
+	case resolve.Let:
+		c.report(b.ID, CheckUnused, "unused variable: %s", b.Name)
+	}
+}
+
+// checkCall checks the number of arguments passed to a procedure of known arity. The
+// arity of a name assigned with set! anywhere in the source is not trusted.
+func (c *checker) checkCall(call resolve.Call, assigned map[string]bool) {
+	name, ok := call.Form.Children[0].Identifier()
+	if !ok || call.Dotted || assigned[name] {
+		return
+	}
+	var arity *builtins.Arity
+	if call.Binding != nil {
+		arity = call.Binding.Arity
+	} else if v, ok := c.globals.Env.Lookup(name); ok {
+		if p, ok := v.(interface{ Arity() builtins.Arity }); ok {
+			a := p.Arity()
+			arity = &a
+		}
+	}
+	if arity != nil && !arity.Accepts(call.Args) {
+		c.report(call.Form, CheckArity, "wrong number of arguments to %s: expected %s, got %d", name, arity, call.Args)
+	}
+}
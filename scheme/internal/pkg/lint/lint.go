@@ -0,0 +1,126 @@
+// Package lint checks Scheme sources for likely mistakes without evaluating them:
+// unbound identifiers, unused let bindings and parameters, calls with the wrong number
+// of arguments to procedures whose arity is known, definitions shadowing builtins, if
+// without else where its value is used, and names defined twice in the same body.
+//
+// Sources are read into concrete syntax trees, so that findings carry the positions of
+// the code they are about and can be suppressed with comments. A comment
+//
+//	; lint:ignore unused arity
+//
+// on the lines before a form suppresses the findings of the named checks within the
+// form, and at the end of a line those on the line. Without check names it suppresses
+// every finding. A comment lint:file-ignore suppresses the findings of the whole source.
+package lint
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/cst"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+// The checks of the linter, which name the findings they report.
+const (
+	CheckUnbound         = "unbound"
+	CheckUnused          = "unused"
+	CheckArity           = "arity"
+	CheckShadow          = "shadow"
+	CheckIfWithoutElse   = "if-without-else"
+	CheckDuplicateDefine = "duplicate-define"
+)
+
+// Finding is a problem found in a source, with the range of the code it is about.
+type Finding struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	Check     string `json:"check"`
+	Message   string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", f.File, f.Line, f.Column, f.Message, f.Check)
+}
+
+// Source checks the source src, named filename in its findings, resolving the names it
+// does not define against the global environment of globals. The findings are sorted
+// by position. It returns cst.ErrSyntax when src cannot be read.
+func Source(src io.Reader, filename string, globals *builtins.Runtime) ([]Finding, error) {
+	f, err := cst.Read(src, filename)
+	if err != nil {
+		return nil, err
+	}
+	c := newChecker(filename, globals)
+	c.check(f)
+	findings := suppress(c.findings, f)
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return findings, nil
+}
+
+// directive is a lint:ignore comment, suppressing the findings of checks, or of every
+// check when it names none, from line first to line last.
+type directive struct {
+	checks      []string
+	first, last int
+}
+
+func (d directive) suppresses(f Finding) bool {
+	return f.Line >= d.first && f.Line <= d.last && (len(d.checks) == 0 || slices.Contains(d.checks, f.Check))
+}
+
+// suppress returns the findings that the directives of f do not suppress.
+func suppress(findings []Finding, f *cst.File) []Finding {
+	var directives []directive
+	// directives leading a node span it, and the others the line they are on
+	add := func(trivia []cst.Trivia, first, last int) {
+		for _, t := range trivia {
+			if !t.IsComment() {
+				continue
+			}
+			text := strings.TrimSpace(strings.TrimLeft(t.Token.Literal, ";"))
+			name, rest, _ := strings.Cut(text, " ")
+			checks := strings.FieldsFunc(rest, func(r rune) bool { return r == ' ' || r == ',' || r == '\t' })
+			switch name {
+			case "lint:ignore":
+				d := directive{checks: checks, first: first, last: last}
+				if first == 0 {
+					d.first, d.last = t.Pos.Line, t.Pos.Line
+				}
+				directives = append(directives, d)
+			case "lint:file-ignore":
+				directives = append(directives, directive{checks: checks, first: 1, last: math.MaxInt})
+			}
+		}
+	}
+	var visit func(n *cst.Node)
+	visit = func(n *cst.Node) {
+		add(n.Leading, n.Pos.Line, n.End.Line)
+		add(n.Trailing, 0, 0)
+		add(n.Inner, 0, 0)
+		for _, child := range n.Children {
+			visit(child)
+		}
+	}
+	for _, n := range f.Nodes {
+		visit(n)
+	}
+	add(f.Trailing, 0, 0)
+
+	kept := findings[:0]
+	for _, finding := range findings {
+		if !slices.ContainsFunc(directives, func(d directive) bool { return d.suppresses(finding) }) {
+			kept = append(kept, finding)
+		}
+	}
+	return kept
+}
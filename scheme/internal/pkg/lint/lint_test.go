@@ -0,0 +1,167 @@
+package lint
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/cst"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "clean",
+			src: `(define (fact n)
+  (if (= n 0) 1 (* n (fact (- n 1)))))
+(define-record-type point (make-point x y) point? (x point-x set-point-x!))
+(let loop ((i 0)) (if (< i 3) (loop (+ i 1)) (point-x (make-point i 0))))
+(define (ignore _x) 0)
+(define add (lambda (a . rest) (apply + a rest)))
+(display (add 1 2 3))
+(if #t (display "side effect"))`,
+		},
+		{
+			name: "unbound identifiers",
+			src:  "(define (f x) (g x y))\n(define (g a b) (list a b))\n(set! z 1)",
+			want: []string{
+				"t.scm:1:20: undefined identifier: y (unbound)",
+				"t.scm:3:7: undefined identifier: z (unbound)",
+			},
+		},
+		{
+			name: "format to t",
+			src:  "(format t \"~a\" 1)",
+		},
+		{
+			name: "loaded code may bind any name",
+			src:  "(load \"lib.scm\")\n(helper 1)",
+		},
+		{
+			name: "unused bindings",
+			src:  "(define (f x y) x)\n(let ((a 1) (b 2)) b)\n(lambda args 0)",
+			want: []string{
+				"t.scm:1:14: unused parameter: y (unused)",
+				"t.scm:2:8: unused variable: a (unused)",
+				"t.scm:3:9: unused parameter: args (unused)",
+			},
+		},
+		{
+			name: "wrong arity",
+			src:  "(define (f a b) (+ a b))\n(f 1)\n(car 1 2)\n(define g (lambda (x . xs) (cons x xs)))\n(g)\n(f 1 . args)",
+			want: []string{
+				"t.scm:2:1: wrong number of arguments to f: expected 2, got 1 (arity)",
+				"t.scm:3:1: wrong number of arguments to car: expected 1, got 2 (arity)",
+				"t.scm:5:1: wrong number of arguments to g: expected at least 1, got 0 (arity)",
+				"t.scm:6:8: undefined identifier: args (unbound)",
+			},
+		},
+		{
+			name: "assigned procedures have no known arity",
+			src:  "(define (f a) a)\n(set! f (lambda (a b) (+ a b)))\n(f 1 2)",
+		},
+		{
+			name: "shadowed builtins",
+			src:  "(define (list . xs) xs)\n(define (f car) car)\n(let ((length 1)) length)",
+			want: []string{
+				"t.scm:1:10: binding shadows builtin: list (shadow)",
+				"t.scm:2:12: binding shadows builtin: car (shadow)",
+				"t.scm:3:8: binding shadows builtin: length (shadow)",
+			},
+		},
+		{
+			name: "if without else",
+			src:  "(define x (if #t 1))\n(display (if #f 2))\n(let ((y (if #t 3))) y)\n(if #t (display x))\n(define (f) (if #t 1))",
+			want: []string{
+				"t.scm:1:11: if without else used as a value (if-without-else)",
+				"t.scm:2:10: if without else used as a value (if-without-else)",
+				"t.scm:3:10: if without else used as a value (if-without-else)",
+			},
+		},
+		{
+			name: "duplicate definitions",
+			src:  "(define x 1)\n(define (f) (define y 1) (define y 2) y)\n(define x 2)",
+			want: []string{
+				"t.scm:2:34: duplicate definition: y (first defined at line 2) (duplicate-define)",
+				"t.scm:3:9: duplicate definition: x (first defined at line 1) (duplicate-define)",
+			},
+		},
+	}
+	globals := builtins.NewRuntime()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := Source(strings.NewReader(tt.src), "t.scm", globals)
+			if err != nil {
+				t.Fatalf("Source() error = %v", err)
+			}
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Source() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppression(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "comment before a form",
+			src:  "; lint:ignore unused\n(define (f x y)\n  (g))\n(define (h z) 0)",
+			want: []string{
+				"t.scm:3:4: undefined identifier: g (unbound)",
+				"t.scm:4:12: unused parameter: z (unused)",
+			},
+		},
+		{
+			name: "comment at the end of a line",
+			src:  "(define (f x) ; lint:ignore unused, shadow\n  (let ((list 1)) (car 1 2))) ; lint:ignore\n(car 1 2)",
+			want: []string{"t.scm:3:1: wrong number of arguments to car: expected 1, got 2 (arity)"},
+		},
+		{
+			name: "other checks are reported",
+			src:  ";; lint:ignore arity\n(define (f x) 0)",
+			want: []string{"t.scm:2:12: unused parameter: x (unused)"},
+		},
+		{
+			name: "whole file",
+			src:  "(define (f x) 0)\n;; lint:file-ignore unused\n(define (g y) z)",
+			want: []string{"t.scm:3:15: undefined identifier: z (unbound)"},
+		},
+	}
+	globals := builtins.NewRuntime()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := Source(strings.NewReader(tt.src), "t.scm", globals)
+			if err != nil {
+				t.Fatalf("Source() error = %v", err)
+			}
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Source() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	_, err := Source(strings.NewReader("(define (f x)"), "t.scm", builtins.NewRuntime())
+	if !errors.Is(err, cst.ErrSyntax) {
+		t.Errorf("Source() error = %v, want %v", err, cst.ErrSyntax)
+	}
+}
@@ -0,0 +1,57 @@
+package list
+
+// Filter returns the elements of l for which keep reports true, stopping at the first error.
+func Filter[List ~[]T, T any](l List, keep func(T) (bool, error)) ([]T, error) {
+	in, _, err := Partition(l, keep)
+	return in, err
+}
+
+// Partition splits l into the elements for which keep reports true and the others,
+// stopping at the first error.
+func Partition[List ~[]T, T any](l List, keep func(T) (bool, error)) (in []T, out []T, err error) {
+	for _, item := range l {
+		ok, err := keep(item)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			in = append(in, item)
+		} else {
+			out = append(out, item)
+		}
+	}
+	return in, out, nil
+}
+
+// Index returns the index of the first element of l satisfying pred, or -1 if there is none.
+func Index[List ~[]T, T any](l List, pred func(T) (bool, error)) (int, error) {
+	for i, item := range l {
+		ok, err := pred(item)
+		if err != nil {
+			return -1, err
+		}
+		if ok {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// Zip returns the i-th elements of each of lists as the i-th row, stopping at the shortest list.
+func Zip[List ~[]T, T any](lists ...List) [][]T {
+	if len(lists) == 0 {
+		return nil
+	}
+	n := len(lists[0])
+	for _, l := range lists[1:] {
+		n = min(n, len(l))
+	}
+	rows := make([][]T, n)
+	for i := range rows {
+		rows[i] = make([]T, len(lists))
+		for j, l := range lists {
+			rows[i][j] = l[i]
+		}
+	}
+	return rows
+}
@@ -0,0 +1,320 @@
+package lsp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/cst"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/lexer"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/resolve"
+)
+
+// source names the server in the diagnostics it publishes.
+const source = "scheme"
+
+// occurrence is an identifier of a document and the binding it is or refers to. It has
+// no binding when it refers to a global of the runtime or is unbound.
+type occurrence struct {
+	id  *cst.Node
+	def *resolve.Binding
+}
+
+// Document is the analysis of the text of a Scheme source: its syntax errors, the names
+// it defines and what each of its identifiers refers to.
+type Document struct {
+	URI     string
+	Version int
+	text    string
+	lines   []string
+
+	globals     *builtins.Runtime
+	resolved    *resolve.Result
+	diagnostics []Diagnostic
+	occurrences []occurrence
+}
+
+// Analyze reads and analyzes text, resolving identifiers that the document does not
+// define against the global environment of globals. The text is read past its syntax
+// errors, so that the rest of a document being edited is still analyzed.
+func Analyze(uri string, version int, text string, globals *builtins.Runtime) *Document {
+	doc := &Document{URI: uri, Version: version, text: text, lines: strings.Split(text, "\n"), globals: globals}
+	f, errs := cst.ReadTolerant(strings.NewReader(text), "")
+	for _, err := range errs {
+		doc.diagnostics = append(doc.diagnostics, Diagnostic{
+			Range:    Range{Start: positionOf(doc.lines, err.Pos), End: positionOf(doc.lines, err.End)},
+			Severity: SeverityError,
+			Source:   source,
+			Message:  err.Msg,
+		})
+	}
+	doc.resolved = resolve.File(f, globals)
+	if !doc.resolved.Opaque {
+		for _, id := range doc.resolved.Unbound {
+			doc.diagnostics = append(doc.diagnostics, Diagnostic{
+				Range:    rangeOf(doc.lines, id),
+				Severity: SeverityWarning,
+				Source:   source,
+				Message:  fmt.Sprintf("undefined identifier: %s", id.Token.Literal),
+			})
+		}
+	}
+	for _, b := range doc.resolved.Bindings {
+		doc.occurrences = append(doc.occurrences, occurrence{id: b.ID, def: b})
+	}
+	for _, ref := range doc.resolved.References {
+		doc.occurrences = append(doc.occurrences, occurrence{id: ref.ID, def: ref.Binding})
+	}
+	return doc
+}
+
+// Diagnostics returns the problems found in the document, in the order of the document
+// for each kind: syntax errors first, then unbound identifiers.
+func (doc *Document) Diagnostics() []Diagnostic {
+	if doc.diagnostics == nil {
+		return []Diagnostic{}
+	}
+	return doc.diagnostics
+}
+
+// signature returns the call form of a procedure, such as (fact n), or "" when b is not
+// a procedure.
+func signature(b *resolve.Binding) string {
+	parts := []string{b.Name}
+	switch {
+	case b.Formals == nil && b.Arity != nil:
+		parts = append(parts, memberFormals(b)...)
+	case b.Formals == nil:
+		return ""
+	case b.Formals.Kind == cst.List:
+		items, tail := resolve.Elements(b.Formals)
+		for _, formal := range items {
+			parts = append(parts, formal.Token.Literal)
+		}
+		if tail != nil {
+			parts = append(parts, ".", tail.Token.Literal)
+		}
+	default:
+		parts = append(parts, ".", b.Formals.Token.Literal)
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+// memberFormals returns the formals shown for a procedure of a record type: the fields
+// of the constructor, obj for the predicate, the record for an accessor, and the record
+// and field for a modifier.
+func memberFormals(b *resolve.Binding) []string {
+	form := b.Form
+	record := form.Children[1].Token.Literal
+	if ctor := form.Children[2]; ctor.Kind == cst.List && len(ctor.Children) > 0 && ctor.Children[0] == b.ID {
+		var fields []string
+		for _, field := range ctor.Children[1:] {
+			fields = append(fields, field.Token.Literal)
+		}
+		return fields
+	}
+	for _, field := range form.Children[4:] {
+		switch {
+		case field.Kind != cst.List:
+		case len(field.Children) > 1 && field.Children[1] == b.ID:
+			return []string{record}
+		case len(field.Children) > 2 && field.Children[2] == b.ID:
+			return []string{record, field.Children[0].Token.Literal}
+		}
+	}
+	return []string{"obj"}
+}
+
+// docstring returns the docstring of a procedure, a string starting a body of more than
+// one expression.
+func docstring(b *resolve.Binding) string {
+	body := b.Body
+	if len(body) < 2 || body[0].Kind != cst.Atom || body[0].Token.Type != lexer.TokenString {
+		return ""
+	}
+	return body[0].Token.Text
+}
+
+// symbolKind returns the kind of symbol of a definition.
+func symbolKind(b *resolve.Binding) int {
+	switch {
+	case b.Kind == resolve.RecordType:
+		return symbolStruct
+	case b.Arity != nil:
+		return symbolFunction
+	}
+	return symbolVariable
+}
+
+// occurrenceAt returns the identifier at pos.
+func (doc *Document) occurrenceAt(pos Position) (occurrence, bool) {
+	for _, occ := range doc.occurrences {
+		if rangeOf(doc.lines, occ.id).contains(pos) {
+			return occ, true
+		}
+	}
+	return occurrence{}, false
+}
+
+// Definition returns the location of the definition of the identifier at pos.
+func (doc *Document) Definition(pos Position) (Location, bool) {
+	occ, ok := doc.occurrenceAt(pos)
+	if !ok || occ.def == nil {
+		return Location{}, false
+	}
+	return Location{URI: doc.URI, Range: rangeOf(doc.lines, occ.def.ID)}, true
+}
+
+// Hover describes the identifier at pos: the signature, arity and docstring of the procedures the
+// document defines, and the kind, arity and documentation of builtins.
+func (doc *Document) Hover(pos Position) (Hover, bool) {
+	occ, ok := doc.occurrenceAt(pos)
+	if !ok {
+		return Hover{}, false
+	}
+	var text string
+	if occ.def != nil {
+		text = describeDefinition(occ.def)
+	} else {
+		v, ok := doc.globals.Env.Lookup(occ.id.Token.Literal)
+		if !ok {
+			return Hover{}, false
+		}
+		text = describeGlobal(occ.id.Token.Literal, v)
+	}
+	return Hover{Contents: markupContent{Kind: "markdown", Value: text}, Range: rangeOf(doc.lines, occ.id)}, true
+}
+
+func describeDefinition(def *resolve.Binding) string {
+	line := def.ID.Pos.Line
+	switch {
+	case def.Kind == resolve.RecordType:
+		return fmt.Sprintf("`%s` record type, defined at line %d", def.Name, line)
+	case def.Arity != nil:
+		text := fmt.Sprintf("```scheme\n%s\n```\nprocedure, arity %s, defined at line %d", signature(def), def.Arity, line)
+		if doc := docstring(def); doc != "" {
+			text += "\n\n" + doc
+		}
+		return text
+	}
+	return fmt.Sprintf("`%s` variable, defined at line %d", def.Name, line)
+}
+
+func describeGlobal(name string, v values.Interface) string {
+	text := fmt.Sprintf("`%s` %s", name, kindOf(v))
+	if doc, ok := builtins.Docstring(name); ok {
+		text += "\n\n" + doc
+	}
+	return text
+}
+
+// kindOf describes a global value, e.g. "procedure, arity 1" or "special form".
+func kindOf(v values.Interface) string {
+	switch v := v.(type) {
+	case builtins.Syntax:
+		return "special form"
+	case interface{ Arity() builtins.Arity }:
+		return "procedure, arity " + v.Arity().String()
+	}
+	return "variable"
+}
+
+// Completion returns the names that complete the identifier before pos: those the
+// document binds in scope at pos, innermost first, followed by the globals of the runtime.
+func (doc *Document) Completion(pos Position) []CompletionItem {
+	prefix := doc.prefixAt(pos)
+	items := []CompletionItem{}
+	seen := make(map[string]bool)
+	for sc := doc.scopeAt(pos); sc != nil; sc = sc.Parent {
+		var names []string
+		for name := range sc.Names {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if seen[name] || !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			seen[name] = true
+			def := sc.Names[name]
+			item := CompletionItem{Label: name, Kind: completionVariable, Detail: "variable"}
+			if def.Arity != nil {
+				item.Kind, item.Detail, item.Documentation = completionFunction, signature(def), docstring(def)
+			}
+			items = append(items, item)
+		}
+	}
+	names := doc.globals.Env.Names()
+	slices.Sort(names)
+	for _, name := range names {
+		if seen[name] || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		v, _ := doc.globals.Env.Lookup(name)
+		item := CompletionItem{Label: name, Kind: completionVariable, Detail: kindOf(v)}
+		switch v.(type) {
+		case builtins.Syntax:
+			item.Kind = completionKeyword
+		case interface{ Arity() builtins.Arity }:
+			item.Kind = completionFunction
+		}
+		item.Documentation, _ = builtins.Docstring(name)
+		items = append(items, item)
+	}
+	return items
+}
+
+// scopeAt returns the innermost scope containing pos.
+func (doc *Document) scopeAt(pos Position) *resolve.Scope {
+	innermost, innermostRng := doc.resolved.Top, Range{End: advance(Position{}, doc.text)}
+	for _, sc := range doc.resolved.Scopes {
+		if sc.Node == nil {
+			continue
+		}
+		if rng := rangeOf(doc.lines, sc.Node); rng.contains(pos) && innermostRng.contains(rng.Start) && innermostRng.contains(rng.End) {
+			innermost, innermostRng = sc, rng
+		}
+	}
+	return innermost
+}
+
+// prefixAt returns the part of the identifier that ends at pos.
+func (doc *Document) prefixAt(pos Position) string {
+	if pos.Line >= len(doc.lines) {
+		return ""
+	}
+	line := []rune(doc.lines[pos.Line])
+	end := min(runeIndex(doc.lines[pos.Line], pos.Character), len(line))
+	start := end
+	for start > 0 && !strings.ContainsRune(" \t\r()[]\"';", line[start-1]) {
+		start--
+	}
+	return string(line[start:end])
+}
+
+// Symbols returns the definitions of the document, with the definitions of procedure
+// bodies and the procedures of record types nested in them.
+func (doc *Document) Symbols() []DocumentSymbol {
+	return doc.symbolsOf(doc.resolved.Top.Definitions)
+}
+
+func (doc *Document) symbolsOf(defs []*resolve.Binding) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, def := range defs {
+		children := def.Members
+		if def.Inner != nil {
+			children = def.Inner.Definitions
+		}
+		symbols = append(symbols, DocumentSymbol{
+			Name:           def.Name,
+			Detail:         signature(def),
+			Kind:           symbolKind(def),
+			Range:          rangeOf(doc.lines, def.Form),
+			SelectionRange: rangeOf(doc.lines, def.ID),
+			Children:       doc.symbolsOf(children),
+		})
+	}
+	return symbols
+}
@@ -0,0 +1,121 @@
+package lsp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+// diagnostic is the line, character and message of a Diagnostic, for comparison.
+type diagnostic struct {
+	line, character int
+	message         string
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []diagnostic
+	}{
+		{
+			name: "well formed",
+			src: `(define (fact n)
+  (if (= n 0) 1 (* n (fact (- n 1)))))
+(define-record-type point (make-point x y) point? (x point-x set-point-x!))
+(let loop ((i 0)) (if (< i 3) (loop (+ i 1)) (point-x (make-point i 0))))
+(cond ((pair? '(1 . a)) => not) (else 'none))
+(guard (e ((string? e) e) (else (raise e))) (raise "oops"))
+(define add (lambda (a . rest) (apply + a rest)))
+(case-lambda ((a) a) ((a b) (+ a b)))`,
+		},
+		{
+			name: "unbalanced parens",
+			src:  "(define (f x)\n  (+ x 1)",
+			want: []diagnostic{{0, 0, "unbalanced (: missing )"}},
+		},
+		{
+			name: "unexpected close",
+			src:  "(f))\n[g)",
+			want: []diagnostic{
+				{0, 3, "unexpected )"},
+				{1, 2, ") does not match [ on line 2"},
+				{0, 1, "undefined identifier: f"},
+				{1, 1, "undefined identifier: g"},
+			},
+		},
+		{
+			name: "invalid tokens",
+			src:  "(display #x)\n(display 1.2.3)\n\"open",
+			want: []diagnostic{
+				{0, 9, "invalid boolean literal: #x"},
+				{1, 9, "invalid number literal: 1.2.3"},
+				{2, 0, "unterminated string literal: \"open"},
+			},
+		},
+		{
+			name: "bad dots",
+			src:  "(. a)\n'(a . b c)",
+			want: []diagnostic{
+				{0, 1, "unexpected ."},
+				{1, 8, "more than one datum after the dot of a dotted list"},
+				{0, 3, "undefined identifier: a"},
+			},
+		},
+		{
+			name: "unbound identifiers",
+			src:  "(define (f x) (g x y))\n(define (g a b) (let ((c a)) (list c b)))\n(set! z 1)",
+			want: []diagnostic{
+				{0, 19, "undefined identifier: y"},
+				{2, 6, "undefined identifier: z"},
+			},
+		},
+		{
+			name: "characters outside the basic multilingual plane",
+			src:  "(display \"😀\" y)",
+			want: []diagnostic{{0, 14, "undefined identifier: y"}},
+		},
+		{
+			name: "shadowed special form",
+			src:  "(define (f define) (define x))",
+			want: []diagnostic{{0, 27, "undefined identifier: x"}},
+		},
+		{
+			name: "loaded code may bind any name",
+			src:  "(load \"lib.scm\")\n(helper 1)",
+		},
+		{
+			name: "unknown library may bind any name",
+			src:  "(import (mylib util))\n(helper 1)",
+		},
+		{
+			name: "standard library",
+			src:  "(import (srfi 1))\n(helper 1)",
+			want: []diagnostic{{1, 1, "undefined identifier: helper"}},
+		},
+	}
+	globals := builtins.NewRuntime()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []diagnostic
+			for _, d := range Analyze("file:///test.scm", 1, tt.src, globals).Diagnostics() {
+				got = append(got, diagnostic{d.Range.Start.Line, d.Range.Start.Character, d.Message})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diagnostics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUTF16Positions(t *testing.T) {
+	doc := Analyze("file:///test.scm", 1, "(define s \"😀\") (define t s)", builtins.NewRuntime())
+	loc, ok := doc.Definition(Position{Line: 0, Character: 26})
+	if want := (Range{Start: Position{0, 8}, End: Position{0, 9}}); !ok || loc.Range != want {
+		t.Errorf("Definition() = %v, %v, want %v", loc.Range, ok, want)
+	}
+	if got := doc.prefixAt(Position{Line: 0, Character: 27}); got != "s" {
+		t.Errorf("prefixAt() = %q, want %q", got, "s")
+	}
+}
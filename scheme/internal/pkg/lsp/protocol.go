@@ -0,0 +1,297 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"text/scanner"
+	"unicode/utf16"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/cst"
+)
+
+// The messages of the Language Server Protocol this server uses. Each message is a
+// JSON-RPC 2.0 object preceded by a Content-Length header. Requests carry an id and
+// are answered by a response with the same id; notifications carry none.
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type result struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+type failure struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   responseError   `json:"error"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidParams  = -32602
+	codeMethodNotFound = -32601
+	codeInvalidRequest = -32600
+)
+
+type initializeResult struct {
+	Capabilities serverCapabilities `json:"capabilities"`
+	ServerInfo   serverInfo         `json:"serverInfo"`
+}
+
+type serverCapabilities struct {
+	TextDocumentSync       int               `json:"textDocumentSync"`
+	DefinitionProvider     bool              `json:"definitionProvider"`
+	HoverProvider          bool              `json:"hoverProvider"`
+	CompletionProvider     completionOptions `json:"completionProvider"`
+	DocumentSymbolProvider bool              `json:"documentSymbolProvider"`
+}
+
+type completionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
+}
+
+type serverInfo struct {
+	Name string `json:"name"`
+}
+
+// textDocumentSyncFull makes clients send the whole text of a document on every change.
+const textDocumentSyncFull = 1
+
+// Position is a zero based line and character offset in a document. Characters are
+// counted in UTF-16 code units, the position encoding every client supports.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is the part of a document from Start up to, but excluding, End.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// positionOf converts a position of the scanner, whose lines and columns count from 1
+// and whose columns count runes, to a position of the protocol in a document of lines.
+func positionOf(lines []string, pos scanner.Position) Position {
+	line, column := max(pos.Line-1, 0), max(pos.Column-1, 0)
+	if line < len(lines) {
+		column = utf16Len(lines[line], column)
+	}
+	return Position{Line: line, Character: column}
+}
+
+// rangeOf returns the range of the source of n in a document of lines.
+func rangeOf(lines []string, n *cst.Node) Range {
+	return Range{Start: positionOf(lines, n.Pos), End: positionOf(lines, n.End)}
+}
+
+// advance returns the position after text when it starts at pos.
+func advance(pos Position, text string) Position {
+	for _, r := range text {
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+			continue
+		}
+		pos.Character += utf16.RuneLen(r)
+	}
+	return pos
+}
+
+// utf16Len returns the number of UTF-16 code units of the first n runes of line.
+// Runes past the end of line count as one unit each.
+func utf16Len(line string, n int) int {
+	units := 0
+	for _, r := range line {
+		if n == 0 {
+			break
+		}
+		units += utf16.RuneLen(r)
+		n--
+	}
+	return units + n
+}
+
+// runeIndex returns the number of runes of line before the UTF-16 code unit offset units.
+func runeIndex(line string, units int) int {
+	n := 0
+	for _, r := range line {
+		if units <= 0 {
+			break
+		}
+		units -= utf16.RuneLen(r)
+		n++
+	}
+	return n
+}
+
+// contains reports whether pos is within rng, including its end so that the cursor
+// just after an identifier is on it.
+func (rng Range) contains(pos Position) bool {
+	return !pos.before(rng.Start) && !rng.End.before(pos)
+}
+
+func (pos Position) before(other Position) bool {
+	return pos.Line < other.Line || pos.Line == other.Line && pos.Character < other.Character
+}
+
+// Location is a range in the document URI.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+	Text    string `json:"text"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentItem `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type documentSymbolParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+// Diagnostic severities
+const (
+	SeverityError   = 1
+	SeverityWarning = 2
+)
+
+// Diagnostic is a problem found in a document.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     int          `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Hover is the information shown for the identifier under the cursor.
+type Hover struct {
+	Contents markupContent `json:"contents"`
+	Range    Range         `json:"range"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Completion item kinds
+const (
+	completionFunction = 3
+	completionVariable = 6
+	completionKeyword  = 14
+)
+
+// CompletionItem is a name that may be completed at the cursor.
+type CompletionItem struct {
+	Label         string `json:"label"`
+	Kind          int    `json:"kind"`
+	Detail        string `json:"detail,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// Symbol kinds
+const (
+	symbolFunction = 12
+	symbolVariable = 13
+	symbolStruct   = 23
+)
+
+// DocumentSymbol is a definition of a document, with the definitions nested in it.
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+// maxContentLength is the largest message content readMessage accepts.
+const maxContentLength = 16 << 20
+
+// readMessage reads the content of the next message from r.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("%w: invalid Content-Length %q", ErrProtocol, header.Get("Content-Length"))
+	}
+	if length > maxContentLength {
+		return nil, fmt.Errorf("%w: Content-Length %d exceeds %d", ErrProtocol, length, maxContentLength)
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// writeMessage writes msg to w as the content of a message.
+func writeMessage(w io.Writer, msg any) error {
+	content, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
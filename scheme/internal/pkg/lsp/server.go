@@ -0,0 +1,212 @@
+// Package lsp serves the Language Server Protocol, so that editors can report the syntax
+// errors and unbound identifiers of Scheme sources as they are edited, jump to the
+// definitions of names, show the arity and documentation of procedures, complete names
+// and outline the definitions of a document.
+//
+// Documents are read into concrete syntax trees past their syntax errors, and their
+// identifiers resolved without being evaluated. Names a document does not define are
+// resolved against the global environment of a runtime.
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+var (
+	ErrProtocol = errors.New("protocol error")
+)
+
+// Server is a language server reading messages from one stream and writing responses
+// and notifications to another.
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	globals   *builtins.Runtime
+	documents map[string]*Document
+	shutdown  bool
+}
+
+// NewServer returns a server reading messages from in and writing to out. The global
+// environment names are resolved against is that of a runtime made with rtOpts.
+func NewServer(in io.Reader, out io.Writer, rtOpts ...builtins.OptionRuntime) *Server {
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		globals:   builtins.NewRuntime(rtOpts...),
+		documents: make(map[string]*Document),
+	}
+}
+
+// Serve handles messages until the client sends exit, the input ends or ctx is done.
+func (s *Server) Serve(ctx context.Context) error {
+	contents := make(chan []byte)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			content, err := readMessage(s.in)
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case contents <- content:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for {
+		var content []byte
+		select {
+		case content = <-contents:
+		case err := <-errs:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		var msg message
+		if err := json.Unmarshal(content, &msg); err != nil {
+			s.fail(nil, codeParseError, err.Error())
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if msg.Method == "" {
+			// a response to a request of the server, which sends none
+			continue
+		}
+		s.handle(msg)
+	}
+}
+
+// handle answers msg when it is a request, and applies it when it is a notification.
+func (s *Server) handle(msg message) {
+	var (
+		res any
+		err error
+	)
+	switch msg.Method {
+	case "initialize":
+		res = initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:       textDocumentSyncFull,
+				DefinitionProvider:     true,
+				HoverProvider:          true,
+				CompletionProvider:     completionOptions{TriggerCharacters: []string{"("}},
+				DocumentSymbolProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "scheme-lsp"},
+		}
+	case "initialized":
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			s.update(params.TextDocument)
+		}
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil && len(params.ContentChanges) > 0 {
+			item := params.TextDocument
+			item.Text = params.ContentChanges[len(params.ContentChanges)-1].Text
+			s.update(item)
+		}
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			delete(s.documents, params.TextDocument.URI)
+			s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		}
+	case "textDocument/definition":
+		res, err = s.atPosition(msg.Params, func(doc *Document, pos Position) any {
+			if loc, ok := doc.Definition(pos); ok {
+				return loc
+			}
+			return nil
+		})
+	case "textDocument/hover":
+		res, err = s.atPosition(msg.Params, func(doc *Document, pos Position) any {
+			if hover, ok := doc.Hover(pos); ok {
+				return hover
+			}
+			return nil
+		})
+	case "textDocument/completion":
+		res, err = s.atPosition(msg.Params, func(doc *Document, pos Position) any {
+			return doc.Completion(pos)
+		})
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			if doc, ok := s.documents[params.TextDocument.URI]; ok {
+				res = doc.Symbols()
+			}
+		}
+	default:
+		if msg.ID != nil {
+			s.fail(msg.ID, codeMethodNotFound, fmt.Sprintf("unsupported method %s", msg.Method))
+		}
+		return
+	}
+	if msg.ID == nil {
+		return
+	}
+	switch {
+	case err != nil:
+		s.fail(msg.ID, codeInvalidParams, err.Error())
+	case s.shutdown && msg.Method != "shutdown":
+		s.fail(msg.ID, codeInvalidRequest, "the server is shut down")
+	default:
+		s.send(result{JSONRPC: "2.0", ID: msg.ID, Result: res})
+	}
+}
+
+// update analyzes the text of a document and publishes its diagnostics.
+func (s *Server) update(item textDocumentItem) {
+	doc := Analyze(item.URI, item.Version, item.Text, s.globals)
+	s.documents[item.URI] = doc
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         doc.URI,
+		Version:     doc.Version,
+		Diagnostics: doc.Diagnostics(),
+	})
+}
+
+// atPosition answers a request about a position of an open document with f, and with
+// null for documents that are not open.
+func (s *Server) atPosition(params json.RawMessage, f func(doc *Document, pos Position) any) (any, error) {
+	var args textDocumentPositionParams
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, err
+	}
+	doc, ok := s.documents[args.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	return f(doc, args.Position), nil
+}
+
+func (s *Server) notify(method string, params any) {
+	s.send(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) fail(id json.RawMessage, code int, msg string) {
+	s.send(failure{JSONRPC: "2.0", ID: id, Error: responseError{Code: code, Message: msg}})
+}
+
+func (s *Server) send(msg any) {
+	_ = writeMessage(s.out, msg)
+}
@@ -0,0 +1,46 @@
+# initialize, then open a document without problems
+-> {"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{}}}
+<- {"jsonrpc":"2.0","id":1,"result":{"capabilities":{"textDocumentSync":1,"definitionProvider":true,"hoverProvider":true,"completionProvider":{"triggerCharacters":["("]},"documentSymbolProvider":true},"serverInfo":{"name":"scheme-lsp"}}}
+-> {"jsonrpc":"2.0","method":"initialized","params":{}}
+-> {"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///fact.scm","languageId":"scheme","version":1,"text":"(define (fact n)\n  \"Returns n factorial.\"\n  (if (= n 0)\n      1\n      (* n (fact (- n 1)))))\n\n(define-record-type point (make-point x y) point?\n  (x point-x))\n\n(define (main)\n  (define limit 5)\n  (display (fact limit))\n  (newline))\n"}}}
+<- {"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///fact.scm","version":1,"diagnostics":[]}}
+# go to the definition of fact; builtins have no definition in the document
+-> {"jsonrpc":"2.0","id":2,"method":"textDocument/definition","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":11,"character":13}}}
+<- {"jsonrpc":"2.0","id":2,"result":{"uri":"file:///fact.scm","range":{"start":{"line":0,"character":9},"end":{"line":0,"character":13}}}}
+-> {"jsonrpc":"2.0","id":3,"method":"textDocument/definition","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":11,"character":4}}}
+<- {"jsonrpc":"2.0","id":3,"result":null}
+# hover shows the signature, arity and docstring of procedures, and the documentation of builtins
+-> {"jsonrpc":"2.0","id":4,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":11,"character":13}}}
+<- {"jsonrpc":"2.0","id":4,"result":{"contents":{"kind":"markdown","value":"```scheme\n(fact n)\n```\nprocedure, arity 1, defined at line 1\n\nReturns n factorial."},"range":{"start":{"line":11,"character":12},"end":{"line":11,"character":16}}}}
+-> {"jsonrpc":"2.0","id":5,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":11,"character":4}}}
+<- {"jsonrpc":"2.0","id":5,"result":{"contents":{"kind":"markdown","value":"`display` procedure, arity 1 to 2"},"range":{"start":{"line":11,"character":3},"end":{"line":11,"character":10}}}}
+-> {"jsonrpc":"2.0","id":6,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":2,"character":4}}}
+<- {"jsonrpc":"2.0","id":6,"result":{"contents":{"kind":"markdown","value":"`if` special form\n\n(if test consequent [alternate]) evaluates consequent when test is truthy, otherwise alternate"},"range":{"start":{"line":2,"character":3},"end":{"line":2,"character":5}}}}
+-> {"jsonrpc":"2.0","id":7,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":3,"character":6}}}
+<- {"jsonrpc":"2.0","id":7,"result":null}
+# completion offers the names in scope that start with the word before the cursor
+-> {"jsonrpc":"2.0","id":8,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":11,"character":20}}}
+<- {"jsonrpc":"2.0","id":8,"result":[{"label":"limit","kind":6,"detail":"variable"}]}
+-> {"jsonrpc":"2.0","id":9,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":12,"character":6}}}
+<- {"jsonrpc":"2.0","id":9,"result":[{"label":"newline","kind":3,"detail":"procedure, arity 0 to 1"}]}
+-> {"jsonrpc":"2.0","id":10,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":4,"character":16}}}
+<- {"jsonrpc":"2.0","id":10,"result":[{"label":"fact","kind":3,"detail":"(fact n)","documentation":"Returns n factorial."}]}
+# document symbols outline the definitions, nesting internal defines and record procedures
+-> {"jsonrpc":"2.0","id":11,"method":"textDocument/documentSymbol","params":{"textDocument":{"uri":"file:///fact.scm"}}}
+<- {"jsonrpc":"2.0","id":11,"result":[{"name":"fact","detail":"(fact n)","kind":12,"range":{"start":{"line":0,"character":0},"end":{"line":4,"character":28}},"selectionRange":{"start":{"line":0,"character":9},"end":{"line":0,"character":13}}},{"name":"point","kind":23,"range":{"start":{"line":6,"character":0},"end":{"line":7,"character":14}},"selectionRange":{"start":{"line":6,"character":20},"end":{"line":6,"character":25}},"children":[{"name":"make-point","detail":"(make-point x y)","kind":12,"range":{"start":{"line":6,"character":0},"end":{"line":7,"character":14}},"selectionRange":{"start":{"line":6,"character":27},"end":{"line":6,"character":37}}},{"name":"point?","detail":"(point? obj)","kind":12,"range":{"start":{"line":6,"character":0},"end":{"line":7,"character":14}},"selectionRange":{"start":{"line":6,"character":43},"end":{"line":6,"character":49}}},{"name":"point-x","detail":"(point-x point)","kind":12,"range":{"start":{"line":6,"character":0},"end":{"line":7,"character":14}},"selectionRange":{"start":{"line":7,"character":5},"end":{"line":7,"character":12}}}]},{"name":"main","detail":"(main)","kind":12,"range":{"start":{"line":9,"character":0},"end":{"line":12,"character":12}},"selectionRange":{"start":{"line":9,"character":9},"end":{"line":9,"character":13}},"children":[{"name":"limit","kind":13,"range":{"start":{"line":10,"character":2},"end":{"line":10,"character":18}},"selectionRange":{"start":{"line":10,"character":10},"end":{"line":10,"character":15}}}]}]}
+# an edit that leaves a paren open and a call to a name the document no longer defines
+-> {"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///fact.scm","version":2},"contentChanges":[{"text":"(define (main)\n  (display (fact 5))\n"}]}}
+<- {"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///fact.scm","version":2,"diagnostics":[{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":1}},"severity":1,"source":"scheme","message":"unbalanced (: missing )"},{"range":{"start":{"line":1,"character":12},"end":{"line":1,"character":16}},"severity":2,"source":"scheme","message":"undefined identifier: fact"}]}}
+# unsupported requests fail, and closing a document clears its diagnostics
+-> {"jsonrpc":"2.0","id":12,"method":"textDocument/formatting","params":{"textDocument":{"uri":"file:///fact.scm"}}}
+<- {"jsonrpc":"2.0","id":12,"error":{"code":-32601,"message":"unsupported method textDocument/formatting"}}
+-> {"jsonrpc":"2.0","method":"textDocument/didClose","params":{"textDocument":{"uri":"file:///fact.scm"}}}
+<- {"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///fact.scm","diagnostics":[]}}
+-> {"jsonrpc":"2.0","id":13,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":0,"character":2}}}
+<- {"jsonrpc":"2.0","id":13,"result":null}
+# after shutdown every request but exit fails
+-> {"jsonrpc":"2.0","id":14,"method":"shutdown"}
+<- {"jsonrpc":"2.0","id":14,"result":null}
+-> {"jsonrpc":"2.0","id":15,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///fact.scm"},"position":{"line":0,"character":2}}}
+<- {"jsonrpc":"2.0","id":15,"error":{"code":-32600,"message":"the server is shut down"}}
+-> {"jsonrpc":"2.0","method":"exit"}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestArity(t *testing.T) {
+	atLeast := func(n int64) values.Interface {
+		return values.Cons(values.NewInt(n), values.NewBool(false))
+	}
+	runEvalTests(t, []evalTest{
+		{
+			name: "case-lambda dispatches on the number of arguments",
+			src: `(define area (case-lambda ((r) (* 3 r r)) ((w h) (* w h)) ((a b . rest) (length rest))))
+			      (list (area 2) (area 2 5) (area 1 2 3 4))`,
+			want: values.List(values.NewInt(12), values.NewInt(10), values.NewInt(2)),
+		},
+		{
+			name: "case-lambda uses the first matching clause",
+			src:  "((case-lambda (args 'rest) ((x) 'one)) 1)",
+			want: values.NewIdentifier("rest"),
+		},
+		{
+			name:    "case-lambda without a matching clause",
+			src:     "((case-lambda ((x) x) ((x y z) x)) 1 2)",
+			wantErr: builtins.ErrWrongNumberOfArguments,
+		},
+		{
+			name: "arity of lambdas",
+			src: `(list (procedure-arity (lambda (x y) x)) (procedure-arity (lambda args args))
+			            (procedure-arity (lambda (x . rest) x)))`,
+			want: values.List(values.NewInt(2), atLeast(0), atLeast(1)),
+		},
+		{
+			name: "arity of defined procedures and case-lambda",
+			src:  "(define (f a b c) a) (list (procedure-arity f) (procedure-arity (case-lambda ((x) x) ((x y z) x))))",
+			want: values.List(values.NewInt(3), values.Cons(values.NewInt(1), values.NewInt(3))),
+		},
+		{
+			name: "arity of builtins",
+			src: `(list (procedure-arity car) (procedure-arity +) (procedure-arity vector-ref)
+			            (procedure-arity display) (procedure-arity equal?) (procedure-arity (make-parameter 1)))`,
+			want: values.List(values.NewInt(1), atLeast(0), values.NewInt(2),
+				values.Cons(values.NewInt(1), values.NewInt(2)), values.NewInt(2), values.NewInt(0)),
+		},
+		{
+			name:    "builtins check their arity",
+			src:     "(car '(1) '(2))",
+			wantErr: builtins.ErrWrongNumberOfArguments,
+		},
+		{
+			name:    "builtins without arguments check their arity",
+			src:     "(thread-yield! 1)",
+			wantErr: builtins.ErrWrongNumberOfArguments,
+		},
+		{
+			name:    "builtins check a missing argument",
+			src:     "(vector-ref (vector 1))",
+			wantErr: builtins.ErrWrongNumberOfArguments,
+		},
+		{
+			name:    "record procedures check their arity",
+			src:     "(define-record-type point (make-point x y) point? (x point-x) (y point-y)) (point-x (make-point 1 2) 3)",
+			wantErr: builtins.ErrWrongNumberOfArguments,
+		},
+		{
+			name:    "lambdas check their arity",
+			src:     "((lambda (x) x))",
+			wantErr: builtins.ErrWrongNumberOfArguments,
+		},
+		{
+			name:    "procedure-arity of a non procedure",
+			src:     "(procedure-arity 1)",
+			wantErr: builtins.ErrTypeMismatch,
+		},
+	})
+}
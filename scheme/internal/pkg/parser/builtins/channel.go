@@ -0,0 +1,234 @@
+package builtins
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Channel is a Go channel of Scheme values.
+type Channel struct {
+	ch chan values.Interface
+}
+
+func (c *Channel) Equal(p values.Interface) bool {
+	other, ok := p.(*Channel)
+	return ok && other == c
+}
+
+func (c *Channel) Type() types.Type {
+	return types.Channel
+}
+
+func (c *Channel) IsTruthy() bool {
+	return true
+}
+
+func (c *Channel) DisplayString() string {
+	return fmt.Sprintf("#<channel %d/%d>", len(c.ch), cap(c.ch))
+}
+
+func (c *Channel) WriteString() string {
+	return c.DisplayString()
+}
+
+// MakeChannelImpl implements the make-channel procedure
+// (make-channel [capacity]) returns a new channel, unbuffered unless a capacity is given
+func MakeChannelImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	capacity := 0
+	if len(operands) == 1 {
+		if capacity, err = indexArg(operands[0], -1); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	return &Channel{ch: make(chan values.Interface, capacity)}, nil
+}
+
+// ChannelSendImpl implements the channel-send procedure
+// (channel-send channel obj) blocks until obj is received or buffered
+func ChannelSendImpl(args values.Interface, rt *Runtime) (_ values.Interface, err error) {
+	c, operands, err := channelArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	defer recoverClosed(&err)
+	select {
+	case c.ch <- operands[1]:
+		return values.NewVoidType(), nil
+	case <-rt.Context().Done():
+		return values.NewVoidType(), rt.Context().Err()
+	}
+}
+
+// ChannelReceiveImpl implements the channel-receive procedure
+// (channel-receive channel) blocks until a value is sent and returns it.
+// It returns the end of file object once the channel is closed and drained.
+func ChannelReceiveImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	c, _, err := channelArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	select {
+	case v, ok := <-c.ch:
+		if !ok {
+			return values.NewEof(), nil
+		}
+		return v, nil
+	case <-rt.Context().Done():
+		return values.NewVoidType(), rt.Context().Err()
+	}
+}
+
+// ChannelCloseImpl implements the channel-close! procedure
+func ChannelCloseImpl(args values.Interface, rt *Runtime) (_ values.Interface, err error) {
+	c, _, err := channelArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	defer recoverClosed(&err)
+	close(c.ch)
+	return values.NewVoidType(), nil
+}
+
+// SelectImpl implements the select special form
+// (select clause ...) waits until one of the clauses can proceed and evaluates its body, where a clause is
+//
+//	(recv channel var body ...)    receives a value from channel and binds it to var
+//	(send channel obj body ...)    sends obj on channel
+//	(after seconds body ...)       proceeds once the timeout expires
+//	(else body ...)                proceeds when no other clause can
+//
+// Channel, obj and seconds expressions are evaluated once, in order, before waiting.
+func SelectImpl(args values.Interface, rt *Runtime, cb Expression) (_ values.Interface, err error) {
+	clauses, err := values.ToSlice(args)
+	if err != nil {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	var (
+		cases  []reflect.SelectCase
+		bodies [][]values.Interface
+		vars   []string
+		stops  []func()
+	)
+	defer func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}()
+	for _, clause := range clauses {
+		parts, err := unpackArgs(clause, 1, -1)
+		if err != nil {
+			return values.NewVoidType(), ErrInvalidFormat
+		}
+		keyword, _ := symbolName(parts[0])
+		var (
+			selectCase reflect.SelectCase
+			name       string
+			body       []values.Interface
+		)
+		switch keyword {
+		case "else":
+			selectCase, body = reflect.SelectCase{Dir: reflect.SelectDefault}, parts[1:]
+		case "recv":
+			if len(parts) < 3 {
+				return values.NewVoidType(), fmt.Errorf("%w: %s", ErrInvalidFormat, clause.WriteString())
+			}
+			c, err := selectChannel(parts[1], rt, cb)
+			if err != nil {
+				return values.NewVoidType(), err
+			}
+			var ok bool
+			if name, ok = symbolName(parts[2]); !ok {
+				return values.NewVoidType(), ErrBadArgument
+			}
+			selectCase, body = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.ch)}, parts[3:]
+		case "send":
+			if len(parts) < 3 {
+				return values.NewVoidType(), fmt.Errorf("%w: %s", ErrInvalidFormat, clause.WriteString())
+			}
+			c, err := selectChannel(parts[1], rt, cb)
+			if err != nil {
+				return values.NewVoidType(), err
+			}
+			v, err := cb(parts[2], rt)
+			if err != nil {
+				return values.NewVoidType(), err
+			}
+			selectCase = reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(c.ch), Send: reflect.ValueOf(values.Unquote(v))}
+			body = parts[3:]
+		case "after":
+			if len(parts) < 2 {
+				return values.NewVoidType(), fmt.Errorf("%w: %s", ErrInvalidFormat, clause.WriteString())
+			}
+			seconds, err := cb(parts[1], rt)
+			if err != nil {
+				return values.NewVoidType(), err
+			}
+			timeout, stop, err := timeoutArg([]values.Interface{seconds}, 0)
+			if err != nil {
+				return values.NewVoidType(), err
+			}
+			stops = append(stops, stop)
+			selectCase, body = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timeout)}, parts[2:]
+		default:
+			return values.NewVoidType(), fmt.Errorf("%w: select clause %s", ErrInvalidFormat, clause.WriteString())
+		}
+		cases = append(cases, selectCase)
+		bodies = append(bodies, body)
+		vars = append(vars, name)
+	}
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(rt.Context().Done())})
+	defer recoverClosed(&err)
+	chosen, received, ok := reflect.Select(cases)
+	if chosen == len(cases)-1 {
+		return values.NewVoidType(), rt.Context().Err()
+	}
+	scope := rt
+	if vars[chosen] != "" {
+		var value = values.NewEof()
+		if ok {
+			value = received.Interface().(values.Interface)
+		}
+		frame := ExtendEnvironment(rt.Env)
+		frame.Define(vars[chosen], value)
+		scope = rt.WithEnvironment(frame)
+	}
+	return evalBody(bodies[chosen], scope, cb)
+}
+
+func selectChannel(expr values.Interface, rt *Runtime, cb Expression) (*Channel, error) {
+	v, err := cb(expr, rt)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.(*Channel)
+	if !ok {
+		return nil, ErrTypeMismatch
+	}
+	return c, nil
+}
+
+func channelArgs(args values.Interface) (*Channel, []values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return nil, operands, err
+	}
+	c, ok := operands[0].(*Channel)
+	if !ok {
+		return nil, operands, ErrTypeMismatch
+	}
+	return c, operands, nil
+}
+
+// recoverClosed turns the panic of sending on or closing a closed channel into ErrChannelClosed.
+func recoverClosed(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("%w: %v", ErrChannelClosed, r)
+	}
+}
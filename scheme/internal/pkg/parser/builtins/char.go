@@ -0,0 +1,97 @@
+package builtins
+
+import (
+	"unicode"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// charPredicate adapts a rune classifier to a procedure taking a single character
+// and returning a boolean.
+func charPredicate(classify func(rune) bool) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		c, err := charArg(args)
+		if err != nil {
+			return values.NewBool(false), err
+		}
+		return values.NewBool(classify(c)), nil
+	}
+}
+
+// charMapping adapts a rune conversion to a procedure taking and returning a single character.
+func charMapping(convert func(rune) rune) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		c, err := charArg(args)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		return values.NewChar(convert(c)), nil
+	}
+}
+
+// stringMapping adapts a string conversion to a procedure taking and returning a single string.
+func stringMapping(convert func(string) string) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		operands, err := procedureArgs(args)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		s, ok := operands[0].(values.String)
+		if !ok {
+			return values.NewVoidType(), ErrTypeMismatch
+		}
+		converted := convert(s.String())
+		if err := rt.AllocString(len(converted)); err != nil {
+			return values.NewVoidType(), err
+		}
+		return values.NewString(converted), nil
+	}
+}
+
+// DigitValueImpl implements the digit-value procedure
+// It returns the numeric value of a decimal digit character or #f for any other character
+func DigitValueImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	c, err := charArg(args)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	if !unicode.IsDigit(c) {
+		return values.NewBool(false), nil
+	}
+	return values.NewInt(int64(c - '0')), nil
+}
+
+// CharToIntegerImpl implements the char->integer procedure
+func CharToIntegerImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	c, err := charArg(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewInt(int64(c)), nil
+}
+
+// IntegerToCharImpl implements the integer->char procedure
+func IntegerToCharImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	n, ok := operands[0].(values.Numeric)
+	if !ok || !n.IsInteger() {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	code, _ := n.AsInt()
+	return values.NewChar(rune(code)), nil
+}
+
+func charArg(args values.Interface) (rune, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return 0, err
+	}
+	c, ok := operands[0].(values.Char)
+	if !ok {
+		return 0, ErrTypeMismatch
+	}
+	return c.Rune(), nil
+}
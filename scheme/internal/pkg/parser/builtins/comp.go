@@ -1,6 +1,7 @@
 package builtins
 
 import (
+	"fmt"
 	"slices"
 
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/list"
@@ -149,19 +150,26 @@ func GreatThanOrImpl(args values.Interface, rt *Runtime, cb Expression) (values.
 }
 
 // EqualImpl implements the = procedure
-// It returns #t if all arguments are equal
-// It returns #f if any argument is not equal
+// It returns #t if all arguments are the same number, comparing integers and floats by value
+// It returns #f if any argument is a different number
+// It fails with ErrNumberExpected if any argument is not a number
 func EqualImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
-	if args.Type() == types.Nil {
-		return values.NewBool(true), nil
+	operands, err := values.ToSlice(args)
+	if err != nil {
+		return values.NewBool(false), ErrBadArgument
 	}
-	current := values.Car(args)
-	tail := values.Cdr(args)
-	for tail.Type() != types.Nil {
-		if !current.Equal(values.Car(tail)) {
+	numbers := make([]values.Numeric, len(operands))
+	for i, operand := range operands {
+		n, ok := operand.(values.Numeric)
+		if !ok || !ArithmeticAllowedGate(operand.Type()) {
+			return values.NewBool(false), fmt.Errorf("%w: %s", ErrNumberExpected, operand.WriteString())
+		}
+		numbers[i] = n
+	}
+	for i := 1; i < len(numbers); i++ {
+		if !numbers[i-1].EqualTo(numbers[i]) {
 			return values.NewBool(false), nil
 		}
-		tail = values.Cdr(tail)
 	}
 	return values.NewBool(true), nil
 }
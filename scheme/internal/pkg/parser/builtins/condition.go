@@ -0,0 +1,128 @@
+package builtins
+
+import (
+	"errors"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Raised is the error returned by raise for objects that are not conditions.
+type Raised struct {
+	Payload values.Interface
+}
+
+func (r Raised) Error() string {
+	return "uncaught exception: " + r.Payload.WriteString()
+}
+
+// RaisedValue returns the object a guard clause sees for err: the payload of raise,
+// or the condition wrapping err, so Go errors surface in Scheme as error objects.
+func RaisedValue(err error) values.Interface {
+	var raised Raised
+	if errors.As(err, &raised) {
+		return raised.Payload
+	}
+	return values.ConditionFromError(err)
+}
+
+// ErrorImpl implements the error procedure
+// (error message irritant ...) raises a new error object
+func ErrorImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewVoidType(), values.NewCondition(operands[0].DisplayString(), operands[1:]...)
+}
+
+// RaiseImpl implements the raise procedure
+// It raises obj as an exception; conditions are raised as themselves
+func RaiseImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if c, ok := operands[0].(*values.Condition); ok {
+		return values.NewVoidType(), c
+	}
+	return values.NewVoidType(), Raised{Payload: operands[0]}
+}
+
+// IsErrorObjectImpl implements the error-object? procedure
+func IsErrorObjectImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	_, ok := operands[0].(*values.Condition)
+	return values.NewBool(ok), nil
+}
+
+// ErrorObjectMessageImpl implements the error-object-message procedure
+func ErrorObjectMessageImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	c, err := conditionArg(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewString(c.Message), nil
+}
+
+// ErrorObjectIrritantsImpl implements the error-object-irritants procedure
+func ErrorObjectIrritantsImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	c, err := conditionArg(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(len(c.Irritants)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.List(c.Irritants...), nil
+}
+
+func conditionArg(args values.Interface) (*values.Condition, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := operands[0].(*values.Condition)
+	if !ok {
+		return nil, ErrTypeMismatch
+	}
+	return c, nil
+}
+
+// GuardImpl implements the guard special form
+// (guard (var clause ...) body ...) evaluates body; if it raises, var is bound to the raised
+// object and the cond clauses are tried in turn. When no clause matches the exception is re-raised.
+func GuardImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	operands, err := unpackArgs(args, 1, -1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	spec, err := unpackArgs(operands[0], 1, -1)
+	if err != nil {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	name, ok := symbolName(spec[0])
+	if !ok {
+		return values.NewVoidType(), ErrBadArgument
+	}
+	result, bodyErr := rt.Trampoline(evalBody(operands[1:], rt, cb))
+	if bodyErr == nil {
+		return result, nil
+	}
+	if rt.Interrupted() != nil || errors.Is(bodyErr, ErrResourceLimitExceeded) {
+		// cancellation and exhausted limits are not Scheme exceptions and cannot be handled by guard
+		return values.NewVoidType(), bodyErr
+	}
+	frame := ExtendEnvironment(rt.Env)
+	frame.Define(name, RaisedValue(bodyErr))
+	result, matched, err := condClauses(spec[1:], rt.WithEnvironment(frame), cb)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if !matched {
+		return values.NewVoidType(), bodyErr
+	}
+	return result, nil
+}
@@ -0,0 +1,194 @@
+package builtins
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/list"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// ApplyImpl implements the apply procedure
+// (apply proc arg ... list) calls proc with the args followed by the elements of list.
+// The call is a tail call, so (apply f ...) in tail position does not grow the stack.
+func ApplyImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	spread, err := listArg(operands[len(operands)-1])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	callArgs := values.ListWithTail(values.List(spread...), operands[1:len(operands)-1]...)
+	switch proc := operands[0].(type) {
+	case Procedure:
+		return proc.Call(callArgs, rt)
+	case Lambda:
+		return proc.Apply(callArgs)
+	}
+	return values.NewVoidType(), fmt.Errorf("%w: %s is not a procedure", ErrTypeMismatch, operands[0].WriteString())
+}
+
+// ProcedureArityImpl implements the procedure-arity procedure
+// (procedure-arity proc) returns the number of arguments proc accepts when it is fixed, and
+// otherwise a pair of the least and the most, with #f as the most when there is no limit.
+// The arity of a case-lambda spans its clauses, so it may include counts no clause accepts.
+func ProcedureArityImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if _, ok := operands[0].(Lambda); !ok {
+		return values.NewVoidType(), fmt.Errorf("%w: %s is not a procedure", ErrTypeMismatch, operands[0].WriteString())
+	}
+	arity := AtLeast(0)
+	if proc, ok := operands[0].(interface{ Arity() Arity }); ok {
+		arity = proc.Arity()
+	}
+	switch {
+	case arity.Max < 0:
+		return values.Cons(values.NewInt(int64(arity.Min)), values.NewBool(false)), nil
+	case arity.Min == arity.Max:
+		return values.NewInt(int64(arity.Min)), nil
+	}
+	return values.Cons(values.NewInt(int64(arity.Min)), values.NewInt(int64(arity.Max))), nil
+}
+
+// MapImpl implements the map procedure
+// (map proc list1 list2 ...) returns the results of calling proc on the elements of the
+// lists in order, stopping at the shortest list
+func MapImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return mapRows(args, rt, func(result values.Interface, out []values.Interface) ([]values.Interface, error) {
+		return append(out, result), nil
+	})
+}
+
+// ForEachImpl implements the for-each procedure
+// (for-each proc list1 list2 ...) calls proc on the elements of the lists in order for effect
+func ForEachImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	rows, err := listRows(operands[1:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return forEachRow(rt, operands[0], rows)
+}
+
+// VectorMapImpl implements the vector-map procedure
+// (vector-map proc vector1 vector2 ...) returns a vector of the results of calling proc on the elements
+func VectorMapImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	rows, err := vectorRows(operands[1:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(len(rows)); err != nil {
+		return values.NewVoidType(), err
+	}
+	results := make([]values.Interface, len(rows))
+	for i, row := range rows {
+		if results[i], err = applyArg(rt, operands[0], row...); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	return values.NewVector(results...), nil
+}
+
+// VectorForEachImpl implements the vector-for-each procedure
+func VectorForEachImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	rows, err := vectorRows(operands[1:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return forEachRow(rt, operands[0], rows)
+}
+
+// StringMapImpl implements the string-map procedure
+// (string-map proc string1 string2 ...) returns the string of characters returned by proc
+func StringMapImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	rows, err := stringRows(operands[1:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	var sb strings.Builder
+	for _, row := range rows {
+		result, err := applyArg(rt, operands[0], row...)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		c, ok := result.(values.Char)
+		if !ok {
+			return values.NewVoidType(), fmt.Errorf("%w: string-map procedure returned %s", ErrTypeMismatch, result.WriteString())
+		}
+		sb.WriteRune(c.Rune())
+	}
+	if err := rt.AllocString(sb.Len()); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewString(sb.String()), nil
+}
+
+// StringForEachImpl implements the string-for-each procedure
+func StringForEachImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	rows, err := stringRows(operands[1:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return forEachRow(rt, operands[0], rows)
+}
+
+func forEachRow(rt *Runtime, proc values.Interface, rows [][]values.Interface) (values.Interface, error) {
+	for _, row := range rows {
+		if _, err := applyArg(rt, proc, row...); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	return values.NewVoidType(), nil
+}
+
+// vectorRows returns the i-th elements of each vector in operands as the i-th row,
+// stopping at the shortest vector.
+func vectorRows(operands []values.Interface) ([][]values.Interface, error) {
+	vectors := make([][]values.Interface, len(operands))
+	for i, operand := range operands {
+		vec, ok := operand.(values.Vector)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not a vector", ErrTypeMismatch, operand.WriteString())
+		}
+		vectors[i] = vec.Items()
+	}
+	return list.Zip(vectors...), nil
+}
+
+// stringRows returns the i-th characters of each string in operands as the i-th row,
+// stopping at the shortest string.
+func stringRows(operands []values.Interface) ([][]values.Interface, error) {
+	strs := make([][]values.Interface, len(operands))
+	for i, operand := range operands {
+		s, ok := operand.(values.String)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not a string", ErrTypeMismatch, operand.WriteString())
+		}
+		strs[i] = list.Apply([]rune(s.String()), values.NewChar)
+	}
+	return list.Zip(strs...), nil
+}
@@ -0,0 +1,10 @@
+package builtins
+
+//go:generate go run ./gendoc
+
+// Docstring returns the documentation of the builtin procedure or special form name,
+// taken from the doc comment of its implementation.
+func Docstring(name string) (string, bool) {
+	doc, ok := docstrings[name]
+	return doc, ok
+}
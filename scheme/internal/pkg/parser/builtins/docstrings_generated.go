@@ -0,0 +1,122 @@
+// Code generated by gendoc; DO NOT EDIT.
+
+package builtins
+
+var docstrings = map[string]string{
+	"*":                          "Computes the product of all numeric arguments in args.\nIt multiplies each number together.",
+	"+":                          "Computes the sum of all numeric arguments in args.",
+	"-":                          "Computes the difference of all numeric arguments in args.\nIt subtracts each subsequent number from the first, or negates a single argument.",
+	"/":                          "Computes the quotient of all numeric arguments in args.\nIt divides the first number by each subsequent number in order, or inverts a single argument.",
+	"<":                          "It returns #t if the arguments are in strictly increasing order\nIt returns #f if any argument is not a number\nIt returns #f if the arguments are not in strictly increasing order",
+	"<=":                         "It returns #t if the arguments are in non-decreasing order\nIt returns #f if any argument is not a number\nIt returns #f if the arguments are not in non-decreasing order",
+	"=":                          "It returns #t if all arguments are the same number, comparing integers and floats by value\nIt returns #f if any argument is a different number\nIt fails with ErrNumberExpected if any argument is not a number",
+	">":                          "It returns #t if the arguments are in strictly decreasing order\nIt returns #f if any argument is not a number\nIt returns #f if the arguments are not in strictly decreasing order",
+	">=":                         "It returns #t if the arguments are in non-increasing order\nIt returns #f if any argument is not a number\nIt returns #f if the arguments are not in non-increasing order",
+	"alist->hash-table":          "(alist->hash-table alist [equivalence arg ...]) returns a table holding the associations\nof alist. When a key occurs more than once the first association wins.",
+	"alist-copy":                 "It returns a copy of an association list with new pairs for each association",
+	"alist-delete":               "(alist-delete key alist [=]) returns alist without the associations whose key is equal to key",
+	"any":                        "(any pred list1 list2 ...) returns the first true result of pred on the elements, or #f",
+	"append":                     "It returns a list of the elements of each list argument followed by the final argument",
+	"append-map":                 "(append-map f list1 list2 ...) appends the lists returned by calling f on the elements",
+	"apply":                      "(apply proc arg ... list) calls proc with the args followed by the elements of list.\nThe call is a tail call, so (apply f ...) in tail position does not grow the stack.",
+	"begin":                      "It evaluates each expression in order and returns the value of the last one",
+	"call-with-values":           "(call-with-values producer consumer) calls consumer with the values returned by producer",
+	"car":                        "It returns the first element of a pair",
+	"case-lambda":                "(case-lambda (formals body ...) ...) returns a procedure that runs the body of the first\nclause whose formals accept the number of arguments it is called with.",
+	"cdr":                        "It returns the second element of a pair",
+	"channel-receive":            "(channel-receive channel) blocks until a value is sent and returns it.\nIt returns the end of file object once the channel is closed and drained.",
+	"channel-send":               "(channel-send channel obj) blocks until obj is received or buffered",
+	"cond":                       "(cond (test expr ...) ... [(else expr ...)]) evaluates the expressions of the first clause whose\ntest is truthy; (test => receiver) calls receiver with the value of test instead",
+	"cons":                       "It returns a new pair whose car is the first argument and whose cdr is the second",
+	"define":                     "(define name expr) binds the value of expr to name in the current environment\n(define (name . formals) body ...) binds a procedure to name",
+	"define-library":             "(define-library name declaration ...) evaluates the declarations in a new, empty environment\nand registers the library. Supported declarations are export, import, begin, include,\ninclude-ci and include-library-declarations.",
+	"define-record-type":         "(define-record-type <name> (constructor field ...) predicate (field accessor [modifier]) ...)\ndefines <name> as a record type together with its constructor, predicate, accessors and\nmodifiers. The constructor may be #f to define none; fields it does not initialise are #f.",
+	"delay":                      "(delay expr) returns a promise that evaluates expr when it is first forced.",
+	"delay-force":                "(delay-force expr) returns a promise that, when forced, forces the promise expr evaluates to.\nForcing a chain of delay-force promises does not grow the stack.",
+	"delete":                     "(delete x list [=]) returns list without the elements e for which (= x e), comparing with equal? by default",
+	"delete-duplicates":          "(delete-duplicates list [=]) returns list keeping only the first of each set of equal elements",
+	"digit-value":                "It returns the numeric value of a decimal digit character or #f for any other character",
+	"drop":                       "(drop list k) returns the tail of list following its first k elements",
+	"eq?":                        "Mutable objects such as vectors and hash tables are eqv? only to themselves.",
+	"equal?":                     "It returns #t if both arguments are structurally equal",
+	"eqv?":                       "Mutable objects such as vectors and hash tables are eqv? only to themselves.",
+	"error":                      "(error message irritant ...) raises a new error object",
+	"every":                      "(every pred list1 list2 ...) returns #f if pred is false for some elements, and otherwise\nthe result of the last call, or #t when the lists are empty",
+	"filter":                     "(filter pred list) returns the elements of list satisfying pred, in order",
+	"filter-map":                 "(filter-map f list1 list2 ...) returns the true results of calling f on the elements",
+	"find":                       "(find pred list) returns the first element satisfying pred, or #f",
+	"find-tail":                  "(find-tail pred list) returns the first pair of list whose car satisfies pred, or #f",
+	"fold":                       "(fold kons knil list1 list2 ...) calls (kons e1 e2 ... acc) on the elements from left to\nright, starting with knil as acc, and stops at the shortest list",
+	"fold-right":                 "It is fold applied to the elements from right to left",
+	"for-each":                   "(for-each proc list1 list2 ...) calls proc on the elements of the lists in order for effect",
+	"force":                      "(force obj) returns the value of the promise obj, or obj itself if it is not a promise.",
+	"get-output-string":          "It returns the characters written so far to a port created by open-output-string.",
+	"guard":                      "(guard (var clause ...) body ...) evaluates body; if it raises, var is bound to the raised\nobject and the cond clauses are tried in turn. When no clause matches the exception is re-raised.",
+	"hash-table-copy":            "The optional mutability flag is accepted; every table is mutable.",
+	"hash-table-delete!":         "(hash-table-delete! table key ...) removes the keys and returns the number that were present.",
+	"hash-table-keys":            "The keys are listed in the order they were first added.",
+	"hash-table-ref":             "(hash-table-ref table key [failure [success]]) returns the value of key, passed to success\nwhen given. A missing key calls the thunk failure, or is an error without one.",
+	"hash-table-set!":            "(hash-table-set! table key value ...) associates each key with the value following it.",
+	"hash-table-update!":         "(hash-table-update! table key updater [failure [success]]) sets key to the result of\ncalling updater with the value hash-table-ref would return for the same arguments.",
+	"hash-table-update!/default": "(hash-table-update!/default table key updater default) sets key to the result of calling\nupdater with its value, or with default when key is missing.",
+	"hash-table-values":          "The values are listed in the order of hash-table-keys.",
+	"hash-table-walk":            "(hash-table-walk table proc) calls proc with each key and value. Entries added or\nremoved by proc do not affect the walk.",
+	"if":                         "(if test consequent [alternate]) evaluates consequent when test is truthy, otherwise alternate",
+	"import":                     "(import import-set ...) defines the bindings of each import set in the current environment.\nAn import set is a library name or one of (only set id ...), (except set id ...),\n(prefix set prefix-id) and (rename set (from to) ...).",
+	"iota":                       "(iota count [start step]) returns the list (start start+step ... start+(count-1)*step)",
+	"lambda":                     "(lambda formals body ...) returns a procedure closing over the current environment",
+	"last":                       "It returns the last element of a non-empty list",
+	"length":                     "It returns the number of elements in a proper list",
+	"let":                        "(let ((name expr) ...) body ...) evaluates body in a new frame binding each name to the value of expr\n(let loop ((name expr) ...) body ...) additionally binds loop to a procedure taking the names as formals",
+	"list":                       "It returns a newly allocated list of its arguments",
+	"list->stream":               "(list->stream list) returns a stream of the elements of list.",
+	"list-index":                 "(list-index pred list1 list2 ...) returns the index of the first elements satisfying pred, or #f",
+	"list?":                      "It returns #t if the argument is a proper list",
+	"load":                       "(load filename) reads and evaluates every expression of the file in the current environment.\nRelative names are resolved against the directory of the file currently being loaded.",
+	"make-channel":               "(make-channel [capacity]) returns a new channel, unbuffered unless a capacity is given",
+	"make-hash-table":            "(make-hash-table [equivalence arg ...]) returns an empty table comparing keys with\nequivalence, one of equal?, eqv?, eq? or string=?, defaulting to equal?. The hash function\nand size hints SRFI-69 and SRFI-125 allow after the equivalence are accepted and ignored,\nas every equivalence has a matching hash.",
+	"make-parameter":             "(make-parameter value [converter]) returns a parameter whose global value is\n(converter value). Values bound by parameterize are passed through converter as well.",
+	"make-promise":               "(make-promise obj) returns a forced promise of obj, or obj itself if it is a promise.",
+	"make-thread":                "(make-thread thunk [name]) returns a new thread that runs thunk once started",
+	"make-vector":                "(make-vector k [fill]) returns a vector of k elements, each initialized to fill",
+	"map":                        "(map proc list1 list2 ...) returns the results of calling proc on the elements of the\nlists in order, stopping at the shortest list",
+	"modulo":                     "Computes the modulo of the first numeric argument by the second.\nThe result has the sign of the divisor.",
+	"mutex-lock!":                "(mutex-lock! mutex [timeout [thread]]) locks mutex, returning #f if the timeout expires first.\nThe mutex is owned by thread, by default the current thread, or by no thread when thread is #f.\nMutexes are not recursive: without a timeout, locking a mutex the current thread owns\nraises ErrDeadlock, as the lock could never be acquired. Locking a mutex whose owner\nterminated while owning it locks it and raises ErrAbandonedMutex.",
+	"mutex-state":                "It returns the owning thread of a locked mutex, the symbol not-owned for a mutex locked for\nno thread, abandoned for an unlocked mutex whose owner terminated and not-abandoned for\nany other unlocked one",
+	"mutex-unlock!":              "(mutex-unlock! mutex [condition-variable [timeout]]) unlocks mutex. Given a condition variable\nit then waits until the condition variable is signaled, returning #f if the timeout expires first.\nThe mutex is not locked again when the wait ends.",
+	"not":                        "It returns #t if the argument is false\nIt returns #f if the argument is true",
+	"null?":                      "It returns #t if the argument is the empty list",
+	"pair?":                      "It returns #t if the argument is a pair",
+	"parameterize":               "(parameterize ((param value) ...) body...) evaluates body with each param bound to its\nconverted value. The bindings last for the dynamic extent of body.",
+	"partition":                  "(partition pred list) returns two values: the elements satisfying pred and the others",
+	"pretty-print":               "(pretty-print obj [port]) writes obj like write, followed by a newline, breaking the lists\nthat do not fit the width of the page across lines with the indentation of schemefmt.",
+	"procedure-arity":            "(procedure-arity proc) returns the number of arguments proc accepts when it is fixed, and\notherwise a pair of the least and the most, with #f as the most when there is no limit.\nThe arity of a case-lambda spans its clauses, so it may include counts no clause accepts.",
+	"quot":                       "It returns the first argument if multiple arguments are provided\nIt returns the argument itself if a single argument is provided",
+	"quote":                      "It returns its operand unevaluated",
+	"raise":                      "It raises obj as an exception; conditions are raised as themselves",
+	"reduce":                     "(reduce f ridentity list) is (fold f (car list) (cdr list)), or ridentity for the empty list",
+	"remove":                     "(remove pred list) returns the elements of list not satisfying pred, in order",
+	"reverse":                    "It returns a list of the elements of its argument in reverse order",
+	"select":                     "(select clause ...) waits until one of the clauses can proceed and evaluates its body, where a clause is\n\n\t(recv channel var body ...)    receives a value from channel and binds it to var\n\t(send channel obj body ...)    sends obj on channel\n\t(after seconds body ...)       proceeds once the timeout expires\n\t(else body ...)                proceeds when no other clause can\n\nChannel, obj and seconds expressions are evaluated once, in order, before waiting.",
+	"set!":                       "(set! name expr) assigns the value of expr to the binding of name in the innermost\nenclosing frame that binds it. Assigning an unbound name is an error.",
+	"set-car!":                   "(set-car! pair obj) stores obj in the car of pair",
+	"set-cdr!":                   "(set-cdr! pair obj) stores obj in the cdr of pair",
+	"stream->list":               "(stream->list stream [n]) returns a list of the first n elements of stream, or of all of them.",
+	"stream-car":                 "(stream-car stream) returns the first element of a stream pair.",
+	"stream-cdr":                 "(stream-cdr stream) returns the stream of the elements after the first.",
+	"stream-cons":                "(stream-cons obj stream) returns a stream pair without evaluating obj or stream.",
+	"stream-filter":              "(stream-filter pred stream) returns the stream of the elements of stream satisfying pred.",
+	"stream-map":                 "(stream-map proc stream1 stream2 ...) returns the stream of the results of calling proc\non the elements of the streams, ending with the shortest stream.",
+	"stream-take":                "(stream-take n stream) returns the stream of the first n elements of stream.",
+	"string-map":                 "(string-map proc string1 string2 ...) returns the string of characters returned by proc",
+	"take":                       "(take list k) returns the first k elements of list",
+	"thread-join!":               "(thread-join! thread [timeout [timeout-val]]) waits for thread to terminate and returns the\nresult of its thunk. An exception raised by the thread is raised again in the joining thread.\nWithout a timeout, joining a thread that was not started, or the current thread, raises\nErrDeadlock, as the join could never end.",
+	"thread-sleep!":              "(thread-sleep! seconds) suspends the current thread for the given number of seconds",
+	"thread-start!":              "It starts the thread on a new goroutine and returns the thread",
+	"thread-terminate!":          "The thread stops before its next evaluation step; joining it raises ErrThreadTerminated",
+	"vector":                     "It returns a newly allocated vector of its arguments",
+	"vector-map":                 "(vector-map proc vector1 vector2 ...) returns a vector of the results of calling proc on the elements",
+	"vector-ref":                 "(vector-ref vector k) returns element k of vector",
+	"vector-set!":                "(vector-set! vector k obj) stores obj in element k of vector",
+	"write-string":               "(write-string string [port]) writes the characters of string to port",
+}
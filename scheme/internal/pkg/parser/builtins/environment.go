@@ -1,6 +1,11 @@
 package builtins
 
 import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
 )
@@ -8,36 +13,113 @@ import (
 // Environment represents the runtime environment holding variable bindings.
 // It maps variable names to their corresponding values.
 // It supports defining new variables and looking up existing ones.
+// Environments form a chain of frames; lookups that miss in a frame continue in its parent.
+// Copies of an Environment share its frames, and frames are safe for concurrent use.
 type Environment struct {
 	state map[string]values.Interface
+	mu    *sync.RWMutex
+	// version counts the changes to the bindings of the frame
+	version *atomic.Uint64
+	parent  *Environment
 }
 
 func NewEnvironment() Environment {
 	return Environment{
-		state: make(map[string]values.Interface),
+		state:   make(map[string]values.Interface),
+		mu:      &sync.RWMutex{},
+		version: &atomic.Uint64{},
 	}
 }
 
-// ExtendEnvironment creates a new Environment by copying the state from an existing one.
-// This allows for creating a new environment that shares the same variable bindings as the original.
+// ExtendEnvironment creates a new Environment frame whose parent is env.
+// Definitions in the new frame shadow, but do not modify, the bindings of env,
+// while bindings of env remain visible through the new frame.
 func ExtendEnvironment(env Environment) Environment {
 	return Environment{
-		state: env.state,
+		state:   make(map[string]values.Interface),
+		mu:      &sync.RWMutex{},
+		version: &atomic.Uint64{},
+		parent:  &env,
 	}
 }
 
 // Define adds a new variable binding to the environment.
 // It associates the given name with the provided value.
 func (env *Environment) Define(name string, value values.Interface) {
+	env.mu.Lock()
+	defer env.mu.Unlock()
 	env.state[name] = value
+	env.version.Add(1)
+}
+
+// Set assigns value to the binding of name in the innermost frame that binds it.
+// It reports false, without defining name, when no frame binds it.
+func (env *Environment) Set(name string, value values.Interface) bool {
+	for frame := env; frame != nil; frame = frame.parent {
+		frame.mu.Lock()
+		_, ok := frame.state[name]
+		if ok {
+			frame.state[name] = value
+			frame.version.Add(1)
+		}
+		frame.mu.Unlock()
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// undefine removes the binding of name from this frame.
+func (env *Environment) undefine(name string) {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	delete(env.state, name)
+	env.version.Add(1)
+}
+
+// Version returns a number that changes whenever a binding visible through env is
+// defined, assigned or removed, so that values looked up in env can be cached until then.
+func (env *Environment) Version() uint64 {
+	var version uint64
+	for frame := env; frame != nil; frame = frame.parent {
+		version += frame.version.Load()
+	}
+	return version
 }
 
 // Lookup retrieves the value associated with the given variable name.
 // It returns the value and a boolean indicating whether the variable was found.
 // If the variable is not found, the boolean will be false.
 func (env *Environment) Lookup(name string) (values.Interface, bool) {
-	v, ok := env.state[name]
-	return v, ok
+	for frame := env; frame != nil; frame = frame.parent {
+		frame.mu.RLock()
+		v, ok := frame.state[name]
+		frame.mu.RUnlock()
+		if ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Names returns the names bound in this frame, excluding those of enclosing frames.
+func (env *Environment) Names() []string {
+	env.mu.RLock()
+	defer env.mu.RUnlock()
+	names := make([]string, 0, len(env.state))
+	for name := range env.state {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Parent returns the frame enclosing this one, or false for the outermost frame.
+func (env *Environment) Parent() (Environment, bool) {
+	if env.parent == nil {
+		return Environment{}, false
+	}
+	return *env.parent, true
 }
 
 // adaptBuiltin adapts a built-in function to match the expected Expression signature.
@@ -50,28 +132,241 @@ func adaptBuiltin(f func(value values.Interface, rt *Runtime, cb Expression) (va
 	}
 }
 
+// newBuiltin returns the procedure like NewNamedLambda that a new runtime binds to name.
+func newBuiltin(rt *Runtime, name string, arity Arity, expression Expression) values.Interface {
+	return LambdaExpr{
+		Name:    name,
+		Runtime: rt,
+		Body:    expression,
+		arity:   arity,
+		builtin: true,
+	}
+}
+
+// IsBuiltin reports whether v is the builtin procedure a new runtime binds to name,
+// rather than a procedure defined with the same name, so that compilers may rely on what
+// calling it does.
+func IsBuiltin(name string, v values.Interface) bool {
+	l, ok := v.(LambdaExpr)
+	return ok && l.builtin && l.Name == name
+}
+
 func (rt *Runtime) defaultEnvironment(cb Expression) {
 
-	rt.Env.Define("newline", values.NewString("\n"))
+	//special forms
+	rt.Env.Define("quote", NewSyntax("quote", QuoteImpl))
+	rt.Env.Define("define", NewSyntax("define", adaptBuiltin(DefineImpl, cb)))
+	rt.Env.Define("set!", NewSyntax("set!", adaptBuiltin(SetImpl, cb)))
+	rt.Env.Define("lambda", NewSyntax("lambda", adaptBuiltin(LambdaImpl, cb)))
+	rt.Env.Define("case-lambda", NewSyntax("case-lambda", adaptBuiltin(CaseLambdaImpl, cb)))
+	rt.Env.Define("if", NewSyntax("if", adaptBuiltin(IfImpl, cb)))
+	rt.Env.Define("begin", NewSyntax("begin", adaptBuiltin(BeginImpl, cb)))
+	rt.Env.Define("let", NewSyntax("let", adaptBuiltin(LetImpl, cb)))
+	rt.Env.Define("cond", NewSyntax("cond", adaptBuiltin(CondImpl, cb)))
+	rt.Env.Define("guard", NewSyntax("guard", adaptBuiltin(GuardImpl, cb)))
+	rt.Env.Define("define-record-type", NewSyntax("define-record-type", adaptBuiltin(DefineRecordTypeImpl, cb)))
+	rt.Env.Define("parameterize", NewSyntax("parameterize", adaptBuiltin(ParameterizeImpl, cb)))
+	//libraries
+	rt.Env.Define("define-library", NewSyntax("define-library", adaptBuiltin(DefineLibraryImpl, cb)))
+	rt.Env.Define("import", NewSyntax("import", ImportImpl))
+	rt.Env.Define("load", newBuiltin(rt, "load", Exactly(1), adaptBuiltin(LoadImpl, cb)))
 	//I/O
-	rt.Env.Define(types.Format.String(), NewLambda(rt, FormatImpl))
-	rt.Env.Define(types.Write.String(), NewLambda(rt, WriteImpl))
-	rt.Env.Define(types.Display.String(), NewLambda(rt, DisplayImpl))
+	rt.Env.Define("newline", newBuiltin(rt, "newline", Between(0, 1), NewlineImpl))
+	rt.Env.Define("t", values.NewBool(true))
+	rt.Env.Define(types.Format.String(), newBuiltin(rt, types.Format.String(), AtLeast(1), FormatImpl))
+	rt.Env.Define(types.Write.String(), newBuiltin(rt, types.Write.String(), Between(1, 2), WriteImpl))
+	rt.Env.Define(types.Display.String(), newBuiltin(rt, types.Display.String(), Between(1, 2), DisplayImpl))
+	rt.Env.Define("pretty-print", newBuiltin(rt, "pretty-print", Between(1, 2), PrettyPrintImpl))
+	rt.Env.Define("write-string", newBuiltin(rt, "write-string", Between(1, 2), WriteStringImpl))
+	rt.Env.Define("write-char", newBuiltin(rt, "write-char", Between(1, 2), WriteCharImpl))
+	rt.Env.Define("read-char", newBuiltin(rt, "read-char", Between(0, 1), ReadCharImpl))
+	rt.Env.Define("read-line", newBuiltin(rt, "read-line", Between(0, 1), ReadLineImpl))
+	//ports and parameters
+	rt.Env.Define("make-parameter", newBuiltin(rt, "make-parameter", Between(1, 2), MakeParameterImpl))
+	rt.Env.Define("current-output-port", currentOutputPort)
+	rt.Env.Define("current-error-port", currentErrorPort)
+	rt.Env.Define("current-input-port", currentInputPort)
+	rt.Env.Define("port?", newBuiltin(rt, "port?", Exactly(1), typePredicate(isPort)))
+	rt.Env.Define("input-port?", newBuiltin(rt, "input-port?", Exactly(1), portDirection(false)))
+	rt.Env.Define("output-port?", newBuiltin(rt, "output-port?", Exactly(1), portDirection(true)))
+	rt.Env.Define("open-output-string", newBuiltin(rt, "open-output-string", Exactly(0), OpenOutputStringImpl))
+	rt.Env.Define("open-input-string", newBuiltin(rt, "open-input-string", Exactly(1), OpenInputStringImpl))
+	rt.Env.Define("get-output-string", newBuiltin(rt, "get-output-string", Exactly(1), GetOutputStringImpl))
+	rt.Env.Define("close-port", newBuiltin(rt, "close-port", Exactly(1), ClosePortImpl))
 	//quote
-	rt.Env.Define("quot", NewLambda(rt, QuotImpl))
+	rt.Env.Define("quot", newBuiltin(rt, "quot", AtLeast(0), QuotImpl))
 	//relational operators
-	rt.Env.Define("<", NewLambda(rt, adaptBuiltin(LessThanImpl, cb)))
-	rt.Env.Define("<=", NewLambda(rt, adaptBuiltin(LessThanOrImpl, cb)))
-	rt.Env.Define(">", NewLambda(rt, adaptBuiltin(GreatThanImpl, cb)))
-	rt.Env.Define(">=", NewLambda(rt, adaptBuiltin(GreatThanOrImpl, cb)))
-	rt.Env.Define("=", NewLambda(rt, EqualImpl))
+	rt.Env.Define("<", newBuiltin(rt, "<", AtLeast(0), adaptBuiltin(LessThanImpl, cb)))
+	rt.Env.Define("<=", newBuiltin(rt, "<=", AtLeast(0), adaptBuiltin(LessThanOrImpl, cb)))
+	rt.Env.Define(">", newBuiltin(rt, ">", AtLeast(0), adaptBuiltin(GreatThanImpl, cb)))
+	rt.Env.Define(">=", newBuiltin(rt, ">=", AtLeast(0), adaptBuiltin(GreatThanOrImpl, cb)))
+	rt.Env.Define("=", newBuiltin(rt, "=", AtLeast(0), EqualImpl))
 	//boolean operators
-	rt.Env.Define("not", NewLambda(rt, NotImpl))
+	rt.Env.Define("not", newBuiltin(rt, "not", Exactly(1), NotImpl))
 	//arithmetic
-	rt.Env.Define("+", NewLambda(rt, adaptBuiltin(SumImpl, cb)))
-	rt.Env.Define("-", NewLambda(rt, adaptBuiltin(DifferenceImpl, cb)))
-	rt.Env.Define("*", NewLambda(rt, adaptBuiltin(ProductImpl, cb)))
-	rt.Env.Define("/", NewLambda(rt, adaptBuiltin(QuotientImpl, cb)))
-	rt.Env.Define("modulo", NewLambda(rt, adaptBuiltin(RemainderImpl, cb)))
+	rt.Env.Define("+", newBuiltin(rt, "+", AtLeast(0), adaptBuiltin(SumImpl, cb)))
+	rt.Env.Define("-", newBuiltin(rt, "-", AtLeast(0), adaptBuiltin(DifferenceImpl, cb)))
+	rt.Env.Define("*", newBuiltin(rt, "*", AtLeast(0), adaptBuiltin(ProductImpl, cb)))
+	rt.Env.Define("/", newBuiltin(rt, "/", AtLeast(1), adaptBuiltin(QuotientImpl, cb)))
+	rt.Env.Define("modulo", newBuiltin(rt, "modulo", Exactly(2), adaptBuiltin(RemainderImpl, cb)))
+	//type predicates
+	rt.Env.Define("boolean?", newBuiltin(rt, "boolean?", Exactly(1), typePredicate(isBoolean)))
+	rt.Env.Define("number?", newBuiltin(rt, "number?", Exactly(1), typePredicate(isNumber)))
+	rt.Env.Define("integer?", newBuiltin(rt, "integer?", Exactly(1), typePredicate(isInteger)))
+	rt.Env.Define("string?", newBuiltin(rt, "string?", Exactly(1), typePredicate(isString)))
+	rt.Env.Define("char?", newBuiltin(rt, "char?", Exactly(1), typePredicate(isChar)))
+	rt.Env.Define("symbol?", newBuiltin(rt, "symbol?", Exactly(1), typePredicate(isSymbol)))
+	rt.Env.Define("procedure?", newBuiltin(rt, "procedure?", Exactly(1), typePredicate(isProcedure)))
+	//pairs and lists
+	rt.Env.Define("cons", newBuiltin(rt, "cons", Exactly(2), ConsImpl))
+	rt.Env.Define("car", newBuiltin(rt, "car", Exactly(1), CarImpl))
+	rt.Env.Define("cdr", newBuiltin(rt, "cdr", Exactly(1), CdrImpl))
+	rt.Env.Define("set-car!", newBuiltin(rt, "set-car!", Exactly(2), SetCarImpl))
+	rt.Env.Define("set-cdr!", newBuiltin(rt, "set-cdr!", Exactly(2), SetCdrImpl))
+	rt.Env.Define("list", newBuiltin(rt, "list", AtLeast(0), ListImpl))
+	rt.Env.Define("null?", newBuiltin(rt, "null?", Exactly(1), IsNullImpl))
+	rt.Env.Define("pair?", newBuiltin(rt, "pair?", Exactly(1), IsPairImpl))
+	rt.Env.Define("list?", newBuiltin(rt, "list?", Exactly(1), IsListImpl))
+	rt.Env.Define("length", newBuiltin(rt, "length", Exactly(1), LengthImpl))
+	rt.Env.Define("append", newBuiltin(rt, "append", AtLeast(0), AppendImpl))
+	rt.Env.Define("reverse", newBuiltin(rt, "reverse", Exactly(1), ReverseImpl))
+	rt.Env.Define("filter", newBuiltin(rt, "filter", Exactly(2), FilterImpl))
+	rt.Env.Define("remove", newBuiltin(rt, "remove", Exactly(2), RemoveImpl))
+	rt.Env.Define("partition", newBuiltin(rt, "partition", Exactly(2), PartitionImpl))
+	rt.Env.Define("fold", newBuiltin(rt, "fold", AtLeast(3), FoldImpl))
+	rt.Env.Define("fold-right", newBuiltin(rt, "fold-right", AtLeast(3), FoldRightImpl))
+	rt.Env.Define("reduce", newBuiltin(rt, "reduce", Exactly(3), ReduceImpl))
+	rt.Env.Define("append-map", newBuiltin(rt, "append-map", AtLeast(2), AppendMapImpl))
+	rt.Env.Define("filter-map", newBuiltin(rt, "filter-map", AtLeast(2), FilterMapImpl))
+	rt.Env.Define("find", newBuiltin(rt, "find", Exactly(2), FindImpl))
+	rt.Env.Define("find-tail", newBuiltin(rt, "find-tail", Exactly(2), FindTailImpl))
+	rt.Env.Define("any", newBuiltin(rt, "any", AtLeast(2), AnyImpl))
+	rt.Env.Define("every", newBuiltin(rt, "every", AtLeast(2), EveryImpl))
+	rt.Env.Define("list-index", newBuiltin(rt, "list-index", AtLeast(2), ListIndexImpl))
+	rt.Env.Define("delete", newBuiltin(rt, "delete", Between(2, 3), DeleteImpl))
+	rt.Env.Define("delete-duplicates", newBuiltin(rt, "delete-duplicates", Between(1, 2), DeleteDuplicatesImpl))
+	rt.Env.Define("iota", newBuiltin(rt, "iota", Between(1, 3), IotaImpl))
+	rt.Env.Define("take", newBuiltin(rt, "take", Exactly(2), TakeImpl))
+	rt.Env.Define("drop", newBuiltin(rt, "drop", Exactly(2), DropImpl))
+	rt.Env.Define("last", newBuiltin(rt, "last", Exactly(1), LastImpl))
+	rt.Env.Define("alist-copy", newBuiltin(rt, "alist-copy", Exactly(1), AlistCopyImpl))
+	rt.Env.Define("alist-delete", newBuiltin(rt, "alist-delete", Between(2, 3), AlistDeleteImpl))
+	//higher-order procedures
+	rt.Env.Define("procedure-arity", newBuiltin(rt, "procedure-arity", Exactly(1), ProcedureArityImpl))
+	rt.Env.Define("apply", newBuiltin(rt, "apply", AtLeast(2), ApplyImpl))
+	rt.Env.Define("map", newBuiltin(rt, "map", AtLeast(2), MapImpl))
+	rt.Env.Define("for-each", newBuiltin(rt, "for-each", AtLeast(2), ForEachImpl))
+	rt.Env.Define("vector-map", newBuiltin(rt, "vector-map", AtLeast(2), VectorMapImpl))
+	rt.Env.Define("vector-for-each", newBuiltin(rt, "vector-for-each", AtLeast(2), VectorForEachImpl))
+	rt.Env.Define("string-map", newBuiltin(rt, "string-map", AtLeast(2), StringMapImpl))
+	rt.Env.Define("string-for-each", newBuiltin(rt, "string-for-each", AtLeast(2), StringForEachImpl))
+	//promises and streams
+	rt.Env.Define("delay", NewSyntax("delay", adaptBuiltin(DelayImpl, cb)))
+	rt.Env.Define("delay-force", NewSyntax("delay-force", adaptBuiltin(DelayForceImpl, cb)))
+	rt.Env.Define("force", newBuiltin(rt, "force", Exactly(1), ForceImpl))
+	rt.Env.Define("make-promise", newBuiltin(rt, "make-promise", Exactly(1), MakePromiseImpl))
+	rt.Env.Define("promise?", newBuiltin(rt, "promise?", Exactly(1), typePredicate(isPromise)))
+	rt.Env.Define("stream-null", streamNull)
+	rt.Env.Define("stream-cons", NewSyntax("stream-cons", adaptBuiltin(StreamConsImpl, cb)))
+	rt.Env.Define("stream?", newBuiltin(rt, "stream?", Exactly(1), typePredicate(isPromise)))
+	rt.Env.Define("stream-null?", newBuiltin(rt, "stream-null?", Exactly(1), IsStreamNullImpl))
+	rt.Env.Define("stream-pair?", newBuiltin(rt, "stream-pair?", Exactly(1), IsStreamPairImpl))
+	rt.Env.Define("stream-car", newBuiltin(rt, "stream-car", Exactly(1), StreamCarImpl))
+	rt.Env.Define("stream-cdr", newBuiltin(rt, "stream-cdr", Exactly(1), StreamCdrImpl))
+	rt.Env.Define("stream-take", newBuiltin(rt, "stream-take", Exactly(2), StreamTakeImpl))
+	rt.Env.Define("stream-map", newBuiltin(rt, "stream-map", AtLeast(2), StreamMapImpl))
+	rt.Env.Define("stream-filter", newBuiltin(rt, "stream-filter", Exactly(2), StreamFilterImpl))
+	rt.Env.Define("stream->list", newBuiltin(rt, "stream->list", Between(1, 2), StreamToListImpl))
+	rt.Env.Define("list->stream", newBuiltin(rt, "list->stream", Exactly(1), ListToStreamImpl))
+	//multiple values
+	rt.Env.Define("values", newBuiltin(rt, "values", AtLeast(0), ValuesImpl))
+	rt.Env.Define("call-with-values", newBuiltin(rt, "call-with-values", Exactly(2), CallWithValuesImpl))
+	//vectors
+	rt.Env.Define("vector", newBuiltin(rt, "vector", AtLeast(0), VectorImpl))
+	rt.Env.Define("make-vector", newBuiltin(rt, "make-vector", Between(1, 2), MakeVectorImpl))
+	rt.Env.Define("vector?", newBuiltin(rt, "vector?", Exactly(1), IsVectorImpl))
+	rt.Env.Define("vector-length", newBuiltin(rt, "vector-length", Exactly(1), VectorLengthImpl))
+	rt.Env.Define("vector-ref", newBuiltin(rt, "vector-ref", Exactly(2), VectorRefImpl))
+	rt.Env.Define("vector-set!", newBuiltin(rt, "vector-set!", Exactly(3), VectorSetImpl))
+	rt.Env.Define("vector->list", newBuiltin(rt, "vector->list", Exactly(1), VectorToListImpl))
+	rt.Env.Define("list->vector", newBuiltin(rt, "list->vector", Exactly(1), ListToVectorImpl))
+	//threads
+	rt.Env.Define("make-thread", newBuiltin(rt, "make-thread", Between(1, 2), MakeThreadImpl))
+	rt.Env.Define("thread?", newBuiltin(rt, "thread?", Exactly(1), typePredicate(isThread)))
+	rt.Env.Define("thread-start!", newBuiltin(rt, "thread-start!", Exactly(1), ThreadStartImpl))
+	rt.Env.Define("thread-join!", newBuiltin(rt, "thread-join!", Between(1, 3), ThreadJoinImpl))
+	rt.Env.Define("thread-terminate!", newBuiltin(rt, "thread-terminate!", Exactly(1), ThreadTerminateImpl))
+	rt.Env.Define("thread-yield!", newBuiltin(rt, "thread-yield!", Exactly(0), ThreadYieldImpl))
+	rt.Env.Define("thread-sleep!", newBuiltin(rt, "thread-sleep!", Exactly(1), ThreadSleepImpl))
+	rt.Env.Define("current-thread", newBuiltin(rt, "current-thread", Exactly(0), CurrentThreadImpl))
+	rt.Env.Define("thread-name", newBuiltin(rt, "thread-name", Exactly(1), ThreadNameImpl))
+	rt.Env.Define("thread-specific", newBuiltin(rt, "thread-specific", Exactly(1), ThreadSpecificImpl))
+	rt.Env.Define("thread-specific-set!", newBuiltin(rt, "thread-specific-set!", Exactly(2), ThreadSpecificSetImpl))
+	rt.Env.Define("make-mutex", newBuiltin(rt, "make-mutex", Between(0, 1), MakeMutexImpl))
+	rt.Env.Define("mutex?", newBuiltin(rt, "mutex?", Exactly(1), typePredicate(isMutex)))
+	rt.Env.Define("mutex-name", newBuiltin(rt, "mutex-name", Exactly(1), MutexNameImpl))
+	rt.Env.Define("mutex-state", newBuiltin(rt, "mutex-state", Exactly(1), MutexStateImpl))
+	rt.Env.Define("mutex-lock!", newBuiltin(rt, "mutex-lock!", Between(1, 3), MutexLockImpl))
+	rt.Env.Define("mutex-unlock!", newBuiltin(rt, "mutex-unlock!", Between(1, 3), MutexUnlockImpl))
+	rt.Env.Define("make-condition-variable", newBuiltin(rt, "make-condition-variable", Between(0, 1), MakeConditionVariableImpl))
+	rt.Env.Define("condition-variable?", newBuiltin(rt, "condition-variable?", Exactly(1), typePredicate(isConditionVariable)))
+	rt.Env.Define("condition-variable-signal!", newBuiltin(rt, "condition-variable-signal!", Exactly(1), conditionVariableWake(1)))
+	rt.Env.Define("condition-variable-broadcast!", newBuiltin(rt, "condition-variable-broadcast!", Exactly(1), conditionVariableWake(-1)))
+	//channels
+	rt.Env.Define("make-channel", newBuiltin(rt, "make-channel", Between(0, 1), MakeChannelImpl))
+	rt.Env.Define("channel?", newBuiltin(rt, "channel?", Exactly(1), typePredicate(isChannel)))
+	rt.Env.Define("channel-send", newBuiltin(rt, "channel-send", Exactly(2), ChannelSendImpl))
+	rt.Env.Define("channel-receive", newBuiltin(rt, "channel-receive", Exactly(1), ChannelReceiveImpl))
+	rt.Env.Define("channel-close!", newBuiltin(rt, "channel-close!", Exactly(1), ChannelCloseImpl))
+	rt.Env.Define("select", NewSyntax("select", adaptBuiltin(SelectImpl, cb)))
+	rt.Env.Define("eof-object", newBuiltin(rt, "eof-object", Exactly(0), EofObjectImpl))
+	rt.Env.Define("eof-object?", newBuiltin(rt, "eof-object?", Exactly(1), typePredicate(isEof)))
+	//errors
+	rt.Env.Define("error", newBuiltin(rt, "error", AtLeast(1), ErrorImpl))
+	rt.Env.Define("raise", newBuiltin(rt, "raise", Exactly(1), RaiseImpl))
+	rt.Env.Define("error-object?", newBuiltin(rt, "error-object?", Exactly(1), IsErrorObjectImpl))
+	rt.Env.Define("error-object-message", newBuiltin(rt, "error-object-message", Exactly(1), ErrorObjectMessageImpl))
+	rt.Env.Define("error-object-irritants", newBuiltin(rt, "error-object-irritants", Exactly(1), ErrorObjectIrritantsImpl))
+	//equivalence
+	rt.Env.Define("eq?", newEquivalence(rt, "eq?", values.EqvComparator, Exactly(2), EqvImpl))
+	rt.Env.Define("eqv?", newEquivalence(rt, "eqv?", values.EqvComparator, Exactly(2), EqvImpl))
+	rt.Env.Define("equal?", newEquivalence(rt, "equal?", values.EqualComparator, Exactly(2), IsEqualImpl))
+	rt.Env.Define("string=?", newEquivalence(rt, "string=?", values.StringComparator, AtLeast(1), StringEqualImpl))
+	//hash tables
+	rt.Env.Define("make-hash-table", newBuiltin(rt, "make-hash-table", AtLeast(0), MakeHashTableImpl))
+	rt.Env.Define("alist->hash-table", newBuiltin(rt, "alist->hash-table", AtLeast(1), AlistToHashTableImpl))
+	rt.Env.Define("hash-table?", newBuiltin(rt, "hash-table?", Exactly(1), typePredicate(isHashTable)))
+	rt.Env.Define("hash-table-ref", newBuiltin(rt, "hash-table-ref", Between(2, 4), HashTableRefImpl))
+	rt.Env.Define("hash-table-ref/default", newBuiltin(rt, "hash-table-ref/default", Exactly(3), HashTableRefDefaultImpl))
+	rt.Env.Define("hash-table-set!", newBuiltin(rt, "hash-table-set!", AtLeast(3), HashTableSetImpl))
+	rt.Env.Define("hash-table-delete!", newBuiltin(rt, "hash-table-delete!", AtLeast(1), HashTableDeleteImpl))
+	rt.Env.Define("hash-table-contains?", newBuiltin(rt, "hash-table-contains?", Exactly(2), HashTableContainsImpl))
+	rt.Env.Define("hash-table-exists?", newBuiltin(rt, "hash-table-exists?", Exactly(2), HashTableContainsImpl))
+	rt.Env.Define("hash-table-update!", newBuiltin(rt, "hash-table-update!", Between(3, 5), HashTableUpdateImpl))
+	rt.Env.Define("hash-table-update!/default", newBuiltin(rt, "hash-table-update!/default", Exactly(4), HashTableUpdateDefaultImpl))
+	rt.Env.Define("hash-table-size", newBuiltin(rt, "hash-table-size", Exactly(1), HashTableSizeImpl))
+	rt.Env.Define("hash-table-keys", newBuiltin(rt, "hash-table-keys", Exactly(1), HashTableKeysImpl))
+	rt.Env.Define("hash-table-values", newBuiltin(rt, "hash-table-values", Exactly(1), HashTableValuesImpl))
+	rt.Env.Define("hash-table-walk", newBuiltin(rt, "hash-table-walk", Exactly(2), HashTableWalkImpl))
+	rt.Env.Define("hash-table->alist", newBuiltin(rt, "hash-table->alist", Exactly(1), HashTableToAlistImpl))
+	rt.Env.Define("hash-table-clear!", newBuiltin(rt, "hash-table-clear!", Exactly(1), HashTableClearImpl))
+	rt.Env.Define("hash-table-copy", newBuiltin(rt, "hash-table-copy", Between(1, 2), HashTableCopyImpl))
+	rt.Env.Define("hash", newBuiltin(rt, "hash", Between(1, 2), hashProcedure(values.EqualComparator)))
+	rt.Env.Define("string-hash", newBuiltin(rt, "string-hash", Between(1, 2), hashProcedure(values.StringComparator)))
+	//characters
+	rt.Env.Define("char-alphabetic?", newBuiltin(rt, "char-alphabetic?", Exactly(1), charPredicate(unicode.IsLetter)))
+	rt.Env.Define("char-numeric?", newBuiltin(rt, "char-numeric?", Exactly(1), charPredicate(unicode.IsDigit)))
+	rt.Env.Define("char-whitespace?", newBuiltin(rt, "char-whitespace?", Exactly(1), charPredicate(unicode.IsSpace)))
+	rt.Env.Define("char-upper-case?", newBuiltin(rt, "char-upper-case?", Exactly(1), charPredicate(unicode.IsUpper)))
+	rt.Env.Define("char-lower-case?", newBuiltin(rt, "char-lower-case?", Exactly(1), charPredicate(unicode.IsLower)))
+	rt.Env.Define("char-upcase", newBuiltin(rt, "char-upcase", Exactly(1), charMapping(unicode.ToUpper)))
+	rt.Env.Define("char-downcase", newBuiltin(rt, "char-downcase", Exactly(1), charMapping(unicode.ToLower)))
+	rt.Env.Define("char-foldcase", newBuiltin(rt, "char-foldcase", Exactly(1), charMapping(unicode.ToLower)))
+	rt.Env.Define("digit-value", newBuiltin(rt, "digit-value", Exactly(1), DigitValueImpl))
+	rt.Env.Define("char->integer", newBuiltin(rt, "char->integer", Exactly(1), CharToIntegerImpl))
+	rt.Env.Define("integer->char", newBuiltin(rt, "integer->char", Exactly(1), IntegerToCharImpl))
+	rt.Env.Define("string-upcase", newBuiltin(rt, "string-upcase", Exactly(1), stringMapping(strings.ToUpper)))
+	rt.Env.Define("string-downcase", newBuiltin(rt, "string-downcase", Exactly(1), stringMapping(strings.ToLower)))
+	rt.Env.Define("string-foldcase", newBuiltin(rt, "string-foldcase", Exactly(1), stringMapping(strings.ToLower)))
 
 }
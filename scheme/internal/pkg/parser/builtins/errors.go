@@ -11,6 +11,7 @@ var (
 	ErrBadArgument             = errors.New("bad argument")
 	ErrOperatorIsNotAProcedure = errors.New("operator is not a procedure")
 	ErrNotAPrimitive           = values.ErrNotAPrimitive
+	ErrImmutable               = values.ErrImmutable
 	ErrUnexpectedToken         = errors.New("unexpected token")
 	ErrUndefinedIdent          = errors.New("undefined identifier")
 	ErrInvalidToken            = errors.New("invalid token")
@@ -19,6 +20,18 @@ var (
 	ErrNumberExpected          = errors.New("number expected")
 	ErrDivideByZero            = errors.New("divide by zero")
 	ErrTypeMismatch            = errors.New("type mismatch")
+	ErrLibraryNotFound         = errors.New("library not found")
+	ErrCircularImport          = errors.New("circular library import")
+	ErrNoDatumReader           = errors.New("no datum reader configured")
+	ErrIndexOutOfRange         = errors.New("index out of range")
+	ErrResourceLimitExceeded   = errors.New("resource limit exceeded")
+	ErrJoinTimeout             = errors.New("thread join timed out")
+	ErrThreadTerminated        = errors.New("thread terminated")
+	ErrDeadlock                = errors.New("deadlock")
+	ErrAbandonedMutex          = errors.New("abandoned mutex")
+	ErrUncaughtException       = errors.New("uncaught exception")
+	ErrChannelClosed           = errors.New("channel closed")
+	ErrKeyNotFound             = errors.New("key not found")
 )
 
 func ErrIo(err error) error {
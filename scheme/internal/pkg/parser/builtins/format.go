@@ -1,34 +1,123 @@
 package builtins
 
 import (
-	"fmt"
+	"strings"
 
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/pretty"
 )
 
 func DisplayImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
 	if args == nil {
 		return values.NewVoidType(), ErrBadArgument
 	}
-	//TODO if cdr is not nil handle PORT implementation
-
-	// Print the display string of the value
-	if _, err := fmt.Fprintf(rt.Out, "%s", args.DisplayString()); err != nil {
-		return values.NewVoidType(), ErrIo(err)
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
 	}
-	return values.NewVoidType(), nil
+
+	// Print the display string of the value to the port, or the current output port
+	return writePort(operands, 1, rt, operands[0].DisplayString())
 }
 
 func WriteImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
-	if _, err := fmt.Fprintf(rt.Out, "%s", args.WriteString()); err != nil {
-		return values.NewVoidType(), ErrIo(err)
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return writePort(operands, 1, rt, operands[0].WriteString())
+}
+
+// PrettyPrintImpl implements the pretty-print procedure
+// (pretty-print obj [port]) writes obj like write, followed by a newline, breaking the lists
+// that do not fit the width of the page across lines with the indentation of schemefmt.
+func PrettyPrintImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return writePort(operands, 1, rt, pretty.Datum(operands[0], pretty.Width)+"\n")
+}
+
+// NewlineImpl implements the newline procedure
+func NewlineImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
 	}
-	return values.NewVoidType(), nil
+	return writePort(operands, 0, rt, "\n")
 }
 
+// FormatImpl implements the format procedure
+// (format destination control-string arg ...) writes control-string to the current output
+// port when destination is #t, to destination when it is an output port, and returns it as a
+// string when destination is #f. The destination may be omitted, in which case it is written
+// to the current output port. As in Common Lisp, the destination may be written t, which is
+// bound to #t.
+// The directives ~a (display), ~s (write), ~% (newline) and ~~ (tilde) are supported.
 func FormatImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
-	if _, err := fmt.Fprintf(rt.Out, "%s", args.DisplayString()); err != nil {
-		return values.NewVoidType(), ErrIo(err)
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	toOutput := true
+	var destination []values.Interface
+	switch operands[0].Type() {
+	case types.Bool:
+		toOutput = operands[0].IsTruthy()
+		operands = operands[1:]
+	case types.Port:
+		destination = operands[:1]
+		operands = operands[1:]
+	}
+	if len(operands) == 0 {
+		return values.NewVoidType(), ErrWrongNumberOfArguments
+	}
+	control, ok := operands[0].(values.String)
+	if !ok {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	formatted, err := formatDirectives(control.String(), operands[1:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if !toOutput {
+		if err := rt.AllocString(len(formatted)); err != nil {
+			return values.NewVoidType(), err
+		}
+		return values.NewString(formatted), nil
+	}
+	return writePort(destination, 0, rt, formatted)
+}
+
+func formatDirectives(control string, args []values.Interface) (string, error) {
+	var sb strings.Builder
+	runes := []rune(control)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '~' || i == len(runes)-1 {
+			sb.WriteRune(runes[i])
+			continue
+		}
+		i++
+		switch runes[i] {
+		case 'a', 'A', 's', 'S':
+			if len(args) == 0 {
+				return "", ErrWrongNumberOfArguments
+			}
+			if runes[i] == 'a' || runes[i] == 'A' {
+				sb.WriteString(args[0].DisplayString())
+			} else {
+				sb.WriteString(args[0].WriteString())
+			}
+			args = args[1:]
+		case '%':
+			sb.WriteRune('\n')
+		case '~':
+			sb.WriteRune('~')
+		default:
+			return "", ErrInvalidFormat
+		}
 	}
-	return values.NewVoidType(), nil
+	return sb.String(), nil
 }
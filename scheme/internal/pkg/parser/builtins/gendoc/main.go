@@ -0,0 +1,129 @@
+// Command gendoc writes docstrings_generated.go, the docstrings of the builtins, from the
+// doc comments of the functions implementing them. It is run by go generate in the
+// builtins package.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const output = "docstrings_generated.go"
+
+// implementsLine is the first line of the doc comment of a builtin, which names it.
+var implementsLine = regexp.MustCompile(`^\w+ implements the .+$`)
+
+func main() {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != output
+	}, parser.ParseComments)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkg, ok := pkgs["builtins"]
+	if !ok {
+		log.Fatal("gendoc: not in the builtins package")
+	}
+
+	comments := make(map[string]string)
+	var register *ast.FuncDecl
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			if fd.Name.Name == "defaultEnvironment" {
+				register = fd
+			}
+			if fd.Recv == nil && fd.Doc != nil {
+				comments[fd.Name.Name] = fd.Doc.Text()
+			}
+		}
+	}
+	if register == nil {
+		log.Fatal("gendoc: defaultEnvironment not found")
+	}
+
+	docs := make(map[string]string)
+	ast.Inspect(register.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "Define" {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		name, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		impl := implementation(call.Args[1])
+		if doc := docstring(impl, comments[impl]); doc != "" {
+			docs[name] = doc
+		}
+		return false
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gendoc; DO NOT EDIT.\n\npackage builtins\n\n")
+	buf.WriteString("var docstrings = map[string]string{\n")
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(&buf, "\t%s: %s,\n", strconv.Quote(name), strconv.Quote(docs[name]))
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// implementation returns the name of the function implementing a builtin registered
+// as expr, the first identifier in it naming an Impl function.
+func implementation(expr ast.Expr) string {
+	var name string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && name == "" && strings.HasSuffix(id.Name, "Impl") {
+			name = id.Name
+		}
+		return name == ""
+	})
+	return name
+}
+
+// docstring returns the doc comment of the implementation impl without the line naming
+// it, and without the name of impl starting a sentence about it.
+func docstring(impl, comment string) string {
+	lines := strings.Split(strings.TrimSpace(comment), "\n")
+	if implementsLine.MatchString(lines[0]) {
+		lines = lines[1:]
+	}
+	doc := strings.TrimSpace(strings.Join(lines, "\n"))
+	if rest, ok := strings.CutPrefix(doc, impl+" "); ok && rest != "" {
+		doc = strings.ToUpper(rest[:1]) + rest[1:]
+	}
+	return doc
+}
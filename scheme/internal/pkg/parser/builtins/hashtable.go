@@ -0,0 +1,387 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// equivalence is an equivalence predicate such as equal? or string=?. Passed to
+// make-hash-table it selects the comparator the table uses for its keys.
+type equivalence struct {
+	LambdaExpr
+	cmp *values.Comparator
+}
+
+func newEquivalence(rt *Runtime, name string, cmp *values.Comparator, arity Arity, expression Expression) values.Interface {
+	return equivalence{
+		LambdaExpr: LambdaExpr{Name: name, Runtime: rt, Body: expression, arity: arity},
+		cmp:        cmp,
+	}
+}
+
+// EqvImpl implements the eq? and eqv? procedures
+// Mutable objects such as vectors and hash tables are eqv? only to themselves.
+func EqvImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	return values.NewBool(values.Eqv(operands[0], operands[1])), nil
+}
+
+// StringEqualImpl implements the string=? procedure
+func StringEqualImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	for i, operand := range operands {
+		if operand.Type() != types.String {
+			return values.NewBool(false), ErrTypeMismatch
+		}
+		if i > 0 && !operands[i-1].Equal(operand) {
+			return values.NewBool(false), nil
+		}
+	}
+	return values.NewBool(true), nil
+}
+
+// MakeHashTableImpl implements the make-hash-table procedure
+// (make-hash-table [equivalence arg ...]) returns an empty table comparing keys with
+// equivalence, one of equal?, eqv?, eq? or string=?, defaulting to equal?. The hash function
+// and size hints SRFI-69 and SRFI-125 allow after the equivalence are accepted and ignored,
+// as every equivalence has a matching hash.
+func MakeHashTableImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	cmp, err := comparatorArg(operands, 0)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewHashTableWith(cmp), nil
+}
+
+// AlistToHashTableImpl implements the alist->hash-table procedure
+// (alist->hash-table alist [equivalence arg ...]) returns a table holding the associations
+// of alist. When a key occurs more than once the first association wins.
+func AlistToHashTableImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	cmp, err := comparatorArg(operands, 1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	alist, err := values.ToSlice(operands[0])
+	if err != nil {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	table := values.NewHashTableWith(cmp)
+	for _, entry := range alist {
+		if entry.Type() != types.Pair {
+			return values.NewVoidType(), fmt.Errorf("%w: association %s", ErrTypeMismatch, entry.WriteString())
+		}
+		key := values.Car(entry)
+		if err := keyArg(table, key); err != nil {
+			return values.NewVoidType(), err
+		}
+		if _, ok := table.Ref(key); !ok {
+			table.Set(key, values.Cdr(entry))
+		}
+	}
+	return table, nil
+}
+
+// HashTableRefImpl implements the hash-table-ref procedure
+// (hash-table-ref table key [failure [success]]) returns the value of key, passed to success
+// when given. A missing key calls the thunk failure, or is an error without one.
+func HashTableRefImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, operands, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := keyArg(table, operands[1]); err != nil {
+		return values.NewVoidType(), err
+	}
+	value, ok := table.Ref(operands[1])
+	if !ok {
+		if len(operands) < 3 {
+			return values.NewVoidType(), fmt.Errorf("%w: %s", ErrKeyNotFound, operands[1].WriteString())
+		}
+		return applyArg(rt, operands[2])
+	}
+	if len(operands) == 4 {
+		return applyArg(rt, operands[3], value)
+	}
+	return value, nil
+}
+
+// HashTableRefDefaultImpl implements the hash-table-ref/default procedure
+func HashTableRefDefaultImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, operands, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := keyArg(table, operands[1]); err != nil {
+		return values.NewVoidType(), err
+	}
+	if value, ok := table.Ref(operands[1]); ok {
+		return value, nil
+	}
+	return operands[2], nil
+}
+
+// HashTableSetImpl implements the hash-table-set! procedure
+// (hash-table-set! table key value ...) associates each key with the value following it.
+func HashTableSetImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, operands, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	entries := operands[1:]
+	if len(entries)%2 != 0 {
+		return values.NewVoidType(), fmt.Errorf("%w: key without a value", ErrWrongNumberOfArguments)
+	}
+	for i := 0; i < len(entries); i += 2 {
+		if err := keyArg(table, entries[i]); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	for i := 0; i < len(entries); i += 2 {
+		table.Set(entries[i], entries[i+1])
+	}
+	return values.NewVoidType(), nil
+}
+
+// HashTableDeleteImpl implements the hash-table-delete! procedure
+// (hash-table-delete! table key ...) removes the keys and returns the number that were present.
+func HashTableDeleteImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, operands, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	var deleted int64
+	for _, key := range operands[1:] {
+		if err := keyArg(table, key); err != nil {
+			return values.NewVoidType(), err
+		}
+		if table.Delete(key) {
+			deleted++
+		}
+	}
+	return values.NewInt(deleted), nil
+}
+
+// HashTableContainsImpl implements the hash-table-contains? and hash-table-exists? procedures
+func HashTableContainsImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, operands, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := keyArg(table, operands[1]); err != nil {
+		return values.NewVoidType(), err
+	}
+	_, ok := table.Ref(operands[1])
+	return values.NewBool(ok), nil
+}
+
+// HashTableUpdateImpl implements the hash-table-update! procedure
+// (hash-table-update! table key updater [failure [success]]) sets key to the result of
+// calling updater with the value hash-table-ref would return for the same arguments.
+func HashTableUpdateImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, operands, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	refArgs := append([]values.Interface{table, operands[1]}, operands[3:]...)
+	current, err := HashTableRefImpl(values.List(refArgs...), rt)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	updated, err := applyArg(rt, operands[2], current)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	table.Set(operands[1], updated)
+	return values.NewVoidType(), nil
+}
+
+// HashTableUpdateDefaultImpl implements the hash-table-update!/default procedure
+// (hash-table-update!/default table key updater default) sets key to the result of calling
+// updater with its value, or with default when key is missing.
+func HashTableUpdateDefaultImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, operands, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := keyArg(table, operands[1]); err != nil {
+		return values.NewVoidType(), err
+	}
+	current, ok := table.Ref(operands[1])
+	if !ok {
+		current = operands[3]
+	}
+	updated, err := applyArg(rt, operands[2], current)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	table.Set(operands[1], updated)
+	return values.NewVoidType(), nil
+}
+
+// HashTableSizeImpl implements the hash-table-size procedure
+func HashTableSizeImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, _, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewInt(int64(table.Len())), nil
+}
+
+// HashTableKeysImpl implements the hash-table-keys procedure
+// The keys are listed in the order they were first added.
+func HashTableKeysImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, _, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(table.Len()); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.List(table.Keys()...), nil
+}
+
+// HashTableValuesImpl implements the hash-table-values procedure
+// The values are listed in the order of hash-table-keys.
+func HashTableValuesImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, _, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(table.Len()); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.List(table.Values()...), nil
+}
+
+// HashTableWalkImpl implements the hash-table-walk procedure
+// (hash-table-walk table proc) calls proc with each key and value. Entries added or
+// removed by proc do not affect the walk.
+func HashTableWalkImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, operands, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	keys, vals := table.Keys(), table.Values()
+	for i, key := range keys {
+		if _, err := applyArg(rt, operands[1], key, vals[i]); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	return values.NewVoidType(), nil
+}
+
+// HashTableToAlistImpl implements the hash-table->alist procedure
+func HashTableToAlistImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, _, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(2 * table.Len()); err != nil {
+		return values.NewVoidType(), err
+	}
+	keys, vals := table.Keys(), table.Values()
+	alist := make([]values.Interface, len(keys))
+	for i, key := range keys {
+		alist[i] = values.Cons(key, vals[i])
+	}
+	return values.List(alist...), nil
+}
+
+// HashTableClearImpl implements the hash-table-clear! procedure
+func HashTableClearImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, _, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	table.Clear()
+	return values.NewVoidType(), nil
+}
+
+// HashTableCopyImpl implements the hash-table-copy procedure
+// The optional mutability flag is accepted; every table is mutable.
+func HashTableCopyImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	table, _, err := hashTableArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(table.Len()); err != nil {
+		return values.NewVoidType(), err
+	}
+	return table.Copy(), nil
+}
+
+// hashProcedure returns the hash and string-hash procedures
+// (hash obj [bound]) returns a non-negative integer below bound, if given, such that
+// objects accepted by cmp and equivalent under it have the same hash.
+func hashProcedure(cmp *values.Comparator) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		operands, err := procedureArgs(args)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		if !cmp.Accepts(operands[0].Type()) {
+			return values.NewVoidType(), ErrTypeMismatch
+		}
+		sum := int64(cmp.Hash(operands[0]) >> 1)
+		if len(operands) == 2 {
+			bound, err := indexArg(operands[1], -1)
+			if err != nil {
+				return values.NewVoidType(), err
+			}
+			if bound == 0 {
+				return values.NewVoidType(), fmt.Errorf("%w: bound must be positive", ErrBadArgument)
+			}
+			sum %= int64(bound)
+		}
+		return values.NewInt(sum), nil
+	}
+}
+
+func hashTableArgs(args values.Interface) (values.HashTable, []values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return nil, operands, err
+	}
+	table, ok := operands[0].(values.HashTable)
+	if !ok {
+		return nil, operands, ErrTypeMismatch
+	}
+	return table, operands, nil
+}
+
+// comparatorArg returns the comparator selected by the equivalence operands[i], or the
+// equal? comparator when it is omitted.
+func comparatorArg(operands []values.Interface, i int) (*values.Comparator, error) {
+	if i >= len(operands) {
+		return values.EqualComparator, nil
+	}
+	e, ok := operands[i].(equivalence)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported equivalence %s", ErrBadArgument, operands[i].WriteString())
+	}
+	return e.cmp, nil
+}
+
+// keyArg checks that key may be stored in table.
+func keyArg(table values.HashTable, key values.Interface) error {
+	if !table.Comparator().Accepts(key.Type()) {
+		return fmt.Errorf("%w: %s key %s", ErrTypeMismatch, table.Comparator().Name, key.WriteString())
+	}
+	return nil
+}
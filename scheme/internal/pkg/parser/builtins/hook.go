@@ -0,0 +1,58 @@
+package builtins
+
+import "github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+
+// Hook follows an evaluation for a debugger. The evaluator reports every compound
+// expression before evaluating it, and every procedure call once its arguments are
+// evaluated and its frame is on the call stack. A procedure defined in Scheme also
+// reports when it has bound its arguments, in the runtime that evaluates its body.
+// Any method may block to pause the evaluation, and an error it returns aborts the
+// evaluation with that error.
+type Hook interface {
+	Evaluating(expr values.Interface, rt *Runtime) error
+	Calling(proc values.Interface, args values.Interface, rt *Runtime) error
+	Entered(proc values.Interface, args values.Interface, rt *Runtime) error
+}
+
+// WithHook installs hook in the runtime and in every evaluation made with it.
+func WithHook(hook Hook) OptionRuntime {
+	return func(c *configRuntime) {
+		c.hook = hook
+	}
+}
+
+// Evaluating reports to the hook of the runtime, if any, that expr is about to be evaluated in rt.
+func (rt *Runtime) Evaluating(expr values.Interface) error {
+	if rt.hook == nil {
+		return nil
+	}
+	return rt.hook.Evaluating(expr, rt)
+}
+
+// Calling reports to the hook of the runtime, if any, that proc is about to be called with args.
+func (rt *Runtime) Calling(proc values.Interface, args values.Interface) error {
+	if rt.hook == nil {
+		return nil
+	}
+	return rt.hook.Calling(proc, args, rt)
+}
+
+// Entered reports to the hook of the runtime, if any, that the procedure whose body rt
+// runs has bound args to its formals in the environment of rt.
+func (rt *Runtime) Entered(args values.Interface) error {
+	if rt.hook == nil || rt.callee == nil {
+		return nil
+	}
+	return rt.hook.Entered(rt.callee, args, rt)
+}
+
+// calling returns the runtime to run the body of proc in. While a hook follows the
+// evaluation it is a copy of rt knowing proc, so that the body can report it entered.
+func (rt *Runtime) calling(proc values.Interface) *Runtime {
+	if rt == nil || rt.hook == nil {
+		return rt
+	}
+	scope := *rt
+	scope.callee = proc
+	return &scope
+}
@@ -1,6 +1,12 @@
 package builtins
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/scanner"
+
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/lexer"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
@@ -13,24 +19,137 @@ type Lambda interface {
 
 type Expression func(args values.Interface, rt *Runtime) (values.Interface, error)
 
+// Procedure is a Lambda that can be called in the runtime of its call site, so that it
+// observes the dynamic state of the caller, such as the context and parameterizations.
+// The evaluator returns the result of Call without completing tail calls.
+type Procedure interface {
+	Lambda
+	Call(args values.Interface, rt *Runtime) (values.Interface, error)
+}
+
+// LambdaExpr is a procedure. Name is the name it was defined with and Source the position
+// of its definition, when it has one; both show in its printed form and in its errors.
 type LambdaExpr struct {
 	Name     string
+	Source   scanner.Position
 	Runtime  *Runtime
 	Body     Expression
+	arity    Arity
+	compound bool
+	builtin  bool
 	srcToken lexer.Token
 }
 
+// Arity is the number of arguments a procedure accepts: from Min to Max, or at least
+// Min when Max is negative.
+type Arity struct {
+	Min int
+	Max int
+}
+
+// Exactly returns the arity of a procedure taking n arguments.
+func Exactly(n int) Arity {
+	return Arity{Min: n, Max: n}
+}
+
+// AtLeast returns the arity of a procedure taking n or more arguments.
+func AtLeast(n int) Arity {
+	return Arity{Min: n, Max: -1}
+}
+
+// Between returns the arity of a procedure taking from minimum to maximum arguments.
+func Between(minimum, maximum int) Arity {
+	return Arity{Min: minimum, Max: maximum}
+}
+
+// Accepts reports whether a procedure of arity a may be called with n arguments.
+func (a Arity) Accepts(n int) bool {
+	return n >= a.Min && (a.Max < 0 || n <= a.Max)
+}
+
+func (a Arity) String() string {
+	return arityString(a.Min, a.Max)
+}
+
+// union returns the smallest arity accepting every argument count a or b accepts. The
+// union is lossy: it also accepts the counts between a and b that neither accepts, so the
+// union of the arities 1 and 3 is from 1 to 3.
+func (a Arity) union(b Arity) Arity {
+	u := Arity{Min: min(a.Min, b.Min), Max: max(a.Max, b.Max)}
+	if a.Max < 0 || b.Max < 0 {
+		u.Max = -1
+	}
+	return u
+}
+
+// check returns ErrWrongNumberOfArguments unless a accepts the arguments in args.
+func (a Arity) check(args values.Interface) error {
+	n, err := argCount(args)
+	if err != nil {
+		return err
+	}
+	if !a.Accepts(n) {
+		return fmt.Errorf("%w: expected %s, got %d", ErrWrongNumberOfArguments, a, n)
+	}
+	return nil
+}
+
+// argCount returns the number of arguments in the argument list args.
+func argCount(args values.Interface) (int, error) {
+	n := 0
+	for ; args.Type() == types.Pair; args = values.Cdr(args) {
+		n++
+	}
+	if args.Type() != types.Nil {
+		return n, ErrInvalidFormat
+	}
+	return n, nil
+}
+
+// formalsArity returns the arity of a procedure with the formal parameters formals.
+func formalsArity(formals values.Interface) Arity {
+	n := 0
+	for ; formals.Type() == types.Pair; formals = values.Cdr(formals) {
+		n++
+	}
+	if formals.Type() == types.Nil {
+		return Exactly(n)
+	}
+	return AtLeast(n)
+}
+
+// WriteString returns the printed form of the procedure, such as #<procedure car> for a
+// builtin or #<procedure fact at lib.scm:12> for a procedure defined in source code.
 func (l LambdaExpr) WriteString() string {
-	return "#<procedure>"
+	var sb strings.Builder
+	sb.WriteString("#<procedure")
+	if l.Name != "" {
+		sb.WriteString(" " + l.Name)
+	}
+	if l.Source.IsValid() {
+		sb.WriteString(" at " + values.Location(l.Source))
+	}
+	sb.WriteString(">")
+	return sb.String()
 }
 
 func (l LambdaExpr) DisplayString() string {
-	return "#<procedure>"
+	return l.WriteString()
 }
 
 func (l LambdaExpr) String() string {
-	return "#<procedure>"
+	return l.WriteString()
+}
 
+// ProcedureName describes the procedure in stack traces.
+func (l LambdaExpr) ProcedureName() string {
+	switch {
+	case l.Name != "":
+		return l.Name
+	case l.Source.IsValid():
+		return "anonymous procedure at " + values.Location(l.Source)
+	}
+	return "anonymous procedure"
 }
 
 func (l LambdaExpr) Equal(p values.Interface) bool {
@@ -46,24 +165,233 @@ func (l LambdaExpr) SetToken(token lexer.Token) {
 	l.srcToken = token
 }
 
-func NewExpression(env Environment, body []values.Interface) Expression {
+// NewExpression creates the body of a user defined procedure.
+// Calling it binds the arguments to formals in a new frame extending env, evaluates
+// every body expression but the last and returns the last one as a TailCall so the
+// evaluator can continue with it without growing the Go stack.
+func NewExpression(env Environment, formals values.Interface, body []values.Interface, cb Expression) Expression {
 	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
-		//TODO evaluate body in environment
-		return values.NewVoidType(), nil
+		frame := ExtendEnvironment(env)
+		if err := bindFormals(&frame, formals, args); err != nil {
+			return values.NewVoidType(), err
+		}
+		scope := rt.WithEnvironment(frame)
+		if err := scope.Entered(args); err != nil {
+			return values.NewVoidType(), err
+		}
+		return evalBody(body, scope, cb)
 	}
 }
 
-func NewLambda(rt *Runtime, expression Expression) values.Interface {
+// newClosure returns the procedure with formals and body closing over env, named name
+// and located at the source position of form, the definition it was made by.
+func newClosure(rt *Runtime, env Environment, name string, form, formals values.Interface, body []values.Interface, cb Expression) values.Interface {
 	return LambdaExpr{
+		Name:     name,
+		Source:   rt.definedAt(form),
+		Runtime:  rt,
+		Body:     NewExpression(env, formals, body, cb),
+		arity:    formalsArity(formals),
+		compound: true,
+	}
+}
+
+// definedAt returns the source position of form, the definition of a procedure, naming
+// its file as it was given to load, include or import rather than by its path.
+func (rt *Runtime) definedAt(form values.Interface) scanner.Position {
+	pos, _ := values.SourcePosition(form)
+	if pos.Filename != "" {
+		pos.Filename = rt.libraries.sourceName(pos.Filename)
+	}
+	return pos
+}
+
+// NewLambda returns a procedure accepting arity arguments and running expression.
+// Calls with a number of arguments arity does not accept fail before expression runs.
+func NewLambda(rt *Runtime, arity Arity, expression Expression) values.Interface {
+	return LambdaExpr{
+		Runtime: rt,
+		Body:    expression,
+		arity:   arity,
+	}
+}
+
+// NewNamedLambda returns a procedure like NewLambda that is called name.
+func NewNamedLambda(rt *Runtime, name string, arity Arity, expression Expression) values.Interface {
+	return LambdaExpr{
+		Name:    name,
 		Runtime: rt,
 		Body:    expression,
+		arity:   arity,
 	}
 }
 
+// Arity returns the number of arguments the procedure accepts.
+func (l LambdaExpr) Arity() Arity {
+	return l.arity
+}
+
+// Compound reports whether the procedure was defined in Scheme, by lambda, define or
+// case-lambda, rather than implemented in Go.
+func (l LambdaExpr) Compound() bool {
+	return l.compound
+}
+
 func (l LambdaExpr) Apply(args values.Interface) (values.Interface, error) {
-	return l.Body(args, l.Runtime)
+	return l.Runtime.Trampoline(l.Call(args, l.Runtime))
+}
+
+// Call runs the procedure body in rt, the runtime of the call site.
+func (l LambdaExpr) Call(args values.Interface, rt *Runtime) (values.Interface, error) {
+	if err := l.arity.check(args); err != nil {
+		return values.NewVoidType(), l.attribute(err)
+	}
+	if l.compound {
+		rt = rt.calling(l)
+	}
+	v, err := l.Body(args, rt)
+	if err != nil {
+		return v, l.attribute(err)
+	}
+	return v, nil
+}
+
+// ApplyContext calls the procedure from Go as a new evaluation bounded by ctx
+// instead of the context of the runtime the procedure was created in.
+func (l LambdaExpr) ApplyContext(ctx context.Context, args values.Interface) (values.Interface, error) {
+	if l.Runtime == nil {
+		return l.Call(args, nil)
+	}
+	return l.Runtime.NewEvaluation(ctx).Apply(l, args)
+}
+
+// attribute names the procedure in errors about the arguments it was called with.
+// Other errors, such as conditions raised by the procedure, are returned unchanged.
+func (l LambdaExpr) attribute(err error) error {
+	if l.Name == "" {
+		return err
+	}
+	if errors.Is(err, ErrWrongNumberOfArguments) || errors.Is(err, ErrTypeMismatch) || errors.Is(err, ErrBadArgument) {
+		return fmt.Errorf("%s: %w", l.Name, err)
+	}
+	return err
 }
 
 func (l LambdaExpr) IsTruthy() bool {
 	return true
 }
+
+// bindFormals binds args to the formal parameters of a procedure.
+// formals is either a proper list of identifiers, an improper list whose final cdr
+// collects the remaining arguments, or a single identifier bound to all arguments.
+func bindFormals(frame *Environment, formals values.Interface, args values.Interface) error {
+	for formals.Type() == types.Pair {
+		name, ok := symbolName(values.Car(formals))
+		if !ok {
+			return ErrBadArgument
+		}
+		if args.Type() != types.Pair {
+			return ErrWrongNumberOfArguments
+		}
+		frame.Define(name, values.Car(args))
+		formals = values.Cdr(formals)
+		args = values.Cdr(args)
+	}
+	if formals.Type() == types.Nil {
+		if args.Type() != types.Nil {
+			return ErrWrongNumberOfArguments
+		}
+		return nil
+	}
+	rest, ok := symbolName(formals)
+	if !ok {
+		return ErrBadArgument
+	}
+	frame.Define(rest, args)
+	return nil
+}
+
+// evalBody evaluates a sequence of expressions, returning the final one as a TailCall.
+func evalBody(body []values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	if len(body) == 0 {
+		return values.NewVoidType(), nil
+	}
+	for _, expr := range body[:len(body)-1] {
+		if _, err := cb(expr, rt); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	return TailCall{Expr: body[len(body)-1], Runtime: rt}, nil
+}
+
+// applyArg calls the procedure proc with args.
+func applyArg(rt *Runtime, proc values.Interface, args ...values.Interface) (values.Interface, error) {
+	lambda, ok := proc.(Lambda)
+	if !ok {
+		return values.NewVoidType(), fmt.Errorf("%w: %s is not a procedure", ErrTypeMismatch, proc.WriteString())
+	}
+	return rt.Apply(lambda, values.List(args...))
+}
+
+// TailCall is returned by an Expression to ask the evaluator to continue with Expr in
+// the environment of Runtime rather than evaluating it on a new Go stack frame.
+// It never escapes the evaluator; Go callers complete it with Runtime.Trampoline.
+type TailCall struct {
+	Expr    values.Interface
+	Runtime *Runtime
+}
+
+func (t TailCall) Equal(values.Interface) bool {
+	return false
+}
+
+func (t TailCall) Type() types.Type {
+	return types.Void
+}
+
+func (t TailCall) IsTruthy() bool {
+	return true
+}
+
+func (t TailCall) DisplayString() string {
+	return t.Expr.DisplayString()
+}
+
+func (t TailCall) WriteString() string {
+	return t.Expr.WriteString()
+}
+
+// Syntax is a special form. Unlike a procedure its Body receives the unevaluated
+// operands together with the runtime of the call site, and evaluates them as needed.
+type Syntax struct {
+	Name string
+	Body Expression
+}
+
+func NewSyntax(name string, expression Expression) values.Interface {
+	return Syntax{
+		Name: name,
+		Body: expression,
+	}
+}
+
+func (s Syntax) Equal(p values.Interface) bool {
+	other, ok := p.(Syntax)
+	return ok && other.Name == s.Name
+}
+
+func (s Syntax) Type() types.Type {
+	return types.Syntax
+}
+
+func (s Syntax) IsTruthy() bool {
+	return true
+}
+
+func (s Syntax) DisplayString() string {
+	return "#<syntax " + s.Name + ">"
+}
+
+func (s Syntax) WriteString() string {
+	return s.DisplayString()
+}
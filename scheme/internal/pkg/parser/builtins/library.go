@@ -0,0 +1,623 @@
+package builtins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// LibraryName identifies a library, e.g. (scheme base) or (srfi 1).
+type LibraryName []string
+
+func (n LibraryName) String() string {
+	return "(" + strings.Join(n, " ") + ")"
+}
+
+// libraryNameFrom converts a datum such as (mylib util) to a LibraryName.
+// Each part must be an identifier or an exact non-negative integer.
+func libraryNameFrom(datum values.Interface) (LibraryName, error) {
+	parts, err := values.ToSlice(datum)
+	if err != nil || len(parts) == 0 {
+		return nil, fmt.Errorf("%w: library name %s", ErrInvalidFormat, datum.WriteString())
+	}
+	name := make(LibraryName, 0, len(parts))
+	for _, part := range parts {
+		if id, ok := symbolName(part); ok {
+			name = append(name, id)
+			continue
+		}
+		if n, ok := part.(values.Numeric); ok && n.IsInteger() {
+			i, _ := n.AsInt()
+			if i >= 0 {
+				name = append(name, strconv.FormatInt(i, 10))
+				continue
+			}
+		}
+		return nil, fmt.Errorf("%w: library name %s", ErrInvalidFormat, datum.WriteString())
+	}
+	return name, nil
+}
+
+// Library is a named set of exported bindings together with the environment
+// in which they are defined.
+type Library struct {
+	Name    LibraryName
+	Env     Environment
+	exports map[string]string
+}
+
+// NewLibrary creates a library exporting the given names of env unchanged.
+func NewLibrary(name LibraryName, env Environment, exports ...string) *Library {
+	lib := &Library{
+		Name:    name,
+		Env:     env,
+		exports: make(map[string]string, len(exports)),
+	}
+	for _, export := range exports {
+		lib.Export(export, export)
+	}
+	return lib
+}
+
+// Export makes the binding internal of the library's environment visible to importers as external.
+func (lib *Library) Export(internal, external string) {
+	lib.exports[external] = internal
+}
+
+// Exports returns the sorted external names of the library.
+func (lib *Library) Exports() []string {
+	names := make([]string, 0, len(lib.exports))
+	for external := range lib.exports {
+		names = append(names, external)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Bindings returns the current values of the exported bindings keyed by their external names.
+func (lib *Library) Bindings() (map[string]values.Interface, error) {
+	bindings := make(map[string]values.Interface, len(lib.exports))
+	for external, internal := range lib.exports {
+		value, ok := lib.Env.Lookup(internal)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s exports undefined %s", ErrUndefinedIdent, lib.Name, internal)
+		}
+		bindings[external] = value
+	}
+	return bindings, nil
+}
+
+// Libraries is the registry of the libraries known to a runtime.
+// Libraries that are not yet registered are located with its resolver and loaded on first import.
+// It is safe for concurrent use; a library imported by several evaluations at once may be loaded more than once.
+type Libraries struct {
+	mu       sync.RWMutex
+	loaded   map[string]*Library
+	resolver LibraryResolver
+	// sources maps the paths of the files read by load, include and import to the
+	// names they were given by, which the procedures they define print.
+	sources map[string]string
+}
+
+func newLibraries(resolver LibraryResolver) *Libraries {
+	return &Libraries{
+		loaded:   make(map[string]*Library),
+		resolver: resolver,
+		sources:  make(map[string]string),
+	}
+}
+
+// Register adds lib to the registry, replacing any library with the same name.
+func (reg *Libraries) Register(lib *Library) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.loaded[lib.Name.String()] = lib
+}
+
+// Lookup returns the registered library with the given name.
+func (reg *Libraries) Lookup(name LibraryName) (*Library, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	lib, ok := reg.loaded[name.String()]
+	return lib, ok
+}
+
+// sourceName returns the name the file at path was given by when it was read, or path
+// for a file the runtime did not read.
+func (reg *Libraries) sourceName(path string) string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	if name, ok := reg.sources[path]; ok {
+		return name
+	}
+	return path
+}
+
+func (reg *Libraries) addSource(path, name string) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.sources[path] = name
+}
+
+// Names returns the sorted names of the registered libraries.
+func (reg *Libraries) Names() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	names := make([]string, 0, len(reg.loaded))
+	for name := range reg.loaded {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// standardLibraries lists the bindings of the default environment exported by each standard library.
+var standardLibraries = []struct {
+	name    LibraryName
+	exports []string
+}{
+	{
+		name: LibraryName{"scheme", "base"},
+		exports: []string{
+			"define", "lambda", "case-lambda", "if", "begin", "let", "quote", "cond", "guard", "define-record-type", "set!",
+			"+", "-", "*", "/", "modulo", "<", "<=", ">", ">=", "=", "not",
+			"boolean?", "number?", "integer?", "string?", "char?", "symbol?", "procedure?",
+			"cons", "car", "cdr", "list", "null?", "pair?", "list?", "length", "append", "reverse",
+			"set-car!", "set-cdr!",
+			"eq?", "eqv?", "equal?", "string=?", "char->integer", "integer->char", "newline",
+			"vector", "make-vector", "vector?", "vector-length", "vector-ref", "vector-set!",
+			"vector->list", "list->vector",
+			"error", "raise", "error-object?", "error-object-message", "error-object-irritants",
+			"eof-object", "eof-object?", "values", "call-with-values",
+			"apply", "map", "for-each", "vector-map", "vector-for-each", "string-map", "string-for-each",
+			"make-parameter", "parameterize", "current-output-port", "current-error-port", "current-input-port",
+			"port?", "input-port?", "output-port?", "open-output-string", "open-input-string", "get-output-string",
+			"close-port", "write-string", "write-char", "read-char", "read-line",
+		},
+	},
+	{
+		name: LibraryName{"srfi", "1"},
+		exports: []string{
+			"cons", "car", "cdr", "list", "null?", "pair?", "list?", "length", "append", "reverse",
+			"map", "for-each", "filter", "remove", "partition", "fold", "fold-right", "reduce", "append-map", "filter-map",
+			"find", "find-tail", "any", "every", "list-index", "delete", "delete-duplicates", "iota",
+			"take", "drop", "last", "alist-copy", "alist-delete",
+		},
+	},
+	{
+		name: LibraryName{"srfi", "18"},
+		exports: []string{
+			"make-thread", "thread?", "thread-start!", "thread-join!", "thread-terminate!", "thread-yield!",
+			"thread-sleep!", "current-thread", "thread-name", "thread-specific", "thread-specific-set!",
+			"make-mutex", "mutex?", "mutex-name", "mutex-state", "mutex-lock!", "mutex-unlock!",
+			"make-condition-variable", "condition-variable?", "condition-variable-signal!",
+			"condition-variable-broadcast!",
+		},
+	},
+	{
+		name: LibraryName{"srfi", "41"},
+		exports: []string{
+			"stream-null", "stream-cons", "stream?", "stream-null?", "stream-pair?", "stream-car", "stream-cdr",
+			"stream-take", "stream-map", "stream-filter", "stream->list", "list->stream",
+		},
+	},
+	{
+		name: LibraryName{"srfi", "69"},
+		exports: []string{
+			"make-hash-table", "alist->hash-table", "hash-table?", "hash-table-ref", "hash-table-ref/default",
+			"hash-table-set!", "hash-table-delete!", "hash-table-exists?", "hash-table-update!",
+			"hash-table-update!/default", "hash-table-size", "hash-table-keys", "hash-table-values",
+			"hash-table-walk", "hash-table->alist", "hash-table-copy", "hash", "string-hash",
+		},
+	},
+	{
+		name: LibraryName{"srfi", "125"},
+		exports: []string{
+			"make-hash-table", "alist->hash-table", "hash-table?", "hash-table-ref", "hash-table-ref/default",
+			"hash-table-set!", "hash-table-delete!", "hash-table-contains?", "hash-table-exists?",
+			"hash-table-update!", "hash-table-update!/default", "hash-table-size", "hash-table-keys",
+			"hash-table-values", "hash-table-walk", "hash-table->alist", "hash-table-clear!", "hash-table-copy",
+		},
+	},
+	{
+		name: LibraryName{"go", "channels"},
+		exports: []string{
+			"make-channel", "channel?", "channel-send", "channel-receive", "channel-close!", "select",
+		},
+	},
+	{
+		name:    LibraryName{"scheme", "lazy"},
+		exports: []string{"delay", "delay-force", "force", "make-promise", "promise?"},
+	},
+	{
+		name:    LibraryName{"scheme", "write"},
+		exports: []string{"display", "write"},
+	},
+	{
+		name: LibraryName{"scheme", "char"},
+		exports: []string{
+			"char-alphabetic?", "char-numeric?", "char-whitespace?", "char-upper-case?", "char-lower-case?",
+			"char-upcase", "char-downcase", "char-foldcase", "digit-value",
+			"string-upcase", "string-downcase", "string-foldcase",
+		},
+	},
+}
+
+// registerStandardLibraries registers the standard libraries with bindings copied from env,
+// so that later redefinitions in env do not leak into programs importing them.
+func (reg *Libraries) registerStandardLibraries(env Environment) {
+	for _, std := range standardLibraries {
+		libEnv := NewEnvironment()
+		exports := make([]string, 0, len(std.exports))
+		for _, name := range std.exports {
+			// bindings removed from a restricted environment are not exported either
+			if value, ok := env.Lookup(name); ok {
+				libEnv.Define(name, value)
+				exports = append(exports, name)
+			}
+		}
+		reg.Register(NewLibrary(std.name, libEnv, exports...))
+	}
+}
+
+// LibraryResolver maps a library name to the file that defines it.
+type LibraryResolver interface {
+	Resolve(name LibraryName) (string, error)
+}
+
+// FileResolver resolves a library name such as (mylib util) to the file mylib/util
+// with one of its extensions below the first of its paths that contains it.
+type FileResolver struct {
+	Paths      []string
+	Extensions []string
+}
+
+func NewFileResolver(paths ...string) FileResolver {
+	return FileResolver{
+		Paths:      paths,
+		Extensions: []string{".sld", ".scm"},
+	}
+}
+
+func (r FileResolver) Resolve(name LibraryName) (string, error) {
+	for _, dir := range r.Paths {
+		for _, ext := range r.Extensions {
+			candidate := filepath.Join(append([]string{dir}, name...)...) + ext
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrLibraryNotFound, name)
+}
+
+// DefineLibraryImpl implements the define-library special form
+// (define-library name declaration ...) evaluates the declarations in a new, empty environment
+// and registers the library. Supported declarations are export, import, begin, include,
+// include-ci and include-library-declarations.
+func DefineLibraryImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	if args.Type() != types.Pair {
+		return values.NewVoidType(), ErrWrongNumberOfArguments
+	}
+	name, err := libraryNameFrom(values.Car(args))
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	lib := NewLibrary(name, NewEnvironment())
+	scope := rt.WithEnvironment(lib.Env)
+	declarations, err := values.ToSlice(values.Cdr(args))
+	if err != nil {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	if err := scope.libraryDeclarations(lib, declarations, cb); err != nil {
+		return values.NewVoidType(), fmt.Errorf("%s: %w", name, err)
+	}
+	rt.libraries.Register(lib)
+	return values.NewVoidType(), nil
+}
+
+func (rt *Runtime) libraryDeclarations(lib *Library, declarations []values.Interface, cb Expression) error {
+	for _, declaration := range declarations {
+		if declaration.Type() != types.Pair {
+			return fmt.Errorf("%w: library declaration %s", ErrInvalidFormat, declaration.WriteString())
+		}
+		keyword, _ := symbolName(values.Car(declaration))
+		operands, err := values.ToSlice(values.Cdr(declaration))
+		if err != nil {
+			return ErrInvalidFormat
+		}
+		switch keyword {
+		case "export":
+			if err := exportSpecs(lib, operands); err != nil {
+				return err
+			}
+		case "import":
+			if _, err := ImportImpl(values.Cdr(declaration), rt); err != nil {
+				return err
+			}
+		case "begin":
+			for _, expr := range operands {
+				if _, err := cb(expr, rt); err != nil {
+					return err
+				}
+			}
+		case "include", "include-ci":
+			for _, operand := range operands {
+				body, err := rt.includeFile(operand)
+				if err != nil {
+					return err
+				}
+				for _, expr := range body {
+					if _, err := cb(expr, rt); err != nil {
+						return err
+					}
+				}
+			}
+		case "include-library-declarations":
+			for _, operand := range operands {
+				included, err := rt.includeFile(operand)
+				if err != nil {
+					return err
+				}
+				if err := rt.libraryDeclarations(lib, included, cb); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%w: library declaration %s", ErrInvalidFormat, declaration.WriteString())
+		}
+	}
+	return nil
+}
+
+// exportSpecs adds the export specifications id and (rename internal external) to lib.
+func exportSpecs(lib *Library, specs []values.Interface) error {
+	for _, spec := range specs {
+		if name, ok := symbolName(spec); ok {
+			lib.Export(name, name)
+			continue
+		}
+		parts, err := values.ToSlice(spec)
+		if err != nil || len(parts) != 3 {
+			return fmt.Errorf("%w: export %s", ErrInvalidFormat, spec.WriteString())
+		}
+		keyword, _ := symbolName(parts[0])
+		internal, okInternal := symbolName(parts[1])
+		external, okExternal := symbolName(parts[2])
+		if keyword != "rename" || !okInternal || !okExternal {
+			return fmt.Errorf("%w: export %s", ErrInvalidFormat, spec.WriteString())
+		}
+		lib.Export(internal, external)
+	}
+	return nil
+}
+
+// includeFile reads the datums of the file named by operand, resolved relative to
+// the directory of the file currently being loaded.
+func (rt *Runtime) includeFile(operand values.Interface) ([]values.Interface, error) {
+	path, name, err := rt.sourcePath(operand)
+	if err != nil {
+		return nil, err
+	}
+	return rt.readFile(path, name)
+}
+
+// sourcePath resolves the file named by operand relative to the directory of the file
+// currently being loaded. It returns the path of the file and its name as given.
+func (rt *Runtime) sourcePath(operand values.Interface) (string, string, error) {
+	filename, ok := operand.(values.String)
+	if !ok {
+		return "", "", ErrTypeMismatch
+	}
+	path := filename.String()
+	if !filepath.IsAbs(path) && rt.source != "" {
+		path = filepath.Join(filepath.Dir(rt.source), path)
+	}
+	return path, filename.String(), nil
+}
+
+// LoadImpl implements the load procedure
+// (load filename) reads and evaluates every expression of the file in the current environment.
+// Relative names are resolved against the directory of the file currently being loaded.
+func LoadImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	path, name, err := rt.sourcePath(operands[0])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	datums, err := rt.readFile(path, name)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	source := *rt
+	source.source = path
+	for _, datum := range datums {
+		if _, err := cb(datum, &source); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	return values.NewVoidType(), nil
+}
+
+// readFile reads the datums of the file at path, given by name to load, include or import.
+func (rt *Runtime) readFile(path, name string) ([]values.Interface, error) {
+	if err := rt.Interrupted(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, ErrIo(err)
+	}
+	defer f.Close()
+	rt.libraries.addSource(path, name)
+	source := *rt
+	source.source = path
+	return rt.read(f, path, &source)
+}
+
+// ImportImpl implements the import special form
+// (import import-set ...) defines the bindings of each import set in the current environment.
+// An import set is a library name or one of (only set id ...), (except set id ...),
+// (prefix set prefix-id) and (rename set (from to) ...).
+func ImportImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	sets, err := values.ToSlice(args)
+	if err != nil {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	for _, set := range sets {
+		bindings, err := rt.importSet(set)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		for name, value := range bindings {
+			rt.Env.Define(name, value)
+		}
+	}
+	return values.NewVoidType(), nil
+}
+
+func (rt *Runtime) importSet(set values.Interface) (map[string]values.Interface, error) {
+	parts, err := values.ToSlice(set)
+	if err != nil || len(parts) == 0 {
+		return nil, fmt.Errorf("%w: import set %s", ErrInvalidFormat, set.WriteString())
+	}
+	keyword, _ := symbolName(parts[0])
+	switch keyword {
+	case "only", "except", "prefix", "rename":
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("%w: import set %s", ErrInvalidFormat, set.WriteString())
+		}
+	default:
+		name, err := libraryNameFrom(set)
+		if err != nil {
+			return nil, err
+		}
+		lib, err := rt.loadLibrary(name)
+		if err != nil {
+			return nil, err
+		}
+		return lib.Bindings()
+	}
+
+	bindings, err := rt.importSet(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	switch keyword {
+	case "only":
+		selected := make(map[string]values.Interface, len(parts)-2)
+		for _, part := range parts[2:] {
+			name, ok := symbolName(part)
+			if !ok {
+				return nil, ErrBadArgument
+			}
+			value, ok := bindings[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s is not exported by %s", ErrUndefinedIdent, name, parts[1].WriteString())
+			}
+			selected[name] = value
+		}
+		return selected, nil
+	case "except":
+		for _, part := range parts[2:] {
+			name, ok := symbolName(part)
+			if !ok {
+				return nil, ErrBadArgument
+			}
+			if _, ok := bindings[name]; !ok {
+				return nil, fmt.Errorf("%w: %s is not exported by %s", ErrUndefinedIdent, name, parts[1].WriteString())
+			}
+			delete(bindings, name)
+		}
+		return bindings, nil
+	case "prefix":
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%w: import set %s", ErrInvalidFormat, set.WriteString())
+		}
+		prefix, ok := symbolName(parts[2])
+		if !ok {
+			return nil, ErrBadArgument
+		}
+		prefixed := make(map[string]values.Interface, len(bindings))
+		for name, value := range bindings {
+			prefixed[prefix+name] = value
+		}
+		return prefixed, nil
+	default:
+		renamed := make(map[string]values.Interface, len(bindings))
+		for name, value := range bindings {
+			renamed[name] = value
+		}
+		for _, part := range parts[2:] {
+			pair, err := values.ToSlice(part)
+			if err != nil || len(pair) != 2 {
+				return nil, fmt.Errorf("%w: rename %s", ErrInvalidFormat, part.WriteString())
+			}
+			from, okFrom := symbolName(pair[0])
+			to, okTo := symbolName(pair[1])
+			if !okFrom || !okTo {
+				return nil, ErrBadArgument
+			}
+			value, ok := bindings[from]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s is not exported by %s", ErrUndefinedIdent, from, parts[1].WriteString())
+			}
+			delete(renamed, from)
+			renamed[to] = value
+		}
+		return renamed, nil
+	}
+}
+
+// loadLibrary returns the library with the given name, reading it from the file
+// chosen by the runtime's resolver when it is not yet registered.
+func (rt *Runtime) loadLibrary(name LibraryName) (*Library, error) {
+	if lib, ok := rt.libraries.Lookup(name); ok {
+		return lib, nil
+	}
+	key := name.String()
+	// loads in progress are tracked per evaluation, so concurrent imports are not mistaken for cycles
+	if rt.evaluation.loading[key] {
+		return nil, fmt.Errorf("%w: %s", ErrCircularImport, name)
+	}
+	path, err := rt.libraries.resolver.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	rt.evaluation.loading[key] = true
+	defer delete(rt.evaluation.loading, key)
+
+	// the file is named relative to the library root, such as mylib/util.sld
+	datums, err := rt.readFile(path, filepath.Join(name...)+filepath.Ext(path))
+	if err != nil {
+		return nil, err
+	}
+	source := *rt
+	source.source = path
+	for _, datum := range datums {
+		if datum.Type() != types.Pair {
+			return nil, fmt.Errorf("%w: %s: expected define-library", ErrInvalidFormat, path)
+		}
+		if keyword, _ := symbolName(values.Car(datum)); keyword != "define-library" {
+			return nil, fmt.Errorf("%w: %s: expected define-library", ErrInvalidFormat, path)
+		}
+		if _, err := DefineLibraryImpl(values.Cdr(datum), &source, source.eval); err != nil {
+			return nil, err
+		}
+	}
+	lib, ok := rt.libraries.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not define %s", ErrLibraryNotFound, path, name)
+	}
+	return lib, nil
+}
@@ -0,0 +1,158 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// limits bounds the resources a single evaluation may use. A zero limit is unlimited.
+type limits struct {
+	maxSteps       int64
+	maxDepth       int64
+	maxConsCells   int64
+	maxStringBytes int64
+	allowed        map[string]bool
+}
+
+// budget counts the resources used by a top level evaluation, including the threads it starts.
+type budget struct {
+	steps       atomic.Int64
+	consCells   atomic.Int64
+	stringBytes atomic.Int64
+}
+
+// evaluation holds the state of a thread of a top level evaluation: the shared budget
+// it draws from, its nesting depth, the libraries it is loading, its thread object and
+// the procedure calls in progress.
+type evaluation struct {
+	*budget
+	depth   atomic.Int64
+	loading map[string]bool
+	thread  *Thread
+	stack   callStack
+}
+
+func newEvaluation() *evaluation {
+	return &evaluation{
+		budget:  &budget{},
+		loading: make(map[string]bool),
+		thread:  newPrimordialThread(),
+	}
+}
+
+// fork returns the state of a new thread drawing from the same budget as e.
+func (e *evaluation) fork(thread *Thread) *evaluation {
+	return &evaluation{
+		budget:  e.budget,
+		loading: make(map[string]bool),
+		thread:  thread,
+	}
+}
+
+// WithMaxSteps limits the number of procedure calls and special forms evaluated by each evaluation.
+func WithMaxSteps(n int64) OptionRuntime {
+	return func(c *configRuntime) {
+		c.limits.maxSteps = n
+	}
+}
+
+// WithMaxDepth limits the nesting of non-tail evaluations, which bounds non-tail recursion.
+func WithMaxDepth(n int64) OptionRuntime {
+	return func(c *configRuntime) {
+		c.limits.maxDepth = n
+	}
+}
+
+// WithMaxConsCells limits the pairs and vector elements allocated by builtins in each evaluation.
+func WithMaxConsCells(n int64) OptionRuntime {
+	return func(c *configRuntime) {
+		c.limits.maxConsCells = n
+	}
+}
+
+// WithMaxStringBytes limits the bytes of the strings created by builtins in each evaluation.
+func WithMaxStringBytes(n int64) OptionRuntime {
+	return func(c *configRuntime) {
+		c.limits.maxStringBytes = n
+	}
+}
+
+// WithAllowedBuiltins restricts the default environment to the named builtins and special forms.
+// Everything else, such as load, is left undefined.
+func WithAllowedBuiltins(names ...string) OptionRuntime {
+	return func(c *configRuntime) {
+		c.limits.allowed = make(map[string]bool, len(names))
+		for _, name := range names {
+			c.limits.allowed[name] = true
+		}
+	}
+}
+
+// restrict removes the bindings of env that are not allowed.
+func (l limits) restrict(env *Environment) {
+	if l.allowed == nil {
+		return
+	}
+	for _, name := range env.Names() {
+		if !l.allowed[name] {
+			env.undefine(name)
+		}
+	}
+}
+
+// NewEvaluation returns a copy of the runtime for a new top level evaluation bounded by ctx.
+// Resource limits apply to each evaluation separately.
+func (rt *Runtime) NewEvaluation(ctx context.Context) *Runtime {
+	scope := rt.WithContext(ctx)
+	scope.evaluation = newEvaluation()
+	return scope
+}
+
+// Step accounts for the evaluation of a procedure call or special form. It returns the
+// context's error once the evaluation is canceled and ErrResourceLimitExceeded once it
+// exceeds its step limit.
+func (rt *Runtime) Step() error {
+	if err := rt.Interrupted(); err != nil {
+		return err
+	}
+	if rt.limits.maxSteps > 0 && rt.evaluation.steps.Add(1) > rt.limits.maxSteps {
+		return fmt.Errorf("%w: more than %d evaluation steps", ErrResourceLimitExceeded, rt.limits.maxSteps)
+	}
+	return nil
+}
+
+// Enter accounts for a nested evaluation, which must be matched by a call to Leave.
+func (rt *Runtime) Enter() error {
+	if depth := rt.evaluation.depth.Add(1); rt.limits.maxDepth > 0 && depth > rt.limits.maxDepth {
+		rt.evaluation.depth.Add(-1)
+		return fmt.Errorf("%w: evaluation nested deeper than %d", ErrResourceLimitExceeded, rt.limits.maxDepth)
+	}
+	return nil
+}
+
+// Leave ends a nested evaluation started by Enter.
+func (rt *Runtime) Leave() {
+	rt.evaluation.depth.Add(-1)
+}
+
+// Depth returns the number of nested evaluations in progress in the thread of rt.
+func (rt *Runtime) Depth() int {
+	return int(rt.evaluation.depth.Load())
+}
+
+// AllocConsCells accounts for n newly allocated pairs or vector elements.
+func (rt *Runtime) AllocConsCells(n int) error {
+	if rt.limits.maxConsCells > 0 && rt.evaluation.consCells.Add(int64(n)) > rt.limits.maxConsCells {
+		return fmt.Errorf("%w: more than %d cons cells", ErrResourceLimitExceeded, rt.limits.maxConsCells)
+	}
+	return nil
+}
+
+// AllocString accounts for a newly created string of n bytes.
+func (rt *Runtime) AllocString(n int) error {
+	if rt.limits.maxStringBytes > 0 && rt.evaluation.stringBytes.Add(int64(n)) > rt.limits.maxStringBytes {
+		return fmt.Errorf("%w: more than %d string bytes", ErrResourceLimitExceeded, rt.limits.maxStringBytes)
+	}
+	return nil
+}
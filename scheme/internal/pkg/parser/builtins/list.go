@@ -0,0 +1,182 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// ConsImpl implements the cons procedure
+// It returns a new pair whose car is the first argument and whose cdr is the second
+func ConsImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(1); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.Cons(operands[0], operands[1]), nil
+}
+
+// CarImpl implements the car procedure
+// It returns the first element of a pair
+func CarImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if operands[0].Type() != types.Pair {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	return values.Car(operands[0]), nil
+}
+
+// CdrImpl implements the cdr procedure
+// It returns the second element of a pair
+func CdrImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if operands[0].Type() != types.Pair {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	return values.Cdr(operands[0]), nil
+}
+
+// SetCarImpl implements the set-car! procedure
+// (set-car! pair obj) stores obj in the car of pair
+func SetCarImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return setPair(args, values.Pair.SetCar)
+}
+
+// SetCdrImpl implements the set-cdr! procedure
+// (set-cdr! pair obj) stores obj in the cdr of pair
+func SetCdrImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return setPair(args, values.Pair.SetCdr)
+}
+
+func setPair(args values.Interface, set func(values.Pair, values.Interface) error) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	pair, ok := operands[0].(values.Pair)
+	if !ok {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	if err := set(pair, operands[1]); err != nil {
+		return values.NewVoidType(), fmt.Errorf("%w: %s", err, pair.WriteString())
+	}
+	return values.NewVoidType(), nil
+}
+
+// ListImpl implements the list procedure
+// It returns a newly allocated list of its arguments
+func ListImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	items, err := values.ToSlice(args)
+	if err != nil {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	if err := rt.AllocConsCells(len(items)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return args, nil
+}
+
+// IsNullImpl implements the null? procedure
+// It returns #t if the argument is the empty list
+func IsNullImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	return values.NewBool(operands[0].Type() == types.Nil), nil
+}
+
+// IsPairImpl implements the pair? procedure
+// It returns #t if the argument is a pair
+func IsPairImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	return values.NewBool(operands[0].Type() == types.Pair), nil
+}
+
+// IsListImpl implements the list? procedure
+// It returns #t if the argument is a proper list
+func IsListImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	return values.NewBool(values.IsList(operands[0])), nil
+}
+
+// LengthImpl implements the length procedure
+// It returns the number of elements in a proper list
+func LengthImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	items, err := values.ToSlice(operands[0])
+	if err != nil {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	return values.NewInt(int64(len(items))), nil
+}
+
+// AppendImpl implements the append procedure
+// It returns a list of the elements of each list argument followed by the final argument
+func AppendImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if len(operands) == 0 {
+		return values.NewNil(), nil
+	}
+	var items []values.Interface
+	for _, operand := range operands[:len(operands)-1] {
+		elements, err := values.ToSlice(operand)
+		if err != nil {
+			return values.NewVoidType(), ErrTypeMismatch
+		}
+		items = append(items, elements...)
+	}
+	if err := rt.AllocConsCells(len(items)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.ListWithTail(operands[len(operands)-1], items...), nil
+}
+
+// ReverseImpl implements the reverse procedure
+// It returns a list of the elements of its argument in reverse order
+func ReverseImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	items, err := values.ToSlice(operands[0])
+	if err != nil {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	if err := rt.AllocConsCells(len(items)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.Reverse(operands[0]), nil
+}
+
+// IsEqualImpl implements the equal? procedure
+// It returns #t if both arguments are structurally equal
+func IsEqualImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	return values.NewBool(operands[0].Equal(operands[1])), nil
+}
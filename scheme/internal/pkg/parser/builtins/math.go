@@ -58,7 +58,7 @@ func SumImpl(args values.Interface, rt *Runtime, evaluationCallback Expression)
 }
 
 // DifferenceImpl computes the difference of all numeric arguments in args.
-// It subtracts each subsequent number from the first.
+// It subtracts each subsequent number from the first, or negates a single argument.
 func DifferenceImpl(args values.Interface, rt *Runtime, evaluationCallback Expression) (_ values.Interface, err error) {
 	if args.Type() == types.Nil {
 		return values.NewInt(0), nil
@@ -66,15 +66,22 @@ func DifferenceImpl(args values.Interface, rt *Runtime, evaluationCallback Expre
 	if ArithmeticAllowedGate(args.Type()) {
 		return args, nil
 	}
-	diff := values.Zero
-	for args.Type() != types.Nil {
-		current := values.Car(args)
-		lhs, err := evalToNumber(current, rt, evaluationCallback)
+	diff, err := evalToNumber(values.Car(args), rt, evaluationCallback)
+	if err != nil {
+		return values.NewNil(), err
+	}
+	tail := values.Cdr(args)
+	if tail.Type() == types.Nil {
+		return values.Zero.Sub(diff), nil
+	}
+	for tail.Type() != types.Nil {
+		current := values.Car(tail)
+		rhs, err := evalToNumber(current, rt, evaluationCallback)
 		if err != nil {
 			return values.NewNil(), err
 		}
-		diff = diff.Sub(lhs)
-		args = values.Cdr(args)
+		diff = diff.Sub(rhs)
+		tail = values.Cdr(tail)
 	}
 
 	return diff, nil
@@ -105,7 +112,7 @@ func ProductImpl(args values.Interface, rt *Runtime, evaluationCallback Expressi
 }
 
 // QuotientImpl computes the quotient of all numeric arguments in args.
-// It divides the first number by each subsequent number in order.
+// It divides the first number by each subsequent number in order, or inverts a single argument.
 func QuotientImpl(args values.Interface, rt *Runtime, evaluationCallback Expression) (_ values.Interface, err error) {
 	if args.Type() == types.Nil {
 		return values.NewNil(), ErrWrongNumberOfArguments
@@ -113,12 +120,15 @@ func QuotientImpl(args values.Interface, rt *Runtime, evaluationCallback Express
 	if ArithmeticAllowedGate(args.Type()) {
 		return args, nil
 	}
+	quotient, err := evalToNumber(values.Car(args), rt, evaluationCallback)
+	if err != nil {
+		return values.NewNil(), err
+	}
 	tail := values.Cdr(args)
-
 	if tail.Type() == types.Nil {
-		return values.NewNil(), ErrWrongNumberOfArguments
+		tail = values.Cons(quotient, values.NewNil())
+		quotient = values.One
 	}
-	var quotient = values.One
 	for tail.Type() != types.Nil {
 		current := values.Car(tail)
 		rhs, err := evalToNumber(current, rt, evaluationCallback)
@@ -138,34 +148,30 @@ func QuotientImpl(args values.Interface, rt *Runtime, evaluationCallback Express
 	return quotient, nil
 }
 
-// RemainderImpl computes the remainder of the division of the first numeric argument by each subsequent numeric argument in args.
+// RemainderImpl computes the modulo of the first numeric argument by the second.
+// The result has the sign of the divisor.
 func RemainderImpl(args values.Interface, rt *Runtime, evaluationCallback Expression) (_ values.Interface, err error) {
-	if args.Type() == types.Nil {
-		return values.NewNil(), ErrWrongNumberOfArguments
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewNil(), err
 	}
-
-	if ArithmeticAllowedGate(args.Type()) {
-		return args, nil
+	dividend, err := evalToNumber(operands[0], rt, evaluationCallback)
+	if err != nil {
+		return values.NewNil(), ErrNumberExpected
 	}
-	current := values.Car(args)
-	tail := values.Cdr(args)
-	var remainder = values.Zero
-	for tail.Type() != types.Nil {
-
-		rhsNum, err := evalToNumber(current, rt, evaluationCallback)
-		if err != nil {
-			return values.NewNil(), ErrNumberExpected
-		}
-		if rhsNum.Equal(values.Zero) {
-			return values.NewNil(), ErrDivideByZero
-		}
-		remainder, err = remainder.Mod(rhsNum)
-		if err != nil {
-			return values.NewNil(), err
-		}
-		current = values.Car(tail)
-		tail = values.Cdr(tail)
+	divisor, err := evalToNumber(operands[1], rt, evaluationCallback)
+	if err != nil {
+		return values.NewNil(), ErrNumberExpected
+	}
+	if divisor.Equal(values.Zero) {
+		return values.NewNil(), ErrDivideByZero
+	}
+	remainder, err := dividend.Mod(divisor)
+	if err != nil {
+		return values.NewNil(), err
+	}
+	if !remainder.Equal(values.Zero) && remainder.LessThan(values.Zero) != divisor.LessThan(values.Zero) {
+		remainder = remainder.Add(divisor)
 	}
-
 	return remainder, nil
 }
@@ -0,0 +1,166 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Parameter is an R7RS parameter object. Calling it without arguments returns its value
+// in the dynamic extent of the call: the innermost parameterize binding it, or its global
+// value. Bindings made by parameterize are held by the runtime of the body, so they are
+// undone when the body returns or raises, and are not seen by other threads.
+type Parameter struct {
+	name    string
+	value   values.Interface
+	initial func(rt *Runtime) values.Interface
+	convert func(v values.Interface, rt *Runtime) (values.Interface, error)
+}
+
+// dynamicBinding is a parameterize binding. Bindings form an immutable list from the
+// innermost binding outwards, shared by the runtimes of nested evaluations.
+type dynamicBinding struct {
+	param  *Parameter
+	value  values.Interface
+	parent *dynamicBinding
+}
+
+// newRuntimeParameter returns a parameter whose global value is derived from the runtime
+// it is called in, checking values bound by parameterize with convert.
+func newRuntimeParameter(name string, initial func(rt *Runtime) values.Interface, convert func(v values.Interface, rt *Runtime) (values.Interface, error)) *Parameter {
+	return &Parameter{name: name, initial: initial, convert: convert}
+}
+
+// Value returns the value of the parameter in rt.
+func (p *Parameter) Value(rt *Runtime) values.Interface {
+	if rt != nil {
+		for b := rt.dynamic; b != nil; b = b.parent {
+			if b.param == p {
+				return b.value
+			}
+		}
+	}
+	if p.initial != nil {
+		return p.initial(rt)
+	}
+	return p.value
+}
+
+// Call returns the value of the parameter in the runtime of the call site.
+func (p *Parameter) Call(args values.Interface, rt *Runtime) (values.Interface, error) {
+	if _, err := unpackArgs(args, 0, 0); err != nil {
+		return values.NewVoidType(), err
+	}
+	return p.Value(rt), nil
+}
+
+// Apply returns the global value of the parameter, as Go callers have no dynamic extent.
+func (p *Parameter) Apply(args values.Interface) (values.Interface, error) {
+	return p.Call(args, nil)
+}
+
+// Arity returns the arity of a parameter, which takes no arguments.
+func (p *Parameter) Arity() Arity {
+	return Exactly(0)
+}
+
+func (p *Parameter) Equal(other values.Interface) bool {
+	o, ok := other.(*Parameter)
+	return ok && o == p
+}
+
+func (p *Parameter) Type() types.Type {
+	return types.Lambda
+}
+
+func (p *Parameter) IsTruthy() bool {
+	return true
+}
+
+func (p *Parameter) DisplayString() string {
+	if p.name == "" {
+		return "#<parameter>"
+	}
+	return "#<parameter " + p.name + ">"
+}
+
+func (p *Parameter) WriteString() string {
+	return p.DisplayString()
+}
+
+// MakeParameterImpl implements the make-parameter procedure
+// (make-parameter value [converter]) returns a parameter whose global value is
+// (converter value). Values bound by parameterize are passed through converter as well.
+func MakeParameterImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	p := &Parameter{}
+	if len(operands) == 2 {
+		converter, ok := operands[1].(Lambda)
+		if !ok {
+			return values.NewVoidType(), fmt.Errorf("%w: converter %s is not a procedure", ErrTypeMismatch, operands[1].WriteString())
+		}
+		p.convert = func(v values.Interface, rt *Runtime) (values.Interface, error) {
+			return rt.Apply(converter, values.List(v))
+		}
+	}
+	if p.value, err = p.converted(operands[0], rt); err != nil {
+		return values.NewVoidType(), err
+	}
+	return p, nil
+}
+
+func (p *Parameter) converted(v values.Interface, rt *Runtime) (values.Interface, error) {
+	if p.convert == nil {
+		return v, nil
+	}
+	v, err := p.convert(v, rt)
+	return values.Unquote(v), err
+}
+
+// ParameterizeImpl implements the parameterize special form
+// (parameterize ((param value) ...) body...) evaluates body with each param bound to its
+// converted value. The bindings last for the dynamic extent of body.
+func ParameterizeImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	if args.Type() != types.Pair {
+		return values.NewVoidType(), ErrWrongNumberOfArguments
+	}
+	bindings, err := values.ToSlice(values.Car(args))
+	if err != nil {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	body, err := values.ToSlice(values.Cdr(args))
+	if err != nil || len(body) == 0 {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	// every parameter and value is evaluated before any binding takes effect
+	dynamic := rt.dynamic
+	for _, binding := range bindings {
+		parts, err := unpackArgs(binding, 2, 2)
+		if err != nil {
+			return values.NewVoidType(), ErrInvalidFormat
+		}
+		param, err := cb(parts[0], rt)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		p, ok := param.(*Parameter)
+		if !ok {
+			return values.NewVoidType(), fmt.Errorf("%w: %s is not a parameter", ErrTypeMismatch, param.WriteString())
+		}
+		value, err := cb(parts[1], rt)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		if value, err = p.converted(values.Unquote(value), rt); err != nil {
+			return values.NewVoidType(), err
+		}
+		dynamic = &dynamicBinding{param: p, value: value, parent: dynamic}
+	}
+	scope := *rt
+	scope.dynamic = dynamic
+	return evalBody(body, &scope, cb)
+}
@@ -0,0 +1,223 @@
+package builtins
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// The current ports are parameters. They default to ports over the Out, Err and input of
+// the runtime they are called in, and parameterize redirects them for the dynamic extent of a body.
+var (
+	currentOutputPort = newRuntimeParameter("current-output-port", func(rt *Runtime) values.Interface {
+		if rt == nil {
+			return values.NewOutputPort("stdout", os.Stdout)
+		}
+		return values.NewOutputPort("stdout", rt.Out)
+	}, portConverter(true))
+	currentErrorPort = newRuntimeParameter("current-error-port", func(rt *Runtime) values.Interface {
+		if rt == nil {
+			return values.NewOutputPort("stderr", os.Stderr)
+		}
+		return values.NewOutputPort("stderr", rt.Err)
+	}, portConverter(true))
+	currentInputPort = newRuntimeParameter("current-input-port", func(rt *Runtime) values.Interface {
+		if rt == nil || rt.in == nil {
+			return values.NewInputPort("stdin", os.Stdin)
+		}
+		return rt.in
+	}, portConverter(false))
+)
+
+// portConverter checks that values bound to a current port parameter are ports of the right direction.
+func portConverter(output bool) func(v values.Interface, rt *Runtime) (values.Interface, error) {
+	return func(v values.Interface, rt *Runtime) (values.Interface, error) {
+		port, ok := v.(*values.Port)
+		if !ok || (output && !port.IsOutput()) || (!output && !port.IsInput()) {
+			return values.NewVoidType(), fmt.Errorf("%w: %s is not an %s port", ErrTypeMismatch, v.WriteString(), direction(output))
+		}
+		return port, nil
+	}
+}
+
+func direction(output bool) string {
+	if output {
+		return "output"
+	}
+	return "input"
+}
+
+// portArg returns operands[i] as a port of the given direction, or the value of the
+// current port parameter when the operand is omitted or unspecified, as in (display x (newline)).
+func portArg(operands []values.Interface, i int, rt *Runtime, current *Parameter) (*values.Port, error) {
+	v := current.Value(rt)
+	if i < len(operands) && operands[i].Type() != types.Void {
+		v = operands[i]
+	}
+	port, err := current.convert(v, rt)
+	if err != nil {
+		return nil, err
+	}
+	return port.(*values.Port), nil
+}
+
+// writePort writes s to the output port operands[i], or to the current output port.
+func writePort(operands []values.Interface, i int, rt *Runtime, s string) (values.Interface, error) {
+	port, err := portArg(operands, i, rt, currentOutputPort)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := port.Write(s); err != nil {
+		return values.NewVoidType(), ErrIo(err)
+	}
+	return values.NewVoidType(), nil
+}
+
+// readPort reads from the input port operands[i], or from the current input port,
+// returning the eof object at the end of input. A read waiting for input returns the error
+// of the context of rt once it is done.
+func readPort(operands []values.Interface, i int, rt *Runtime, read func(*values.Port) (values.Interface, error)) (values.Interface, error) {
+	port, err := portArg(operands, i, rt, currentInputPort)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	v, err := read(port)
+	switch {
+	case errors.Is(err, io.EOF):
+		return values.NewEof(), nil
+	case err != nil && err == rt.Context().Err():
+		return values.NewVoidType(), err
+	case err != nil:
+		return values.NewVoidType(), ErrIo(err)
+	}
+	return v, nil
+}
+
+// WriteStringImpl implements the write-string procedure
+// (write-string string [port]) writes the characters of string to port
+func WriteStringImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	s, ok := operands[0].(values.String)
+	if !ok {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	return writePort(operands, 1, rt, s.String())
+}
+
+// WriteCharImpl implements the write-char procedure
+func WriteCharImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	c, ok := operands[0].(values.Char)
+	if !ok {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	return writePort(operands, 1, rt, string(c.Rune()))
+}
+
+// ReadCharImpl implements the read-char procedure
+func ReadCharImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return readPort(operands, 0, rt, func(port *values.Port) (values.Interface, error) {
+		r, err := port.ReadChar(rt.Context())
+		return values.NewChar(r), err
+	})
+}
+
+// ReadLineImpl implements the read-line procedure
+func ReadLineImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return readPort(operands, 0, rt, func(port *values.Port) (values.Interface, error) {
+		line, err := port.ReadLine(rt.Context())
+		if err == nil {
+			err = rt.AllocString(len(line))
+		}
+		return values.NewString(line), err
+	})
+}
+
+// OpenOutputStringImpl implements the open-output-string procedure
+func OpenOutputStringImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return values.NewStringOutputPort(), nil
+}
+
+// OpenInputStringImpl implements the open-input-string procedure
+func OpenInputStringImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	s, ok := operands[0].(values.String)
+	if !ok {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	return values.NewInputPort("string", strings.NewReader(s.String())), nil
+}
+
+// GetOutputStringImpl implements the get-output-string procedure
+// It returns the characters written so far to a port created by open-output-string.
+func GetOutputStringImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	port, ok := operands[0].(*values.Port)
+	if !ok {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	s, ok := port.Contents()
+	if !ok {
+		return values.NewVoidType(), fmt.Errorf("%w: %s is not a string port", ErrBadArgument, port.WriteString())
+	}
+	if err := rt.AllocString(len(s)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewString(s), nil
+}
+
+// ClosePortImpl implements the close-port procedure
+func ClosePortImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	port, ok := operands[0].(*values.Port)
+	if !ok {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	if err := port.Close(); err != nil {
+		return values.NewVoidType(), ErrIo(err)
+	}
+	return values.NewVoidType(), nil
+}
+
+// portDirection returns a predicate testing whether its argument is a port of the given direction.
+func portDirection(output bool) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		operands, err := procedureArgs(args)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		port, ok := operands[0].(*values.Port)
+		if !ok {
+			return values.NewBool(false), nil
+		}
+		return values.NewBool(output && port.IsOutput() || !output && port.IsInput()), nil
+	}
+}
@@ -0,0 +1,41 @@
+package builtins
+
+import (
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// typePredicate returns a procedure of one argument reporting whether its type passes gate
+func typePredicate(gate types.TypeGate) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		operands, err := procedureArgs(args)
+		if err != nil {
+			return values.NewBool(false), err
+		}
+		return values.NewBool(gate(operands[0].Type())), nil
+	}
+}
+
+var (
+	isBoolean   = types.NewTypeGate(types.Bool)
+	isNumber    = types.NewTypeGate(types.Int, types.Float)
+	isInteger   = types.NewTypeGate(types.Int)
+	isString    = types.NewTypeGate(types.String)
+	isChar      = types.NewTypeGate(types.Char)
+	isSymbol    = types.NewTypeGate(types.Identifier, types.RelationalOperator, types.ArithmeticOperator, types.BooleanOperator)
+	isProcedure = types.NewTypeGate(types.Lambda)
+	isEof       = types.NewTypeGate(types.Eof)
+	isThread    = types.NewTypeGate(types.Thread)
+	isMutex     = types.NewTypeGate(types.Mutex)
+	isChannel   = types.NewTypeGate(types.Channel)
+	isPort      = types.NewTypeGate(types.Port)
+	isHashTable = types.NewTypeGate(types.Map)
+	isPromise   = types.NewTypeGate(types.Promise)
+
+	isConditionVariable = types.NewTypeGate(types.ConditionVariable)
+)
+
+// EofObjectImpl implements the eof-object procedure
+func EofObjectImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return values.NewEof(), nil
+}
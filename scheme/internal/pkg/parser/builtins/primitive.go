@@ -0,0 +1,140 @@
+package builtins
+
+import (
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Primitive is an arithmetic or comparison builtin that compiled code may compute itself
+// when it is called with arguments the primitive handles, rather than calling the
+// builtin with a list of them.
+type Primitive int8
+
+const (
+	NoPrimitive Primitive = iota
+	PrimAdd
+	PrimSub
+	PrimLess
+	PrimLessOrEqual
+	PrimGreater
+	PrimGreaterOrEqual
+	PrimNumEqual
+	PrimNot
+)
+
+// primitives maps the names of the primitives to them and the number of arguments of
+// the calls they compute.
+var primitives = map[string]struct {
+	primitive Primitive
+	args      int
+}{
+	"+":   {PrimAdd, 2},
+	"-":   {PrimSub, 2},
+	"<":   {PrimLess, 2},
+	"<=":  {PrimLessOrEqual, 2},
+	">":   {PrimGreater, 2},
+	">=":  {PrimGreaterOrEqual, 2},
+	"=":   {PrimNumEqual, 2},
+	"not": {PrimNot, 1},
+}
+
+// PrimitiveCall reports whether a call of the builtin name with args arguments may be
+// computed by its primitive.
+func PrimitiveCall(name string, args int) bool {
+	p, ok := primitives[name]
+	return ok && p.args == args
+}
+
+// PrimitiveOf returns the primitive v is when it is the builtin bound to name by
+// default, or NoPrimitive.
+func PrimitiveOf(name string, v values.Interface) Primitive {
+	if p, ok := primitives[name]; ok && IsBuiltin(name, v) {
+		return p.primitive
+	}
+	return NoPrimitive
+}
+
+// Apply computes the primitive for args as its builtin would, or returns false when
+// the arguments are not ones it handles.
+func (p Primitive) Apply(args []values.Interface) (values.Interface, bool) {
+	switch p {
+	case PrimNot:
+		return values.NewBool(!args[0].IsTruthy()), true
+	}
+	if lhs, ok := args[0].(values.Number); ok && lhs.IsInt {
+		if rhs, ok := args[1].(values.Number); ok && rhs.IsInt {
+			return p.applyInts(lhs.IntVal, rhs.IntVal)
+		}
+	}
+	lhs, ok := primitiveNumber(args[0])
+	if !ok {
+		return nil, false
+	}
+	rhs, ok := primitiveNumber(args[1])
+	if !ok {
+		return nil, false
+	}
+	switch p {
+	case PrimAdd:
+		return values.Zero.Add(lhs).Add(rhs), true
+	case PrimSub:
+		return lhs.Sub(rhs), true
+	case PrimNumEqual:
+		return values.NewBool(lhs.EqualTo(rhs)), true
+	case PrimLess:
+		return values.NewBool(lhs.LessThan(rhs)), true
+	case PrimLessOrEqual:
+		return values.NewBool(lhs.LessThanOrEqual(rhs)), true
+	case PrimGreater:
+		return values.NewBool(lhs.GreaterThan(rhs)), true
+	case PrimGreaterOrEqual:
+		return values.NewBool(lhs.GreaterThanOrEqual(rhs)), true
+	}
+	return nil, false
+}
+
+// applyInts computes the primitive for two integers.
+func (p Primitive) applyInts(lhs, rhs int64) (values.Interface, bool) {
+	switch p {
+	case PrimAdd:
+		return smallInt(lhs + rhs), true
+	case PrimSub:
+		return smallInt(lhs - rhs), true
+	case PrimNumEqual:
+		return values.NewBool(lhs == rhs), true
+	case PrimLess:
+		return values.NewBool(lhs < rhs), true
+	case PrimLessOrEqual:
+		return values.NewBool(lhs <= rhs), true
+	case PrimGreater:
+		return values.NewBool(lhs > rhs), true
+	case PrimGreaterOrEqual:
+		return values.NewBool(lhs >= rhs), true
+	}
+	return nil, false
+}
+
+// smallInts holds the small integers, which are computed often enough to be worth
+// allocating once.
+var smallInts = func() (ints [1024]values.Interface) {
+	for i := range ints {
+		ints[i] = values.NewInt(int64(i) + minSmallInt)
+	}
+	return ints
+}()
+
+const minSmallInt = -128
+
+func smallInt(i int64) values.Interface {
+	if i >= minSmallInt && i < minSmallInt+int64(len(smallInts)) {
+		return smallInts[i-minSmallInt]
+	}
+	return values.NewInt(i)
+}
+
+func primitiveNumber(v values.Interface) (values.Numeric, bool) {
+	if !ArithmeticAllowedGate(v.Type()) {
+		return nil, false
+	}
+	n, ok := v.(values.Numeric)
+	return n, ok
+}
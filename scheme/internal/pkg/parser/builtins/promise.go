@@ -0,0 +1,148 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Promise is an R7RS promise. Forcing it computes its value once and remembers it.
+// A promise made by delay-force takes over the state of the promise its expression
+// returns, so chains of delay-force are forced in a loop rather than by recursion and
+// run in constant space. Like vectors, promises are not locked.
+type Promise struct {
+	state *promiseState
+}
+
+type promiseState struct {
+	done  bool
+	value values.Interface
+	// thunk computes the value of a promise that is not done. The result of a lazy
+	// thunk is the promise to continue forcing instead of a value.
+	thunk func(rt *Runtime) (values.Interface, error)
+	lazy  bool
+}
+
+// newPromise returns a promise whose value is computed by thunk in the runtime forcing it.
+func newPromise(thunk func(rt *Runtime) (values.Interface, error)) *Promise {
+	return &Promise{state: &promiseState{thunk: thunk}}
+}
+
+// newLazyPromise returns a promise that continues with the promise returned by thunk.
+func newLazyPromise(thunk func(rt *Runtime) (values.Interface, error)) *Promise {
+	return &Promise{state: &promiseState{thunk: thunk, lazy: true}}
+}
+
+// newForcedPromise returns a promise whose value is already known.
+func newForcedPromise(v values.Interface) *Promise {
+	return &Promise{state: &promiseState{done: true, value: v}}
+}
+
+// Force returns the value of the promise, computing it in rt if it has not been forced.
+func (p *Promise) Force(rt *Runtime) (values.Interface, error) {
+	for !p.state.done {
+		state := p.state
+		v, err := state.thunk(rt)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		v = values.Unquote(v)
+		if state.done {
+			// the thunk forced the promise itself, and the first value computed wins
+			break
+		}
+		if !state.lazy {
+			state.done, state.value, state.thunk = true, v, nil
+			break
+		}
+		next, ok := v.(*Promise)
+		if !ok {
+			return values.NewVoidType(), fmt.Errorf("%w: delay-force expression returned %s, not a promise", ErrTypeMismatch, v.WriteString())
+		}
+		// next shares the state from now on, unless it is already forced and may be shared
+		*state = *next.state
+		if !state.done {
+			next.state = state
+		}
+	}
+	return p.state.value, nil
+}
+
+func (p *Promise) Equal(other values.Interface) bool {
+	o, ok := other.(*Promise)
+	return ok && o == p
+}
+
+func (p *Promise) Type() types.Type {
+	return types.Promise
+}
+
+func (p *Promise) IsTruthy() bool {
+	return true
+}
+
+func (p *Promise) DisplayString() string {
+	return "#<promise>"
+}
+
+func (p *Promise) WriteString() string {
+	return p.DisplayString()
+}
+
+// delayed returns a thunk evaluating expr in the environment of rt and the dynamic
+// environment of the runtime forcing the promise.
+func delayed(expr values.Interface, rt *Runtime, cb Expression) func(*Runtime) (values.Interface, error) {
+	env := rt.Env
+	return func(caller *Runtime) (values.Interface, error) {
+		return cb(expr, caller.WithEnvironment(env))
+	}
+}
+
+// DelayImpl implements the delay special form
+// (delay expr) returns a promise that evaluates expr when it is first forced.
+func DelayImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	operands, err := unpackArgs(args, 1, 1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return newPromise(delayed(operands[0], rt, cb)), nil
+}
+
+// DelayForceImpl implements the delay-force special form
+// (delay-force expr) returns a promise that, when forced, forces the promise expr evaluates to.
+// Forcing a chain of delay-force promises does not grow the stack.
+func DelayForceImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	operands, err := unpackArgs(args, 1, 1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return newLazyPromise(delayed(operands[0], rt, cb)), nil
+}
+
+// ForceImpl implements the force procedure
+// (force obj) returns the value of the promise obj, or obj itself if it is not a promise.
+func ForceImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	p, ok := operands[0].(*Promise)
+	if !ok {
+		return operands[0], nil
+	}
+	return p.Force(rt)
+}
+
+// MakePromiseImpl implements the make-promise procedure
+// (make-promise obj) returns a forced promise of obj, or obj itself if it is a promise.
+func MakePromiseImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if p, ok := operands[0].(*Promise); ok {
+		return p, nil
+	}
+	return newForcedPromise(operands[0]), nil
+}
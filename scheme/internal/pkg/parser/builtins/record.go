@@ -0,0 +1,148 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// DefineRecordTypeImpl implements the define-record-type special form
+// (define-record-type <name> (constructor field ...) predicate (field accessor [modifier]) ...)
+// defines <name> as a record type together with its constructor, predicate, accessors and
+// modifiers. The constructor may be #f to define none; fields it does not initialise are #f.
+func DefineRecordTypeImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	operands, err := unpackArgs(args, 3, -1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	typeName, ok := symbolName(operands[0])
+	if !ok {
+		return values.NewVoidType(), fmt.Errorf("%w: record type name %s", ErrBadArgument, operands[0].WriteString())
+	}
+	predicateName, ok := symbolName(operands[2])
+	if !ok {
+		return values.NewVoidType(), fmt.Errorf("%w: record predicate %s", ErrBadArgument, operands[2].WriteString())
+	}
+	type procedure struct {
+		name     string
+		field    int
+		modifier bool
+	}
+	var (
+		fields     = make([]string, 0, len(operands)-3)
+		procedures []procedure
+	)
+	for i, spec := range operands[3:] {
+		parts, err := unpackArgs(spec, 1, 3)
+		if err != nil {
+			return values.NewVoidType(), fmt.Errorf("%w: field %s", ErrInvalidFormat, spec.WriteString())
+		}
+		names := make([]string, len(parts))
+		for j, part := range parts {
+			if names[j], ok = symbolName(part); !ok {
+				return values.NewVoidType(), fmt.Errorf("%w: field %s", ErrBadArgument, spec.WriteString())
+			}
+		}
+		fields = append(fields, names[0])
+		for j, name := range names[1:] {
+			procedures = append(procedures, procedure{name: name, field: i, modifier: j == 1})
+		}
+	}
+	rtype := values.NewRecordType(typeName, fields...)
+	if constructor := operands[1]; constructor.Type() != types.Bool || constructor.IsTruthy() {
+		parts, err := values.ToSlice(constructor)
+		if err != nil || len(parts) == 0 {
+			return values.NewVoidType(), fmt.Errorf("%w: record constructor %s", ErrInvalidFormat, constructor.WriteString())
+		}
+		name, ok := symbolName(parts[0])
+		if !ok {
+			return values.NewVoidType(), fmt.Errorf("%w: record constructor %s", ErrBadArgument, constructor.WriteString())
+		}
+		indexes := make([]int, len(parts)-1)
+		for i, part := range parts[1:] {
+			field, _ := symbolName(part)
+			if indexes[i] = rtype.FieldIndex(field); indexes[i] < 0 {
+				return values.NewVoidType(), fmt.Errorf("%w: %s is not a field of %s", ErrBadArgument, part.WriteString(), typeName)
+			}
+		}
+		rt.Env.Define(name, NewNamedLambda(rt, name, Exactly(len(indexes)), recordConstructor(rtype, indexes)))
+	}
+	rt.Env.Define(typeName, rtype)
+	rt.Env.Define(predicateName, NewNamedLambda(rt, predicateName, Exactly(1), recordPredicate(rtype)))
+	for _, proc := range procedures {
+		if proc.modifier {
+			rt.Env.Define(proc.name, NewNamedLambda(rt, proc.name, Exactly(2), recordModifier(rtype, proc.field)))
+		} else {
+			rt.Env.Define(proc.name, NewNamedLambda(rt, proc.name, Exactly(1), recordAccessor(rtype, proc.field)))
+		}
+	}
+	return values.NewVoidType(), nil
+}
+
+// recordConstructor returns a procedure creating a record of type t whose arguments
+// initialise the fields at indexes.
+func recordConstructor(t *values.RecordType, indexes []int) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		operands, err := procedureArgs(args)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		if err := rt.AllocConsCells(len(t.Fields())); err != nil {
+			return values.NewVoidType(), err
+		}
+		fields := make([]values.Interface, len(t.Fields()))
+		for i := range fields {
+			fields[i] = values.NewBool(false)
+		}
+		for i, index := range indexes {
+			fields[index] = operands[i]
+		}
+		return values.NewRecord(t, fields...), nil
+	}
+}
+
+func recordPredicate(t *values.RecordType) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		operands, err := procedureArgs(args)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		r, ok := operands[0].(*values.Record)
+		return values.NewBool(ok && r.RecordType() == t), nil
+	}
+}
+
+func recordAccessor(t *values.RecordType, field int) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		r, _, err := recordArgs(args, t)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		return r.Field(field), nil
+	}
+}
+
+func recordModifier(t *values.RecordType, field int) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		r, operands, err := recordArgs(args, t)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		r.SetField(field, operands[1])
+		return values.NewVoidType(), nil
+	}
+}
+
+// recordArgs unpacks the arguments of a record procedure, the first of which must be a record of type t.
+func recordArgs(args values.Interface, t *values.RecordType) (*values.Record, []values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return nil, operands, err
+	}
+	r, ok := operands[0].(*values.Record)
+	if !ok || r.RecordType() != t {
+		return nil, operands, fmt.Errorf("%w: %s is not a %s", ErrTypeMismatch, operands[0].WriteString(), t.Name())
+	}
+	return r, operands, nil
+}
@@ -1,6 +1,7 @@
 package builtins
 
 import (
+	"context"
 	"io"
 	"os"
 
@@ -9,17 +10,47 @@ import (
 
 type EvaluatorCallback Expression
 
+// DatumReader reads every datum from src. The filename is used to report positions
+// and to resolve paths that are relative to the file being read.
+type DatumReader func(src io.Reader, filename string, rt *Runtime) ([]values.Interface, error)
+
+// Runtime holds the state shared by evaluations: the global environment, the output
+// writers and the library registry. A Runtime may be used by several goroutines at once;
+// environments and libraries are locked, and each top level evaluation started with
+// NewEvaluation has its own context and resource accounting. Values such as vectors and
+// hash tables are not locked, and Out and Err must be safe for concurrent writes when
+// evaluations that print run concurrently.
 type Runtime struct {
 	Out io.Writer
 	Err io.Writer
 	Env Environment
+
+	ctx        context.Context
+	limits     limits
+	evaluation *evaluation
+	dynamic    *dynamicBinding
+	in         *values.Port
+	eval       Expression
+	read       DatumReader
+	libraries  *Libraries
+	source     string
+	hook       Hook
+	// callee is the procedure defined in Scheme whose body the runtime runs, while a
+	// hook follows the evaluation.
+	callee values.Interface
 }
 
 type configRuntime struct {
+	ctx      context.Context
 	out      io.Writer
 	err      io.Writer
+	in       io.Reader
 	env      Environment
 	callback Expression
+	reader   DatumReader
+	resolver LibraryResolver
+	limits   limits
+	hook     Hook
 }
 
 type OptionRuntime func(*configRuntime)
@@ -30,6 +61,14 @@ func WithEnv(env Environment) OptionRuntime {
 	}
 }
 
+// WithContext sets the context checked by the evaluator. Evaluation stops with the
+// context's error once it is canceled or its deadline passes.
+func WithContext(ctx context.Context) OptionRuntime {
+	return func(c *configRuntime) {
+		c.ctx = ctx
+	}
+}
+
 func WithOut(out io.Writer) OptionRuntime {
 	return func(c *configRuntime) {
 		c.out = out
@@ -42,20 +81,52 @@ func WithErr(err io.Writer) OptionRuntime {
 	}
 }
 
+// WithIn sets the reader of the initial current-input-port.
+func WithIn(in io.Reader) OptionRuntime {
+	return func(c *configRuntime) {
+		c.in = in
+	}
+}
+
 func WithEvaluatorCallback(cb Expression) OptionRuntime {
 	return func(c *configRuntime) {
 		c.callback = cb
 	}
 }
 
+// WithDatumReader sets the reader used to load library and source files.
+func WithDatumReader(reader DatumReader) OptionRuntime {
+	return func(c *configRuntime) {
+		c.reader = reader
+	}
+}
+
+// WithLibraryResolver sets the resolver used to locate libraries that are not yet loaded.
+func WithLibraryResolver(resolver LibraryResolver) OptionRuntime {
+	return func(c *configRuntime) {
+		c.resolver = resolver
+	}
+}
+
+// WithLibraryPath resolves libraries to files below the given directories.
+func WithLibraryPath(paths ...string) OptionRuntime {
+	return WithLibraryResolver(NewFileResolver(paths...))
+}
+
 func defaultConfig() configRuntime {
 	return configRuntime{
+		ctx: context.Background(),
 		out: os.Stdout,
 		err: os.Stderr,
+		in:  os.Stdin,
 		env: NewEnvironment(),
 		callback: func(v values.Interface, runtime *Runtime) (values.Interface, error) {
 			return v, nil
 		},
+		reader: func(io.Reader, string, *Runtime) ([]values.Interface, error) {
+			return nil, ErrNoDatumReader
+		},
+		resolver: NewFileResolver("."),
 	}
 }
 
@@ -65,10 +136,110 @@ func NewRuntime(opt ...OptionRuntime) *Runtime {
 		o(&cfg)
 	}
 	rt := &Runtime{
-		Out: cfg.out,
-		Err: cfg.err,
-		Env: cfg.env,
+		Out:        cfg.out,
+		Err:        cfg.err,
+		Env:        cfg.env,
+		ctx:        cfg.ctx,
+		limits:     cfg.limits,
+		evaluation: newEvaluation(),
+		in:         values.NewInputPort("stdin", cfg.in),
+		eval:       cfg.callback,
+		read:       cfg.reader,
+		libraries:  newLibraries(cfg.resolver),
+		hook:       cfg.hook,
 	}
 	rt.defaultEnvironment(cfg.callback)
+	cfg.limits.restrict(&rt.Env)
+	rt.libraries.registerStandardLibraries(rt.Env)
 	return rt
 }
+
+// WithEnvironment returns a copy of the runtime that evaluates in env.
+// The copy shares its output, error and library state with rt.
+func (rt *Runtime) WithEnvironment(env Environment) *Runtime {
+	scope := *rt
+	scope.Env = env
+	return &scope
+}
+
+// WithOutput returns a copy of the runtime that writes its output to out.
+// The copy shares its environment and library state with rt. Within Scheme code
+// output is redirected with parameterize and current-output-port instead.
+func (rt *Runtime) WithOutput(out io.Writer) *Runtime {
+	scope := *rt
+	scope.Out = out
+	return &scope
+}
+
+// WithContext returns a copy of the runtime whose evaluation is bounded by ctx.
+// The copy shares its environment, output and library state with rt.
+func (rt *Runtime) WithContext(ctx context.Context) *Runtime {
+	scope := *rt
+	scope.ctx = ctx
+	return &scope
+}
+
+// Context returns the context bounding evaluation in the runtime.
+func (rt *Runtime) Context() context.Context {
+	if rt == nil || rt.ctx == nil {
+		return context.Background()
+	}
+	return rt.ctx
+}
+
+// Interrupted returns the error of the runtime's context once it is done, and nil otherwise.
+// The evaluator checks it before every procedure call, so long running loops can be canceled.
+func (rt *Runtime) Interrupted() error {
+	select {
+	case <-rt.Context().Done():
+		return rt.Context().Err()
+	default:
+		return nil
+	}
+}
+
+// Apply calls proc with args from Go code running in rt, so that procedures
+// defined in Scheme observe the runtime's context rather than the one they were defined in.
+func (rt *Runtime) Apply(proc Lambda, args values.Interface) (values.Interface, error) {
+	depth := rt.StackDepth()
+	defer rt.Unwind(depth)
+	rt.PushFrame(proc, nil)
+	if err := rt.Calling(proc, args); err != nil {
+		return values.NewVoidType(), rt.Traced(err)
+	}
+	var (
+		v   values.Interface
+		err error
+	)
+	if procedure, ok := proc.(Procedure); ok {
+		v, err = rt.Trampoline(procedure.Call(args, rt))
+	} else {
+		v, err = proc.Apply(args)
+	}
+	if err != nil {
+		return v, rt.Traced(err)
+	}
+	return v, nil
+}
+
+// Libraries returns the registry of libraries known to the runtime.
+func (rt *Runtime) Libraries() *Libraries {
+	return rt.libraries
+}
+
+// Evaluate evaluates expr in the environment of rt with the runtime's evaluator.
+func (rt *Runtime) Evaluate(expr values.Interface) (values.Interface, error) {
+	return rt.eval(expr, rt)
+}
+
+// Trampoline completes a TailCall returned by an Expression, evaluating the deferred
+// expression with the runtime's evaluator. Other results are returned unchanged.
+func (rt *Runtime) Trampoline(v values.Interface, err error) (values.Interface, error) {
+	if err != nil {
+		return v, err
+	}
+	if tail, ok := v.(TailCall); ok {
+		return rt.eval(tail.Expr, tail.Runtime)
+	}
+	return v, nil
+}
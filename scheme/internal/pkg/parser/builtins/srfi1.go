@@ -0,0 +1,542 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/list"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// FilterImpl implements the filter procedure
+// (filter pred list) returns the elements of list satisfying pred, in order
+func FilterImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return partitioned(args, rt, true)
+}
+
+// RemoveImpl implements the remove procedure
+// (remove pred list) returns the elements of list not satisfying pred, in order
+func RemoveImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return partitioned(args, rt, false)
+}
+
+// PartitionImpl implements the partition procedure
+// (partition pred list) returns two values: the elements satisfying pred and the others
+func PartitionImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	items, err := listArg(operands[1])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	in, out, err := list.Partition(items, predicate(rt, operands[0]))
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(len(items)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewValues(values.List(in...), values.List(out...)), nil
+}
+
+func partitioned(args values.Interface, rt *Runtime, keep bool) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	items, err := listArg(operands[1])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	in, out, err := list.Partition(items, predicate(rt, operands[0]))
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if !keep {
+		in = out
+	}
+	if err := rt.AllocConsCells(len(in)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.List(in...), nil
+}
+
+// FoldImpl implements the fold procedure
+// (fold kons knil list1 list2 ...) calls (kons e1 e2 ... acc) on the elements from left to
+// right, starting with knil as acc, and stops at the shortest list
+func FoldImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return fold(args, rt, false)
+}
+
+// FoldRightImpl implements the fold-right procedure
+// It is fold applied to the elements from right to left
+func FoldRightImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return fold(args, rt, true)
+}
+
+func fold(args values.Interface, rt *Runtime, right bool) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	rows, err := listRows(operands[2:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	acc := operands[1]
+	for i := range rows {
+		row := rows[i]
+		if right {
+			row = rows[len(rows)-1-i]
+		}
+		if acc, err = applyArg(rt, operands[0], append(row, acc)...); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	return acc, nil
+}
+
+// ReduceImpl implements the reduce procedure
+// (reduce f ridentity list) is (fold f (car list) (cdr list)), or ridentity for the empty list
+func ReduceImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	items, err := listArg(operands[2])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if len(items) == 0 {
+		return operands[1], nil
+	}
+	acc := items[0]
+	for _, item := range items[1:] {
+		if acc, err = applyArg(rt, operands[0], item, acc); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	return acc, nil
+}
+
+// AppendMapImpl implements the append-map procedure
+// (append-map f list1 list2 ...) appends the lists returned by calling f on the elements
+func AppendMapImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return mapRows(args, rt, func(result values.Interface, out []values.Interface) ([]values.Interface, error) {
+		items, err := listArg(result)
+		return append(out, items...), err
+	})
+}
+
+// FilterMapImpl implements the filter-map procedure
+// (filter-map f list1 list2 ...) returns the true results of calling f on the elements
+func FilterMapImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return mapRows(args, rt, func(result values.Interface, out []values.Interface) ([]values.Interface, error) {
+		if result.IsTruthy() {
+			out = append(out, result)
+		}
+		return out, nil
+	})
+}
+
+// mapRows calls the procedure in args on each row of elements of the lists following it,
+// collecting the results with collect.
+func mapRows(args values.Interface, rt *Runtime, collect func(result values.Interface, out []values.Interface) ([]values.Interface, error)) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	rows, err := listRows(operands[1:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	var out []values.Interface
+	for _, row := range rows {
+		result, err := applyArg(rt, operands[0], row...)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		if out, err = collect(result, out); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	if err := rt.AllocConsCells(len(out)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.List(out...), nil
+}
+
+// FindImpl implements the find procedure
+// (find pred list) returns the first element satisfying pred, or #f
+func FindImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	tail, err := FindTailImpl(args, rt)
+	if err != nil || tail.Type() != types.Pair {
+		return tail, err
+	}
+	return values.Car(tail), nil
+}
+
+// FindTailImpl implements the find-tail procedure
+// (find-tail pred list) returns the first pair of list whose car satisfies pred, or #f
+func FindTailImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	pred := predicate(rt, operands[0])
+	for tail := operands[1]; tail.Type() == types.Pair; tail = values.Cdr(tail) {
+		ok, err := pred(values.Car(tail))
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		if ok {
+			return tail, nil
+		}
+	}
+	return values.NewBool(false), nil
+}
+
+// AnyImpl implements the any procedure
+// (any pred list1 list2 ...) returns the first true result of pred on the elements, or #f
+func AnyImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	rows, err := listRows(operands[1:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	for _, row := range rows {
+		result, err := applyArg(rt, operands[0], row...)
+		if err != nil || result.IsTruthy() {
+			return result, err
+		}
+	}
+	return values.NewBool(false), nil
+}
+
+// EveryImpl implements the every procedure
+// (every pred list1 list2 ...) returns #f if pred is false for some elements, and otherwise
+// the result of the last call, or #t when the lists are empty
+func EveryImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	rows, err := listRows(operands[1:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	var result = values.NewBool(true)
+	for _, row := range rows {
+		if result, err = applyArg(rt, operands[0], row...); err != nil || !result.IsTruthy() {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// ListIndexImpl implements the list-index procedure
+// (list-index pred list1 list2 ...) returns the index of the first elements satisfying pred, or #f
+func ListIndexImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	rows, err := listRows(operands[1:])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	i, err := list.Index(rows, func(row []values.Interface) (bool, error) {
+		result, err := applyArg(rt, operands[0], row...)
+		return err == nil && result.IsTruthy(), err
+	})
+	if err != nil || i < 0 {
+		return values.NewBool(false), err
+	}
+	return values.NewInt(int64(i)), nil
+}
+
+// DeleteImpl implements the delete procedure
+// (delete x list [=]) returns list without the elements e for which (= x e), comparing with equal? by default
+func DeleteImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	items, err := listArg(operands[1])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	equal := equalityArg(rt, operands, 2)
+	kept, err := list.Filter(items, func(item values.Interface) (bool, error) {
+		same, err := equal(operands[0], item)
+		return !same, err
+	})
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(len(kept)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.List(kept...), nil
+}
+
+// DeleteDuplicatesImpl implements the delete-duplicates procedure
+// (delete-duplicates list [=]) returns list keeping only the first of each set of equal elements
+func DeleteDuplicatesImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	items, err := listArg(operands[0])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	var kept []values.Interface
+	if cmp, err := comparatorArg(operands, 1); err == nil {
+		// the builtin equivalences hash, so duplicates are found without comparing every pair
+		seen := values.NewHashTableWith(cmp)
+		for _, item := range items {
+			if _, ok := seen.Ref(item); !ok {
+				seen.Set(item, item)
+				kept = append(kept, item)
+			}
+		}
+	} else {
+		equal := equalityArg(rt, operands, 1)
+		for _, item := range items {
+			i, err := list.Index(kept, func(k values.Interface) (bool, error) { return equal(k, item) })
+			if err != nil {
+				return values.NewVoidType(), err
+			}
+			if i < 0 {
+				kept = append(kept, item)
+			}
+		}
+	}
+	if err := rt.AllocConsCells(len(kept)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.List(kept...), nil
+}
+
+// IotaImpl implements the iota procedure
+// (iota count [start step]) returns the list (start start+step ... start+(count-1)*step)
+func IotaImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	count, err := indexArg(operands[0], -1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	start, step := values.Zero, values.One
+	for i, n := range []*values.Numeric{&start, &step} {
+		if len(operands) > i+1 {
+			v, ok := operands[i+1].(values.Numeric)
+			if !ok {
+				return values.NewVoidType(), ErrNumberExpected
+			}
+			*n = v
+		}
+	}
+	if err := rt.AllocConsCells(count); err != nil {
+		return values.NewVoidType(), err
+	}
+	items := make([]values.Interface, count)
+	for i := range items {
+		items[i] = start.Add(step.Mul(values.NewInt(int64(i)).(values.Numeric)))
+	}
+	return values.List(items...), nil
+}
+
+// TakeImpl implements the take procedure
+// (take list k) returns the first k elements of list
+func TakeImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	k, err := indexArg(operands[1], -1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(k); err != nil {
+		return values.NewVoidType(), err
+	}
+	items := make([]values.Interface, 0, k)
+	for tail := operands[0]; len(items) < k; tail = values.Cdr(tail) {
+		if tail.Type() != types.Pair {
+			return values.NewVoidType(), fmt.Errorf("%w: %d", ErrIndexOutOfRange, k)
+		}
+		items = append(items, values.Car(tail))
+	}
+	return values.List(items...), nil
+}
+
+// DropImpl implements the drop procedure
+// (drop list k) returns the tail of list following its first k elements
+func DropImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	k, err := indexArg(operands[1], -1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	tail := operands[0]
+	for i := 0; i < k; i++ {
+		if tail.Type() != types.Pair {
+			return values.NewVoidType(), fmt.Errorf("%w: %d", ErrIndexOutOfRange, k)
+		}
+		tail = values.Cdr(tail)
+	}
+	return tail, nil
+}
+
+// LastImpl implements the last procedure
+// It returns the last element of a non-empty list
+func LastImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if operands[0].Type() != types.Pair {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	tail := operands[0]
+	for values.Cdr(tail).Type() == types.Pair {
+		tail = values.Cdr(tail)
+	}
+	return values.Car(tail), nil
+}
+
+// AlistCopyImpl implements the alist-copy procedure
+// It returns a copy of an association list with new pairs for each association
+func AlistCopyImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	alist, err := listArg(operands[0])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(2 * len(alist)); err != nil {
+		return values.NewVoidType(), err
+	}
+	entries := make([]values.Interface, len(alist))
+	for i, entry := range alist {
+		if entry.Type() != types.Pair {
+			return values.NewVoidType(), fmt.Errorf("%w: association %s", ErrTypeMismatch, entry.WriteString())
+		}
+		entries[i] = values.Cons(values.Car(entry), values.Cdr(entry))
+	}
+	return values.List(entries...), nil
+}
+
+// AlistDeleteImpl implements the alist-delete procedure
+// (alist-delete key alist [=]) returns alist without the associations whose key is equal to key
+func AlistDeleteImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	alist, err := listArg(operands[1])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	equal := equalityArg(rt, operands, 2)
+	kept, err := list.Filter(alist, func(entry values.Interface) (bool, error) {
+		if entry.Type() != types.Pair {
+			return false, fmt.Errorf("%w: association %s", ErrTypeMismatch, entry.WriteString())
+		}
+		same, err := equal(operands[0], values.Car(entry))
+		return !same, err
+	})
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(len(kept)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.List(kept...), nil
+}
+
+// ValuesImpl implements the values procedure
+func ValuesImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewValues(operands...), nil
+}
+
+// CallWithValuesImpl implements the call-with-values procedure
+// (call-with-values producer consumer) calls consumer with the values returned by producer
+func CallWithValuesImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	produced, err := applyArg(rt, operands[0])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	results := []values.Interface{produced}
+	if multiple, ok := produced.(values.MultipleValues); ok {
+		results = multiple.Items()
+	}
+	return applyArg(rt, operands[1], results...)
+}
+
+// listArg returns the elements of the proper list v.
+func listArg(v values.Interface) ([]values.Interface, error) {
+	items, err := values.ToSlice(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s is not a list", ErrTypeMismatch, v.WriteString())
+	}
+	return items, nil
+}
+
+// listRows returns the i-th elements of each list in operands as the i-th row,
+// stopping at the shortest list.
+func listRows(operands []values.Interface) ([][]values.Interface, error) {
+	lists := make([][]values.Interface, len(operands))
+	for i, operand := range operands {
+		items, err := listArg(operand)
+		if err != nil {
+			return nil, err
+		}
+		lists[i] = items
+	}
+	return list.Zip(lists...), nil
+}
+
+// predicate returns a Go predicate calling the procedure proc.
+func predicate(rt *Runtime, proc values.Interface) func(values.Interface) (bool, error) {
+	return func(v values.Interface) (bool, error) {
+		result, err := applyArg(rt, proc, v)
+		return err == nil && result.IsTruthy(), err
+	}
+}
+
+// equalityArg returns the equality predicate operands[i], defaulting to equal?.
+func equalityArg(rt *Runtime, operands []values.Interface, i int) func(a, b values.Interface) (bool, error) {
+	if i >= len(operands) {
+		return func(a, b values.Interface) (bool, error) { return a.Equal(b), nil }
+	}
+	if e, ok := operands[i].(equivalence); ok {
+		return func(a, b values.Interface) (bool, error) { return e.cmp.Equal(a, b), nil }
+	}
+	return func(a, b values.Interface) (bool, error) {
+		result, err := applyArg(rt, operands[i], a, b)
+		return err == nil && result.IsTruthy(), err
+	}
+}
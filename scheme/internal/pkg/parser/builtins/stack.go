@@ -0,0 +1,136 @@
+package builtins
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// callStack holds the procedure calls in progress in a thread of an evaluation, outermost
+// first. It is read to build the stack trace of an error and by debuggers. Calls made from Go with
+// Apply on the runtime of a closure may share it with the evaluation that made the
+// closure, so it is locked.
+type callStack struct {
+	mu     sync.Mutex
+	frames []values.Frame
+}
+
+// StackDepth returns the number of calls in progress, to be restored with Unwind.
+func (rt *Runtime) StackDepth() int {
+	s := &rt.evaluation.stack
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.frames)
+}
+
+// PushFrame records a call of proc made by the form call, which may be nil for calls
+// made from Go.
+func (rt *Runtime) PushFrame(proc values.Interface, call values.Interface) {
+	frame := values.Frame{Procedure: procedureName(proc)}
+	if call != nil {
+		frame.Call, _ = values.SourcePosition(call)
+	}
+	s := &rt.evaluation.stack
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frames = append(s.frames, frame)
+}
+
+// PushCall records the call described by frame, for evaluators that do not keep the form
+// of the call, and returns the depth to Unwind to once it returns.
+func (rt *Runtime) PushCall(frame values.Frame) int {
+	s := &rt.evaluation.stack
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frames = append(s.frames, frame)
+	return len(s.frames) - 1
+}
+
+// TailCall replaces the call on top of the stack by the tail call described by frame,
+// which it made, counting the calls it replaces.
+func (rt *Runtime) TailCall(frame values.Frame) {
+	s := &rt.evaluation.stack
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.frames) == 0 {
+		s.frames = append(s.frames, frame)
+		return
+	}
+	top := &s.frames[len(s.frames)-1]
+	frame.TailCalls = top.TailCalls + 1
+	*top = frame
+}
+
+// CollapseTailCall replaces the frame at depth by the calls above it, after the call on
+// top of the stack returned a tail call. The frame at depth is the call whose body made
+// the tail call, and it no longer continues once the tail call is made.
+func (rt *Runtime) CollapseTailCall(depth int) {
+	s := &rt.evaluation.stack
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.frames) < depth+2 {
+		return
+	}
+	top := s.frames[len(s.frames)-1]
+	top.TailCalls += s.frames[depth].TailCalls + 1
+	s.frames[depth] = top
+	s.frames = s.frames[:depth+1]
+}
+
+// Unwind removes the calls made since the stack had depth calls in progress.
+func (rt *Runtime) Unwind(depth int) {
+	s := &rt.evaluation.stack
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if depth < len(s.frames) {
+		clear(s.frames[depth:])
+		s.frames = s.frames[:depth]
+	}
+}
+
+// Traced attaches the calls in progress to err as the stack trace of the condition it is
+// raised as, unless the condition already has one or no call is in progress. An error
+// that is not a condition is returned as a condition wrapping it.
+func (rt *Runtime) Traced(err error) error {
+	s := &rt.evaluation.stack
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.frames) == 0 {
+		return err
+	}
+	var c *values.Condition
+	if !errors.As(err, &c) {
+		c = values.ConditionFromError(err)
+		err = c
+	}
+	if c.Stack == nil {
+		c.Stack = s.innermostFirst()
+	}
+	return err
+}
+
+// Stack returns the calls in progress in the thread of rt, innermost first.
+func (rt *Runtime) Stack() []values.Frame {
+	s := &rt.evaluation.stack
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.innermostFirst()
+}
+
+// innermostFirst returns a copy of the frames of s in reverse order. s must be locked.
+func (s *callStack) innermostFirst() []values.Frame {
+	frames := make([]values.Frame, len(s.frames))
+	for i, frame := range s.frames {
+		frames[len(s.frames)-1-i] = frame
+	}
+	return frames
+}
+
+// procedureName describes proc in stack traces.
+func procedureName(proc values.Interface) string {
+	if l, ok := proc.(interface{ ProcedureName() string }); ok {
+		return l.ProcedureName()
+	}
+	return proc.WriteString()
+}
@@ -0,0 +1,299 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Streams follow SRFI-41. A stream is a promise whose value is either the empty list or
+// a stream pair: a pair of a promise of the first element and the stream of the rest.
+// Both halves of a stream pair are computed on demand and remembered, and the stream
+// procedures are themselves lazy, so they work on streams that are long or infinite.
+
+// streamNull is the empty stream.
+var streamNull = newForcedPromise(values.NewNil())
+
+// streamCons returns a stream pair of the promise car and the stream cdr.
+func streamCons(car, cdr *Promise) *Promise {
+	return newForcedPromise(values.Cons(car, cdr))
+}
+
+// streamArg returns the stream operand v.
+func streamArg(v values.Interface) (*Promise, error) {
+	s, ok := v.(*Promise)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a stream", ErrTypeMismatch, v.WriteString())
+	}
+	return s, nil
+}
+
+// streamNext forces the stream s, returning the promise of its first element and the
+// stream of the rest, or ok false when s is empty.
+func streamNext(s *Promise, rt *Runtime) (car, cdr *Promise, ok bool, err error) {
+	v, err := s.Force(rt)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if v.Type() == types.Nil {
+		return nil, nil, false, nil
+	}
+	if v.Type() == types.Pair {
+		car, carOk := values.Car(v).(*Promise)
+		cdr, cdrOk := values.Cdr(v).(*Promise)
+		if carOk && cdrOk {
+			return car, cdr, true, nil
+		}
+	}
+	return nil, nil, false, fmt.Errorf("%w: %s is not a stream", ErrTypeMismatch, v.WriteString())
+}
+
+// StreamConsImpl implements the stream-cons special form
+// (stream-cons obj stream) returns a stream pair without evaluating obj or stream.
+func StreamConsImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	operands, err := unpackArgs(args, 2, 2)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return streamCons(newPromise(delayed(operands[0], rt, cb)), newLazyPromise(delayed(operands[1], rt, cb))), nil
+}
+
+// IsStreamNullImpl implements the stream-null? procedure
+func IsStreamNullImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return streamTest(args, rt, false)
+}
+
+// IsStreamPairImpl implements the stream-pair? procedure
+func IsStreamPairImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return streamTest(args, rt, true)
+}
+
+func streamTest(args values.Interface, rt *Runtime, pair bool) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	s, ok := operands[0].(*Promise)
+	if !ok {
+		return values.NewBool(false), nil
+	}
+	_, _, ok, err = streamNext(s, rt)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	return values.NewBool(ok == pair), nil
+}
+
+// StreamCarImpl implements the stream-car procedure
+// (stream-car stream) returns the first element of a stream pair.
+func StreamCarImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	car, _, err := streamPairArg(args, rt)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return car.Force(rt)
+}
+
+// StreamCdrImpl implements the stream-cdr procedure
+// (stream-cdr stream) returns the stream of the elements after the first.
+func StreamCdrImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	_, cdr, err := streamPairArg(args, rt)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return cdr, nil
+}
+
+func streamPairArg(args values.Interface, rt *Runtime) (car, cdr *Promise, err error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := streamArg(operands[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	car, cdr, ok, err := streamNext(s, rt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: the stream is empty", ErrBadArgument)
+	}
+	return car, cdr, nil
+}
+
+// StreamTakeImpl implements the stream-take procedure
+// (stream-take n stream) returns the stream of the first n elements of stream.
+func StreamTakeImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	n, err := indexArg(operands[0], -1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	s, err := streamArg(operands[1])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return streamTake(n, s), nil
+}
+
+func streamTake(n int, s *Promise) *Promise {
+	return newLazyPromise(func(rt *Runtime) (values.Interface, error) {
+		if n == 0 {
+			return streamNull, nil
+		}
+		car, cdr, ok, err := streamNext(s, rt)
+		if err != nil || !ok {
+			return streamNull, err
+		}
+		return streamCons(car, streamTake(n-1, cdr)), nil
+	})
+}
+
+// StreamMapImpl implements the stream-map procedure
+// (stream-map proc stream1 stream2 ...) returns the stream of the results of calling proc
+// on the elements of the streams, ending with the shortest stream.
+func StreamMapImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if _, ok := operands[0].(Lambda); !ok {
+		return values.NewVoidType(), fmt.Errorf("%w: %s is not a procedure", ErrTypeMismatch, operands[0].WriteString())
+	}
+	streams := make([]*Promise, len(operands)-1)
+	for i, operand := range operands[1:] {
+		if streams[i], err = streamArg(operand); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	return streamMap(operands[0], streams), nil
+}
+
+func streamMap(proc values.Interface, streams []*Promise) *Promise {
+	return newLazyPromise(func(rt *Runtime) (values.Interface, error) {
+		cars := make([]*Promise, len(streams))
+		cdrs := make([]*Promise, len(streams))
+		for i, s := range streams {
+			car, cdr, ok, err := streamNext(s, rt)
+			if err != nil || !ok {
+				return streamNull, err
+			}
+			cars[i], cdrs[i] = car, cdr
+		}
+		element := newPromise(func(rt *Runtime) (values.Interface, error) {
+			args := make([]values.Interface, len(cars))
+			for i, car := range cars {
+				v, err := car.Force(rt)
+				if err != nil {
+					return values.NewVoidType(), err
+				}
+				args[i] = v
+			}
+			return applyArg(rt, proc, args...)
+		})
+		return streamCons(element, streamMap(proc, cdrs)), nil
+	})
+}
+
+// StreamFilterImpl implements the stream-filter procedure
+// (stream-filter pred stream) returns the stream of the elements of stream satisfying pred.
+func StreamFilterImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	s, err := streamArg(operands[1])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return streamFilter(operands[0], s), nil
+}
+
+func streamFilter(pred values.Interface, s *Promise) *Promise {
+	return newLazyPromise(func(rt *Runtime) (values.Interface, error) {
+		keep := predicate(rt, pred)
+		for {
+			car, cdr, ok, err := streamNext(s, rt)
+			if err != nil || !ok {
+				return streamNull, err
+			}
+			v, err := car.Force(rt)
+			if err != nil {
+				return values.NewVoidType(), err
+			}
+			if ok, err = keep(v); err != nil {
+				return values.NewVoidType(), err
+			}
+			if ok {
+				return streamCons(car, streamFilter(pred, cdr)), nil
+			}
+			if err := rt.Interrupted(); err != nil {
+				return values.NewVoidType(), err
+			}
+			s = cdr
+		}
+	})
+}
+
+// StreamToListImpl implements the stream->list procedure
+// (stream->list stream [n]) returns a list of the first n elements of stream, or of all of them.
+func StreamToListImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	s, err := streamArg(operands[0])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	n := -1
+	if len(operands) == 2 {
+		if n, err = indexArg(operands[1], -1); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	var items []values.Interface
+	for ; n != 0; n-- {
+		car, cdr, ok, err := streamNext(s, rt)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		if !ok {
+			break
+		}
+		v, err := car.Force(rt)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		if err := rt.AllocConsCells(1); err != nil {
+			return values.NewVoidType(), err
+		}
+		items = append(items, v)
+		s = cdr
+	}
+	return values.List(items...), nil
+}
+
+// ListToStreamImpl implements the list->stream procedure
+// (list->stream list) returns a stream of the elements of list.
+func ListToStreamImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	items, err := listArg(operands[0])
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	s := streamNull
+	for i := len(items) - 1; i >= 0; i-- {
+		s = streamCons(newForcedPromise(items[i]), s)
+	}
+	return s, nil
+}
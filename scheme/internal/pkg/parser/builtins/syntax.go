@@ -0,0 +1,321 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// QuoteImpl implements the quote special form
+// It returns its operand unevaluated
+func QuoteImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := unpackArgs(args, 1, 1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewQuot(operands[0]), nil
+}
+
+// DefineImpl implements the define special form
+// (define name expr) binds the value of expr to name in the current environment
+// (define (name . formals) body ...) binds a procedure to name
+func DefineImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	if args.Type() != types.Pair {
+		return values.NewVoidType(), ErrWrongNumberOfArguments
+	}
+	target := values.Car(args)
+	if target.Type() == types.Pair {
+		name, ok := symbolName(values.Car(target))
+		if !ok {
+			return values.NewVoidType(), ErrBadArgument
+		}
+		body, err := values.ToSlice(values.Cdr(args))
+		if err != nil || len(body) == 0 {
+			return values.NewVoidType(), ErrInvalidFormat
+		}
+		rt.Env.Define(name, newClosure(rt, rt.Env, name, args, values.Cdr(target), body, cb))
+		return values.NewVoidType(), nil
+	}
+	name, ok := symbolName(target)
+	if !ok {
+		return values.NewVoidType(), ErrBadArgument
+	}
+	operands, err := unpackArgs(values.Cdr(args), 0, 1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	var value = values.NewVoidType()
+	if len(operands) == 1 {
+		if value, err = cb(operands[0], rt); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	value = values.Unquote(value)
+	// an anonymous procedure takes the name it is first defined with
+	if proc, ok := value.(LambdaExpr); ok && proc.Name == "" {
+		proc.Name = name
+		value = proc
+	}
+	rt.Env.Define(name, value)
+	return values.NewVoidType(), nil
+}
+
+// SetImpl implements the set! special form
+// (set! name expr) assigns the value of expr to the binding of name in the innermost
+// enclosing frame that binds it. Assigning an unbound name is an error.
+func SetImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	operands, err := unpackArgs(args, 2, 2)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	name, ok := symbolName(operands[0])
+	if !ok {
+		return values.NewVoidType(), ErrBadArgument
+	}
+	value, err := cb(operands[1], rt)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if !rt.Env.Set(name, values.Unquote(value)) {
+		return values.NewVoidType(), fmt.Errorf("%w: %s", ErrUndefinedIdent, name)
+	}
+	return values.NewVoidType(), nil
+}
+
+// LambdaImpl implements the lambda special form
+// (lambda formals body ...) returns a procedure closing over the current environment
+func LambdaImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	if args.Type() != types.Pair {
+		return values.NewVoidType(), ErrWrongNumberOfArguments
+	}
+	body, err := values.ToSlice(values.Cdr(args))
+	if err != nil || len(body) == 0 {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	return newClosure(rt, rt.Env, "", args, values.Car(args), body, cb), nil
+}
+
+// CaseLambdaImpl implements the case-lambda special form
+// (case-lambda (formals body ...) ...) returns a procedure that runs the body of the first
+// clause whose formals accept the number of arguments it is called with.
+func CaseLambdaImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	clauses, err := values.ToSlice(args)
+	if err != nil || len(clauses) == 0 {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	type clause struct {
+		arity Arity
+		body  Expression
+	}
+	compiled := make([]clause, len(clauses))
+	for i, c := range clauses {
+		if c.Type() != types.Pair {
+			return values.NewVoidType(), fmt.Errorf("%w: case-lambda clause %s", ErrInvalidFormat, c.WriteString())
+		}
+		body, err := values.ToSlice(values.Cdr(c))
+		if err != nil || len(body) == 0 {
+			return values.NewVoidType(), fmt.Errorf("%w: case-lambda clause %s", ErrInvalidFormat, c.WriteString())
+		}
+		compiled[i] = clause{arity: formalsArity(values.Car(c)), body: NewExpression(rt.Env, values.Car(c), body, cb)}
+	}
+	// the procedure reports the range spanning its clauses, while a call must still match a clause
+	arity := compiled[0].arity
+	for _, c := range compiled[1:] {
+		arity = arity.union(c.arity)
+	}
+	return LambdaExpr{Source: rt.definedAt(args), Runtime: rt, arity: arity, compound: true, Body: func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		n, err := argCount(args)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		for _, c := range compiled {
+			if c.arity.Accepts(n) {
+				return c.body(args, rt)
+			}
+		}
+		return values.NewVoidType(), fmt.Errorf("%w: no case-lambda clause accepts %d", ErrWrongNumberOfArguments, n)
+	}}, nil
+}
+
+// IfImpl implements the if special form
+// (if test consequent [alternate]) evaluates consequent when test is truthy, otherwise alternate
+func IfImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	operands, err := unpackArgs(args, 2, 3)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	test, err := cb(operands[0], rt)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if test.IsTruthy() {
+		return TailCall{Expr: operands[1], Runtime: rt}, nil
+	}
+	if len(operands) == 3 {
+		return TailCall{Expr: operands[2], Runtime: rt}, nil
+	}
+	return values.NewVoidType(), nil
+}
+
+// BeginImpl implements the begin special form
+// It evaluates each expression in order and returns the value of the last one
+func BeginImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	body, err := values.ToSlice(args)
+	if err != nil {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	return evalBody(body, rt, cb)
+}
+
+// CondImpl implements the cond special form
+// (cond (test expr ...) ... [(else expr ...)]) evaluates the expressions of the first clause whose
+// test is truthy; (test => receiver) calls receiver with the value of test instead
+func CondImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	clauses, err := values.ToSlice(args)
+	if err != nil {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	result, _, err := condClauses(clauses, rt, cb)
+	return result, err
+}
+
+// condClauses evaluates the first matching cond clause, reporting whether any clause matched.
+func condClauses(clauses []values.Interface, rt *Runtime, cb Expression) (values.Interface, bool, error) {
+	for _, clause := range clauses {
+		parts, err := unpackArgs(clause, 1, -1)
+		if err != nil {
+			return values.NewVoidType(), false, ErrInvalidFormat
+		}
+		if name, ok := symbolName(parts[0]); ok && name == "else" {
+			result, err := evalBody(parts[1:], rt, cb)
+			return result, true, err
+		}
+		test, err := cb(parts[0], rt)
+		if err != nil {
+			return values.NewVoidType(), false, err
+		}
+		if !test.IsTruthy() {
+			continue
+		}
+		if len(parts) == 1 {
+			return test, true, nil
+		}
+		if name, ok := symbolName(parts[1]); ok && name == "=>" {
+			if len(parts) != 3 {
+				return values.NewVoidType(), true, ErrInvalidFormat
+			}
+			receiver, err := cb(parts[2], rt)
+			if err != nil {
+				return values.NewVoidType(), true, err
+			}
+			proc, ok := receiver.(Lambda)
+			if !ok {
+				return values.NewVoidType(), true, ErrOperatorIsNotAProcedure
+			}
+			result, err := rt.Apply(proc, values.List(values.Unquote(test)))
+			return result, true, err
+		}
+		result, err := evalBody(parts[1:], rt, cb)
+		return result, true, err
+	}
+	return values.NewVoidType(), false, nil
+}
+
+// LetImpl implements the let special form
+// (let ((name expr) ...) body ...) evaluates body in a new frame binding each name to the value of expr
+// (let loop ((name expr) ...) body ...) additionally binds loop to a procedure taking the names as formals
+func LetImpl(args values.Interface, rt *Runtime, cb Expression) (values.Interface, error) {
+	if args.Type() != types.Pair {
+		return values.NewVoidType(), ErrWrongNumberOfArguments
+	}
+	loopName, named := symbolName(values.Car(args))
+	if named {
+		args = values.Cdr(args)
+		if args.Type() != types.Pair {
+			return values.NewVoidType(), ErrWrongNumberOfArguments
+		}
+	}
+	bindings, err := values.ToSlice(values.Car(args))
+	if err != nil {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	body, err := values.ToSlice(values.Cdr(args))
+	if err != nil || len(body) == 0 {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	var (
+		formals = make([]values.Interface, 0, len(bindings))
+		actuals = make([]values.Interface, 0, len(bindings))
+	)
+	for _, binding := range bindings {
+		parts, err := unpackArgs(binding, 1, 2)
+		if err != nil {
+			return values.NewVoidType(), ErrInvalidFormat
+		}
+		if _, ok := symbolName(parts[0]); !ok {
+			return values.NewVoidType(), ErrBadArgument
+		}
+		var value = values.NewVoidType()
+		if len(parts) == 2 {
+			if value, err = cb(parts[1], rt); err != nil {
+				return values.NewVoidType(), err
+			}
+		}
+		formals = append(formals, parts[0])
+		actuals = append(actuals, values.Unquote(value))
+	}
+	frame := ExtendEnvironment(rt.Env)
+	if named {
+		frame.Define(loopName, newClosure(rt, frame, loopName, args, values.List(formals...), body, cb))
+		frame = ExtendEnvironment(frame)
+	}
+	if err := bindFormals(&frame, values.List(formals...), values.List(actuals...)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return evalBody(body, rt.WithEnvironment(frame), cb)
+}
+
+// symbolName returns the name of an identifier or operator symbol.
+func symbolName(v values.Interface) (string, bool) {
+	switch sym := v.(type) {
+	case values.Identifier:
+		return sym.GetName(), true
+	case values.Operator:
+		return sym.GetName(), true
+	}
+	return "", false
+}
+
+// unpackArgs converts the operands of a special form to a slice, checking that it holds between
+// minimum and maximum elements. A negative maximum allows any number of elements.
+func unpackArgs(args values.Interface, minimum, maximum int) ([]values.Interface, error) {
+	items, err := values.ToSlice(args)
+	if err != nil {
+		return items, ErrInvalidFormat
+	}
+	if len(items) < minimum || (maximum >= 0 && len(items) > maximum) {
+		return items, fmt.Errorf("%w: expected %s, got %d", ErrWrongNumberOfArguments, arityString(minimum, maximum), len(items))
+	}
+	return items, nil
+}
+
+// procedureArgs converts the argument list of a procedure to a slice. Its length
+// has already been checked against the arity the procedure was defined with.
+func procedureArgs(args values.Interface) ([]values.Interface, error) {
+	items, err := values.ToSlice(args)
+	if err != nil {
+		return items, ErrInvalidFormat
+	}
+	return items, nil
+}
+
+func arityString(minimum, maximum int) string {
+	switch {
+	case maximum < 0:
+		return fmt.Sprintf("at least %d", minimum)
+	case minimum == maximum:
+		return fmt.Sprintf("%d", minimum)
+	}
+	return fmt.Sprintf("%d to %d", minimum, maximum)
+}
@@ -0,0 +1,610 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Thread is a SRFI-18 thread. A started thread runs its thunk on its own goroutine, in a
+// runtime sharing the global environment and resource budget of the thread that started it.
+// The thread inherits the parameterizations in effect where it was made.
+type Thread struct {
+	name       values.Interface
+	thunk      Lambda
+	dynamic    *dynamicBinding
+	started    atomic.Bool
+	terminated atomic.Bool
+	done       chan struct{}
+	result     values.Interface
+	err        error
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	specific values.Interface
+	// owned holds the mutexes the thread owns, which it abandons when it terminates
+	owned map[*Mutex]struct{}
+}
+
+func newThread(thunk Lambda, name values.Interface) *Thread {
+	return &Thread{
+		name:     name,
+		thunk:    thunk,
+		done:     make(chan struct{}),
+		specific: values.NewVoidType(),
+	}
+}
+
+// newPrimordialThread returns the thread object of a top level evaluation.
+func newPrimordialThread() *Thread {
+	t := newThread(nil, values.NewIdentifier("primordial"))
+	t.started.Store(true)
+	return t
+}
+
+// start runs the thread's thunk on a new goroutine with a runtime forked from rt.
+func (t *Thread) start(rt *Runtime) {
+	ctx, cancel := context.WithCancel(rt.Context())
+	child := rt.WithContext(ctx)
+	child.evaluation = rt.evaluation.fork(t)
+	child.dynamic = t.dynamic
+	t.mu.Lock()
+	t.cancel = cancel
+	t.mu.Unlock()
+	if t.terminated.Load() {
+		cancel()
+	}
+	go func() {
+		defer close(t.done)
+		defer t.abandon()
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				t.result, t.err = values.NewVoidType(), fmt.Errorf("thread %s panicked: %v", t.name.DisplayString(), r)
+			}
+		}()
+		t.result, t.err = child.Apply(t.thunk, values.NewNil())
+	}()
+}
+
+// terminate cancels the evaluation of the thread, before or after it starts.
+func (t *Thread) terminate() {
+	if t.terminated.Swap(true) {
+		return
+	}
+	t.mu.Lock()
+	cancel := t.cancel
+	t.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// own records that the thread owns m.
+func (t *Thread) own(m *Mutex) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.owned == nil {
+		t.owned = make(map[*Mutex]struct{})
+	}
+	t.owned[m] = struct{}{}
+}
+
+// disown records that the thread no longer owns m.
+func (t *Thread) disown(m *Mutex) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.owned, m)
+}
+
+// abandon unlocks the mutexes the terminated thread still owns, leaving them abandoned.
+func (t *Thread) abandon() {
+	t.mu.Lock()
+	owned := t.owned
+	t.owned = nil
+	t.mu.Unlock()
+	for m := range owned {
+		if m.owner.CompareAndSwap(t, nil) {
+			m.abandoned.Store(true)
+			m.release()
+		}
+	}
+}
+
+func (t *Thread) Equal(p values.Interface) bool {
+	other, ok := p.(*Thread)
+	return ok && other == t
+}
+
+func (t *Thread) Type() types.Type {
+	return types.Thread
+}
+
+func (t *Thread) IsTruthy() bool {
+	return true
+}
+
+func (t *Thread) DisplayString() string {
+	return describeNamed("thread", t.name)
+}
+
+func (t *Thread) WriteString() string {
+	return t.DisplayString()
+}
+
+// Mutex is a SRFI-18 mutex. Locking blocks until the mutex is available, a timeout
+// expires or the evaluation is canceled. A mutex whose owner terminates is unlocked and
+// abandoned until it is locked again.
+type Mutex struct {
+	name      values.Interface
+	sem       chan struct{}
+	owner     atomic.Pointer[Thread]
+	abandoned atomic.Bool
+}
+
+func (m *Mutex) Equal(p values.Interface) bool {
+	other, ok := p.(*Mutex)
+	return ok && other == m
+}
+
+func (m *Mutex) Type() types.Type {
+	return types.Mutex
+}
+
+func (m *Mutex) IsTruthy() bool {
+	return true
+}
+
+func (m *Mutex) DisplayString() string {
+	return describeNamed("mutex", m.name)
+}
+
+func (m *Mutex) WriteString() string {
+	return m.DisplayString()
+}
+
+// lock records that the mutex was locked for owner, or for no thread when owner is nil.
+// It reports whether the mutex was abandoned.
+func (m *Mutex) lock(owner *Thread) bool {
+	m.owner.Store(owner)
+	if owner != nil {
+		owner.own(m)
+	}
+	return m.abandoned.Swap(false)
+}
+
+// unlock releases the mutex, reporting whether it was locked.
+func (m *Mutex) unlock() bool {
+	if owner := m.owner.Swap(nil); owner != nil {
+		owner.disown(m)
+	}
+	return m.release()
+}
+
+// release releases the lock of the mutex, reporting whether it was locked.
+func (m *Mutex) release() bool {
+	select {
+	case <-m.sem:
+		return true
+	default:
+		return false
+	}
+}
+
+// ConditionVariable is a SRFI-18 condition variable. Threads wait on it with mutex-unlock!
+// and are woken by condition-variable-signal! and condition-variable-broadcast!.
+type ConditionVariable struct {
+	name    values.Interface
+	mu      sync.Mutex
+	waiters []chan struct{}
+}
+
+func (cv *ConditionVariable) Equal(p values.Interface) bool {
+	other, ok := p.(*ConditionVariable)
+	return ok && other == cv
+}
+
+func (cv *ConditionVariable) Type() types.Type {
+	return types.ConditionVariable
+}
+
+func (cv *ConditionVariable) IsTruthy() bool {
+	return true
+}
+
+func (cv *ConditionVariable) DisplayString() string {
+	return describeNamed("condition-variable", cv.name)
+}
+
+func (cv *ConditionVariable) WriteString() string {
+	return cv.DisplayString()
+}
+
+func (cv *ConditionVariable) wait() chan struct{} {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	waiter := make(chan struct{})
+	cv.waiters = append(cv.waiters, waiter)
+	return waiter
+}
+
+// cancelWait removes a waiter whose wait timed out.
+func (cv *ConditionVariable) cancelWait(waiter chan struct{}) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	for i, w := range cv.waiters {
+		if w == waiter {
+			cv.waiters = append(cv.waiters[:i], cv.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// wake wakes up to n waiting threads, or all of them when n is negative.
+func (cv *ConditionVariable) wake(n int) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	if n < 0 || n > len(cv.waiters) {
+		n = len(cv.waiters)
+	}
+	for _, waiter := range cv.waiters[:n] {
+		close(waiter)
+	}
+	cv.waiters = cv.waiters[n:]
+}
+
+// MakeThreadImpl implements the make-thread procedure
+// (make-thread thunk [name]) returns a new thread that runs thunk once started
+func MakeThreadImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	thunk, ok := operands[0].(Lambda)
+	if !ok {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	t := newThread(thunk, nameArg(operands, 1))
+	t.dynamic = rt.dynamic
+	return t, nil
+}
+
+// ThreadStartImpl implements the thread-start! procedure
+// It starts the thread on a new goroutine and returns the thread
+func ThreadStartImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	t, _, err := threadArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if t.started.Swap(true) {
+		return values.NewVoidType(), fmt.Errorf("%w: %s already started", ErrBadArgument, t.DisplayString())
+	}
+	t.start(rt)
+	return t, nil
+}
+
+// ThreadJoinImpl implements the thread-join! procedure
+// (thread-join! thread [timeout [timeout-val]]) waits for thread to terminate and returns the
+// result of its thunk. An exception raised by the thread is raised again in the joining thread.
+// Without a timeout, joining a thread that was not started, or the current thread, raises
+// ErrDeadlock, as the join could never end.
+func ThreadJoinImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	t, operands, err := threadArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	timeout, stop, err := timeoutArg(operands, 1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	defer stop()
+	switch {
+	case timeout != nil:
+	case !t.started.Load():
+		return values.NewVoidType(), fmt.Errorf("%w: %s joined before it was started", ErrDeadlock, t.DisplayString())
+	case t == rt.evaluation.thread:
+		return values.NewVoidType(), fmt.Errorf("%w: %s joined itself", ErrDeadlock, t.DisplayString())
+	}
+	select {
+	case <-t.done:
+	case <-timeout:
+		if len(operands) == 3 {
+			return operands[2], nil
+		}
+		return values.NewVoidType(), fmt.Errorf("%w: %s", ErrJoinTimeout, t.DisplayString())
+	case <-rt.Context().Done():
+		return values.NewVoidType(), rt.Context().Err()
+	}
+	switch {
+	case t.terminated.Load():
+		return values.NewVoidType(), fmt.Errorf("%w: %s", ErrThreadTerminated, t.DisplayString())
+	case t.err != nil:
+		return values.NewVoidType(), fmt.Errorf("%w in %s: %w", ErrUncaughtException, t.DisplayString(), t.err)
+	}
+	return t.result, nil
+}
+
+// ThreadTerminateImpl implements the thread-terminate! procedure
+// The thread stops before its next evaluation step; joining it raises ErrThreadTerminated
+func ThreadTerminateImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	t, _, err := threadArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	t.terminate()
+	return values.NewVoidType(), nil
+}
+
+// CurrentThreadImpl implements the current-thread procedure
+func CurrentThreadImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	return rt.evaluation.thread, nil
+}
+
+// ThreadNameImpl implements the thread-name procedure
+func ThreadNameImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	t, _, err := threadArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return t.name, nil
+}
+
+// ThreadSpecificImpl implements the thread-specific procedure
+func ThreadSpecificImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	t, _, err := threadArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.specific, nil
+}
+
+// ThreadSpecificSetImpl implements the thread-specific-set! procedure
+func ThreadSpecificSetImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	t, operands, err := threadArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.specific = operands[1]
+	return values.NewVoidType(), nil
+}
+
+// ThreadYieldImpl implements the thread-yield! procedure
+func ThreadYieldImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	runtime.Gosched()
+	return values.NewVoidType(), nil
+}
+
+// ThreadSleepImpl implements the thread-sleep! procedure
+// (thread-sleep! seconds) suspends the current thread for the given number of seconds
+func ThreadSleepImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	timeout, stop, err := timeoutArg(operands, 0)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	defer stop()
+	select {
+	case <-timeout:
+		return values.NewVoidType(), nil
+	case <-rt.Context().Done():
+		return values.NewVoidType(), rt.Context().Err()
+	}
+}
+
+// MakeMutexImpl implements the make-mutex procedure
+func MakeMutexImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return &Mutex{
+		name: nameArg(operands, 0),
+		sem:  make(chan struct{}, 1),
+	}, nil
+}
+
+// MutexNameImpl implements the mutex-name procedure
+func MutexNameImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	m, _, err := mutexArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return m.name, nil
+}
+
+// MutexStateImpl implements the mutex-state procedure
+// It returns the owning thread of a locked mutex, the symbol not-owned for a mutex locked for
+// no thread, abandoned for an unlocked mutex whose owner terminated and not-abandoned for
+// any other unlocked one
+func MutexStateImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	m, _, err := mutexArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if len(m.sem) == 0 {
+		if m.abandoned.Load() {
+			return values.NewIdentifier("abandoned"), nil
+		}
+		return values.NewIdentifier("not-abandoned"), nil
+	}
+	if owner := m.owner.Load(); owner != nil {
+		return owner, nil
+	}
+	return values.NewIdentifier("not-owned"), nil
+}
+
+// MutexLockImpl implements the mutex-lock! procedure
+// (mutex-lock! mutex [timeout [thread]]) locks mutex, returning #f if the timeout expires first.
+// The mutex is owned by thread, by default the current thread, or by no thread when thread is #f.
+// Mutexes are not recursive: without a timeout, locking a mutex the current thread owns
+// raises ErrDeadlock, as the lock could never be acquired. Locking a mutex whose owner
+// terminated while owning it locks it and raises ErrAbandonedMutex.
+func MutexLockImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	m, operands, err := mutexArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	timeout, stop, err := timeoutArg(operands, 1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	defer stop()
+	owner := rt.evaluation.thread
+	if len(operands) == 3 {
+		t, ok := operands[2].(*Thread)
+		switch {
+		case ok:
+			owner = t
+		case operands[2].Type() == types.Bool && !operands[2].IsTruthy():
+			owner = nil
+		default:
+			return values.NewVoidType(), ErrTypeMismatch
+		}
+	}
+	if timeout == nil && m.owner.Load() == rt.evaluation.thread {
+		return values.NewVoidType(), fmt.Errorf("%w: %s is already locked by %s", ErrDeadlock, m.DisplayString(), rt.evaluation.thread.DisplayString())
+	}
+	select {
+	case m.sem <- struct{}{}:
+		if m.lock(owner) {
+			return values.NewVoidType(), fmt.Errorf("%w: %s", ErrAbandonedMutex, m.DisplayString())
+		}
+		return values.NewBool(true), nil
+	case <-timeout:
+		return values.NewBool(false), nil
+	case <-rt.Context().Done():
+		return values.NewVoidType(), rt.Context().Err()
+	}
+}
+
+// MutexUnlockImpl implements the mutex-unlock! procedure
+// (mutex-unlock! mutex [condition-variable [timeout]]) unlocks mutex. Given a condition variable
+// it then waits until the condition variable is signaled, returning #f if the timeout expires first.
+// The mutex is not locked again when the wait ends.
+func MutexUnlockImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	m, operands, err := mutexArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if len(operands) == 1 {
+		m.unlock()
+		return values.NewBool(true), nil
+	}
+	cv, ok := operands[1].(*ConditionVariable)
+	if !ok {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	timeout, stop, err := timeoutArg(operands, 2)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	defer stop()
+	waiter := cv.wait()
+	m.unlock()
+	select {
+	case <-waiter:
+		return values.NewBool(true), nil
+	case <-timeout:
+		cv.cancelWait(waiter)
+		return values.NewBool(false), nil
+	case <-rt.Context().Done():
+		cv.cancelWait(waiter)
+		return values.NewVoidType(), rt.Context().Err()
+	}
+}
+
+// MakeConditionVariableImpl implements the make-condition-variable procedure
+func MakeConditionVariableImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return &ConditionVariable{name: nameArg(operands, 0)}, nil
+}
+
+// conditionVariableWake returns the condition-variable-signal! procedure when n is 1
+// and the condition-variable-broadcast! procedure when n is negative
+func conditionVariableWake(n int) Expression {
+	return func(args values.Interface, rt *Runtime) (values.Interface, error) {
+		operands, err := procedureArgs(args)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		cv, ok := operands[0].(*ConditionVariable)
+		if !ok {
+			return values.NewVoidType(), ErrTypeMismatch
+		}
+		cv.wake(n)
+		return values.NewVoidType(), nil
+	}
+}
+
+func threadArgs(args values.Interface) (*Thread, []values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return nil, operands, err
+	}
+	t, ok := operands[0].(*Thread)
+	if !ok {
+		return nil, operands, ErrTypeMismatch
+	}
+	return t, operands, nil
+}
+
+func mutexArgs(args values.Interface) (*Mutex, []values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return nil, operands, err
+	}
+	m, ok := operands[0].(*Mutex)
+	if !ok {
+		return nil, operands, ErrTypeMismatch
+	}
+	return m, operands, nil
+}
+
+// nameArg returns the optional name at index i of operands, defaulting to the empty string.
+func nameArg(operands []values.Interface, i int) values.Interface {
+	if len(operands) > i {
+		return operands[i]
+	}
+	return values.NewString("")
+}
+
+// describeNamed returns the printed form of an object of the kind given with name, such
+// as #<thread worker>, or #<thread> when its name is the empty string.
+func describeNamed(kind string, name values.Interface) string {
+	if s := name.DisplayString(); s != "" {
+		return "#<" + kind + " " + s + ">"
+	}
+	return "#<" + kind + ">"
+}
+
+// timeoutArg converts the optional timeout at index i of operands, a number of seconds, to a
+// channel receiving when it expires. The channel is nil, and never receives, without a timeout
+// or when the timeout is #f. The returned function releases the timer.
+func timeoutArg(operands []values.Interface, i int) (<-chan time.Time, func(), error) {
+	if len(operands) <= i || (operands[i].Type() == types.Bool && !operands[i].IsTruthy()) {
+		return nil, func() {}, nil
+	}
+	n, ok := operands[i].(values.Numeric)
+	if !ok {
+		return nil, func() {}, ErrTypeMismatch
+	}
+	seconds, _ := n.AsFloat()
+	timer := time.NewTimer(time.Duration(seconds * float64(time.Second)))
+	return timer.C, func() { timer.Stop() }, nil
+}
@@ -0,0 +1,149 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// VectorImpl implements the vector procedure
+// It returns a newly allocated vector of its arguments
+func VectorImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	items, err := values.ToSlice(args)
+	if err != nil {
+		return values.NewVoidType(), ErrInvalidFormat
+	}
+	if err := rt.AllocConsCells(len(items)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewVector(items...), nil
+}
+
+// MakeVectorImpl implements the make-vector procedure
+// (make-vector k [fill]) returns a vector of k elements, each initialized to fill
+func MakeVectorImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	k, err := indexArg(operands[0], -1)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(k); err != nil {
+		return values.NewVoidType(), err
+	}
+	var fill = values.NewBool(false)
+	if len(operands) == 2 {
+		fill = operands[1]
+	}
+	items := make([]values.Interface, k)
+	for i := range items {
+		items[i] = fill
+	}
+	return values.NewVector(items...), nil
+}
+
+// IsVectorImpl implements the vector? procedure
+func IsVectorImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewBool(false), err
+	}
+	return values.NewBool(operands[0].Type() == types.Vector), nil
+}
+
+// VectorLengthImpl implements the vector-length procedure
+func VectorLengthImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	vec, _, err := vectorArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewInt(int64(vec.Len())), nil
+}
+
+// VectorRefImpl implements the vector-ref procedure
+// (vector-ref vector k) returns element k of vector
+func VectorRefImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	vec, operands, err := vectorArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	k, err := indexArg(operands[1], vec.Len())
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	return vec.Ref(k), nil
+}
+
+// VectorSetImpl implements the vector-set! procedure
+// (vector-set! vector k obj) stores obj in element k of vector
+func VectorSetImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	vec, operands, err := vectorArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	k, err := indexArg(operands[1], vec.Len())
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := vec.Set(k, operands[2]); err != nil {
+		return values.NewVoidType(), fmt.Errorf("%w: %s", err, vec.WriteString())
+	}
+	return values.NewVoidType(), nil
+}
+
+// VectorToListImpl implements the vector->list procedure
+func VectorToListImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	vec, _, err := vectorArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if err := rt.AllocConsCells(vec.Len()); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.List(vec.Items()...), nil
+}
+
+// ListToVectorImpl implements the list->vector procedure
+func ListToVectorImpl(args values.Interface, rt *Runtime) (values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	items, err := values.ToSlice(operands[0])
+	if err != nil {
+		return values.NewVoidType(), ErrTypeMismatch
+	}
+	if err := rt.AllocConsCells(len(items)); err != nil {
+		return values.NewVoidType(), err
+	}
+	return values.NewVector(items...), nil
+}
+
+// vectorArgs unpacks an argument list whose first element must be a vector.
+func vectorArgs(args values.Interface) (values.Vector, []values.Interface, error) {
+	operands, err := procedureArgs(args)
+	if err != nil {
+		return nil, operands, err
+	}
+	vec, ok := operands[0].(values.Vector)
+	if !ok {
+		return nil, operands, ErrTypeMismatch
+	}
+	return vec, operands, nil
+}
+
+// indexArg converts v to an index below length. A negative length only checks that v is a non-negative integer.
+func indexArg(v values.Interface, length int) (int, error) {
+	n, ok := v.(values.Numeric)
+	if !ok || !n.IsInteger() {
+		return 0, ErrTypeMismatch
+	}
+	i, _ := n.AsInt()
+	if i < 0 || (length >= 0 && i >= int64(length)) {
+		return 0, fmt.Errorf("%w: %d", ErrIndexOutOfRange, i)
+	}
+	return int(i), nil
+}
@@ -0,0 +1,224 @@
+package parser
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+// modes are the ways of evaluating datums, each made by its parser options.
+var modes = []struct {
+	name string
+	opts []Option
+}{
+	{"tree", nil},
+	{"bytecode", []Option{WithBytecode(true)}},
+	{"analysis", []Option{WithAnalysis(true)}},
+}
+
+func TestCompiled(t *testing.T) {
+	tests := []evalTest{
+		{
+			name:      "recursion",
+			src:       "(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2))))) (fib 15)",
+			wantWrite: "610",
+		},
+		{
+			name: "closures share their variables",
+			src: `(define (counter)
+			        (define n 0)
+			        (lambda () (set! n (+ n 1)) n))
+			      (define c (counter))
+			      (c) (c) (list (c) ((counter)))`,
+			wantWrite: "(3 1)",
+		},
+		{
+			name:      "named let and tail calls",
+			src:       "(let loop ((i 0) (acc '())) (if (= i 100000) (length acc) (loop (+ i 1) (if (< i 3) (cons i acc) acc))))",
+			wantWrite: "3",
+		},
+		{
+			name:      "let shadows globals",
+			src:       "(define x 1) (define (f x) (let ((x (* x 2)) (y x)) (list x y))) (f 5)",
+			wantWrite: "(10 5)",
+		},
+		{
+			name:      "cond clauses",
+			src:       "(define (f x) (cond ((if (= x 1) '(one) #f) => car) ((= x 2)) ((= x 3) 'three) (else 'other))) (list (f 1) (f 2) (f 3) (f 4))",
+			wantWrite: "(one #t three other)",
+		},
+		{
+			name:      "rest parameters",
+			src:       "(define (f a . rest) (list a rest)) (define g (lambda args args)) (list (f 1 2 3) (g) (g 1))",
+			wantWrite: "((1 (2 3)) () (1))",
+		},
+		{
+			name:      "quoted data",
+			src:       "(define (f) '(a (b c) #(1 2))) (f)",
+			wantWrite: "(a (b c) #(1 2))",
+		},
+		{
+			name:      "higher order builtins",
+			src:       "(define (square x) (* x x)) (map (lambda (x) (+ (square x) 1)) '(1 2 3))",
+			wantWrite: "(2 5 10)",
+		},
+		{
+			name:      "other special forms fall back to the evaluator",
+			src:       "(define-record-type point (make-point x y) point? (x point-x) (y point-y)) (define (f p) (guard (e (#t 'caught)) (point-x p))) (list (f (make-point 1 2)) (f 3))",
+			wantWrite: "(1 caught)",
+		},
+		{
+			name:      "procedures are named by define",
+			src:       "(define f (lambda (x) x)) f",
+			wantWrite: "#<procedure f at line 1>",
+		},
+		{
+			name:      "arithmetic on integers and floats",
+			src:       "(define (f a b) (list (+ a b) (- a b) (< a b) (>= a b) (= a b) (not a))) (list (f 1 2) (f 1.5 1) (f 1000 -1000))",
+			wantWrite: "((3 -1 #t #f #f #f) (2.5 0.5 #f #t #f #f) (0 2000 #f #t #f #f))",
+		},
+		{
+			name:      "numeric equality across integers and floats",
+			src:       "(define (f a b) (= a b)) (list (= 1 1.0) (= 2 (/ 4 2) 2.0) (f 1 1.0) (f 2 2.5) (eqv? 1 1.0) (equal? '(2) '(2.0)))",
+			wantWrite: "(#t #t #t #f #f #f)",
+		},
+		{
+			name:    "numeric equality of other values",
+			src:     "(define (f a b) (= a b)) (f 'a 'a)",
+			wantErr: builtins.ErrNumberExpected,
+		},
+		{
+			name:      "redefined builtins are called",
+			src:       "(define (f) (+ 2 3)) (define a (f)) (set! + (lambda (x y) (* x y))) (list a (f))",
+			wantWrite: "(5 6)",
+		},
+		{
+			name:      "builtins rebound to other builtins are called",
+			src:       "(define (f) (car '(1 2))) (define a (f)) (set! car cdr) (list a (f))",
+			wantWrite: "(1 (2))",
+		},
+		{
+			name:      "builtins shadowed by locals are called",
+			src:       "(define (f + x) (+ x 1)) (let ((not car)) (list (f - 5) (not '(1))))",
+			wantWrite: "(4 1)",
+		},
+		{
+			name:      "folded calls of redefined builtins are made",
+			src:       "(define (f) (* (+ 1 2) (if (< 1 2) 4 (car '())))) (define a (f)) (set! + -) (list a (f))",
+			wantWrite: "(12 -4)",
+		},
+		{
+			name:      "calls failing when folded are left to run",
+			src:       "(define (f x) (if x (+ 1 'a) 'ok)) (f #f)",
+			wantWrite: "ok",
+		},
+		{
+			name: "internal definitions refer to each other",
+			src: `(define (f n)
+			        (define (even? n) (if (= n 0) #t (odd? (- n 1))))
+			        (define (odd? n) (if (= n 0) #f (even? (- n 1))))
+			        (list (even? n) (odd? n)))
+			      (f 1001)`,
+			wantWrite: "(#f #t)",
+		},
+		{
+			name:    "wrong number of arguments",
+			src:     "(define (f a b) a) (f 1)",
+			wantErr: builtins.ErrWrongNumberOfArguments,
+		},
+		{
+			name:    "undefined identifiers",
+			src:     "(define (f) (g)) (f)",
+			wantErr: builtins.ErrUndefinedIdent,
+		},
+		{
+			name:    "step limit",
+			src:     "(let loop ((i 0)) (loop (+ i 1)))",
+			opts:    []builtins.OptionRuntime{builtins.WithMaxSteps(1000)},
+			wantErr: builtins.ErrResourceLimitExceeded,
+		},
+		{
+			name:    "depth limit",
+			src:     "(define (sum n) (if (= n 0) 0 (+ n (sum (- n 1))))) (sum 1000)",
+			opts:    []builtins.OptionRuntime{builtins.WithMaxDepth(100)},
+			wantErr: builtins.ErrResourceLimitExceeded,
+		},
+	}
+	for _, tt := range tests {
+		for _, mode := range modes {
+			t.Run(tt.name+"/"+mode.name, func(t *testing.T) {
+				tt.run(t, mode.opts)
+			})
+		}
+	}
+}
+
+func TestCompiledStackTraces(t *testing.T) {
+	tests := []evalTest{
+		{
+			name: "nested calls",
+			src: `(define (inner x) (car x))
+			      (define (outer x) (+ 1 (inner x)))
+			      (outer 5)`,
+			wantStack: []string{"car, called at line 1", "inner, called at line 2", "outer, called at line 3"},
+		},
+		{
+			name: "tail calls are collapsed",
+			src: `(define (loop n) (if (= n 0) (raise 'done) (loop (- n 1))))
+			      (loop 50)`,
+			wantStack: []string{"raise, called at line 1", "loop, called at line 1, after 50 tail calls"},
+		},
+		{
+			name:      "procedures called from builtins",
+			src:       "(define (f) (map (lambda (x) (error \"bad\" x)) '(1)))\n(f)",
+			wantStack: []string{"error, called at line 1", "anonymous procedure at line 1", "map, called at line 1", "f, called at line 2"},
+		},
+		{
+			name:      "receivers of cond clauses",
+			src:       "(cond ((car '(1)) => (lambda (x) (error \"bad\" x))))",
+			wantStack: []string{"error, called at line 1", "anonymous procedure at line 1"},
+		},
+	}
+	for _, tt := range tests {
+		for _, mode := range modes[1:] {
+			t.Run(tt.name+"/"+mode.name, func(t *testing.T) {
+				tt.run(t, mode.opts)
+			})
+		}
+	}
+}
+
+const (
+	fibSource = "(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))"
+	takSource = "(define (tak x y z) (if (not (< y x)) z (tak (tak (- x 1) y z) (tak (- y 1) z x) (tak (- z 1) x y))))"
+)
+
+func BenchmarkFib(b *testing.B) {
+	benchmarkEval(b, fibSource, "(fib 20)")
+}
+
+func BenchmarkTak(b *testing.B) {
+	benchmarkEval(b, takSource, "(tak 18 12 6)")
+}
+
+// benchmarkEval runs call once per iteration after evaluating def, in every mode.
+func benchmarkEval(b *testing.B, def, call string) {
+	for _, mode := range modes {
+		b.Run(mode.name, func(b *testing.B) {
+			rt := newTestRuntime(io.Discard)
+			ctx := context.Background()
+			if _, err := EvalReader(ctx, strings.NewReader(def), rt, mode.opts...); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for b.Loop() {
+				if _, err := EvalReader(ctx, strings.NewReader(call), rt, mode.opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestEvalContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		timeout time.Duration
+		wantErr error
+	}{
+		{
+			name:    "deadline stops a tail recursive loop",
+			src:     "(let loop ((i 0)) (loop (+ i 1)))",
+			timeout: 50 * time.Millisecond,
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "guard does not catch cancellation",
+			src:     "(guard (e (#t 'caught)) (let loop () (loop)))",
+			timeout: 50 * time.Millisecond,
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "canceled before evaluation",
+			src:     "(+ 1 2)",
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := newTestRuntime(io.Discard)
+			var (
+				ctx    context.Context
+				cancel context.CancelFunc
+			)
+			if tt.timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), tt.timeout)
+			} else {
+				ctx, cancel = context.WithCancel(context.Background())
+				cancel()
+			}
+			defer cancel()
+			_, err := EvalString(ctx, tt.src, rt)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("EvalString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvalContextDoesNotOutliveEvaluation(t *testing.T) {
+	rt := newTestRuntime(io.Discard)
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := EvalString(ctx, "(define (inc n) (+ n 1))", rt); err != nil {
+		t.Fatalf("EvalString() error = %v", err)
+	}
+	cancel()
+	if _, err := EvalString(context.Background(), "(inc 1)", rt); err != nil {
+		t.Errorf("EvalString() error = %v after the defining context was canceled", err)
+	}
+}
+
+func TestEvalContextInterruptsBlockingReads(t *testing.T) {
+	in, w := io.Pipe()
+	defer w.Close()
+	rt := newTestRuntime(io.Discard, builtins.WithIn(in))
+	for _, src := range []string{"(read-line)", "(read-char)"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := EvalReader(ctx, bytes.NewBufferString(src), rt)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("EvalReader(%s) error = %v, wantErr %v", src, err, context.DeadlineExceeded)
+		}
+	}
+	// the input that arrives after a read was interrupted is read by the next read
+	go func() { _, _ = io.WriteString(w, "line\n") }()
+	got, err := EvalReader(context.Background(), bytes.NewBufferString("(read-line)"), rt)
+	if err != nil {
+		t.Fatalf("EvalReader() error = %v", err)
+	}
+	if want := values.NewString("line"); !got.Equal(want) {
+		t.Errorf("EvalReader() = %v, want %v", got.WriteString(), want.WriteString())
+	}
+}
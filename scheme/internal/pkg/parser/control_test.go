@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestHigherOrderProcedures(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{
+			name: "apply spreads the final list",
+			src:  "(list (apply + '(1 2 3)) (apply + 1 2 '(3 4)) (apply (lambda args args) 1 '()))",
+			want: values.List(values.NewInt(6), values.NewInt(10), values.List(values.NewInt(1))),
+		},
+		{
+			name: "apply in tail position",
+			src:  "(define (count n) (if (= n 0) 'done (apply count (list (- n 1))))) (count 100000)",
+			want: values.NewIdentifier("done"),
+		},
+		{
+			name:    "apply requires a list",
+			src:     "(apply + 1 2)",
+			wantErr: builtins.ErrTypeMismatch,
+		},
+		{
+			name: "map builtins and lambdas",
+			src:  "(list (map car '((1) (2))) (map (lambda (x) (* x x)) '(1 2 3)))",
+			want: values.List(values.List(values.NewInt(1), values.NewInt(2)), values.List(values.NewInt(1), values.NewInt(4), values.NewInt(9))),
+		},
+		{
+			name: "map stops at the shortest list",
+			src:  "(map + '(1 2 3) '(10 20))",
+			want: values.List(values.NewInt(11), values.NewInt(22)),
+		},
+		{
+			name:    "for-each runs in order",
+			src:     "(for-each (lambda (x y) (display x) (display y)) '(1 2) '(a b c))",
+			want:    values.NewVoidType(),
+			wantOut: "1a2b",
+		},
+		{
+			name: "vector-map",
+			src:  "(vector-map + #(1 2) #(10 20 30))",
+			want: values.NewVector(values.NewInt(11), values.NewInt(22)),
+		},
+		{
+			name:    "vector-for-each",
+			src:     "(vector-for-each display #(1 2 3))",
+			want:    values.NewVoidType(),
+			wantOut: "123",
+		},
+		{
+			name: "string-map",
+			src:  `(string-map char-upcase "abc")`,
+			want: values.NewString("ABC"),
+		},
+		{
+			name:    "string-map requires characters",
+			src:     `(string-map (lambda (c) 1) "abc")`,
+			wantErr: builtins.ErrTypeMismatch,
+		},
+		{
+			name:    "string-for-each over several strings",
+			src:     `(string-for-each (lambda (a b) (write-char a) (write-char b)) "ab" "xyz")`,
+			want:    values.NewVoidType(),
+			wantOut: "axby",
+		},
+		{
+			name: "errors propagate",
+			src:  `(guard (e (#t (error-object-message e))) (map (lambda (x) (error "boom" x)) '(1)))`,
+			want: values.NewString("boom"),
+		},
+	})
+}
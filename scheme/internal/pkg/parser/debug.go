@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/debugger"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// The REPL's ,debug command toggles debug mode, in which the debugger pauses evaluations
+// at breakpoints and while stepping. A paused evaluation reads commands and expressions
+// from the REPL's input until a command resumes it:
+//
+//	,break spec      add a breakpoint on a procedure name, file:line or line
+//	,delete id       remove a breakpoint
+//	,breakpoints     list the breakpoints
+//	,step            pause at the next expression, entering procedure calls
+//	,next            pause at the next expression after the current one
+//	,finish          pause once the current procedure returns
+//	,continue        run to the next breakpoint
+//	,abort           end the evaluation
+//	,locals          show the bindings of each local environment frame
+//	,backtrace       show the procedure calls in progress
+//
+// Other expressions are evaluated in the environment of the paused evaluation. Breakpoints
+// may also be managed at the prompt, and only pause evaluations in debug mode. ,step at
+// the prompt makes the next evaluation pause at its first expression.
+
+const debugPrompt = "debug> "
+
+// newDebugger returns the debugger of the REPL, which is off until ,debug turns it on.
+// Its pauses are handled by reading commands from the input of p.
+func (p *Parser) newDebugger() *debugger.Debugger {
+	var d *debugger.Debugger
+	d = debugger.New(func(stop *debugger.Stop) debugger.Action {
+		return p.debugPause(d, stop)
+	})
+	d.SetEnabled(false)
+	return d
+}
+
+// replEval reads the next datum and runs it as a REPL command or evaluates it as part of
+// the evaluation of rt.
+func (p *Parser) replEval(d *debugger.Debugger, rt *builtins.Runtime) (values.Interface, error) {
+	datum, err := ReadDatum(p, rt)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	if command, ok := replCommand(datum); ok {
+		return values.NewVoidType(), p.replCommand(command, d, rt)
+	}
+	defer d.Finish()
+	// compiled code does not run the hooks of the debugger, so it is off while debugging
+	return p.evaluate(datum, rt, !d.Enabled())
+}
+
+// replCommand returns the name of the REPL command datum is, if it is one.
+func replCommand(datum values.Interface) (string, bool) {
+	id, ok := datum.(values.Identifier)
+	if !ok || len(id.GetName()) < 2 || !strings.HasPrefix(id.GetName(), ",") {
+		return "", false
+	}
+	return id.GetName(), true
+}
+
+// replCommand runs a command entered at the REPL prompt.
+func (p *Parser) replCommand(command string, d *debugger.Debugger, rt *builtins.Runtime) error {
+	if command == ",debug" {
+		d.SetEnabled(!d.Enabled())
+		if d.Enabled() {
+			_, _ = fmt.Fprintln(rt.Out, "Debugging on")
+		} else {
+			_, _ = fmt.Fprintln(rt.Out, "Debugging off")
+		}
+		return nil
+	}
+	if command == ",step" {
+		if !d.Enabled() {
+			return fmt.Errorf("%w: %s, enter ,debug first", ErrUnknownCommand, command)
+		}
+		d.Step()
+		return nil
+	}
+	return p.breakpointCommand(command, d, rt)
+}
+
+// breakpointCommand runs the commands managing breakpoints, which work both at the
+// prompt and in a paused evaluation.
+func (p *Parser) breakpointCommand(command string, d *debugger.Debugger, rt *builtins.Runtime) error {
+	switch command {
+	case ",break":
+		spec, err := ReadDatum(p, rt)
+		if err != nil {
+			return err
+		}
+		b, err := d.Break(spec.DisplayString())
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(rt.Out, "Breakpoint %d at %s\n", b.ID, b)
+	case ",delete":
+		id, err := ReadDatum(p, rt)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(id.DisplayString())
+		if err != nil || !d.Delete(n) {
+			return fmt.Errorf("%w: no breakpoint %s", ErrBadArgument, id.WriteString())
+		}
+	case ",breakpoints":
+		for _, b := range d.Breakpoints() {
+			_, _ = fmt.Fprintf(rt.Out, "Breakpoint %d at %s\n", b.ID, b)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, command)
+	}
+	return nil
+}
+
+// debugPause reads commands and expressions for the paused evaluation stop until a
+// command resumes it. The evaluation is aborted when the input ends.
+func (p *Parser) debugPause(d *debugger.Debugger, stop *debugger.Stop) debugger.Action {
+	rt := stop.Runtime
+	_, _ = fmt.Fprintf(rt.Out, "Paused: %s\n", stop)
+	for {
+		if p.prompt != "" {
+			_, _ = fmt.Fprint(rt.Out, debugPrompt)
+		}
+		datum, err := ReadDatum(p, rt)
+		switch {
+		case errors.Is(err, ErrEof), errors.Is(err, context.Canceled):
+			return debugger.Abort
+		case err != nil:
+			ReportError(rt.Err, err)
+			continue
+		}
+		command, ok := replCommand(datum)
+		if !ok {
+			p.debugEval(stop, datum)
+			continue
+		}
+		switch command {
+		case ",step":
+			return debugger.StepIn
+		case ",next":
+			return debugger.StepOver
+		case ",finish":
+			return debugger.StepOut
+		case ",continue":
+			return debugger.Continue
+		case ",abort":
+			return debugger.Abort
+		case ",locals":
+			printLocals(stop)
+		case ",backtrace":
+			_, _ = fmt.Fprint(rt.Out, values.Backtrace(stop.Backtrace(), backtraceLimit))
+		default:
+			if err := p.breakpointCommand(command, d, rt); err != nil {
+				ReportError(rt.Err, err)
+			}
+		}
+	}
+}
+
+// debugEval evaluates expr in the paused evaluation stop and prints its value.
+func (p *Parser) debugEval(stop *debugger.Stop, expr values.Interface) {
+	rt := stop.Runtime
+	val, err := stop.Evaluate(expr)
+	switch {
+	case err != nil:
+		ReportError(rt.Err, err)
+	case val.Type() != types.Void:
+		if _, err := builtins.DisplayImpl(values.List(val), rt); err != nil {
+			ReportError(rt.Err, err)
+		}
+		_, _ = fmt.Fprintln(rt.Out)
+	}
+}
+
+// printLocals prints the bindings of the local environment frames of stop, innermost first.
+func printLocals(stop *debugger.Stop) {
+	out := stop.Runtime.Out
+	n := 0
+	for _, scope := range stop.Scopes() {
+		if scope.Global {
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "frame %d:\n", n)
+		for _, b := range scope.Bindings {
+			_, _ = fmt.Fprintf(out, "  %s = %s\n", b.Name, b.Value.WriteString())
+		}
+		n++
+	}
+	if n == 0 {
+		_, _ = fmt.Fprintln(out, "no local bindings")
+	}
+}
@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/debugger"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/lexer"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+const (
+	factDefinition = `(define (fact n)
+  (if (= n 0)
+      1
+      (* n (fact (- n 1)))))
+`
+	debugFact = factDefinition + ",debug\n"
+)
+
+func TestReplDebug(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "breakpoints pause in debug mode",
+			src: factDefinition + `,break fact
+,step
+(fact 1)
+,debug
+(fact 2)
+,continue
+,frobnicate
+,continue
+,continue
+`,
+			want: `Breakpoint 1 at fact
+1
+Debugging on
+Paused: breakpoint 1, fact called with (2) at line 9
+Paused: breakpoint 1, fact called with (1) at line 4
+Paused: breakpoint 1, fact called with (0) at line 4
+2
+`,
+			wantErr: "Error unknown command: ,step, enter ,debug first\nError unknown command: ,frobnicate\n",
+		},
+		{
+			name: "procedure breakpoint",
+			src: debugFact + `,break fact
+(fact 2)
+n
+,locals
+,continue
+(* n 10)
+,delete 1
+,continue
+`,
+			want: `Debugging on
+Breakpoint 1 at fact
+Paused: breakpoint 1, fact called with (2) at line 7
+2
+frame 0:
+  n = 2
+Paused: breakpoint 1, fact called with (1) at line 4
+10
+2
+`,
+		},
+		{
+			name: "breakpoint on a builtin",
+			src: debugFact + `,break car
+(car '(1 2))
+,locals
+,continue
+`,
+			want: `Debugging on
+Breakpoint 1 at car
+Paused: breakpoint 1, car called with ((1 2)) at line 7
+no local bindings
+1
+`,
+		},
+		{
+			name: "step into, over and out",
+			src: debugFact + `,step
+(list (fact 1) (+ 2 3))
+,step
+,step
+,next
+,locals
+,finish
+,continue
+`,
+			want: `Debugging on
+Paused: (list (fact 1) (+ 2 3)) at line 7
+Paused: (fact 1) at line 7
+Paused: (if (= n 0) 1 (* n (fact (- n 1)))) at line 2
+Paused: (* n (fact (- n 1))) at line 4
+frame 0:
+  n = 1
+Paused: (+ 2 3) at line 7
+(1 5)
+`,
+		},
+		{
+			name: "line breakpoint",
+			src: debugFact + `,break 4
+,breakpoints
+(fact 3)
+,backtrace
+,continue
+(list n (fact n))
+,locals
+,abort
+`,
+			want: `Debugging on
+Breakpoint 1 at line 4
+Breakpoint 1 at line 4
+Paused: breakpoint 1, (* n (fact (- n 1))) at line 4
+  in fact, called at line 8
+Paused: breakpoint 1, (* n (fact (- n 1))) at line 4
+(2 2)
+frame 0:
+  n = 2
+`,
+			wantErr: "Error evaluation aborted\n  in fact, called at line 4\n  in fact, called at line 8\n",
+		},
+		{
+			name: "closure frames",
+			src: `(define (adder x) (lambda (y) (+ x y)))
+(define add2 (adder 2))
+,debug
+,break 1
+(add2 5)
+,locals
+,continue
+,debug
+(add2 1)
+`,
+			want: `Debugging on
+Breakpoint 1 at line 1
+Paused: breakpoint 1, (+ x y) at line 1
+frame 0:
+  y = 5
+frame 1:
+  x = 2
+7
+Debugging off
+3
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, errOut := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+			p := New(context.Background(), lexer.New(bytes.NewBufferString(tt.src)), WithPrompt(""))
+			p.Repl(builtins.WithOut(out), builtins.WithErr(errOut), builtins.WithEvaluatorCallback(evalSexpression))
+			if out.String() != tt.want {
+				t.Errorf("Repl() output = %q, want %q", out.String(), tt.want)
+			}
+			if errOut.String() != tt.wantErr {
+				t.Errorf("Repl() error output = %q, want %q", errOut.String(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDebuggerHook(t *testing.T) {
+	var stops []string
+	d := debugger.New(func(stop *debugger.Stop) debugger.Action {
+		stops = append(stops, stop.String())
+		if len(stops) == 2 {
+			return debugger.Abort
+		}
+		return debugger.StepIn
+	})
+	if _, err := d.Break("lib.scm:2"); err != nil {
+		t.Fatal(err)
+	}
+	rt := newTestRuntime(io.Discard, builtins.WithHook(d))
+	datums, err := DefaultDatumReader()(bytes.NewBufferString("(define (f x)\n  (g (+ x 1)))\n(define (g x) x)\n(f 1)"), "src/lib.scm", rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last error
+	for _, datum := range datums {
+		_, last = evalSexpression(datum, rt.NewEvaluation(context.Background()))
+	}
+	if !errors.Is(last, debugger.ErrAborted) {
+		t.Errorf("evaluation error = %v, want %v", last, debugger.ErrAborted)
+	}
+	want := []string{"breakpoint 1, (g (+ x 1)) at src/lib.scm:2", "(+ x 1) at src/lib.scm:2"}
+	if len(stops) != len(want) || stops[0] != want[0] || stops[1] != want[1] {
+		t.Errorf("stops = %q, want %q", stops, want)
+	}
+	var c *values.Condition
+	if !errors.As(last, &c) || len(c.Stack) != 1 || c.Stack[0].Procedure != "f" {
+		t.Errorf("evaluation error = %#v, want a condition raised in f", last)
+	}
+}
@@ -3,22 +3,25 @@ package parser
 import (
 	"errors"
 
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
 )
 
 var (
-	ErrInvalidFormat           = errors.New("invalid format")
-	ErrBadArgument             = errors.New("bad argument")
-	ErrOperatorIsNotAProcedure = errors.New("operator is not a procedure")
+	ErrInvalidFormat           = builtins.ErrInvalidFormat
+	ErrBadArgument             = builtins.ErrBadArgument
+	ErrOperatorIsNotAProcedure = builtins.ErrOperatorIsNotAProcedure
 	ErrNotAPrimitive           = values.ErrNotAPrimitive
-	ErrUnexpectedToken         = errors.New("unexpected token")
-	ErrUndefinedIdent          = errors.New("undefined identifier")
-	ErrInvalidToken            = errors.New("invalid token")
-	ErrEof                     = errors.New("eof")
-	ErrWrongNumberOfArguments  = errors.New("wrong number of arguments")
-	ErrNumberExpected          = errors.New("number expected")
-	ErrDivideByZero            = errors.New("divide by zero")
-	ErrTypeMismatch            = errors.New("type mismatch")
+	ErrUnexpectedToken         = builtins.ErrUnexpectedToken
+	ErrUndefinedIdent          = builtins.ErrUndefinedIdent
+	ErrInvalidToken            = builtins.ErrInvalidToken
+	ErrEof                     = builtins.ErrEof
+	ErrUnterminatedList        = errors.New("unterminated list")
+	ErrWrongNumberOfArguments  = builtins.ErrWrongNumberOfArguments
+	ErrNumberExpected          = builtins.ErrNumberExpected
+	ErrDivideByZero            = builtins.ErrDivideByZero
+	ErrTypeMismatch            = builtins.ErrTypeMismatch
+	ErrUnknownCommand          = errors.New("unknown command")
 )
 
 func ErrIo(err error) error {
@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"errors"
+	"fmt"
 
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
@@ -9,29 +9,55 @@ import (
 )
 
 // evalSexpression evaluate a S-Expression in the given environment
+// Expressions in tail position are returned by special forms and procedures as a
+// builtins.TailCall and evaluated by this loop, so tail calls run in constant Go stack space.
+// The procedure calls made by the loop are recorded on the call stack of the runtime,
+// where a tail call replaces the frame of the call that made it. The hook of the runtime
+// is told of every compound expression and procedure call, so a debugger can pause them.
 func evalSexpression(l values.Interface, rt *builtins.Runtime) (values.Interface, error) {
-
-	switch l.(type) {
-	case values.Nil:
-		return values.NewNil(), nil
-	case values.Operator:
-		lambda, err := applyOperator(l.(values.Operator), rt)
-		if err != nil {
-			return values.NewVoidType(), err
+	nested := false
+	depth := 0
+	for {
+		switch l.(type) {
+		case values.Operator:
+			lambda, err := applyOperator(l.(values.Operator), rt)
+			if err != nil {
+				return values.NewVoidType(), err
+			}
+			return lambda, nil
+		case values.Identifier:
+			return lookupIdentifier(l.(values.Identifier), rt)
+		case values.Pair:
+			if !nested {
+				if err := rt.Enter(); err != nil {
+					return values.NewVoidType(), err
+				}
+				defer rt.Leave()
+				depth = rt.StackDepth()
+				defer rt.Unwind(depth)
+				nested = true
+			}
+			if err := rt.Step(); err != nil {
+				return values.NewVoidType(), err
+			}
+			if err := rt.Evaluating(l); err != nil {
+				return values.NewVoidType(), rt.Traced(err)
+			}
+			// continue to S-Expression evaluation
+			val, err := evaluatePair(l.(values.Pair), rt)
+			if err != nil {
+				return values.NewVoidType(), rt.Traced(err)
+			}
+			tail, ok := val.(builtins.TailCall)
+			if !ok {
+				return val, nil
+			}
+			rt.CollapseTailCall(depth)
+			l, rt = tail.Expr, tail.Runtime
+		default:
+			// literals evaluate to themselves
+			return l, nil
 		}
-		return lambda.Apply(values.NewNil())
-	case builtins.Lambda:
-		return l.(builtins.Lambda).Apply(values.NewNil())
-	case values.Quot:
-		return values.NewNil(), nil
-	case values.Identifier:
-		return lookupIdentifier(l.(values.Identifier), rt)
-	case values.Pair:
-		// continue to S-Expression evaluation
-		lst := l.(values.Pair)
-		return evaluatePair(lst, rt)
-	default:
-		return l, ErrOperatorIsNotAProcedure
 	}
 }
 
@@ -41,100 +67,68 @@ func evalSexpression(l values.Interface, rt *builtins.Runtime) (values.Interface
 func evaluatePair(lst values.Pair, rt *builtins.Runtime) (values.Interface, error) {
 	head := lst.Car()
 	tail := lst.Cdr()
-	switch head.(type) {
-	case values.Pair:
-		evaluatedHead, err := evalSexpression(head, rt)
-		if err != nil && !errors.Is(err, ErrOperatorIsNotAProcedure) {
-			return evaluatedHead, err
-		}
-		switch evaluatedHead.(type) {
-		case values.Identifier:
-			p := evaluatedHead.(values.Identifier)
-			resvVal, err := lookupIdentifier(p, rt)
-			if err != nil {
-				return values.NewVoidType(), ErrUndefinedIdent
-			}
-			_, userDefined := types.FromString(p.GetName())
-			switch resvVal.(type) {
-			case builtins.Lambda:
-				if userDefined == nil {
-					lambda := resvVal.(builtins.Lambda)
-					return lambda.Apply(tail)
-				}
-				return resvVal, nil
-			}
-		case values.Operator:
-			lambda, err := applyOperator(evaluatedHead.(values.Operator), rt)
-			if err != nil {
-				return values.NewVoidType(), ErrOperatorIsNotAProcedure
-			}
-			return lambda.Apply(tail)
-		case builtins.Lambda:
-			return evaluatedHead.(builtins.Lambda).Apply(tail)
-		case values.Quot:
-			return tail, nil
-		case values.Numeric, values.Boolean, values.String, values.Char:
-			if tail.Type() == types.Nil {
-				return evaluatedHead, nil
-			}
-			return evalSexpression(values.Cons(tail, evaluatedHead), rt)
-		default:
-			return evaluatedHead, ErrOperatorIsNotAProcedure
-		}
-	case values.Quot:
-		switch tail.Type() {
-		case types.Nil:
-			return values.NewNil(), ErrWrongNumberOfArguments
-		default:
-			return values.NewQuot(tail), nil
-		}
-	case values.Operator:
-		oper, err := applyOperator(head.(values.Operator), rt)
+
+	evaluatedHead, err := evalSexpression(head, rt)
+	if err != nil {
+		return values.NewVoidType(), err
+	}
+	switch evaluatedHead.(type) {
+	case builtins.Syntax:
+		return evaluatedHead.(builtins.Syntax).Body(tail, rt)
+	case builtins.Procedure:
+		args, err := evalOperands(tail, rt)
 		if err != nil {
-			return values.NewVoidType(), ErrOperatorIsNotAProcedure
+			return values.NewVoidType(), err
 		}
-		return oper.Apply(tail)
+		rt.PushFrame(evaluatedHead, lst)
+		if err := rt.Calling(evaluatedHead, args); err != nil {
+			return values.NewVoidType(), err
+		}
+		return evaluatedHead.(builtins.Procedure).Call(args, rt)
 	case builtins.Lambda:
-		return head.(builtins.LambdaExpr), nil
-	case values.Identifier:
-		p := head.(values.Identifier)
-		resolvVal, err := lookupIdentifier(p, rt)
+		args, err := evalOperands(tail, rt)
 		if err != nil {
-			return values.NewVoidType(), ErrUndefinedIdent
+			return values.NewVoidType(), err
 		}
-		switch resolvVal.(type) {
-		case builtins.Lambda:
-			return resolvVal.(builtins.Lambda).Apply(tail)
-		default:
-			return resolvVal, nil
+		rt.PushFrame(evaluatedHead, lst)
+		if err := rt.Calling(evaluatedHead, args); err != nil {
+			return values.NewVoidType(), err
 		}
+		return evaluatedHead.(builtins.Lambda).Apply(args)
 	default:
+		// a value in operator position without operands resolves to itself, e.g. (1234)
 		if tail.Type() == types.Nil {
-			return head, nil
+			return evaluatedHead, nil
 		}
+		return values.NewVoidType(), fmt.Errorf("%w: %s", ErrOperatorIsNotAProcedure, evaluatedHead.WriteString())
 	}
-	return evalSexpression(values.Cons(tail, head), rt)
 }
 
-func applyOperator(p values.Operator, rt *builtins.Runtime) (builtins.Lambda, error) {
-
-	oper, ok := rt.Env.Lookup(p.GetName())
-	if !ok {
-		return nil, ErrOperatorIsNotAProcedure
+// evalOperands evaluates each operand of a procedure call from left to right,
+// returning the list of argument values with quotation removed.
+func evalOperands(operands values.Interface, rt *builtins.Runtime) (values.Interface, error) {
+	var args []values.Interface
+	for operands.Type() == types.Pair {
+		arg, err := evalSexpression(values.Car(operands), rt)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		args = append(args, values.Unquote(arg))
+		operands = values.Cdr(operands)
 	}
-	lambda, ok := oper.(builtins.LambdaExpr)
-	if !ok {
-		return nil, ErrOperatorIsNotAProcedure
+	if operands.Type() != types.Nil {
+		return values.NewVoidType(), ErrInvalidFormat
 	}
-	return lambda, nil
+	return values.List(args...), nil
 }
 
-func lookupProcedure(ident values.Identifier, rt *builtins.Runtime) (builtins.Lambda, error) {
-	resolvVal, ok := rt.Env.Lookup(ident.GetName())
+func applyOperator(p values.Operator, rt *builtins.Runtime) (builtins.Lambda, error) {
+
+	oper, ok := rt.Env.Lookup(p.GetName())
 	if !ok {
-		return nil, ErrUndefinedIdent
+		return nil, fmt.Errorf("%w: %s", ErrUndefinedIdent, p.GetName())
 	}
-	lambda, ok := resolvVal.(builtins.Lambda)
+	lambda, ok := oper.(builtins.Lambda)
 	if !ok {
 		return nil, ErrOperatorIsNotAProcedure
 	}
@@ -144,7 +138,7 @@ func lookupProcedure(ident values.Identifier, rt *builtins.Runtime) (builtins.La
 func lookupIdentifier(ident values.Identifier, rt *builtins.Runtime) (values.Interface, error) {
 	resolvVal, ok := rt.Env.Lookup(ident.GetName())
 	if !ok {
-		return values.NewVoidType(), ErrUndefinedIdent
+		return values.NewVoidType(), fmt.Errorf("%w: %s", ErrUndefinedIdent, ident.GetName())
 	}
 	return resolvVal, nil
 }
@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// newTestRuntime returns a runtime evaluating with the evaluator of the parser, writing
+// its output to out and reading no input, created with opts after those options.
+func newTestRuntime(out io.Writer, opts ...builtins.OptionRuntime) *builtins.Runtime {
+	return builtins.NewRuntime(append([]builtins.OptionRuntime{
+		builtins.WithOut(out),
+		builtins.WithIn(strings.NewReader("")),
+		builtins.WithEvaluatorCallback(evalSexpression),
+		builtins.WithDatumReader(DefaultDatumReader())}, opts...)...)
+}
+
+// evalTest is the evaluation of a source in a new runtime, with the value of its last
+// expression, when want is set, its written form, when wantWrite is set, and the output
+// it writes. A failing evaluation is expected to fail with an error wrapping wantErr,
+// whose message starts with wantMsg, or with a condition raised with the call stack
+// wantStack.
+type evalTest struct {
+	name      string
+	src       string
+	opts      []builtins.OptionRuntime
+	want      values.Interface
+	wantWrite string
+	wantOut   string
+	wantErr   error
+	wantMsg   string
+	wantStack []string
+}
+
+// runEvalTests runs tests in runtimes created with opts and the options of each test.
+func runEvalTests(t *testing.T, tests []evalTest, opts ...builtins.OptionRuntime) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, nil, opts...)
+		})
+	}
+}
+
+// run evaluates the source of tt with the parser options modeOpts in a runtime created
+// with opts and the options of tt, and checks the outcome.
+func (tt evalTest) run(t *testing.T, modeOpts []Option, opts ...builtins.OptionRuntime) {
+	t.Helper()
+	out := new(strings.Builder)
+	rt := newTestRuntime(out, append(opts, tt.opts...)...)
+	got, err := EvalReader(context.Background(), strings.NewReader(tt.src), rt, modeOpts...)
+	switch {
+	case tt.wantErr != nil || tt.wantMsg != "":
+		if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Errorf("EvalReader() error = %v, wantErr %v", err, tt.wantErr)
+		}
+		if tt.wantMsg != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantMsg)) {
+			t.Errorf("EvalReader() error = %v, want %s", err, tt.wantMsg)
+		}
+		return
+	case tt.wantStack != nil:
+		var c *values.Condition
+		if !errors.As(err, &c) {
+			t.Fatalf("EvalReader() error = %v, want a condition", err)
+		}
+		stack := make([]string, len(c.Stack))
+		for i, frame := range c.Stack {
+			stack[i] = frame.String()
+		}
+		if strings.Join(stack, "\n") != strings.Join(tt.wantStack, "\n") {
+			t.Errorf("stack = %q, want %q", stack, tt.wantStack)
+		}
+		if depth := rt.StackDepth(); depth != 0 {
+			t.Errorf("StackDepth() = %d after the error, want 0", depth)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("EvalReader() error = %v", err)
+	}
+	if tt.want != nil && !values.Unquote(got).Equal(tt.want) {
+		t.Errorf("EvalReader() = %v, want %v", got.WriteString(), tt.want.WriteString())
+	}
+	if tt.wantWrite != "" && got.WriteString() != tt.wantWrite {
+		t.Errorf("EvalReader() = %v, want %v", got.WriteString(), tt.wantWrite)
+	}
+	if out.String() != tt.wantOut {
+		t.Errorf("output = %q, want %q", out.String(), tt.wantOut)
+	}
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestHashTables(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{
+			name: "set and ref",
+			src:  "(define h (make-hash-table)) (hash-table-set! h 'a 1 'b 2) (list (hash-table-ref h 'a) (hash-table-ref h 'b) (hash-table-size h))",
+			want: values.List(values.NewInt(1), values.NewInt(2), values.NewInt(2)),
+		},
+		{
+			name:    "ref of a missing key",
+			src:     "(hash-table-ref (make-hash-table) 'missing)",
+			wantErr: builtins.ErrKeyNotFound,
+		},
+		{
+			name: "ref failure and success procedures",
+			src: `(define h (make-hash-table)) (hash-table-set! h 1 10)
+			      (list (hash-table-ref h 2 (lambda () 'none)) (hash-table-ref h 1 (lambda () 'none) (lambda (v) (* v 2))))`,
+			want: values.List(values.NewIdentifier("none"), values.NewInt(20)),
+		},
+		{
+			name: "ref/default",
+			src:  "(hash-table-ref/default (make-hash-table) \"k\" 'default)",
+			want: values.NewIdentifier("default"),
+		},
+		{
+			name: "equal? compares structured keys",
+			src:  "(define h (make-hash-table equal?)) (hash-table-set! h (list 1 \"two\" #\\3) 'found) (hash-table-ref h (list 1 \"two\" #\\3))",
+			want: values.NewIdentifier("found"),
+		},
+		{
+			name: "eqv? compares vectors by identity",
+			src: `(define h (make-hash-table eqv?)) (define v (vector 1 2))
+			      (hash-table-set! h v 'same)
+			      (list (hash-table-ref/default h v #f) (hash-table-ref/default h (vector 1 2) #f) (hash-table-ref/default (alist->hash-table (list (cons (vector 1 2) 'equal))) (vector 1 2) #f))`,
+			want: values.List(values.NewIdentifier("same"), values.NewBool(false), values.NewIdentifier("equal")),
+		},
+		{
+			name:    "string=? tables only hold strings",
+			src:     "(define h (make-hash-table string=?)) (hash-table-set! h 'symbol 1)",
+			wantErr: builtins.ErrTypeMismatch,
+		},
+		{
+			name:    "unsupported equivalence",
+			src:     "(make-hash-table (lambda (a b) #t))",
+			wantErr: builtins.ErrBadArgument,
+		},
+		{
+			name: "delete and contains",
+			src: `(define h (alist->hash-table '((a . 1) (b . 2) (c . 3))))
+			      (list (hash-table-delete! h 'a 'z 'c) (hash-table-contains? h 'a) (hash-table-exists? h 'b) (hash-table-keys h))`,
+			want: values.List(values.NewInt(2), values.NewBool(false), values.NewBool(true), values.List(values.NewIdentifier("b"))),
+		},
+		{
+			name: "delete keeps the insertion order",
+			src: `(define h (alist->hash-table '((1 . a) (2 . b) (3 . c) (4 . d) (5 . e) (6 . f))))
+			      (hash-table-delete! h 1 3)
+			      (define before (hash-table-keys h))
+			      (hash-table-delete! h 5 6)
+			      (hash-table-set! h 1 'g)
+			      (list before (hash-table->alist h) (hash-table-size h) (hash-table-ref/default h 4 #f))`,
+			want: values.List(
+				values.List(values.NewInt(2), values.NewInt(4), values.NewInt(5), values.NewInt(6)),
+				values.List(values.Cons(values.NewInt(2), values.NewIdentifier("b")), values.Cons(values.NewInt(4), values.NewIdentifier("d")),
+					values.Cons(values.NewInt(1), values.NewIdentifier("g"))),
+				values.NewInt(3), values.NewIdentifier("d")),
+		},
+		{
+			name: "update counts words",
+			src: `(define h (make-hash-table string=?))
+			      (define (count words) (if (null? words) h (begin (hash-table-update!/default h (car words) (lambda (n) (+ n 1)) 0) (count (cdr words)))))
+			      (hash-table->alist (count (list "a" "b" "a")))`,
+			want: values.List(values.Cons(values.NewString("a"), values.NewInt(2)), values.Cons(values.NewString("b"), values.NewInt(1))),
+		},
+		{
+			name: "update with failure",
+			src:  "(define h (make-hash-table)) (hash-table-update! h 'k (lambda (v) (cons 1 v)) (lambda () '())) (hash-table-ref h 'k)",
+			want: values.List(values.NewInt(1)),
+		},
+		{
+			name: "walk visits every entry",
+			src: `(define h (alist->hash-table '((1 . 10) (2 . 20))))
+			      (define total (vector 0))
+			      (hash-table-walk h (lambda (k v) (vector-set! total 0 (+ (vector-ref total 0) k v))))
+			      (vector-ref total 0)`,
+			want: values.NewInt(33),
+		},
+		{
+			name: "values, copy and clear",
+			src: `(define h (alist->hash-table '((a . 1) (b . 2))))
+			      (define c (hash-table-copy h))
+			      (hash-table-clear! h)
+			      (list (hash-table-size h) (hash-table-values c) (hash-table? c))`,
+			want: values.List(values.NewInt(0), values.List(values.NewInt(1), values.NewInt(2)), values.NewBool(true)),
+		},
+		{
+			name: "hash agrees with equal?",
+			src:  `(list (= (hash (list 1 "a" 2.5)) (hash (list 1 "a" 2.5))) (< (hash 'symbol 7) 7) (= (string-hash "s") (hash "s")))`,
+			want: values.List(values.NewBool(true), values.NewBool(true), values.NewBool(true)),
+		},
+		{
+			name: "eqv? distinguishes vectors",
+			src:  "(define v (vector 1)) (list (eqv? v v) (eqv? v (vector 1)) (equal? v (vector 1)) (string=? \"a\" \"a\" \"a\"))",
+			want: values.List(values.NewBool(true), values.NewBool(false), values.NewBool(true), values.NewBool(true)),
+		},
+	})
+}
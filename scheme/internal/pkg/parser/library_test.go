@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+const counterLibrary = `
+(define-library (test counter)
+  (export next (rename start initial))
+  (import (scheme base))
+  (begin
+    (define start 10)
+    (define hidden 1)
+    (define (next n) (+ n hidden))))
+`
+
+func TestLibraries(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{
+			name: "import library",
+			src:  counterLibrary + "(import (test counter)) (next initial)",
+			want: values.NewInt(11),
+		},
+		{
+			name:    "unexported bindings are hidden",
+			src:     counterLibrary + "(import (test counter)) hidden",
+			wantErr: ErrUndefinedIdent,
+		},
+		{
+			name: "import only",
+			src:  counterLibrary + "(import (only (test counter) next)) (next 1)",
+			want: values.NewInt(2),
+		},
+		{
+			name:    "import only excludes other bindings",
+			src:     counterLibrary + "(import (only (test counter) next)) initial",
+			wantErr: ErrUndefinedIdent,
+		},
+		{
+			name:    "import only of unexported name",
+			src:     counterLibrary + "(import (only (test counter) hidden))",
+			wantErr: ErrUndefinedIdent,
+		},
+		{
+			name:    "import except",
+			src:     counterLibrary + "(import (except (test counter) next)) (next 1)",
+			wantErr: ErrUndefinedIdent,
+		},
+		{
+			name: "import prefix",
+			src:  counterLibrary + "(import (prefix (test counter) c:)) (c:next c:initial)",
+			want: values.NewInt(11),
+		},
+		{
+			name: "import rename",
+			src:  counterLibrary + "(import (rename (test counter) (next succ))) (succ 41)",
+			want: values.NewInt(42),
+		},
+		{
+			name: "import nested import sets",
+			src:  counterLibrary + "(import (prefix (only (test counter) next) c-)) (c-next 0)",
+			want: values.NewInt(1),
+		},
+		{
+			name:    "library environment starts empty",
+			src:     "(define-library (test empty) (export x) (begin (define x 1)))",
+			wantErr: ErrUndefinedIdent,
+		},
+		{
+			name: "standard libraries",
+			src:  "(import (only (scheme char) char-upcase)) (char-upcase #\\a)",
+			want: values.NewChar('A'),
+		},
+		{
+			name: "library resolved from disk",
+			src:  "(import (mylib util)) (list (double 2) (triple 2) (shout \"hi\"))",
+			want: values.List(values.NewInt(4), values.NewInt(6), values.NewString("HI")),
+		},
+		{
+			name:    "library not found",
+			src:     "(import (mylib missing))",
+			wantErr: builtins.ErrLibraryNotFound,
+		},
+		{
+			name:    "circular import",
+			src:     "(import (cycle a))",
+			wantErr: builtins.ErrCircularImport,
+		},
+	}, builtins.WithLibraryPath("testdata"))
+}
+
+func TestConcurrentImport(t *testing.T) {
+	rt := newTestRuntime(io.Discard, builtins.WithLibraryPath("testdata"))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := EvalReader(context.Background(), bytes.NewBufferString("(import (mylib util)) (double 21)"), rt)
+			if err != nil {
+				t.Errorf("EvalReader() error = %v", err)
+				return
+			}
+			if !got.Equal(values.NewInt(42)) {
+				t.Errorf("EvalReader() = %v, want 42", got.WriteString())
+			}
+		}()
+	}
+	wg.Wait()
+}
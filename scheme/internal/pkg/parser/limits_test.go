@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestResourceLimits(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{
+			name:    "step limit",
+			src:     "(let loop ((i 0)) (loop (+ i 1)))",
+			opts:    []builtins.OptionRuntime{builtins.WithMaxSteps(1000)},
+			wantErr: builtins.ErrResourceLimitExceeded,
+		},
+		{
+			name: "steps within the limit",
+			src:  "(define (count n) (if (= n 0) n (count (- n 1)))) (count 100)",
+			opts: []builtins.OptionRuntime{builtins.WithMaxSteps(1000)},
+			want: values.NewInt(0),
+		},
+		{
+			name:    "steps are counted across the datums of an evaluation",
+			src:     "(define (count n) (if (= n 0) n (count (- n 1)))) (count 100) (count 100) (count 100)",
+			opts:    []builtins.OptionRuntime{builtins.WithMaxSteps(1000)},
+			wantErr: builtins.ErrResourceLimitExceeded,
+		},
+		{
+			name:    "depth limit",
+			src:     "(define (sum n) (if (= n 0) 0 (+ n (sum (- n 1))))) (sum 1000)",
+			opts:    []builtins.OptionRuntime{builtins.WithMaxDepth(100)},
+			wantErr: builtins.ErrResourceLimitExceeded,
+		},
+		{
+			name: "tail calls do not count towards depth",
+			src:  "(let loop ((i 0)) (if (= i 1000) i (loop (+ i 1))))",
+			opts: []builtins.OptionRuntime{builtins.WithMaxDepth(10)},
+			want: values.NewInt(1000),
+		},
+		{
+			name:    "cons cell limit",
+			src:     "(let loop ((l '()) (i 0)) (loop (cons i l) (+ i 1)))",
+			opts:    []builtins.OptionRuntime{builtins.WithMaxConsCells(100)},
+			wantErr: builtins.ErrResourceLimitExceeded,
+		},
+		{
+			name:    "cons cells are counted across the datums of an evaluation",
+			src:     "(iota 60) (iota 60)",
+			opts:    []builtins.OptionRuntime{builtins.WithMaxConsCells(100)},
+			wantErr: builtins.ErrResourceLimitExceeded,
+		},
+		{
+			name:    "vector elements count as cells",
+			src:     "(make-vector 1000 0)",
+			opts:    []builtins.OptionRuntime{builtins.WithMaxConsCells(100)},
+			wantErr: builtins.ErrResourceLimitExceeded,
+		},
+		{
+			name:    "string byte limit",
+			src:     `(format #f "~a~a~a" "0123456789" "0123456789" "0123456789")`,
+			opts:    []builtins.OptionRuntime{builtins.WithMaxStringBytes(16)},
+			wantErr: builtins.ErrResourceLimitExceeded,
+		},
+		{
+			name:    "guard cannot catch an exceeded limit",
+			src:     "(guard (e (#t 'caught)) (let loop () (loop)))",
+			opts:    []builtins.OptionRuntime{builtins.WithMaxSteps(100)},
+			wantErr: builtins.ErrResourceLimitExceeded,
+		},
+		{
+			name:    "builtins outside the allowlist are undefined",
+			src:     `(load "testdata/load/helper.scm")`,
+			opts:    []builtins.OptionRuntime{builtins.WithAllowedBuiltins("define", "+")},
+			wantErr: ErrUndefinedIdent,
+		},
+		{
+			name: "allowed builtins remain",
+			src:  "(define x 1) (+ x 1)",
+			opts: []builtins.OptionRuntime{builtins.WithAllowedBuiltins("define", "+")},
+			want: values.NewInt(2),
+		},
+		{
+			name:    "standard libraries only export allowed builtins",
+			src:     "(import (only (scheme base) cons))",
+			opts:    []builtins.OptionRuntime{builtins.WithAllowedBuiltins("import", "+")},
+			wantErr: ErrUndefinedIdent,
+		},
+		{
+			name: "load",
+			src:  `(load "testdata/load/main.scm") loaded`,
+			want: values.NewInt(21),
+		},
+	})
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestMutation(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{
+			name: "set! a global",
+			src:  "(define x 1) (set! x (+ x 1)) x",
+			want: values.NewInt(2),
+		},
+		{
+			name: "set! a closure variable",
+			src: `(define (make-counter) (let ((n 0)) (lambda () (set! n (+ n 1)) n)))
+			      (define c (make-counter)) (c) (c) (list (c) ((make-counter)))`,
+			want: values.List(values.NewInt(3), values.NewInt(1)),
+		},
+		{
+			name: "set! assigns the innermost binding",
+			src:  "(define x 'global) (define (f x) (set! x 'local) x) (list (f 1) x)",
+			want: values.List(values.NewIdentifier("local"), values.NewIdentifier("global")),
+		},
+		{
+			name:    "set! an unbound variable",
+			src:     "(set! undefined-variable 1)",
+			wantErr: builtins.ErrUndefinedIdent,
+		},
+		{
+			name: "set-car! and set-cdr! share structure",
+			src: `(define tail (list 2 3)) (define l (cons 1 tail))
+			      (set-car! tail 20) (set-cdr! tail '(30))
+			      l`,
+			want: values.List(values.NewInt(1), values.NewInt(20), values.NewInt(30)),
+		},
+		{
+			name: "pairs have identity",
+			src:  "(define p (list 1)) (list (eq? p p) (eq? p (list 1)) (equal? p (list 1)) (eqv? '() '()))",
+			want: values.List(values.NewBool(true), values.NewBool(false), values.NewBool(true), values.NewBool(true)),
+		},
+		{
+			name:    "quoted lists are constant",
+			src:     "(define l '(1 2)) (set-car! l 3)",
+			wantErr: builtins.ErrImmutable,
+		},
+		{
+			name:    "vector literals are constant",
+			src:     "(vector-set! #(1 2) 0 3)",
+			wantErr: builtins.ErrImmutable,
+		},
+		{
+			name: "copies of constants are mutable",
+			src:  "(define l (append '(1) '())) (set-car! l 3) (define v (list->vector '(1))) (vector-set! v 0 4) (list l v)",
+			want: values.List(values.List(values.NewInt(3)), values.NewVector(values.NewInt(4))),
+		},
+		{
+			name: "circular lists are not lists",
+			src:  "(define l (list 1 2 3)) (set-cdr! (cdr (cdr l)) l) (list? l)",
+			want: values.NewBool(false),
+		},
+		{
+			name: "circular lists are written with datum labels",
+			src: `(define l (list 1 2 3)) (set-cdr! (cdr (cdr l)) l)
+			      (define p (list 1 2)) (set-car! p p)
+			      (define v (vector 1 "s")) (vector-set! v 0 v)
+			      (define shared (list 1))
+			      (define (written x) (let ((port (open-output-string))) (write x port) (get-output-string port)))
+			      (define (displayed x) (let ((port (open-output-string))) (display x port) (get-output-string port)))
+			      (list (written l) (written p) (written v) (displayed v) (written (list shared shared)) (written (list l l)))`,
+			want: values.List(
+				values.NewString("#0=(1 2 3 . #0#)"),
+				values.NewString("#0=(#0# 2)"),
+				values.NewString(`#0=#(#0# "s")`),
+				values.NewString("#0=#(#0# s)"),
+				values.NewString("((1) (1))"),
+				values.NewString("(#0=(1 2 3 . #0#) #0#)")),
+		},
+		{
+			name: "equal? compares circular lists as the lists they unfold to",
+			src: `(define (cycle . items) (let ((l (apply list items))) (let loop ((p l)) (if (null? (cdr p)) (set-cdr! p l) (loop (cdr p)))) l))
+			      (define (nest x) (let ((p (list 0 x))) (set-car! p p) p))
+			      (list (equal? (cycle 1 2) (cycle 1 2 1 2)) (equal? (cycle 1 2) (cycle 1 3))
+			            (equal? (nest 1) (nest 1)) (equal? (nest 1) (nest 2))
+			            (equal? (cycle 1) (iota 2000 1 0)))`,
+			want: values.List(values.NewBool(true), values.NewBool(false), values.NewBool(true), values.NewBool(false), values.NewBool(false)),
+		},
+		{
+			name:    "set-car! requires a pair",
+			src:     "(set-car! '() 1)",
+			wantErr: builtins.ErrTypeMismatch,
+		},
+	})
+}
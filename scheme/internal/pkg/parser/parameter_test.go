@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestParameters(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{
+			name: "global value",
+			src:  "(define p (make-parameter 10)) (p)",
+			want: values.NewInt(10),
+		},
+		{
+			name: "converter applies to the initial and bound values",
+			src:  "(define p (make-parameter 1 (lambda (x) (* x 2)))) (list (p) (parameterize ((p 5)) (p)))",
+			want: values.List(values.NewInt(2), values.NewInt(10)),
+		},
+		{
+			name: "bindings are restored after the body",
+			src:  "(define p (make-parameter 'outer)) (list (parameterize ((p 'inner)) (p)) (p))",
+			want: values.List(values.NewIdentifier("inner"), values.NewIdentifier("outer")),
+		},
+		{
+			name: "bindings are dynamic rather than lexical",
+			src: `(define p (make-parameter 1))
+			      (define (get) (p))
+			      (define saved (parameterize ((p 2)) (lambda () (p))))
+			      (list (parameterize ((p 3)) (get)) (saved))`,
+			want: values.List(values.NewInt(3), values.NewInt(1)),
+		},
+		{
+			name: "bindings are restored when the body raises",
+			src: `(define p (make-parameter 'outer))
+			      (guard (e (#t (p))) (parameterize ((p 'inner)) (raise 'oops)))`,
+			want: values.NewIdentifier("outer"),
+		},
+		{
+			name: "threads inherit the bindings where they were made",
+			src: `(define p (make-parameter 'global))
+			      (define t (parameterize ((p 'made)) (make-thread (lambda () (p)))))
+			      (parameterize ((p 'started)) (thread-join! (thread-start! t)))`,
+			want: values.NewIdentifier("made"),
+		},
+		{
+			name:    "parameterize requires a parameter",
+			src:     "(parameterize ((car 1)) 1)",
+			wantErr: builtins.ErrTypeMismatch,
+		},
+		{
+			name:    "redirect output to a string port",
+			src:     `(define port (open-output-string)) (parameterize ((current-output-port port)) (display "inner") (newline)) (display "outer") (get-output-string port)`,
+			want:    values.NewString("inner\n"),
+			wantOut: "outer",
+		},
+		{
+			name:    "explicit port arguments",
+			src:     `(define port (open-output-string)) (write "s" port) (write-char #\x port) (write-string "y" port) (format port "~a" 1) (get-output-string port)`,
+			want:    values.NewString(`"s"xy1`),
+			wantOut: "",
+		},
+		{
+			name:    "format t writes to the current output port",
+			src:     `(format t "~a~%" 1) (define t (open-output-string)) (format t "~a" 2) (get-output-string t)`,
+			want:    values.NewString("2"),
+			wantOut: "1\n",
+		},
+		{
+			name:    "format is a procedure",
+			src:     `(list (procedure? format) (apply format (list #f "~a" 1)))`,
+			want:    values.List(values.NewBool(true), values.NewString("1")),
+			wantOut: "",
+		},
+		{
+			name: "pretty-print",
+			src: `(pretty-print '(define (f x) (list "a long string" "another long string" "and one more string" x)))
+			      (define port (open-output-string)) (pretty-print #(1 2) port) (get-output-string port)`,
+			want:    values.NewString("#(1 2)\n"),
+			wantOut: "(define (f x)\n  (list \"a long string\" \"another long string\" \"and one more string\" x))\n",
+		},
+		{
+			name:    "output redirection is undone after an error",
+			src:     `(define port (open-output-string)) (guard (e (#t #f)) (parameterize ((current-output-port port)) (error "failed"))) (display "after") (get-output-string port)`,
+			want:    values.NewString(""),
+			wantOut: "after",
+		},
+		{
+			name:    "current output port requires an output port",
+			src:     `(parameterize ((current-output-port (open-input-string "x"))) 1)`,
+			wantErr: builtins.ErrTypeMismatch,
+		},
+		{
+			name: "read from a string port",
+			src:  `(define port (open-input-string "ab\ncd")) (list (read-char port) (read-line port) (read-line port) (eof-object? (read-line port)))`,
+			want: values.List(values.NewChar('a'), values.NewString("b"), values.NewString("cd"), values.NewBool(true)),
+		},
+		{
+			name: "read from the current input port",
+			src:  `(parameterize ((current-input-port (open-input-string "line"))) (read-line))`,
+			want: values.NewString("line"),
+		},
+		{
+			name: "port predicates",
+			src:  `(list (port? (current-output-port)) (output-port? (current-output-port)) (input-port? (current-output-port)) (procedure? current-output-port))`,
+			want: values.List(values.NewBool(true), values.NewBool(true), values.NewBool(false), values.NewBool(true)),
+		},
+	})
+}
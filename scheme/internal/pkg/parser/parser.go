@@ -3,12 +3,16 @@ package parser
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/analyze"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/lexer"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/vm"
 )
 
 const (
@@ -25,6 +29,8 @@ type config struct {
 	prompt              string
 	verbose             VerboseLevel
 	showExpressionCount bool
+	bytecode            bool
+	analysis            bool
 }
 
 type Option func(*config)
@@ -47,6 +53,23 @@ func WithShowExpressionCount(showExpressionCount bool) Option {
 	}
 }
 
+// WithBytecode compiles the datums read to bytecode and runs them on the virtual machine
+// of the vm package. Datums it cannot compile are evaluated by the tree-walking evaluator.
+func WithBytecode(bytecode bool) Option {
+	return func(c *config) {
+		c.bytecode = bytecode
+	}
+}
+
+// WithAnalysis converts the datums read with the analyze package, resolving their
+// variables once, before evaluating them. Datums it cannot convert are evaluated by the
+// tree-walking evaluator. WithBytecode takes precedence over it.
+func WithAnalysis(analysis bool) Option {
+	return func(c *config) {
+		c.analysis = analysis
+	}
+}
+
 type Parser struct {
 	config
 	ctx     context.Context
@@ -76,44 +99,57 @@ func (p *Parser) SetPrompt(prompt string) {
 func (p *Parser) Eval(rt *builtins.Runtime) (values.Interface, error) {
 	val, err := EvalSExpression(p, rt)
 	p.exprnNo++
-	_, err = builtins.DisplayImpl(val, rt)
 	if err != nil {
-		_, _ = fmt.Fprintf(rt.Err, "Error %v\n", err)
+		ReportError(rt.Err, err)
+		return val, err
+	}
+	_, err = builtins.DisplayImpl(values.List(val), rt)
+	if err != nil {
+		ReportError(rt.Err, err)
 	}
 	return val, err
 }
 
+// Repl reads, evaluates and prints expressions until the input ends. Commands starting
+// with a comma, such as ,debug, control the REPL instead of being evaluated.
 func (p *Parser) Repl(rtOpts ...builtins.OptionRuntime) {
 
-	rt := builtins.NewRuntime(rtOpts...)
+	dbg := p.newDebugger()
+	rt := builtins.NewRuntime(append([]builtins.OptionRuntime{builtins.WithDatumReader(DefaultDatumReader()), builtins.WithHook(dbg)}, rtOpts...)...)
+	// the session is a single evaluation, so resource limits apply to all of its input
+	rt = rt.NewEvaluation(p.ctx)
 	p.doPrompt(rt)
-	select {
-	case <-p.ctx.Done():
-		return
-	default:
-		for tok := p.tokSrc.NextToken(); ; tok = p.tokSrc.NextToken() {
-			switch tok.Type {
-			case lexer.TokenEOF:
-				if p.verbose > Quiet {
-					_, _ = fmt.Fprintln(rt.Out, "Bye")
-				}
-				return
-			case lexer.TokenError:
-				_, _ = fmt.Fprintf(rt.Err, "Error %#v", tok)
-			case lexer.TokenLParen:
-				//start new S - Expression
-				val, err := EvalSExpression(p, rt)
-				if err != nil {
-					_, _ = fmt.Fprintf(rt.Err, "Error %v\n", err)
-				}
-				_, err = builtins.DisplayImpl(val, rt)
-				if err != nil {
-					_, _ = fmt.Fprintf(rt.Err, "Error %v\n", err)
-				}
-				p.doPrompt(rt)
+	for {
+		val, err := p.replEval(dbg, rt)
+		switch {
+		case errors.Is(err, ErrEof), errors.Is(err, context.Canceled):
+			if p.verbose > Quiet {
+				_, _ = fmt.Fprintln(rt.Out, "Bye")
 			}
-			p.exprnNo++
+			return
+		case err != nil:
+			ReportError(rt.Err, err)
+		case val.Type() != types.Void:
+			_, err = builtins.DisplayImpl(values.List(val), rt)
+			if err != nil {
+				ReportError(rt.Err, err)
+			}
+			_, _ = fmt.Fprintln(rt.Out)
 		}
+		p.exprnNo++
+		p.doPrompt(rt)
+	}
+}
+
+// backtraceLimit is the number of lines of a stack trace printed with an error.
+const backtraceLimit = 10
+
+// ReportError prints err followed by the Scheme stack trace of the condition it carries, if any.
+func ReportError(w io.Writer, err error) {
+	_, _ = fmt.Fprintf(w, "Error %v\n", err)
+	var c *values.Condition
+	if errors.As(err, &c) {
+		_, _ = io.WriteString(w, c.Backtrace(backtraceLimit))
 	}
 }
 
@@ -125,81 +161,224 @@ func (p *Parser) doPrompt(rt *builtins.Runtime) {
 	}
 }
 
+// EvalString evaluates every datum in str and displays the value of the last one.
 func EvalString(ctx context.Context, str string, rt *builtins.Runtime) (values.Interface, error) {
-	p := New(ctx, lexer.New(bytes.NewBufferString(str)))
-	val, err := EvalSExpression(p, rt)
-	p.exprnNo++
-	_, err = builtins.DisplayImpl(val, rt)
+	val, err := EvalReader(ctx, bytes.NewBufferString(str), rt)
 	if err != nil {
-		_, _ = fmt.Fprintf(rt.Err, "Error %v\n", err)
+		return val, err
+	}
+	_, err = builtins.DisplayImpl(values.List(val), rt)
+	if err != nil {
+		ReportError(rt.Err, err)
 	}
 	return val, err
 }
 
+// EvalReader evaluates every datum read from r and returns the value of the last one.
+// Unlike EvalString it does not display the result. Evaluation stops with the error of ctx
+// once ctx is canceled or its deadline passes. The datums are read by a parser made with opts,
+// and evaluated as a single evaluation, so resource limits apply to them together.
+func EvalReader(ctx context.Context, r io.Reader, rt *builtins.Runtime, opts ...Option) (values.Interface, error) {
+	p := New(ctx, lexer.New(r), opts...)
+	rt = rt.NewEvaluation(ctx)
+	var val = values.NewVoidType()
+	for {
+		next, err := evalNext(p, rt)
+		if errors.Is(err, ErrEof) {
+			return val, nil
+		}
+		p.exprnNo++
+		if err != nil {
+			return next, err
+		}
+		val = next
+	}
+}
+
+// ReadDatum reads the next complete datum from the token source.
+// It returns ErrEof when the input ends before a datum starts.
 func ReadDatum(p *Parser, rt *builtins.Runtime) (values.Interface, error) {
 	select {
 	case <-p.ctx.Done():
 		return values.NewVoidType(), p.ctx.Err()
 	default:
+		return p.readDatum(p.nextToken(rt), rt)
+	}
+}
+
+// ReadAll reads every datum from the token source.
+func ReadAll(p *Parser, rt *builtins.Runtime) ([]values.Interface, error) {
+	var datums []values.Interface
+	for {
+		datum, err := ReadDatum(p, rt)
+		if errors.Is(err, ErrEof) {
+			return datums, nil
+		}
+		if err != nil {
+			return datums, err
+		}
+		datums = append(datums, datum)
+	}
+}
+
+// DefaultDatumReader returns a builtins.DatumReader backed by the lexer and ReadAll.
+func DefaultDatumReader() builtins.DatumReader {
+	return func(src io.Reader, filename string, rt *builtins.Runtime) ([]values.Interface, error) {
+		datums, err := ReadAll(New(rt.Context(), lexer.NewNamed(src, filename)), rt)
+		if err != nil {
+			return datums, fmt.Errorf("%s: %w", filename, err)
+		}
+		return datums, nil
+	}
+}
+
+// nextToken returns the next significant token, skipping comments.
+func (p *Parser) nextToken(rt *builtins.Runtime) lexer.Token {
+	for {
 		tok := p.tokSrc.NextToken()
+		if p.verbose >= Debug {
+			_, _ = fmt.Fprintf(rt.Err, "Token Runtime: %v Token Literal: %v\n", tok.Type, tok.Literal)
+		}
+		if tok.Type != lexer.TokenLineComment && tok.Type != lexer.TokenBlockComment {
+			return tok
+		}
+	}
+}
+
+func (p *Parser) readDatum(tok lexer.Token, rt *builtins.Runtime) (values.Interface, error) {
+	switch tok.Type {
+	case lexer.TokenEOF:
+		return values.NewVoidType(), ErrEof
+	case lexer.TokenError:
+		return values.NewVoidType(), fmt.Errorf("%w: %s at %v", ErrInvalidToken, tok.Literal, p.tokSrc.Position())
+	case lexer.TokenDatumComment:
+		if err := p.skipDatum(rt); err != nil {
+			return values.NewVoidType(), err
+		}
+		return p.readDatum(p.nextToken(rt), rt)
+	case lexer.TokenLParen:
+		return p.readList(lexer.TokenRParen, rt)
+	case lexer.TokenLBracket:
+		return p.readList(lexer.TokenRBracket, rt)
+	case lexer.TokenVectorStart:
+		elements, err := p.readList(lexer.TokenRParen, rt)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		items, err := values.ToSlice(elements)
+		if err != nil {
+			return values.NewVoidType(), fmt.Errorf("%w: dotted vector literal", ErrInvalidFormat)
+		}
+		return values.Constant(values.NewVector(items...)), nil
+	case lexer.TokenQuot:
+		quotedExpr, err := ReadDatum(p, rt)
+		if errors.Is(err, ErrEof) {
+			return values.NewVoidType(), ErrUnterminatedList
+		}
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		return values.Constant(values.List(values.NewIdentifier("quote"), quotedExpr)), nil
+	case lexer.TokenColonIdent:
+		return values.NewIdentifier(tok.Literal), nil
+	case
+		lexer.TokenIdent,
+		lexer.TokenNumber,
+		lexer.TokenFloat,
+		lexer.TokenString,
+		lexer.TokenBoolean,
+		lexer.TokenRune,
+		lexer.TokenRelationalOperator,
+		lexer.TokenArithmeticOperator:
+		return values.FromToken(tok), nil
+	}
+	return values.NewVoidType(), fmt.Errorf("%w: %s at %v", ErrUnexpectedToken, tok.Literal, p.tokSrc.Position())
+}
 
-		var atoms = values.NewNil()
-		for ; tok.Type != lexer.TokenEOF; tok = p.tokSrc.NextToken() {
-			if p.verbose >= Debug {
-				_, _ = fmt.Fprintf(rt.Err, "Token Runtime: %v Token Literal: %v\n", tok.Type, tok.Literal)
+// readList reads the elements of a list up to the closing token, after its opening token was consumed.
+// The list is a literal constant, as quoting it yields the list read here.
+func (p *Parser) readList(closing lexer.TokenType, rt *builtins.Runtime) (values.Interface, error) {
+	var items []values.Interface
+	pos := p.tokSrc.Position()
+	for {
+		tok := p.nextToken(rt)
+		switch tok.Type {
+		case closing:
+			return values.Constant(values.Located(values.List(items...), pos)), nil
+		case lexer.TokenEOF:
+			return values.NewVoidType(), ErrUnterminatedList
+		case lexer.TokenDatumComment:
+			if err := p.skipDatum(rt); err != nil {
+				return values.NewVoidType(), err
 			}
-			switch tok.Type {
-			case lexer.TokenQuot:
-				quotedExpr, err := ReadDatum(p, rt)
-				if err != nil {
-					return values.NewVoidType(), err
-				}
-				//quotPair := values.Cons(values.NewIdentifier("quot"), values.Cons(quotedExpr, values.NewNil()))
-				return values.Cons(values.NewQuot(values.NewNil()), quotedExpr), nil
-			case lexer.TokenEOF:
-				return values.NewVoidType(), nil
-			case lexer.TokenError:
-				return values.NewVoidType(), ErrInvalidToken
-			case lexer.TokenLParen:
-				nestedExpr, err := ReadDatum(p, rt)
-				if err != nil {
+			continue
+		case lexer.TokenDot:
+			if len(items) == 0 {
+				return values.NewVoidType(), fmt.Errorf("%w: %s at %v", ErrUnexpectedToken, tok.Literal, p.tokSrc.Position())
+			}
+			tail, err := ReadDatum(p, rt)
+			if errors.Is(err, ErrEof) {
+				return values.NewVoidType(), ErrUnterminatedList
+			}
+			if err != nil {
+				return values.NewVoidType(), err
+			}
+			end := p.nextToken(rt)
+			for ; end.Type == lexer.TokenDatumComment; end = p.nextToken(rt) {
+				if err := p.skipDatum(rt); err != nil {
 					return values.NewVoidType(), err
 				}
-				if atoms.Type() == types.Nil {
-					atoms = nestedExpr
-					continue
-				}
-				atoms = values.Cons(nestedExpr, atoms)
-			case
-				lexer.TokenIdent,
-				lexer.TokenInt,
-				lexer.TokenString,
-				lexer.TokenBoolean,
-				lexer.TokenRelationalOperator,
-				lexer.TokenArithmeticOperator:
-				atoms = values.Cons(values.FromToken(tok), atoms)
-			case lexer.TokenRParen:
-				if atoms.Type() == types.Nil {
-					return values.NewNil(), nil
-				}
-				return values.Reverse(atoms), nil
-			default:
-				return values.NewVoidType(), ErrInvalidToken
 			}
+			if end.Type != closing {
+				return values.NewVoidType(), fmt.Errorf("%w: %s at %v", ErrUnexpectedToken, end.Literal, p.tokSrc.Position())
+			}
+			return values.Constant(values.Located(values.ListWithTail(tail, items...), pos)), nil
 		}
-		if values.Cdr(atoms).Type() != types.Nil {
-			return atoms, nil
+		item, err := p.readDatum(tok, rt)
+		if err != nil {
+			return values.NewVoidType(), err
 		}
-		return values.Car(atoms), nil
+		items = append(items, item)
+	}
+}
+
+// skipDatum reads the datum commented out by a #; datum comment, after the comment.
+func (p *Parser) skipDatum(rt *builtins.Runtime) error {
+	_, err := ReadDatum(p, rt)
+	if errors.Is(err, ErrEof) {
+		return fmt.Errorf("%w: #; at end of input", ErrUnexpectedToken)
 	}
+	return err
 }
 
+// EvalSExpression reads the next datum and evaluates it as a new evaluation bounded by the parser's context.
 func EvalSExpression(p *Parser, rt *builtins.Runtime) (values.Interface, error) {
+	return evalNext(p, rt.NewEvaluation(p.ctx))
+}
+
+// evalNext reads the next datum and evaluates it as part of the evaluation of rt.
+func evalNext(p *Parser, rt *builtins.Runtime) (values.Interface, error) {
 	val, err := ReadDatum(p, rt)
 	if err != nil {
 		return values.NewVoidType(), err
 	}
-	return evalSexpression(val, rt)
+	return p.evaluate(val, rt, true)
+}
+
+// evaluate evaluates datum, compiled to bytecode or analyzed as configured when compiled
+// is set and the compiler or analyzer handles it.
+func (p *Parser) evaluate(datum values.Interface, rt *builtins.Runtime, compiled bool) (values.Interface, error) {
+	switch {
+	case compiled && p.bytecode:
+		if program, err := vm.Compile(datum, rt); err == nil {
+			return program.Run(rt)
+		}
+	case compiled && p.analysis:
+		if expr, err := analyze.Analyze(datum, rt); err == nil {
+			return expr.Eval(rt)
+		}
+	}
+	return evalSexpression(datum, rt)
 }
 
 func DefaultExpressionEvaluator() builtins.Expression {
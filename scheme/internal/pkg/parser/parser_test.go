@@ -3,6 +3,10 @@ package parser
 import (
 	"bytes"
 	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/lexer"
@@ -263,6 +267,125 @@ func TestEvalSExpression(t *testing.T) {
 			},
 			want: values.NewInt(16),
 		},
+		{
+			name: "define and call procedure",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(begin (define (square x) (* x x)) (square 7))"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.NewInt(49),
+		},
+		{
+			name: "lambda with rest formals",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("((lambda (a . rest) rest) 1 2 3)"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.List(values.NewInt(2), values.NewInt(3)),
+		},
+		{
+			name: "if alternate",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(if (< 2 1) 1 2)"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.NewInt(2),
+		},
+		{
+			name: "let bindings",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(let ((x 2) (y 3)) (* x y))"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.NewInt(6),
+		},
+		{
+			name: "named let tail recursion",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(let loop ((i 0)) (if (= i 100000) i (loop (+ i 1))))"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.NewInt(100000),
+		},
+		{
+			name: "difference",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(- 10 4 3)"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.NewInt(3),
+		},
+		{
+			name: "negation",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(- 4)"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.NewInt(-4),
+		},
+		{
+			name: "vector literal",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(vector-ref #(1 2 3) 2)"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.NewInt(3),
+		},
+		{
+			name: "vector index out of range",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(vector-ref (make-vector 2 0) 2)"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want:    values.NewVoidType(),
+			wantErr: true,
+		},
+		{
+			name: "cond receiver clause",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(cond ((> 1 2) 0) ((+ 1 1) => (lambda (x) (* x 10))) (else 1))"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.NewInt(20),
+		},
+		{
+			name: "guard error object",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(guard (e ((error-object? e) (error-object-message e))) (error \"boom\" 1))"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.NewString("boom"),
+		},
+		{
+			name: "guard raised value",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(guard (e ((number? e) (+ e 1))) (raise 41))"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want: values.NewInt(42),
+		},
+		{
+			name: "guard re-raises unmatched",
+			args: args{
+				p: New(context.Background(), lexer.New(bytes.NewBufferString("(guard (e ((string? e) e)) (raise 1))"))),
+				rt: builtins.NewRuntime(builtins.WithOut(bytes.NewBuffer(nil)),
+					builtins.WithEvaluatorCallback(evalSexpression)),
+			},
+			want:    values.NewVoidType(),
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -277,3 +400,49 @@ func TestEvalSExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestReadComments(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{
+			name: "block comments nest",
+			src:  "#| a #| b |# c |# 1",
+			want: values.NewInt(1),
+		},
+		{
+			name: "datum comments",
+			src:  "(list 1 #;(car '()) 2 #; 3) #;4",
+			want: values.List(values.NewInt(1), values.NewInt(2)),
+		},
+		{
+			name: "datum comments in dotted lists",
+			src:  "'(1 . #;2 3 #;4)",
+			want: values.ListWithTail(values.NewInt(3), values.NewInt(1)),
+		},
+		{
+			name:    "datum comment at end of input",
+			src:     "1 #;",
+			wantErr: ErrUnexpectedToken,
+		},
+		{
+			name:    "unterminated block comment",
+			src:     "#| 1",
+			wantErr: ErrInvalidToken,
+		},
+	})
+}
+
+func TestReadErrorsName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.scm")
+	if err := os.WriteFile(path, []byte("(list #x)"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = EvalReader(context.Background(), f, newTestRuntime(io.Discard))
+	if want := "at " + path + ":1:7"; err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("EvalReader() error = %v, want one ending with %s", err, want)
+	}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+func TestProcedureNames(t *testing.T) {
+	root, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runEvalTests(t, []evalTest{
+		{
+			name:    "builtin",
+			src:     "(write car)",
+			wantOut: "#<procedure car>",
+		},
+		{
+			name:    "equivalence",
+			src:     "(write eq?)",
+			wantOut: "#<procedure eq?>",
+		},
+		{
+			name:    "defined procedure",
+			src:     "\n\n(define (fact n)\n  (if (= n 0) 1 (* n (fact (- n 1)))))\n(write fact)",
+			wantOut: "#<procedure fact at line 3>",
+		},
+		{
+			name:    "defined lambda",
+			src:     "(define square (lambda (x) (* x x))) (write square)",
+			wantOut: "#<procedure square at line 1>",
+		},
+		{
+			name:    "anonymous lambda",
+			src:     "(write (lambda (x) x))",
+			wantOut: "#<procedure at line 1>",
+		},
+		{
+			name:    "procedure defined in a loaded file",
+			src:     `(load "testdata/load/main.scm") (write helper)`,
+			wantOut: "#<procedure helper at helper.scm:1>",
+		},
+		{
+			name:    "procedure defined in a file loaded by its absolute path",
+			src:     `(load "` + filepath.Join(root, "load", "main.scm") + `") (write helper)`,
+			wantOut: "#<procedure helper at helper.scm:1>",
+		},
+		{
+			name:    "procedures defined in a library",
+			src:     "(import (mylib util)) (write (list double triple))",
+			opts:    []builtins.OptionRuntime{builtins.WithLibraryPath(root)},
+			wantOut: "(#<procedure double at mylib/util.sld:7> #<procedure triple-impl at util-helpers.scm:1>)",
+		},
+		{
+			name:    "record procedures",
+			src:     "(define-record-type point (make-point x) point? (x point-x)) (write point-x)",
+			wantOut: "#<procedure point-x>",
+		},
+		{
+			name:    "arity errors name the procedure",
+			src:     "(car '(1) '(2))",
+			wantMsg: "car: wrong number of arguments: expected 1, got 2",
+		},
+		{
+			name:    "type errors name the procedure",
+			src:     "(vector-ref '(1) 0)",
+			wantMsg: "vector-ref: type mismatch",
+		},
+		{
+			name:    "arity errors of defined procedures",
+			src:     "(define (f x) x) (f)",
+			wantMsg: "f: wrong number of arguments: expected 1, got 0",
+		},
+	})
+}
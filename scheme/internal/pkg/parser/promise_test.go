@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestPromises(t *testing.T) {
+	ints := func(items ...int64) values.Interface {
+		list := make([]values.Interface, len(items))
+		for i, item := range items {
+			list[i] = values.NewInt(item)
+		}
+		return values.List(list...)
+	}
+	runEvalTests(t, []evalTest{
+		{
+			name: "delay is evaluated once",
+			src:  "(define n 0) (define p (delay (begin (set! n (+ n 1)) n))) (force p) (force p) (list (force p) n)",
+			want: ints(1, 1),
+		},
+		{
+			name: "delay is not evaluated until forced",
+			src:  "(define p (delay (error \"forced\"))) (promise? p)",
+			want: values.NewBool(true),
+		},
+		{
+			name: "make-promise and force of other values",
+			src:  "(define p (make-promise 3)) (list (force p) (eq? p (make-promise p)) (force 4) (promise? 4))",
+			want: values.List(values.NewInt(3), values.NewBool(true), values.NewInt(4), values.NewBool(false)),
+		},
+		{
+			name: "delay-force runs in constant space",
+			src: `(define (loop n) (delay-force (if (= n 0) (delay 'done) (loop (- n 1)))))
+			      (force (loop 100000))`,
+			want: values.NewIdentifier("done"),
+		},
+		{
+			name: "a promise forced by its own expression keeps the first value",
+			src: `(define count 0)
+			      (define p (delay (begin (set! count (+ count 1)) (if (> count 5) count (force p)))))
+			      (force p)`,
+			want: values.NewInt(6),
+		},
+		{
+			name:    "delay-force requires a promise",
+			src:     "(force (delay-force 1))",
+			wantErr: builtins.ErrTypeMismatch,
+		},
+		{
+			name: "infinite streams",
+			src: `(define (integers-from n) (stream-cons n (integers-from (+ n 1))))
+			      (define (even? n) (= (modulo n 2) 0))
+			      (stream->list (stream-take 4 (stream-map * (stream-filter even? (integers-from 1)) (integers-from 1))))`,
+			want: ints(2, 8, 18, 32),
+		},
+		{
+			name: "stream elements are lazy",
+			src: `(define s (stream-cons (error "car") (stream-cons 2 stream-null)))
+			      (list (stream-pair? s) (stream-car (stream-cdr s)) (stream-null? (stream-cdr (stream-cdr s))))`,
+			want: values.List(values.NewBool(true), values.NewInt(2), values.NewBool(true)),
+		},
+		{
+			name: "stream-filter skips long runs",
+			src:  "(define (from n) (stream-cons n (from (+ n 1)))) (stream-car (stream-filter (lambda (n) (> n 50000)) (from 0)))",
+			want: values.NewInt(50001),
+		},
+		{
+			name: "list->stream and stream->list",
+			src:  "(list (stream->list (list->stream '(1 2 3))) (stream->list (list->stream '(1 2 3)) 2) (stream? stream-null))",
+			want: values.List(ints(1, 2, 3), ints(1, 2), values.NewBool(true)),
+		},
+		{
+			name:    "stream-car of the empty stream",
+			src:     "(stream-car stream-null)",
+			wantErr: builtins.ErrBadArgument,
+		},
+		{
+			name: "streams from a port",
+			src: `(define (lines port) (let ((line (read-line port)))
+			        (if (eof-object? line) stream-null (stream-cons line (lines port)))))
+			      (stream->list (stream-filter (lambda (l) (string=? l "ERROR")) (lines (open-input-string "INFO\nERROR\nINFO\nERROR\n"))))`,
+			want: values.List(values.NewString("ERROR"), values.NewString("ERROR")),
+		},
+	})
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestRecords(t *testing.T) {
+	const point = "(define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)) "
+	runEvalTests(t, []evalTest{
+		{
+			name: "constructor and accessors",
+			src:  point + "(define p (make-point 1 2)) (list (point-x p) (point-y p))",
+			want: values.List(values.NewInt(1), values.NewInt(2)),
+		},
+		{
+			name: "predicate",
+			src:  point + "(list (point? (make-point 1 2)) (point? (vector 1 2)) (procedure? point?))",
+			want: values.List(values.NewBool(true), values.NewBool(false), values.NewBool(true)),
+		},
+		{
+			name: "modifier",
+			src:  point + "(define p (make-point 1 2)) (set-point-x! p 10) (point-x p)",
+			want: values.NewInt(10),
+		},
+		{
+			name:    "display",
+			src:     point + `(display (make-point 1 "two")) (write (make-point 1 "two"))`,
+			want:    values.NewVoidType(),
+			wantOut: `#<record point x=1 y=two>#<record point x=1 y="two">`,
+		},
+		{
+			name: "equal? compares fields, eqv? identity",
+			src: point + `(define p (make-point 1 2))
+			      (list (equal? p (make-point 1 2)) (equal? p (make-point 1 3)) (eqv? p (make-point 1 2)) (eqv? p p))`,
+			want: values.List(values.NewBool(true), values.NewBool(false), values.NewBool(false), values.NewBool(true)),
+		},
+		{
+			name: "records of different types are not equal?",
+			src: point + `(define-record-type other (make-other x y) other? (x other-x) (y other-y))
+			      (equal? (make-point 1 2) (make-other 1 2))`,
+			want: values.NewBool(false),
+		},
+		{
+			name: "records as equal? hash table keys",
+			src:  point + "(define h (make-hash-table)) (hash-table-set! h (make-point 1 2) 'found) (hash-table-ref h (make-point 1 2))",
+			want: values.NewIdentifier("found"),
+		},
+		{
+			name: "fields missing from the constructor are #f",
+			src:  "(define-record-type node (make-node value) node? (value node-value) (next node-next set-node-next!)) (node-next (make-node 1))",
+			want: values.NewBool(false),
+		},
+		{
+			name:    "accessor checks the record type",
+			src:     point + "(define-record-type other (make-other) other?) (point-x (make-other))",
+			wantErr: builtins.ErrTypeMismatch,
+		},
+		{
+			name:    "constructor arity",
+			src:     point + "(make-point 1)",
+			wantErr: builtins.ErrWrongNumberOfArguments,
+		},
+		{
+			name:    "constructor fields must be declared",
+			src:     "(define-record-type point (make-point x z) point? (x point-x))",
+			wantErr: builtins.ErrBadArgument,
+		},
+		{
+			name: "no constructor",
+			src:  "(define-record-type abstract #f abstract?) (abstract? 1)",
+			want: values.NewBool(false),
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestListLibrary(t *testing.T) {
+	ints := func(ns ...int64) values.Interface {
+		items := make([]values.Interface, len(ns))
+		for i, n := range ns {
+			items[i] = values.NewInt(n)
+		}
+		return values.List(items...)
+	}
+	even := "(define (even? n) (= (modulo n 2) 0)) "
+	runEvalTests(t, []evalTest{
+		{name: "filter", src: even + "(filter even? '(1 2 3 4))", want: ints(2, 4)},
+		{name: "remove", src: even + "(remove even? '(1 2 3 4))", want: ints(1, 3)},
+		{
+			name: "partition returns two values",
+			src:  even + "(call-with-values (lambda () (partition even? '(1 2 3 4 5))) (lambda (in out) (list in out)))",
+			want: values.List(ints(2, 4), ints(1, 3, 5)),
+		},
+		{name: "fold", src: "(fold cons '() '(1 2 3))", want: ints(3, 2, 1)},
+		{name: "fold over several lists", src: "(fold (lambda (a b acc) (+ acc (* a b))) 0 '(1 2 3) '(4 5))", want: values.NewInt(14)},
+		{name: "fold-right", src: "(fold-right cons '() '(1 2 3))", want: ints(1, 2, 3)},
+		{name: "reduce", src: "(list (reduce + 0 '(1 2 3 4)) (reduce + 0 '()))", want: ints(10, 0)},
+		{name: "append-map", src: "(append-map (lambda (x) (list x x)) '(1 2))", want: ints(1, 1, 2, 2)},
+		{name: "filter-map", src: even + "(filter-map (lambda (x) (if (even? x) (* x 10) #f)) '(1 2 3 4))", want: ints(20, 40)},
+		{name: "find", src: even + "(list (find even? '(1 3 4 6)) (find even? '(1 3)))", want: values.List(values.NewInt(4), values.NewBool(false))},
+		{name: "find-tail", src: even + "(find-tail even? '(1 3 4 5))", want: ints(4, 5)},
+		{name: "any returns the true result", src: even + "(list (any (lambda (x) (if (even? x) x #f)) '(1 4 6)) (any < '(3 1) '(2 2)) (any even? '()))", want: values.List(values.NewInt(4), values.NewBool(true), values.NewBool(false))},
+		{name: "every", src: even + "(list (every even? '(2 4)) (every even? '(2 3)) (every even? '()) (every (lambda (x) x) '(1 2)))", want: values.List(values.NewBool(true), values.NewBool(false), values.NewBool(true), values.NewInt(2))},
+		{name: "list-index", src: even + "(list (list-index even? '(1 3 4)) (list-index even? '(1 3)) (list-index = '(1 2 3) '(3 2 1)))", want: values.List(values.NewInt(2), values.NewBool(false), values.NewInt(1))},
+		{name: "delete", src: "(delete 2 '(1 2 3 2))", want: ints(1, 3)},
+		{name: "delete with an equality procedure", src: "(delete 2 '(1 2 3 4) <)", want: ints(1, 2)},
+		{name: "delete-duplicates", src: "(delete-duplicates '(1 2 1 3 (a) (a) 2))", want: values.List(values.NewInt(1), values.NewInt(2), values.NewInt(3), values.List(values.NewIdentifier("a")))},
+		{name: "delete-duplicates with a procedure", src: "(delete-duplicates '(1 2 3 4 5) (lambda (a b) (= (modulo a 2) (modulo b 2))))", want: ints(1, 2)},
+		{name: "iota", src: "(list (iota 3) (iota 3 1) (iota 3 0 2))", want: values.List(ints(0, 1, 2), ints(1, 2, 3), ints(0, 2, 4))},
+		{name: "iota with a float step", src: "(iota 2 0 0.5)", want: values.List(values.NewFloat(0), values.NewFloat(0.5))},
+		{name: "take and drop", src: "(list (take '(1 2 3 4) 2) (drop '(1 2 3 4) 2))", want: values.List(ints(1, 2), ints(3, 4))},
+		{name: "take beyond the end", src: "(take '(1) 2)", wantErr: builtins.ErrIndexOutOfRange},
+		{name: "last", src: "(last '(1 2 3))", want: values.NewInt(3)},
+		{name: "alist-copy", src: "(alist-copy '((a . 1) (b . 2)))", want: values.List(values.Cons(values.NewIdentifier("a"), values.NewInt(1)), values.Cons(values.NewIdentifier("b"), values.NewInt(2)))},
+		{name: "alist-delete", src: "(alist-delete 'a '((a . 1) (b . 2) (a . 3)))", want: values.List(values.Cons(values.NewIdentifier("b"), values.NewInt(2)))},
+		{name: "builtins and lambdas are both procedures", src: "(fold + 0 (filter-map (lambda (x) (* x x)) '(1 2 3)))", want: values.NewInt(14)},
+		{name: "not a list", src: "(filter car 1)", wantErr: builtins.ErrTypeMismatch},
+	})
+}
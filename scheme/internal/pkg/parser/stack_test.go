@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/lexer"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+func TestStackTraces(t *testing.T) {
+	tests := []evalTest{
+		{
+			name: "nested calls",
+			src: `(define (inner x) (car x))
+			      (define (outer x) (+ 1 (inner x)))
+			      (outer 5)`,
+			wantStack: []string{"car, called at line 1", "inner, called at line 2", "outer, called at line 3"},
+		},
+		{
+			name: "tail calls are collapsed",
+			src: `(define (loop n) (if (= n 0) (raise 'done) (loop (- n 1))))
+			      (loop 50)`,
+			wantStack: []string{"raise, called at line 1", "loop, called at line 1, after 50 tail calls"},
+		},
+		{
+			name:      "procedures called from builtins",
+			src:       "(map (lambda (x) (error \"bad\" x)) '(1))",
+			wantStack: []string{"error, called at line 1", "anonymous procedure at line 1", "map, called at line 1"},
+		},
+		{
+			name:      "handled errors leave the stack",
+			src:       "(define (f) (guard (e (#t 1)) (car 1))) (f) (cdr 1)",
+			wantStack: []string{"cdr, called at line 1"},
+		},
+	}
+	runEvalTests(t, tests)
+}
+
+func TestReplBacktrace(t *testing.T) {
+	src := `(define (count-down n) (if (= n 0) (vector-ref (vector) 0) (+ 1 (count-down (- n 1)))))
+(count-down 20)
+(define (ping n) (if (= n 0) (car n) (+ 1 (pong (- n 1)))))
+(define (pong n) (+ 1 (ping n)))
+(ping 10)
+(guard (e ((error-object? e) (display (error-object-message e)))) (count-down 1))
+`
+	out, errOut := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	p := New(context.Background(), lexer.New(bytes.NewBufferString(src)), WithPrompt(""))
+	p.Repl(builtins.WithOut(out), builtins.WithErr(errOut), builtins.WithEvaluatorCallback(evalSexpression))
+	want := `Error index out of range: 0
+  in vector-ref, called at line 1
+  in count-down, called at line 1 (20 times)
+  in count-down, called at line 2
+Error car: type mismatch
+  in car, called at line 3
+  in ping, called at line 4
+  in pong, called at line 3
+  in ping, called at line 4
+  in pong, called at line 3
+  in ping, called at line 4
+  in pong, called at line 3
+  in ping, called at line 4
+  in pong, called at line 3
+  in ping, called at line 4
+  ... 12 more calls
+`
+	if errOut.String() != want {
+		t.Errorf("Repl() error output = %q, want %q", errOut.String(), want)
+	}
+	if !strings.Contains(out.String(), "index out of range") {
+		t.Errorf("Repl() output = %q, want the handled error", out.String())
+	}
+}
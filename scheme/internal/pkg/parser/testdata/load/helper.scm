@@ -0,0 +1 @@
+(define (helper n) (+ n 1))
@@ -0,0 +1,3 @@
+; loads its helper relative to its own directory
+(load "helper.scm")
+(define loaded (helper 20))
@@ -0,0 +1 @@
+(define (triple-impl x) (* 3 x))
@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestThreads(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{
+			name: "join returns the result of the thunk",
+			src:  "(thread-join! (thread-start! (make-thread (lambda () (* 6 7)))))",
+			want: values.NewInt(42),
+		},
+		{
+			name: "threads share the global environment",
+			src:  "(define box (vector 1)) (thread-join! (thread-start! (make-thread (lambda () (vector-set! box 0 2))))) (vector-ref box 0)",
+			want: values.NewInt(2),
+		},
+		{
+			name: "join raises the exception of the thread",
+			src: `(guard (e ((error-object? e) (error-object-message e)))
+			        (thread-join! (thread-start! (make-thread (lambda () (error "failed"))))))`,
+			want: values.NewString("failed"),
+		},
+		{
+			name: "join timeout value",
+			src:  "(thread-join! (thread-start! (make-thread (lambda () (thread-sleep! 1)))) 0.01 'late)",
+			want: values.NewIdentifier("late"),
+		},
+		{
+			name:    "join timeout",
+			src:     "(thread-join! (thread-start! (make-thread (lambda () (thread-sleep! 1)))) 0.01)",
+			wantErr: builtins.ErrJoinTimeout,
+		},
+		{
+			name:    "terminated thread",
+			src:     "(define t (thread-start! (make-thread (lambda () (let loop () (loop)))))) (thread-terminate! t) (thread-join! t)",
+			wantErr: builtins.ErrThreadTerminated,
+		},
+		{
+			name:    "join an unstarted thread",
+			src:     "(thread-join! (make-thread (lambda () 1)))",
+			wantErr: builtins.ErrDeadlock,
+		},
+		{
+			name:    "join the current thread",
+			src:     "(thread-join! (current-thread))",
+			wantErr: builtins.ErrDeadlock,
+		},
+		{
+			name: "join an unstarted thread with a timeout",
+			src:  "(thread-join! (make-thread (lambda () 1)) 0.01 'timeout)",
+			want: values.NewIdentifier("timeout"),
+		},
+		{
+			name: "thread name and specific",
+			src: `(define t (make-thread (lambda () (thread-specific-set! (current-thread) 'set) (thread-specific (current-thread))) "worker"))
+			      (list (thread-name t) (thread-join! (thread-start! t)) (thread? t))`,
+			want: values.List(values.NewString("worker"), values.NewIdentifier("set"), values.NewBool(true)),
+		},
+		{
+			name: "mutex protects a shared counter",
+			src: `(define m (make-mutex))
+			      (define counter (vector 0))
+			      (define (work n)
+			        (if (= n 0) 'done
+			            (begin (mutex-lock! m)
+			                   (vector-set! counter 0 (+ (vector-ref counter 0) 1))
+			                   (mutex-unlock! m)
+			                   (work (- n 1)))))
+			      (define threads (list (make-thread (lambda () (work 100))) (make-thread (lambda () (work 100))) (make-thread (lambda () (work 100)))))
+			      (let loop ((ts threads)) (if (null? ts) #t (begin (thread-start! (car ts)) (loop (cdr ts)))))
+			      (let loop ((ts threads)) (if (null? ts) #t (begin (thread-join! (car ts)) (loop (cdr ts)))))
+			      (vector-ref counter 0)`,
+			want: values.NewInt(300),
+		},
+		{
+			name: "mutex lock timeout",
+			src:  "(define m (make-mutex)) (mutex-lock! m) (list (mutex-lock! m 0.01) (thread? (mutex-state m)))",
+			want: values.List(values.NewBool(false), values.NewBool(true)),
+		},
+		{
+			name: "mutex abandoned by a terminated owner",
+			src: `(define m (make-mutex))
+			      (thread-join! (thread-start! (make-thread (lambda () (mutex-lock! m)))))
+			      (mutex-state m)`,
+			want: values.NewIdentifier("abandoned"),
+		},
+		{
+			name: "locking an abandoned mutex raises",
+			src: `(define m (make-mutex))
+			      (thread-join! (thread-start! (make-thread (lambda () (mutex-lock! m)))))
+			      (mutex-lock! m)`,
+			wantErr: builtins.ErrAbandonedMutex,
+		},
+		{
+			name: "locking an abandoned mutex locks it",
+			src: `(define m (make-mutex))
+			      (thread-join! (thread-start! (make-thread (lambda () (mutex-lock! m)))))
+			      (guard (e (#t (eq? (mutex-state m) (current-thread)))) (mutex-lock! m))`,
+			want: values.NewBool(true),
+		},
+		{
+			name: "mutex locked for no thread",
+			src:  "(define m (make-mutex)) (mutex-lock! m #f #f) (mutex-state m)",
+			want: values.NewIdentifier("not-owned"),
+		},
+		{
+			name: "mutex locked for another thread",
+			src:  "(define m (make-mutex)) (define t (make-thread (lambda () 1))) (mutex-lock! m #f t) (eq? (mutex-state m) t)",
+			want: values.NewBool(true),
+		},
+		{
+			name: "terminate a thread from another thread",
+			src: `(define t (make-thread (lambda () (let loop () (loop)))))
+			      (define killer (thread-start! (make-thread (lambda () (thread-terminate! t)))))
+			      (thread-start! t)
+			      (thread-join! killer)
+			      (thread-join! t)`,
+			wantErr: builtins.ErrThreadTerminated,
+		},
+		{
+			name:    "relock an owned mutex",
+			src:     "(define m (make-mutex)) (mutex-lock! m) (mutex-lock! m)",
+			wantErr: builtins.ErrDeadlock,
+		},
+		{
+			name: "unnamed objects",
+			src:  `(map (lambda (x) (let ((port (open-output-string))) (display x port) (get-output-string port))) (list (make-thread (lambda () 1)) (make-mutex) (make-condition-variable) (make-mutex "m")))`,
+			want: values.List(values.NewString("#<thread>"), values.NewString("#<mutex>"), values.NewString("#<condition-variable>"), values.NewString("#<mutex m>")),
+		},
+		{
+			name: "condition variable",
+			src: `(define m (make-mutex))
+			      (define cv (make-condition-variable))
+			      (define box (vector #f))
+			      (mutex-lock! m)
+			      (define consumer (thread-start! (make-thread (lambda ()
+			        (mutex-lock! m)
+			        (let wait () (if (vector-ref box 0) (begin (mutex-unlock! m) (vector-ref box 0)) (begin (mutex-unlock! m cv) (mutex-lock! m) (wait))))))))
+			      (vector-set! box 0 'ready)
+			      (condition-variable-broadcast! cv)
+			      (mutex-unlock! m)
+			      (thread-join! consumer)`,
+			want: values.NewIdentifier("ready"),
+		},
+		{
+			name: "channels",
+			src: `(define ch (make-channel))
+			      (thread-start! (make-thread (lambda () (channel-send ch 1) (channel-send ch 2) (channel-send ch 3) (channel-close! ch))))
+			      (let loop ((sum 0))
+			        (let ((v (channel-receive ch)))
+			          (if (eof-object? v) sum (loop (+ sum v)))))`,
+			want: values.NewInt(6),
+		},
+		{
+			name:    "send on closed channel",
+			src:     "(define ch (make-channel 1)) (channel-close! ch) (channel-send ch 1)",
+			wantErr: builtins.ErrChannelClosed,
+		},
+		{
+			name: "select receive",
+			src: `(define a (make-channel 1)) (define b (make-channel 1))
+			      (channel-send b 'from-b)
+			      (select (recv a v (list 'a v)) (recv b v (list 'b v)))`,
+			want: values.List(values.NewIdentifier("b"), values.NewIdentifier("from-b")),
+		},
+		{
+			name: "select send and else",
+			src: `(define full (make-channel))
+			      (list (select (send full 1 'sent) (else 'blocked))
+			            (let ((buffered (make-channel 1))) (select (send buffered 1 (channel-receive buffered)) (else 'blocked))))`,
+			want: values.List(values.NewIdentifier("blocked"), values.NewInt(1)),
+		},
+		{
+			name: "select timeout",
+			src:  "(select (recv (make-channel) v v) (after 0.01 'timeout))",
+			want: values.NewIdentifier("timeout"),
+		},
+	})
+}
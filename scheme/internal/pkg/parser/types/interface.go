@@ -11,7 +11,20 @@ const (
 	Pair               Type = "pair"
 	Nil                Type = "nil"
 	Lambda             Type = "lambda"
+	Syntax             Type = "syntax"
 	Map                Type = "map"
+	Vector             Type = "vector"
+	Condition          Type = "condition"
+	Eof                Type = "eof"
+	Thread             Type = "thread"
+	Mutex              Type = "mutex"
+	ConditionVariable  Type = "conditionVariable"
+	Channel            Type = "channel"
+	Port               Type = "port"
+	Record             Type = "record"
+	RecordType         Type = "recordType"
+	Promise            Type = "promise"
+	Values             Type = "values"
 	String             Type = "string"
 	Identifier         Type = "identifier"
 	Void               Type = "void"
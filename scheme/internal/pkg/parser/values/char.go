@@ -1,8 +1,6 @@
 package values
 
 import (
-	"fmt"
-
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
 )
 
@@ -17,8 +15,15 @@ type char struct {
 }
 
 func (c char) Equal(p Interface) bool {
-	//TODO implement me
-	panic("implement me")
+	other, ok := p.(char)
+	if !ok {
+		return false
+	}
+	return c.rune == other.rune
+}
+
+func (c char) Rune() rune {
+	return c.rune
 }
 
 func (c char) Type() types.Type {
@@ -26,7 +31,7 @@ func (c char) Type() types.Type {
 }
 
 func (c char) DisplayString() string {
-	return fmt.Sprintf("%q", c.rune)
+	return string(c.rune)
 }
 
 func (c char) WriteString() string {
@@ -0,0 +1,143 @@
+package values
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/scanner"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+)
+
+// Condition is an error object, created by the error procedure or from a Go error.
+// It is both a Scheme value and a Go error, so it can be raised by returning it.
+// Stack holds the procedure calls in progress when the condition was raised, innermost first.
+type Condition struct {
+	Message   string
+	Irritants []Interface
+	Err       error
+	Stack     []Frame
+}
+
+// Frame is a procedure call in progress, as recorded in the stack trace of a condition.
+type Frame struct {
+	// Procedure describes the procedure called.
+	Procedure string
+	// Call is the position of the call in source code, when it was read from source code.
+	Call scanner.Position
+	// TailCalls counts the tail calls that replaced the frame since it was called.
+	TailCalls int
+}
+
+func (f Frame) String() string {
+	s := f.Procedure
+	if f.Call.IsValid() {
+		s += ", called at " + Location(f.Call)
+	}
+	switch f.TailCalls {
+	case 0:
+	case 1:
+		s += ", after 1 tail call"
+	default:
+		s += fmt.Sprintf(", after %d tail calls", f.TailCalls)
+	}
+	return s
+}
+
+// Location formats pos as file:line, or as the line alone for code read without a file name.
+func Location(pos scanner.Position) string {
+	if pos.Filename == "" {
+		return fmt.Sprintf("line %d", pos.Line)
+	}
+	return fmt.Sprintf("%s:%d", pos.Filename, pos.Line)
+}
+
+// NewCondition creates an error object with a message and irritants as created by (error message irritant ...).
+func NewCondition(message string, irritants ...Interface) *Condition {
+	return &Condition{
+		Message:   message,
+		Irritants: irritants,
+	}
+}
+
+// ConditionFromError returns the Condition wrapped by err, or a new Condition
+// whose message is the text of err and which unwraps to err.
+func ConditionFromError(err error) *Condition {
+	var c *Condition
+	if errors.As(err, &c) {
+		return c
+	}
+	return &Condition{
+		Message: err.Error(),
+		Err:     err,
+	}
+}
+
+func (c *Condition) Error() string {
+	if len(c.Irritants) == 0 {
+		return c.Message
+	}
+	parts := make([]string, 0, len(c.Irritants)+1)
+	parts = append(parts, c.Message)
+	for _, irritant := range c.Irritants {
+		parts = append(parts, irritant.WriteString())
+	}
+	return strings.Join(parts, " ")
+}
+
+// Backtrace formats the stack trace of the condition as the function Backtrace does.
+// It returns "" when the condition has no stack trace.
+func (c *Condition) Backtrace(limit int) string {
+	return Backtrace(c.Stack, limit)
+}
+
+// Backtrace formats a stack trace, one call per line, innermost first. Runs of identical
+// frames, as left by deep recursion, are shown once with a count, and lines beyond limit
+// are summarised.
+func Backtrace(stack []Frame, limit int) string {
+	var sb strings.Builder
+	shown := 0
+	for i := 0; i < len(stack); {
+		j := i + 1
+		for j < len(stack) && stack[j] == stack[i] {
+			j++
+		}
+		if shown == limit {
+			fmt.Fprintf(&sb, "  ... %d more calls\n", len(stack)-i)
+			break
+		}
+		sb.WriteString("  in " + stack[i].String())
+		if j-i > 1 {
+			fmt.Fprintf(&sb, " (%d times)", j-i)
+		}
+		sb.WriteString("\n")
+		shown++
+		i = j
+	}
+	return sb.String()
+}
+
+func (c *Condition) Unwrap() error {
+	return c.Err
+}
+
+func (c *Condition) Equal(p Interface) bool {
+	other, ok := p.(*Condition)
+	return ok && other == c
+}
+
+func (c *Condition) Type() types.Type {
+	return types.Condition
+}
+
+func (c *Condition) IsTruthy() bool {
+	return true
+}
+
+func (c *Condition) DisplayString() string {
+	return "#<condition " + c.Error() + ">"
+}
+
+func (c *Condition) WriteString() string {
+	return c.DisplayString()
+}
@@ -0,0 +1,29 @@
+package values
+
+import "github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+
+// NewEof returns the end of file object, returned by reads from exhausted sources.
+func NewEof() Interface {
+	return eof{}
+}
+
+type eof struct {
+	truthyValue
+}
+
+func (e eof) Equal(p Interface) bool {
+	_, ok := p.(eof)
+	return ok
+}
+
+func (e eof) Type() types.Type {
+	return types.Eof
+}
+
+func (e eof) DisplayString() string {
+	return "#<eof>"
+}
+
+func (e eof) WriteString() string {
+	return "#<eof>"
+}
@@ -0,0 +1,160 @@
+package values
+
+import (
+	"hash/maphash"
+	"math"
+	"reflect"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+)
+
+// hashSeed seeds every hash computed by the process. Hashes are not stable across processes.
+var hashSeed = maphash.MakeSeed()
+
+// hashLimit bounds the number of elements of pairs and vectors that contribute to a hash,
+// so hashing long or circular structures terminates.
+const hashLimit = 64
+
+// Hash returns a hash of v such that values that are Equal have equal hashes.
+func Hash(v Interface) uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	budget := hashLimit
+	writeHash(&h, v, &budget)
+	return h.Sum64()
+}
+
+// HashIdentity returns a hash of v consistent with Eqv.
+func HashIdentity(v Interface) uint64 {
+	if p, ok := Identity(v); ok {
+		var h maphash.Hash
+		h.SetSeed(hashSeed)
+		_, _ = h.WriteString(string(v.Type()))
+		writeUint(&h, uint64(p))
+		return h.Sum64()
+	}
+	return Hash(v)
+}
+
+func writeHash(h *maphash.Hash, v Interface, budget *int) {
+	_, _ = h.WriteString(string(v.Type()))
+	if *budget <= 0 {
+		return
+	}
+	*budget--
+	switch val := v.(type) {
+	case Number:
+		if val.IsInt {
+			writeUint(h, uint64(val.IntVal))
+			return
+		}
+		f := val.FloatVal
+		if f == 0 {
+			// 0.0 and -0.0 are Equal
+			f = 0
+		}
+		writeUint(h, math.Float64bits(f))
+	case String:
+		_, _ = h.WriteString(val.String())
+	case Char:
+		writeUint(h, uint64(val.Rune()))
+	case identifierValue, operatorValue, booleanValue:
+		_, _ = h.WriteString(val.WriteString())
+	case Quot:
+		writeHash(h, val.GetValue(), budget)
+	case Pair:
+		var tail Interface = val
+		for pair, ok := tail.(Pair); ok && *budget > 0; pair, ok = tail.(Pair) {
+			writeHash(h, pair.Car(), budget)
+			tail = pair.Cdr()
+		}
+		writeHash(h, tail, budget)
+	case Vector:
+		writeUint(h, uint64(val.Len()))
+		for i := 0; i < val.Len() && *budget > 0; i++ {
+			writeHash(h, val.Ref(i), budget)
+		}
+	case *Record:
+		writeUint(h, uint64(reflect.ValueOf(val.rtype).Pointer()))
+		for i := 0; i < len(val.fields) && *budget > 0; i++ {
+			writeHash(h, val.fields[i], budget)
+		}
+	default:
+		// values compared by identity hash their address; the others, such as
+		// procedures, never compare Equal to another value and hash by type alone
+		if p, ok := Identity(v); ok {
+			writeUint(h, uint64(p))
+		}
+	}
+}
+
+func writeUint(h *maphash.Hash, u uint64) {
+	var b [8]byte
+	for i := range b {
+		b[i] = byte(u >> (8 * i))
+	}
+	_, _ = h.Write(b[:])
+}
+
+// Identity returns the address identifying a mutable value, such as a pair, vector, hash
+// table or other value held by pointer. Values without identity in this implementation,
+// such as numbers and strings, report false.
+func Identity(v Interface) (uintptr, bool) {
+	switch val := v.(type) {
+	case vectorValue:
+		if cap(val.items) == 0 {
+			return 0, true
+		}
+		return reflect.ValueOf(val.items).Pointer(), true
+	case hashTable:
+		return reflect.ValueOf(val.hashTableState).Pointer(), true
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer {
+		return rv.Pointer(), true
+	}
+	return 0, false
+}
+
+// Eqv reports whether a and b are equivalent in the sense of eqv?: mutable values are
+// the same object, and other values are Equal.
+func Eqv(a, b Interface) bool {
+	pa, oka := Identity(a)
+	pb, okb := Identity(b)
+	if oka || okb {
+		return oka && okb && pa == pb && a.Type() == b.Type()
+	}
+	return a.Equal(b)
+}
+
+// Comparator determines how a hash table compares its keys. Values that are equivalent
+// under Equal must have equal hashes, and keys must satisfy Accepts.
+type Comparator struct {
+	Name    string
+	Accepts types.TypeGate
+	Equal   func(a, b Interface) bool
+	Hash    func(v Interface) uint64
+}
+
+var (
+	// EqualComparator compares keys with equal?.
+	EqualComparator = &Comparator{
+		Name:    "equal?",
+		Accepts: func(types.Type) bool { return true },
+		Equal:   func(a, b Interface) bool { return a.Equal(b) },
+		Hash:    Hash,
+	}
+	// EqvComparator compares keys with eqv?.
+	EqvComparator = &Comparator{
+		Name:    "eqv?",
+		Accepts: func(types.Type) bool { return true },
+		Equal:   Eqv,
+		Hash:    HashIdentity,
+	}
+	// StringComparator compares string keys with string=?.
+	StringComparator = &Comparator{
+		Name:    "string=?",
+		Accepts: types.NewTypeGate(types.String),
+		Equal:   func(a, b Interface) bool { return a.Equal(b) },
+		Hash:    Hash,
+	}
+)
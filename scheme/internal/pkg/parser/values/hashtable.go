@@ -0,0 +1,193 @@
+package values
+
+import (
+	"fmt"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+)
+
+type HashTable interface {
+	Interface
+	Comparator() *Comparator
+	Ref(key Interface) (Interface, bool)
+	Set(key, value Interface)
+	Delete(key Interface) bool
+	Clear()
+	Len() int
+	Keys() []Interface
+	Values() []Interface
+	Copy() HashTable
+}
+
+// hashTable maps keys to values, comparing keys with its comparator.
+// Entries are kept in insertion order so that iteration is deterministic.
+// Deleting an entry leaves a nil key in its place, which is dropped when the
+// entries are compacted.
+type hashTable struct {
+	*hashTableState
+}
+
+type hashTableState struct {
+	cmp     *Comparator
+	buckets map[uint64][]int
+	keys    []Interface
+	values  []Interface
+	deleted int
+}
+
+// NewHashTable returns an empty hash table comparing keys with equal?.
+func NewHashTable() HashTable {
+	return NewHashTableWith(EqualComparator)
+}
+
+// NewHashTableWith returns an empty hash table comparing keys with cmp.
+func NewHashTableWith(cmp *Comparator) HashTable {
+	return hashTable{
+		hashTableState: &hashTableState{
+			cmp:     cmp,
+			buckets: make(map[uint64][]int),
+		},
+	}
+}
+
+func (h hashTable) Comparator() *Comparator {
+	return h.cmp
+}
+
+// find returns the hash of key and the index of its entry, or -1 when it is absent.
+func (h hashTable) find(key Interface) (uint64, int) {
+	sum := h.cmp.Hash(key)
+	for _, i := range h.buckets[sum] {
+		if h.cmp.Equal(h.keys[i], key) {
+			return sum, i
+		}
+	}
+	return sum, -1
+}
+
+func (h hashTable) Ref(key Interface) (Interface, bool) {
+	if _, i := h.find(key); i >= 0 {
+		return h.values[i], true
+	}
+	return nil, false
+}
+
+func (h hashTable) Set(key, value Interface) {
+	sum, i := h.find(key)
+	if i >= 0 {
+		h.values[i] = value
+		return
+	}
+	h.buckets[sum] = append(h.buckets[sum], len(h.keys))
+	h.keys = append(h.keys, key)
+	h.values = append(h.values, value)
+}
+
+// Delete removes the entry of key, reporting whether there was one.
+func (h hashTable) Delete(key Interface) bool {
+	sum, i := h.find(key)
+	if i < 0 {
+		return false
+	}
+	bucket := h.buckets[sum]
+	for j, k := range bucket {
+		if k == i {
+			bucket = append(bucket[:j], bucket[j+1:]...)
+			break
+		}
+	}
+	if len(bucket) == 0 {
+		delete(h.buckets, sum)
+	} else {
+		h.buckets[sum] = bucket
+	}
+	h.keys[i], h.values[i] = nil, nil
+	h.deleted++
+	if h.deleted > len(h.keys)/2 {
+		h.compact()
+	}
+	return true
+}
+
+// compact drops the entries left by Delete and indexes the remaining ones again.
+func (h hashTable) compact() {
+	keys, vals := h.keys[:0], h.values[:0]
+	for i, key := range h.keys {
+		if key != nil {
+			keys, vals = append(keys, key), append(vals, h.values[i])
+		}
+	}
+	clear(h.keys[len(keys):])
+	clear(h.values[len(vals):])
+	h.keys, h.values, h.deleted = keys, vals, 0
+	clear(h.buckets)
+	for i, key := range h.keys {
+		sum := h.cmp.Hash(key)
+		h.buckets[sum] = append(h.buckets[sum], i)
+	}
+}
+
+func (h hashTable) Clear() {
+	clear(h.buckets)
+	h.keys = nil
+	h.values = nil
+	h.deleted = 0
+}
+
+func (h hashTable) Len() int {
+	return len(h.keys) - h.deleted
+}
+
+// Keys returns the keys of the table in insertion order.
+func (h hashTable) Keys() []Interface {
+	keys := make([]Interface, 0, h.Len())
+	for _, key := range h.keys {
+		if key != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Values returns the values of the table in the order of Keys.
+func (h hashTable) Values() []Interface {
+	vals := make([]Interface, 0, h.Len())
+	for i, key := range h.keys {
+		if key != nil {
+			vals = append(vals, h.values[i])
+		}
+	}
+	return vals
+}
+
+// Copy returns a new table with the same comparator and entries.
+func (h hashTable) Copy() HashTable {
+	c := NewHashTableWith(h.cmp)
+	for i, key := range h.keys {
+		if key != nil {
+			c.Set(key, h.values[i])
+		}
+	}
+	return c
+}
+
+func (h hashTable) Equal(p Interface) bool {
+	other, ok := p.(hashTable)
+	return ok && other.hashTableState == h.hashTableState
+}
+
+func (h hashTable) Type() types.Type {
+	return types.Map
+}
+
+func (h hashTable) IsTruthy() bool {
+	return true
+}
+
+func (h hashTable) DisplayString() string {
+	return fmt.Sprintf("#<hash-table %d>", h.Len())
+}
+
+func (h hashTable) WriteString() string {
+	return h.DisplayString()
+}
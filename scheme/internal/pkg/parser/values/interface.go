@@ -23,6 +23,7 @@ type Numeric interface {
 	Mul(rhs Numeric) Numeric
 	Div(rhs Numeric) (Numeric, error)
 	Mod(rhs Numeric) (Numeric, error)
+	EqualTo(p Numeric) bool
 	LessThan(p Numeric) bool
 	LessThanOrEqual(p Numeric) bool
 	GreaterThan(p Numeric) bool
@@ -0,0 +1,62 @@
+package values
+
+import (
+	"strings"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+)
+
+// MultipleValues holds the results of (values obj ...) for other than exactly one obj.
+type MultipleValues struct {
+	items []Interface
+}
+
+// NewValues returns the single value of items, or items as MultipleValues.
+func NewValues(items ...Interface) Interface {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return MultipleValues{items: items}
+}
+
+// Items returns a copy of the values.
+func (m MultipleValues) Items() []Interface {
+	return append([]Interface(nil), m.items...)
+}
+
+func (m MultipleValues) Equal(p Interface) bool {
+	other, ok := p.(MultipleValues)
+	if !ok || len(other.items) != len(m.items) {
+		return false
+	}
+	for i := range m.items {
+		if !m.items[i].Equal(other.items[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m MultipleValues) Type() types.Type {
+	return types.Values
+}
+
+func (m MultipleValues) IsTruthy() bool {
+	return true
+}
+
+func (m MultipleValues) DisplayString() string {
+	return m.join(Interface.DisplayString)
+}
+
+func (m MultipleValues) WriteString() string {
+	return m.join(Interface.WriteString)
+}
+
+func (m MultipleValues) join(repr func(Interface) string) string {
+	parts := make([]string, len(m.items))
+	for i, item := range m.items {
+		parts[i] = repr(item)
+	}
+	return strings.Join(parts, " ")
+}
@@ -245,22 +245,24 @@ func (n Number) GreaterThan(rhs Numeric) bool {
 		rhsInt, _ := rhs.AsInt()
 		return n.IntVal > rhsInt
 	}
-	rhsFloat, err := n.AsFloat()
+	lhsFloat, _ := n.AsFloat()
+	rhsFloat, err := rhs.AsFloat()
 	if err != nil {
 		return false
 	}
-	return n.FloatVal > rhsFloat
+	return lhsFloat > rhsFloat
 }
 func (n Number) LessThan(rhs Numeric) bool {
 	if n.IsInt && rhs.IsInteger() {
 		rhsInt, _ := rhs.AsInt()
 		return n.IntVal < rhsInt
 	}
+	lhsFloat, _ := n.AsFloat()
 	rhsFloat, err := rhs.AsFloat()
 	if err != nil {
 		return false
 	}
-	return n.FloatVal < rhsFloat
+	return lhsFloat < rhsFloat
 }
 
 func (n Number) GreaterThanOrEqual(rhs Numeric) bool {
@@ -268,20 +270,38 @@ func (n Number) GreaterThanOrEqual(rhs Numeric) bool {
 		rhsInt, _ := rhs.AsInt()
 		return n.IntVal >= rhsInt
 	}
+	lhsFloat, _ := n.AsFloat()
+	rhsFloat, err := rhs.AsFloat()
+	if err != nil {
+		return false
+	}
+	return lhsFloat >= rhsFloat
+}
+
+// EqualTo reports whether n and rhs are the same number. Unlike Equal, which eqv? and
+// equal? use, it compares an integer and a float by their values.
+func (n Number) EqualTo(rhs Numeric) bool {
+	if n.IsInt && rhs.IsInteger() {
+		rhsInt, _ := rhs.AsInt()
+		return n.IntVal == rhsInt
+	}
+	lhsFloat, _ := n.AsFloat()
 	rhsFloat, err := rhs.AsFloat()
 	if err != nil {
 		return false
 	}
-	return n.FloatVal >= rhsFloat
+	return lhsFloat == rhsFloat
 }
+
 func (n Number) LessThanOrEqual(rhs Numeric) bool {
 	if n.IsInt && rhs.IsInteger() {
 		rhsInt, _ := rhs.AsInt()
 		return n.IntVal <= rhsInt
 	}
+	lhsFloat, _ := n.AsFloat()
 	rhsFloat, err := rhs.AsFloat()
 	if err != nil {
 		return false
 	}
-	return n.FloatVal <= rhsFloat
+	return lhsFloat <= rhsFloat
 }
@@ -1,7 +1,7 @@
 package values
 
 import (
-	"strings"
+	"text/scanner"
 
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/lexer"
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
@@ -11,12 +11,19 @@ type Pair interface {
 	Interface
 	Car() Interface
 	Cdr() Interface
+	SetCar(v Interface) error
+	SetCdr(v Interface) error
 }
 
+// pairVal is a cons cell. Pairs are allocated on the heap, so a pair has identity and
+// modifications through set-car! and set-cdr! are seen by every list sharing it.
+// Pairs read from source code are literal constants and cannot be modified.
 type pairVal struct {
 	truthyValue
-	car Interface
-	cdr Interface
+	car      Interface
+	cdr      Interface
+	constant bool
+	source   *scanner.Position
 }
 
 func Cons(car, cdr Interface) Interface {
@@ -26,20 +33,50 @@ func Cons(car, cdr Interface) Interface {
 	if cdr == nil {
 		panic("cdr cannot be nil")
 	}
-	if car.Type() == types.Nil && cdr.Type() == types.Nil {
-		return car
-	}
-	if car.Type() == types.Nil && cdr.Type() != types.Nil {
-		panic("car cannot be nil if cdr is not nil")
-	}
-	return pairVal{
+	return &pairVal{
 		car: car,
 		cdr: cdr,
 	}
 }
 
+// Constant marks a datum read from source code as a literal constant and returns it.
+// The pairs of the spine of a list are marked in place; a vector is returned as a constant copy.
+// Elements are expected to have been marked as they were read.
+func Constant(v Interface) Interface {
+	switch val := v.(type) {
+	case *pairVal:
+		for pair, ok := val, true; ok && !pair.constant; pair, ok = pair.cdr.(*pairVal) {
+			pair.constant = true
+		}
+	case vectorValue:
+		val.constant = true
+		return val
+	}
+	return v
+}
+
+// Located records pos as the source position of the list v, on every pair of its spine,
+// and returns v. The parser records the position of the opening parenthesis of each list.
+func Located(v Interface, pos scanner.Position) Interface {
+	source := &pos
+	for pair, ok := v.(*pairVal); ok && pair.source == nil; pair, ok = pair.cdr.(*pairVal) {
+		pair.source = source
+	}
+	return v
+}
+
+// SourcePosition returns the position of the list that the pair v was read from, if v
+// was read from source code. A pair in the middle of a list reports the position of the list.
+func SourcePosition(v Interface) (scanner.Position, bool) {
+	pair, ok := v.(*pairVal)
+	if !ok || pair.source == nil {
+		return scanner.Position{}, false
+	}
+	return *pair.source, true
+}
+
 func Car(p Interface) Interface {
-	pair, ok := p.(pairVal)
+	pair, ok := p.(*pairVal)
 	if !ok {
 		panic("car called on non-pair")
 	}
@@ -47,118 +84,121 @@ func Car(p Interface) Interface {
 }
 
 func Cdr(p Interface) Interface {
-	pair, ok := p.(pairVal)
+	pair, ok := p.(*pairVal)
 	if !ok {
 		panic("cdr called on non-pair")
 	}
 	return pair.Cdr()
 }
 
-func (pr pairVal) Car() Interface {
+func (pr *pairVal) Car() Interface {
 	return pr.car
 }
 
-func (pr pairVal) Cdr() Interface {
+func (pr *pairVal) Cdr() Interface {
 	return pr.cdr
 }
 
+// SetCar replaces the car of the pair. It returns ErrImmutable for a literal constant.
+func (pr *pairVal) SetCar(v Interface) error {
+	if pr.constant {
+		return ErrImmutable
+	}
+	pr.car = v
+	return nil
+}
+
+// SetCdr replaces the cdr of the pair. It returns ErrImmutable for a literal constant.
+func (pr *pairVal) SetCdr(v Interface) error {
+	if pr.constant {
+		return ErrImmutable
+	}
+	pr.cdr = v
+	return nil
+}
+
 func Reverse(input Interface) (output Interface) {
 	output = NewNil()
 	current := input
 	for {
-		pair, ok := current.(pairVal)
+		pair, ok := current.(*pairVal)
 		if !ok {
 			break
 		}
-		if pair.Cdr().Type() == types.Nil {
-			return Cons(pair.Car(), output)
-		}
 		output = Cons(pair.Car(), output)
 		current = pair.Cdr()
 	}
 	return output
 }
 
-func (pr pairVal) Equal(p Interface) bool {
-	otherPair, ok := p.(pairVal)
-	if !ok {
-		return false
-	}
-	if !pr.Car().Equal(otherPair.Car()) {
-		return false
+// List builds a proper list holding items in order.
+func List(items ...Interface) Interface {
+	return ListWithTail(NewNil(), items...)
+}
+
+// ListWithTail builds a list holding items whose final cdr is tail,
+// producing an improper list when tail is not the empty list.
+func ListWithTail(tail Interface, items ...Interface) Interface {
+	result := tail
+	for i := len(items) - 1; i >= 0; i-- {
+		result = Cons(items[i], result)
 	}
-	if !pr.Cdr().Equal(otherPair.Cdr()) {
-		return false
+	return result
+}
+
+// ToSlice collects the elements of a proper list.
+// It returns ErrNotAList if l is not terminated by the empty list, or is circular.
+func ToSlice(l Interface) ([]Interface, error) {
+	var items []Interface
+	slow := l
+	for l.Type() != types.Nil {
+		pair, ok := l.(*pairVal)
+		if !ok {
+			return items, ErrNotAList
+		}
+		items = append(items, pair.Car())
+		l = pair.Cdr()
+		// slow advances at half the speed of l and meets it only on a cycle
+		if len(items)%2 == 0 {
+			slow = slow.(*pairVal).cdr
+			if slow == l {
+				return items, ErrNotAList
+			}
+		}
 	}
-	return true
+	return items, nil
+}
 
+// IsList reports whether l is a proper list.
+func IsList(l Interface) bool {
+	_, err := ToSlice(l)
+	return err == nil
 }
 
-func (pr pairVal) Type() types.Type {
+// Equal compares pairs structurally, as equal? does. Long lists are compared without deep
+// recursion, and circular lists are compared as the infinite lists they unfold to.
+func (pr *pairVal) Equal(p Interface) bool {
+	return equalCompound(pr, p)
+}
+
+func (pr *pairVal) Type() types.Type {
 
 	return types.Pair
 }
 
-func (pr pairVal) GetToken() lexer.Token {
+func (pr *pairVal) GetToken() lexer.Token {
 	return lexer.Token{
 		Type: lexer.TokenPair,
 	}
 }
 
-func (pr pairVal) DisplayString() string {
-
-	if pr.Cdr().Type() == types.Nil {
-		return pr.Car().DisplayString()
-	}
-
-	sb := strings.Builder{}
-	sb.WriteString("(")
-	sb.WriteString(pr.Car().DisplayString())
-
-	cdr := pr.Cdr()
-	for {
-		if _, ok := cdr.(Nil); ok {
-			break
-		}
-		if pair, ok := cdr.(pairVal); ok {
-			sb.WriteString(" ")
-			sb.WriteString(pair.Car().DisplayString())
-			cdr = pair.Cdr()
-		} else {
-			sb.WriteString(" . ")
-			sb.WriteString(cdr.DisplayString())
-			break
-		}
-	}
-	sb.WriteString(")")
-	return sb.String()
+// DisplayString returns the list as display prints it. A circular list is printed with
+// datum labels, e.g. #0=(1 2 . #0#).
+func (pr *pairVal) DisplayString() string {
+	return printDatum(pr, false)
 }
 
-func (pr pairVal) WriteString() string {
-
-	if pr.Cdr().Type() == types.Nil {
-		return pr.Car().WriteString()
-	}
-
-	sb := strings.Builder{}
-	sb.WriteString("(")
-	sb.WriteString(pr.Car().WriteString())
-
-	cdr := pr.Cdr()
-	for {
-		if _, ok := cdr.(Nil); ok {
-			break
-		}
-		if pair, ok := cdr.(pairVal); ok {
-			sb.WriteString(" ")
-			sb.WriteString(pair.Car().WriteString())
-			cdr = pair.Cdr()
-		} else {
-			sb.WriteString(" . ")
-			sb.WriteString(cdr.WriteString())
-			break
-		}
-	}
-	sb.WriteString(")")
-	return sb.String()
+// WriteString returns the list as write prints it, with datum labels for cycles.
+func (pr *pairVal) WriteString() string {
+	return printDatum(pr, true)
 }
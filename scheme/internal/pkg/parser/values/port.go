@@ -0,0 +1,205 @@
+package values
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+)
+
+// Port is a source or sink of characters. Output ports wrap an io.Writer and input ports an io.Reader.
+type Port struct {
+	name string
+	w    io.Writer
+	r    *bufio.Reader
+	// src is the source r reads from, with any input put back by an interrupted read
+	src    io.Reader
+	buf    *bytes.Buffer
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewOutputPort returns an output port writing to w.
+func NewOutputPort(name string, w io.Writer) *Port {
+	return &Port{name: name, w: w}
+}
+
+// NewInputPort returns an input port reading from r.
+func NewInputPort(name string, r io.Reader) *Port {
+	return &Port{name: name, r: bufio.NewReader(r), src: r}
+}
+
+// NewStringOutputPort returns an output port accumulating its output in a string.
+func NewStringOutputPort() *Port {
+	buf := bytes.NewBuffer(nil)
+	return &Port{name: "string", w: buf, buf: buf}
+}
+
+// IsInput reports whether the port can be read from.
+func (p *Port) IsInput() bool {
+	return p.r != nil
+}
+
+// IsOutput reports whether the port can be written to.
+func (p *Port) IsOutput() bool {
+	return p.w != nil
+}
+
+// Write writes s to an output port.
+func (p *Port) Write(s string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return io.ErrClosedPipe
+	}
+	_, err := io.WriteString(p.w, s)
+	return err
+}
+
+// ReadChar reads the next character from an input port. A read that would block returns
+// the error of ctx once ctx is done.
+func (p *Port) ReadChar(ctx context.Context) (rune, error) {
+	s, err := p.read(ctx, func(r *bufio.Reader) bool {
+		b, _ := r.Peek(r.Buffered())
+		return utf8.FullRune(b)
+	}, func(r *bufio.Reader) (string, error) {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		return string(c), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	c, _ := utf8.DecodeRuneInString(s)
+	return c, nil
+}
+
+// ReadLine reads the next line from an input port, without its line ending. A read that
+// would block returns the error of ctx once ctx is done.
+func (p *Port) ReadLine(ctx context.Context) (string, error) {
+	line, err := p.read(ctx, func(r *bufio.Reader) bool {
+		b, _ := r.Peek(r.Buffered())
+		return bytes.IndexByte(b, '\n') >= 0
+	}, func(r *bufio.Reader) (string, error) {
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return line, err
+	})
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// read reads from an input port with read. When the port is idle and ready reports that
+// its buffered input holds what read needs, it reads right away. Otherwise it reads on
+// another goroutine, which waits for the reads in progress, returning the error of ctx
+// once ctx is done; the text read once the read completes is then put back, so that the
+// next read of the port reads it.
+func (p *Port) read(ctx context.Context, ready func(*bufio.Reader) bool, read func(*bufio.Reader) (string, error)) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if p.mu.TryLock() {
+		if !p.closed && ready(p.r) {
+			defer p.mu.Unlock()
+			return read(p.r)
+		}
+		p.mu.Unlock()
+	}
+	type result struct {
+		s   string
+		err error
+	}
+	results := make(chan result)
+	abandoned := make(chan struct{})
+	go func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.closed {
+			select {
+			case results <- result{err: io.ErrClosedPipe}:
+			case <-abandoned:
+			}
+			return
+		}
+		s, err := read(p.r)
+		select {
+		case results <- result{s: s, err: err}:
+		case <-abandoned:
+			p.unread(s)
+		}
+	}()
+	select {
+	case r := <-results:
+		return r.s, r.err
+	case <-ctx.Done():
+		close(abandoned)
+		return "", ctx.Err()
+	}
+}
+
+// unread puts s back in front of the input of the port.
+func (p *Port) unread(s string) {
+	if s == "" {
+		return
+	}
+	buffered, _ := p.r.Peek(p.r.Buffered())
+	p.src = io.MultiReader(strings.NewReader(s+string(buffered)), p.src)
+	p.r.Reset(p.src)
+}
+
+// Contents returns the output accumulated by a string output port.
+func (p *Port) Contents() (string, bool) {
+	if p.buf == nil {
+		return "", false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.buf.String(), true
+}
+
+// Close closes the port. Closing a port wrapping an io.Closer closes it as well.
+func (p *Port) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	if c, ok := p.w.(io.Closer); ok && p.buf == nil {
+		return c.Close()
+	}
+	return nil
+}
+
+func (p *Port) Equal(other Interface) bool {
+	o, ok := other.(*Port)
+	return ok && o == p
+}
+
+func (p *Port) Type() types.Type {
+	return types.Port
+}
+
+func (p *Port) IsTruthy() bool {
+	return true
+}
+
+func (p *Port) DisplayString() string {
+	kind := "output"
+	if p.IsInput() {
+		kind = "input"
+	}
+	return "#<" + kind + "-port " + p.name + ">"
+}
+
+func (p *Port) WriteString() string {
+	return p.DisplayString()
+}
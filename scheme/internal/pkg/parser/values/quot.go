@@ -45,3 +45,11 @@ func (q quotValue) Equal(p Interface) bool {
 func (q quotValue) Type() types.Type {
 	return types.Quot
 }
+
+// Unquote strips the quotation from a quoted datum, returning any other value unchanged.
+func Unquote(v Interface) Interface {
+	if q, ok := v.(Quot); ok {
+		return q.GetValue()
+	}
+	return v
+}
@@ -0,0 +1,108 @@
+package values
+
+import (
+	"strings"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+)
+
+// RecordType describes the records created by a define-record-type form.
+type RecordType struct {
+	name   string
+	fields []string
+}
+
+// NewRecordType returns a record type with the named fields. Angle brackets around
+// the type name, as in <point>, are dropped.
+func NewRecordType(name string, fields ...string) *RecordType {
+	if len(name) > 2 && strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") {
+		name = name[1 : len(name)-1]
+	}
+	return &RecordType{name: name, fields: fields}
+}
+
+func (t *RecordType) Name() string {
+	return t.name
+}
+
+// Fields returns the field names of the type in declaration order.
+func (t *RecordType) Fields() []string {
+	return append([]string(nil), t.fields...)
+}
+
+// FieldIndex returns the position of the named field, or -1.
+func (t *RecordType) FieldIndex(name string) int {
+	for i, field := range t.fields {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *RecordType) Equal(p Interface) bool {
+	other, ok := p.(*RecordType)
+	return ok && other == t
+}
+
+func (t *RecordType) Type() types.Type {
+	return types.RecordType
+}
+
+func (t *RecordType) IsTruthy() bool {
+	return true
+}
+
+func (t *RecordType) DisplayString() string {
+	return "#<record-type " + t.name + ">"
+}
+
+func (t *RecordType) WriteString() string {
+	return t.DisplayString()
+}
+
+// Record is an instance of a RecordType. Records are equal? when they have the same
+// type and equal? fields, and eqv? only to themselves.
+type Record struct {
+	rtype  *RecordType
+	fields []Interface
+}
+
+// NewRecord returns a record of type rtype holding fields in declaration order.
+func NewRecord(rtype *RecordType, fields ...Interface) *Record {
+	return &Record{rtype: rtype, fields: fields}
+}
+
+func (r *Record) RecordType() *RecordType {
+	return r.rtype
+}
+
+// Field returns the value of the field at index i.
+func (r *Record) Field(i int) Interface {
+	return r.fields[i]
+}
+
+// SetField sets the value of the field at index i.
+func (r *Record) SetField(i int, v Interface) {
+	r.fields[i] = v
+}
+
+func (r *Record) Equal(p Interface) bool {
+	return equalCompound(r, p)
+}
+
+func (r *Record) Type() types.Type {
+	return types.Record
+}
+
+func (r *Record) IsTruthy() bool {
+	return true
+}
+
+func (r *Record) DisplayString() string {
+	return printDatum(r, false)
+}
+
+func (r *Record) WriteString() string {
+	return printDatum(r, true)
+}
@@ -0,0 +1,326 @@
+package values
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Pairs, vectors and records are compared and printed structurally. set-car!, set-cdr!,
+// vector-set! and record modifiers can make them circular, so their comparison and
+// printing keep track of the structures they have seen in order to terminate.
+
+// compoundKey returns the key identifying the pair, vector or record v, or false when v
+// is not one or, as an empty vector, cannot refer to anything.
+func compoundKey(v Interface) (any, bool) {
+	switch val := v.(type) {
+	case *pairVal:
+		return val, true
+	case vectorValue:
+		if len(val.items) == 0 {
+			return nil, false
+		}
+		return vectorKey{first: &val.items[0], n: len(val.items)}, true
+	case *Record:
+		return val, true
+	}
+	return nil, false
+}
+
+// vectorKey identifies a vector by its elements, which are shared by its copies as a value.
+type vectorKey struct {
+	first *Interface
+	n     int
+}
+
+// equalBudget is the number of pairs, vectors and records equal? compares directly before
+// it switches to a comparison that remembers what it has compared, and so terminates on
+// circular structures.
+const equalBudget = 1000
+
+// equalCompound compares the pairs, vectors or records a and b structurally, as equal?
+// does. Circular structures are equal when they unfold to the same infinite structures.
+func equalCompound(a, b Interface) bool {
+	budget := equalBudget
+	if equal, ok := equalBounded(a, b, &budget); ok {
+		return equal
+	}
+	return (&bisimulation{classes: make(map[any]any)}).equal(a, b)
+}
+
+// equalBounded compares a and b while the budget lasts, iterating along the cdrs of pairs
+// so long lists are compared without deep recursion. It returns false when it runs out.
+func equalBounded(a, b Interface, budget *int) (bool, bool) {
+	for {
+		if *budget--; *budget < 0 {
+			return false, false
+		}
+		switch l := a.(type) {
+		case *pairVal:
+			r, ok := b.(*pairVal)
+			if !ok {
+				return false, true
+			}
+			if l == r {
+				return true, true
+			}
+			if equal, ok := equalBounded(l.car, r.car, budget); !ok || !equal {
+				return equal, ok
+			}
+			a, b = l.cdr, r.cdr
+			continue
+		case vectorValue:
+			r, ok := b.(vectorValue)
+			if !ok || len(l.items) != len(r.items) {
+				return false, true
+			}
+			return equalAll(l.items, r.items, budget)
+		case *Record:
+			r, ok := b.(*Record)
+			if !ok || l.rtype != r.rtype {
+				return false, true
+			}
+			if l == r {
+				return true, true
+			}
+			return equalAll(l.fields, r.fields, budget)
+		}
+		return a.Equal(b), true
+	}
+}
+
+func equalAll(left, right []Interface, budget *int) (bool, bool) {
+	for i := range left {
+		if equal, ok := equalBounded(left[i], right[i], budget); !ok || !equal {
+			return equal, ok
+		}
+	}
+	return true, true
+}
+
+// bisimulation compares structures by merging the classes of the pairs, vectors and
+// records it compares, assuming them equal. Meeting two structures of the same class
+// again ends the comparison of that branch, which therefore terminates on cycles.
+type bisimulation struct {
+	classes map[any]any
+}
+
+// find returns the representative of the class of k.
+func (s *bisimulation) find(k any) any {
+	root := k
+	for {
+		parent, ok := s.classes[root]
+		if !ok || parent == root {
+			break
+		}
+		root = parent
+	}
+	for k != root {
+		next := s.classes[k]
+		s.classes[k] = root
+		k = next
+	}
+	return root
+}
+
+// merge merges the classes of a and b, reporting whether they were already the same.
+func (s *bisimulation) merge(a, b any) bool {
+	ra, rb := s.find(a), s.find(b)
+	if ra == rb {
+		return true
+	}
+	s.classes[ra] = rb
+	return false
+}
+
+func (s *bisimulation) equal(a, b Interface) bool {
+	for {
+		switch l := a.(type) {
+		case *pairVal:
+			r, ok := b.(*pairVal)
+			if !ok {
+				return false
+			}
+			if s.merge(l, r) {
+				return true
+			}
+			if !s.equal(l.car, r.car) {
+				return false
+			}
+			a, b = l.cdr, r.cdr
+			continue
+		case vectorValue:
+			r, ok := b.(vectorValue)
+			if !ok || len(l.items) != len(r.items) {
+				return false
+			}
+			if len(l.items) == 0 {
+				return true
+			}
+			lk, _ := compoundKey(l)
+			rk, _ := compoundKey(r)
+			return s.merge(lk, rk) || s.equalAll(l.items, r.items)
+		case *Record:
+			r, ok := b.(*Record)
+			if !ok || l.rtype != r.rtype {
+				return false
+			}
+			return s.merge(l, r) || s.equalAll(l.fields, r.fields)
+		}
+		return a.Equal(b)
+	}
+}
+
+func (s *bisimulation) equalAll(left, right []Interface) bool {
+	for i := range left {
+		if !s.equal(left[i], right[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Circular reports whether v is a pair, vector or record that refers to itself, directly
+// or through the pairs, vectors and records it holds.
+func Circular(v Interface) bool {
+	return len(findCycles(v)) > 0
+}
+
+// findCycles returns the pairs, vectors and records reachable from v that are part of a
+// cycle, which are printed with datum labels.
+func findCycles(v Interface) map[any]int {
+	f := &cycleFinder{active: make(map[any]bool), done: make(map[any]bool)}
+	f.visit(v)
+	return f.cyclic
+}
+
+// cycleFinder walks structures depth first, finding those reached again while they are
+// being walked.
+type cycleFinder struct {
+	active map[any]bool
+	done   map[any]bool
+	cyclic map[any]int
+}
+
+func (f *cycleFinder) visit(v Interface) {
+	// the pairs of the spine of a list stay active until the whole list is walked
+	var spine []any
+	defer func() {
+		for _, k := range spine {
+			delete(f.active, k)
+			f.done[k] = true
+		}
+	}()
+	for {
+		k, ok := compoundKey(v)
+		if !ok || f.done[k] {
+			return
+		}
+		if f.active[k] {
+			if f.cyclic == nil {
+				f.cyclic = make(map[any]int)
+			}
+			f.cyclic[k] = -1
+			return
+		}
+		f.active[k] = true
+		spine = append(spine, k)
+		switch val := v.(type) {
+		case *pairVal:
+			f.visit(val.car)
+			v = val.cdr
+			continue
+		case vectorValue:
+			for _, item := range val.items {
+				f.visit(item)
+			}
+		case *Record:
+			for _, field := range val.fields {
+				f.visit(field)
+			}
+		}
+		return
+	}
+}
+
+// printDatum returns the printed form of v, written as by write when write is set and
+// as by display otherwise. The pairs, vectors and records of a cycle are printed with
+// datum labels, e.g. #0=(a b . #0#).
+func printDatum(v Interface, write bool) string {
+	p := &printer{write: write, labels: findCycles(v)}
+	p.print(v)
+	return p.sb.String()
+}
+
+type printer struct {
+	sb    strings.Builder
+	write bool
+	// labels holds the label of each structure of a cycle, or -1 until it is printed
+	labels map[any]int
+	next   int
+}
+
+func (p *printer) print(v Interface) {
+	if k, ok := compoundKey(v); ok {
+		if label, ok := p.labels[k]; ok {
+			if label >= 0 {
+				p.sb.WriteString("#" + strconv.Itoa(label) + "#")
+				return
+			}
+			p.labels[k] = p.next
+			p.sb.WriteString("#" + strconv.Itoa(p.next) + "=")
+			p.next++
+		}
+	}
+	switch val := v.(type) {
+	case *pairVal:
+		p.printList(val)
+	case vectorValue:
+		p.sb.WriteString("#(")
+		for i, item := range val.items {
+			if i > 0 {
+				p.sb.WriteString(" ")
+			}
+			p.print(item)
+		}
+		p.sb.WriteString(")")
+	case *Record:
+		p.sb.WriteString("#<record ")
+		p.sb.WriteString(val.rtype.name)
+		for i, field := range val.rtype.fields {
+			p.sb.WriteString(" " + field + "=")
+			p.print(val.fields[i])
+		}
+		p.sb.WriteString(">")
+	default:
+		if p.write {
+			p.sb.WriteString(v.WriteString())
+		} else {
+			p.sb.WriteString(v.DisplayString())
+		}
+	}
+}
+
+// printList prints the list starting at pr, in dotted notation from a labelled pair on.
+func (p *printer) printList(pr *pairVal) {
+	p.sb.WriteString("(")
+	p.print(pr.car)
+	cdr := pr.cdr
+	for {
+		switch next := cdr.(type) {
+		case Nil:
+			p.sb.WriteString(")")
+			return
+		case *pairVal:
+			if _, labelled := p.labels[next]; !labelled {
+				p.sb.WriteString(" ")
+				p.print(next.car)
+				cdr = next.cdr
+				continue
+			}
+		}
+		p.sb.WriteString(" . ")
+		p.print(cdr)
+		p.sb.WriteString(")")
+		return
+	}
+}
@@ -6,19 +6,27 @@ import (
 	"github.com/bchisham/go-lisp/scheme/internal/pkg/lexer"
 )
 
-var ErrNotAPrimitive = errors.New("not a primitive")
+var (
+	ErrNotAPrimitive = errors.New("not a primitive")
+	ErrNotAList      = errors.New("not a list")
+	ErrImmutable     = errors.New("cannot modify a literal constant")
+)
 
 func FromToken(tok lexer.Token) (v Interface) {
 
 	switch tok.Type {
 	case lexer.TokenIdent:
 		v = NewIdentifier(tok.Literal)
-	case lexer.TokenInt:
+	case lexer.TokenNumber, lexer.TokenInt:
 		v = NewInt(tok.Int)
+	case lexer.TokenFloat:
+		v = NewFloat(tok.Float)
+	case lexer.TokenRune:
+		v = NewChar(rune(tok.Int))
 	case lexer.TokenBoolean:
 		v = NewBool(tok.Bool)
 	case lexer.TokenString:
-		v = NewString(tok.Literal)
+		v = NewString(tok.Text)
 	case lexer.TokenQuot:
 		v = NewQuot(NewNil())
 	case lexer.TokenRelationalOperator:
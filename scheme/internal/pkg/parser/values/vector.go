@@ -0,0 +1,62 @@
+package values
+
+import (
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+)
+
+type Vector interface {
+	Interface
+	Len() int
+	Ref(i int) Interface
+	Set(i int, v Interface) error
+	Items() []Interface
+}
+
+type vectorValue struct {
+	truthyValue
+	items    []Interface
+	constant bool
+}
+
+// NewVector creates a vector holding items. The vector takes ownership of the slice.
+func NewVector(items ...Interface) Interface {
+	return vectorValue{items: items}
+}
+
+func (v vectorValue) Len() int {
+	return len(v.items)
+}
+
+func (v vectorValue) Ref(i int) Interface {
+	return v.items[i]
+}
+
+// Set replaces the element at index i. It returns ErrImmutable for a literal constant.
+func (v vectorValue) Set(i int, value Interface) error {
+	if v.constant {
+		return ErrImmutable
+	}
+	v.items[i] = value
+	return nil
+}
+
+// Items returns a copy of the elements of the vector.
+func (v vectorValue) Items() []Interface {
+	return append([]Interface(nil), v.items...)
+}
+
+func (v vectorValue) Equal(p Interface) bool {
+	return equalCompound(v, p)
+}
+
+func (v vectorValue) Type() types.Type {
+	return types.Vector
+}
+
+func (v vectorValue) DisplayString() string {
+	return printDatum(v, false)
+}
+
+func (v vectorValue) WriteString() string {
+	return printDatum(v, true)
+}
@@ -0,0 +1,146 @@
+package pretty
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change of a diff.
+const context = 3
+
+type edit struct {
+	op   byte // ' ', '-' or '+'
+	line string
+}
+
+// Diff returns the unified diff turning old, named oldName, into new, named newName,
+// or nil when they are equal.
+func Diff(oldName string, old []byte, newName string, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	edits := diffLines(splitLines(string(old)), splitLines(string(new)))
+	var changes []int
+	for i, e := range edits {
+		if e.op != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+	for i := 0; i < len(changes); {
+		// a hunk shows the unchanged lines around its changes, and joins the changes
+		// separated by no more than twice that many unchanged lines
+		first, last := changes[i], changes[i]
+		for i++; i < len(changes) && changes[i]-last <= 2*context+1; i++ {
+			last = changes[i]
+		}
+		start, end := max(first-context, 0), min(last+context+1, len(edits))
+		oldLine, newLine := lineCounts(edits[:start])
+		oldCount, newCount := lineCounts(edits[start:end])
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, e := range edits[start:end] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			out.WriteByte('\n')
+		}
+	}
+	return out.Bytes()
+}
+
+// lineCounts returns the number of lines of the old and of the new text that edits span.
+func lineCounts(edits []edit) (oldCount, newCount int) {
+	for _, e := range edits {
+		if e.op != '+' {
+			oldCount++
+		}
+		if e.op != '-' {
+			newCount++
+		}
+	}
+	return oldCount, newCount
+}
+
+// hunkRange formats the first line and the number of lines of a hunk; an empty hunk
+// is placed after the line before it.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns a shortest edit script turning a into b, computed with Myers' algorithm.
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack recovers the edits from the furthest reaching paths recorded for each number of edits.
+func backtrack(a, b []string, trace [][]int, offset int) []edit {
+	var edits []edit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			edits = append(edits, edit{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{'+', b[y]})
+		} else {
+			x--
+			edits = append(edits, edit{'-', a[x]})
+		}
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
@@ -0,0 +1,298 @@
+// Package pretty formats Scheme source code and data with the standard Lisp indentation.
+//
+// Source keeps the line breaks and comments of a source and re-indents it: the body
+// forms of special forms are indented two columns from the form, their distinguished
+// operands four, and the arguments of calls are aligned with the first argument when
+// it follows the operator on the same line. Closing parentheses follow the last element
+// of their list, runs of spaces are collapsed and runs of empty lines shortened to one.
+// Formatting is idempotent: formatting formatted source does not change it.
+//
+// Datum lays out data, which has no line breaks of its own, breaking lists that do not
+// fit the width of the page with the same rules.
+package pretty
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/cst"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+var (
+	ErrSyntax = cst.ErrSyntax
+)
+
+// Width is the width of the page Datum lays data out on by default.
+const Width = 80
+
+// bodyForms are the special forms whose last operands are a body, with the number of
+// distinguished operands preceding the body.
+var bodyForms = map[string]int{
+	"begin":              0,
+	"case-lambda":        0,
+	"cond":               0,
+	"delay":              0,
+	"delay-force":        0,
+	"select":             0,
+	"case":               1,
+	"define":             1,
+	"define-library":     1,
+	"define-record-type": 1,
+	"guard":              1,
+	"lambda":             1,
+	"let":                1,
+	"let*":               1,
+	"letrec":             1,
+	"letrec*":            1,
+	"let-values":         1,
+	"parameterize":       1,
+	"unless":             1,
+	"when":               1,
+	"do":                 2,
+}
+
+// Source formats the Scheme source src. It returns ErrSyntax when src cannot be read.
+func Source(src []byte) ([]byte, error) {
+	nodes, err := read(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &printer{}
+	for i, n := range nodes {
+		switch {
+		case i == 0:
+		case n.kind == nodeComment && !n.newline:
+			p.write(" ")
+		default:
+			p.newline(0, n.blank)
+		}
+		p.print(n)
+	}
+	if len(nodes) > 0 {
+		p.write("\n")
+	}
+	return []byte(p.sb.String()), nil
+}
+
+// Datum returns the written representation of v, broken across lines so that it fits
+// width columns where it can. A circular v is written on one line, with datum labels.
+func Datum(v values.Interface, width int) string {
+	if values.Circular(v) {
+		return v.WriteString()
+	}
+	p := &printer{width: width}
+	p.print(nodeOf(v))
+	return p.sb.String()
+}
+
+// nodeOf converts v to the node of its written representation.
+func nodeOf(v values.Interface) *node {
+	switch v := v.(type) {
+	case values.Pair:
+		n := &node{kind: nodeList, text: "(", closing: ")"}
+		var tail values.Interface = v
+		for {
+			pair, ok := tail.(values.Pair)
+			if !ok {
+				break
+			}
+			n.children = append(n.children, nodeOf(pair.Car()))
+			tail = pair.Cdr()
+		}
+		if _, ok := tail.(values.Nil); !ok {
+			n.children = append(n.children, &node{kind: nodeAtom, text: "."}, nodeOf(tail))
+		}
+		return n
+	case values.Vector:
+		n := &node{kind: nodeList, text: "#(", closing: ")"}
+		for _, item := range v.Items() {
+			n.children = append(n.children, nodeOf(item))
+		}
+		return n
+	}
+	return &node{kind: nodeAtom, text: v.WriteString()}
+}
+
+// printer writes nodes, keeping track of the column it writes at. With a width it lays
+// out lists to fit it, otherwise it keeps the line breaks of the nodes.
+type printer struct {
+	sb    strings.Builder
+	col   int
+	width int
+	// quoted counts the quotes enclosing the node being printed, whose lists are data
+	quoted int
+}
+
+func (p *printer) write(s string) {
+	p.sb.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		p.col = utf8.RuneCountInString(s[i+1:])
+		return
+	}
+	p.col += utf8.RuneCountInString(s)
+}
+
+// newline starts a line indented to column indent, after an empty line when blank is set.
+func (p *printer) newline(indent int, blank bool) {
+	if blank {
+		p.sb.WriteByte('\n')
+	}
+	p.write("\n" + strings.Repeat(" ", indent))
+}
+
+func (p *printer) print(n *node) {
+	switch n.kind {
+	case nodeQuote:
+		p.write(n.text)
+		p.quoted++
+		p.print(n.children[0])
+		p.quoted--
+	case nodeList:
+		p.printList(n)
+	default:
+		p.write(n.text)
+	}
+}
+
+// layout is the indentation of the elements of a list that start on a new line.
+type layout struct {
+	// open is the column of the opening parenthesis and inner that of the first element
+	open, inner int
+	// distinguished is the number of distinguished operands of a body form, or -1
+	distinguished int
+	// call is set for lists that are not data, whose head is an identifier
+	call bool
+	// argument is the column of the first argument when it follows the head on its line, or -1
+	argument int
+}
+
+// indent returns the column of the i-th element of the list.
+func (l layout) indent(i int) int {
+	switch {
+	case i == 0 || !l.call:
+		return l.inner
+	case l.distinguished > 0 && i <= l.distinguished:
+		return l.open + 4
+	case l.distinguished > 0:
+		return l.open + 2
+	case l.argument >= 0:
+		return l.argument
+	case l.distinguished == 0:
+		return l.open + 2
+	}
+	return l.inner
+}
+
+func (p *printer) printList(n *node) {
+	l := layout{open: p.col, distinguished: -1, argument: -1}
+	p.write(n.text)
+	l.inner = p.col
+	elements := datums(n.children)
+	if head := firstOf(elements); head != nil && n.text != "#(" && p.quoted == 0 && isIdentifier(head) {
+		l.call = true
+		if k, ok := bodyForms[head.text]; ok {
+			l.distinguished = k
+			if head.text == "let" && len(elements) > 1 && isIdentifier(elements[1]) {
+				l.distinguished = 2
+			}
+		}
+	}
+	if p.width > 0 {
+		p.fill(n, l)
+	}
+
+	i := 0
+	afterComment := false
+	for j, child := range n.children {
+		switch {
+		case afterComment || j > 0 && child.newline:
+			// comments are indented like the element following them
+			p.newline(l.indent(i), child.blank)
+		case j > 0:
+			p.write(" ")
+		}
+		if child.kind == nodeComment {
+			p.write(child.text)
+			afterComment = true
+			continue
+		}
+		if i == 1 && !child.newline && !afterComment {
+			l.argument = p.col
+		}
+		afterComment = false
+		p.print(child)
+		i++
+	}
+	if afterComment {
+		p.newline(l.indent(i), false)
+	}
+	p.write(n.closing)
+}
+
+// fill sets the line breaks of the elements of n for the page width: none when n fits
+// on the rest of the line, otherwise a break before every element but the head, the
+// distinguished operands of a body form and the first argument of a call.
+func (p *printer) fill(n *node, l layout) {
+	fits := l.open+width(n) <= p.width
+	for i, child := range n.children {
+		switch {
+		case fits || i == 0:
+			child.newline = false
+		case l.distinguished >= 0:
+			child.newline = i > l.distinguished
+		case l.call:
+			child.newline = i > 1
+		default:
+			child.newline = true
+		}
+	}
+}
+
+// width returns the width of n written on one line.
+func width(n *node) int {
+	switch n.kind {
+	case nodeQuote:
+		return utf8.RuneCountInString(n.text) + width(n.children[0])
+	case nodeList:
+		w := utf8.RuneCountInString(n.text) + utf8.RuneCountInString(n.closing) + max(len(n.children)-1, 0)
+		for _, child := range n.children {
+			w += width(child)
+		}
+		return w
+	}
+	return utf8.RuneCountInString(n.text)
+}
+
+// datums returns the nodes that are not comments.
+func datums(nodes []*node) []*node {
+	var ds []*node
+	for _, n := range nodes {
+		if n.kind != nodeComment {
+			ds = append(ds, n)
+		}
+	}
+	return ds
+}
+
+func firstOf(nodes []*node) *node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[0]
+}
+
+// isIdentifier reports whether n is a symbol, rather than another atom or a list.
+func isIdentifier(n *node) bool {
+	if n.kind != nodeAtom || n.text == "" || n.text == "." {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(n.text)
+	if strings.ContainsRune("\"#0123456789", r) {
+		return false
+	}
+	if (r == '+' || r == '-' || r == '.') && len(n.text) > 1 && strings.ContainsRune("0123456789.", rune(n.text[1])) {
+		return false
+	}
+	return true
+}
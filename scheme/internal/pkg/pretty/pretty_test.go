@@ -0,0 +1,301 @@
+package pretty
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "body forms are indented two columns",
+			src: `(define (fact n)
+(if (= n 0)
+1
+(* n (fact (- n 1)))))`,
+			want: `(define (fact n)
+  (if (= n 0)
+      1
+      (* n (fact (- n 1)))))
+`,
+		},
+		{
+			name: "arguments align with the first argument",
+			src: `(list 1
+   2
+ (+ 3
+        4))`,
+			want: `(list 1
+      2
+      (+ 3
+         4))
+`,
+		},
+		{
+			name: "arguments on the following lines align with the operator",
+			src: `(list
+    1
+  2)`,
+			want: `(list
+ 1
+ 2)
+`,
+		},
+		{
+			name: "distinguished operands are indented four columns",
+			src: `(let loop
+((i 0))
+(when (< i 3)
+(display i)
+(loop (+ i 1))))
+(lambda
+(x)
+x)`,
+			want: `(let loop
+    ((i 0))
+  (when (< i 3)
+    (display i)
+    (loop (+ i 1))))
+(lambda
+    (x)
+  x)
+`,
+		},
+		{
+			name: "data aligns with its first element",
+			src: `'(define x
+1)
+#(1
+2)
+((lambda (x) x)
+1)`,
+			want: `'(define x
+  1)
+#(1
+  2)
+((lambda (x) x)
+ 1)
+`,
+		},
+		{
+			name: "clauses",
+			src: `(cond ((= x 1) 'one)
+((= x 2) 'two)
+(else 'many))
+(cond
+((pair? x) => car)
+(else x))`,
+			want: `(cond ((= x 1) 'one)
+      ((= x 2) 'two)
+      (else 'many))
+(cond
+  ((pair? x) => car)
+  (else x))
+`,
+		},
+		{
+			name: "comments, blank lines and spaces",
+			src: `  ;; factorial
+(define   (f x)   ; doubles x
+
+
+  ;; the body
+     (*   2 x)     ; twice
+)
+
+
+
+(f 2)  ; call
+;; end   `,
+			want: `;; factorial
+(define (f x) ; doubles x
+
+  ;; the body
+  (* 2 x) ; twice
+  )
+
+(f 2) ; call
+;; end
+`,
+		},
+		{
+			name: "closing parentheses follow the last element",
+			src: `(define (f)
+  (g 1
+  )
+)`,
+			want: `(define (f)
+  (g 1))
+`,
+		},
+		{
+			name: "atoms are kept as written",
+			src:  `(display "a  b\n" #\space [1 . 2] #t -1.5)`,
+			want: `(display "a  b\n" #\space [1 . 2] #t -1.5)
+`,
+		},
+		{
+			name: "multi-line strings",
+			src: `(define s "one
+two") (f s
+ 1)`,
+			want: `(define s "one
+two")
+(f s
+   1)
+`,
+		},
+		{
+			name: "empty",
+			src:  "\n\n",
+			want: "",
+		},
+		{
+			name:    "unbalanced",
+			src:     "(define (f x)\n  x",
+			wantErr: ErrSyntax,
+		},
+		{
+			name:    "mismatched",
+			src:     "[f x)",
+			wantErr: ErrSyntax,
+		},
+		{
+			name:    "invalid token",
+			src:     "(f #x)",
+			wantErr: ErrSyntax,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Source([]byte(tt.src))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Source() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("Source() = %q, want %q", got, tt.want)
+			}
+			again, err := Source(got)
+			if err != nil || string(again) != string(got) {
+				t.Errorf("Source() of formatted source = %q, %v, want it unchanged", again, err)
+			}
+		})
+	}
+}
+
+func TestDatum(t *testing.T) {
+	numbers := func(n int) []values.Interface {
+		var items []values.Interface
+		for i := range n {
+			items = append(items, values.NewInt(int64(i*1000)))
+		}
+		return items
+	}
+	id := values.NewIdentifier
+	tests := []struct {
+		name  string
+		v     values.Interface
+		width int
+		want  string
+	}{
+		{
+			name:  "fits",
+			v:     values.List(values.NewInt(1), values.NewString("two"), values.NewVector(values.NewBool(true))),
+			width: Width,
+			want:  `(1 "two" #(#t))`,
+		},
+		{
+			name:  "data",
+			v:     values.List(numbers(6)...),
+			width: 20,
+			want:  "(0\n 1000\n 2000\n 3000\n 4000\n 5000)",
+		},
+		{
+			name:  "vector",
+			v:     values.NewVector(numbers(4)...),
+			width: 10,
+			want:  "#(0\n  1000\n  2000\n  3000)",
+		},
+		{
+			name:  "calls",
+			v:     values.List(id("list"), values.List(append([]values.Interface{id("+")}, numbers(3)...)...), values.NewInt(7)),
+			width: 16,
+			want:  "(list (+ 0\n         1000\n         2000)\n      7)",
+		},
+		{
+			name:  "code",
+			v:     values.List(id("define"), values.List(id("f"), id("x")), values.List(id("g"), id("x")), id("x")),
+			width: 20,
+			want:  "(define (f x)\n  (g x)\n  x)",
+		},
+		{
+			name:  "dotted",
+			v:     values.Cons(values.NewString("a long string"), values.NewString("another one")),
+			width: 20,
+			want:  "(\"a long string\"\n .\n \"another one\")",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Datum(tt.v, tt.width); got != tt.want {
+				t.Errorf("Datum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "one change",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- a.orig\n+++ a\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:  "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			want: "--- a.orig\n+++ a\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+		{
+			name: "emptied",
+			old:  "a\n",
+			new:  "",
+			want: "--- a.orig\n+++ a\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name: "joined hunks",
+			old:  "1\n2\n3\n4\n5\n",
+			new:  "one\n2\n3\n4\nfive\n",
+			want: "--- a.orig\n+++ a\n@@ -1,5 +1,5 @@\n-1\n+one\n 2\n 3\n 4\n-5\n+five\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Diff("a.orig", []byte(tt.old), "a", []byte(tt.new))); got != tt.want {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package pretty
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/cst"
+)
+
+type nodeKind int
+
+const (
+	nodeAtom nodeKind = iota
+	nodeList
+	nodeQuote
+	nodeComment
+)
+
+// node is a datum or comment of a source, with the line breaks that preceded it.
+type node struct {
+	kind nodeKind
+	// text is the literal of an atom or comment, or the opening of a list or vector
+	text string
+	// closing is the closing parenthesis or bracket of a list
+	closing  string
+	children []*node
+	// newline is set when the node starts on a later line than the previous token ended,
+	// and blank when at least one empty line separates them
+	newline bool
+	blank   bool
+}
+
+// read returns the top level datums and comments of src, read with their trivia.
+func read(src []byte) ([]*node, error) {
+	f, err := cst.Read(bytes.NewReader(src), "")
+	if err != nil {
+		return nil, err
+	}
+	var (
+		c     converter
+		nodes []*node
+	)
+	for _, n := range f.Nodes {
+		if err := c.add(n, &nodes); err != nil {
+			return nil, err
+		}
+	}
+	c.trivia(f.Trailing, &nodes)
+	return nodes, nil
+}
+
+// converter converts the nodes of a concrete syntax tree, turning the comments in their
+// trivia into nodes, and the line breaks into the flags of the nodes following them.
+type converter struct {
+	// newlines counts the line breaks since the previous token or comment
+	newlines int
+}
+
+// add appends n to out, preceded by its leading comments and followed by its trailing ones.
+func (c *converter) add(n *cst.Node, out *[]*node) error {
+	c.trivia(n.Leading, out)
+	pn := c.node(nodeAtom, n.Token.Literal)
+	switch n.Kind {
+	case cst.Quote:
+		var quoted []*node
+		if err := c.add(n.Children[0], &quoted); err != nil {
+			return err
+		}
+		if len(quoted) != 1 {
+			return fmt.Errorf("%w: comment between a quote and its datum at %v", cst.ErrSyntax, n.Pos)
+		}
+		quoted[0].newline, quoted[0].blank = false, false
+		pn.kind, pn.children = nodeQuote, quoted
+	case cst.List, cst.Vector:
+		pn.kind, pn.closing = nodeList, n.Close.Literal
+		for _, child := range n.Children {
+			if err := c.add(child, &pn.children); err != nil {
+				return err
+			}
+		}
+		c.trivia(n.Inner, &pn.children)
+	}
+	*out = append(*out, pn)
+	c.trivia(n.Trailing, out)
+	return nil
+}
+
+// trivia appends the comments of trivia to out.
+func (c *converter) trivia(trivia []cst.Trivia, out *[]*node) {
+	for _, t := range trivia {
+		if t.IsComment() {
+			*out = append(*out, c.node(nodeComment, strings.TrimRight(t.Token.Literal, " \t\r")))
+			continue
+		}
+		c.newlines += t.Newlines()
+	}
+}
+
+// node returns a node following the line breaks counted so far.
+func (c *converter) node(kind nodeKind, text string) *node {
+	n := &node{kind: kind, text: text, newline: c.newlines > 0, blank: c.newlines > 1}
+	c.newlines = 0
+	return n
+}
@@ -0,0 +1,588 @@
+// Package resolve resolves the identifiers of Scheme sources read into concrete syntax
+// trees, without evaluating them. It walks the forms of a source as the evaluator would,
+// binding the names defined by define and define-record-type, the formals of procedures
+// and the names of let and the other binding forms in the scopes they are visible in, and
+// finds the binding each identifier refers to. Names a source does not bind are resolved
+// against the global environment of a runtime, whose special forms are recognized unless
+// the source shadows them.
+//
+// The linter and the language server build their checks and queries on the bindings,
+// references, calls and scopes it finds.
+package resolve
+
+import (
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/cst"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+type Kind int
+
+const (
+	// Define is a name defined by define, or a procedure defined by define-record-type
+	Define Kind = iota
+	// RecordType is the name of a record type defined by define-record-type
+	RecordType
+	// Parameter is a formal of a procedure
+	Parameter
+	// Let is a name bound by let
+	Let
+	// Local is a name bound by a named let, guard or select
+	Local
+)
+
+// Binding is a name bound by a source.
+type Binding struct {
+	Name string
+	Kind Kind
+	// ID is the identifier the name is bound by, and Form the whole defining form, or ID
+	// for the bindings that are not definitions
+	ID, Form *cst.Node
+	Scope    *Scope
+	// Replaces is the binding of the same name in Scope that the binding replaces
+	Replaces *Binding
+	// Used is set when an identifier of the source refers to the binding
+	Used bool
+
+	// Arity is the arity of a procedure whose formals are known, and Formals the formals
+	// of a procedure defined by define or a named let: either a list of identifiers,
+	// possibly dotted, or a single identifier
+	Arity   *builtins.Arity
+	Formals *cst.Node
+	// Body is the body of a procedure defined by define, and Inner the scope of the body
+	// once it is walked
+	Body  []*cst.Node
+	Inner *Scope
+	// Members are the constructor, predicate, accessors and modifiers of a record type
+	Members []*Binding
+}
+
+// Scope is a region of a source in which names are bound, together with the scope
+// enclosing it.
+type Scope struct {
+	Parent *Scope
+	// Node is the form the scope covers, or nil for the scope of the whole source
+	Node  *cst.Node
+	Names map[string]*Binding
+	// Definitions are the names defined by the body of the scope, in the order of the source
+	Definitions []*Binding
+}
+
+// Lookup returns the binding of name in sc or the scopes enclosing it.
+func (sc *Scope) Lookup(name string) (*Binding, bool) {
+	for ; sc != nil; sc = sc.Parent {
+		if b, ok := sc.Names[name]; ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// Reference is an identifier referring to a name, with the binding of the source it refers
+// to, or nil when it refers to a global of the runtime or is unbound.
+type Reference struct {
+	ID      *cst.Node
+	Binding *Binding
+}
+
+// Call is the application of a procedure, with the binding of the source its operator
+// refers to, or nil when the operator is not an identifier the source binds.
+type Call struct {
+	Form    *cst.Node
+	Binding *Binding
+	// Args is the number of arguments before the dot of a dotted list of arguments, whose
+	// datum after the dot is spread into the arguments
+	Args   int
+	Dotted bool
+}
+
+// Value is an expression whose value is used, such as an argument or the value of a
+// definition, with the name of the special form it is an application of, or "".
+type Value struct {
+	Node    *cst.Node
+	Special string
+}
+
+// Result is what resolving a source finds, each in the order it is walked.
+type Result struct {
+	Top    *Scope
+	Scopes []*Scope
+
+	Bindings   []*Binding
+	References []Reference
+	Unbound    []*cst.Node
+	Calls      []Call
+	Values     []Value
+	// Assigned holds the names set! anywhere in the source
+	Assigned map[string]bool
+	// Opaque is set when the source loads, includes or imports code that is not resolved,
+	// so that the identifiers it refers to may be bound without the resolver knowing
+	Opaque bool
+}
+
+// resolver walks the forms of a source, recording what it finds in its result.
+type resolver struct {
+	*Result
+	globals *builtins.Runtime
+	// declared holds the binding of each define and define-record-type form declared
+	// with the body it belongs to
+	declared map[*cst.Node]*Binding
+}
+
+// File resolves the identifiers of f, resolving the names it does not bind against the
+// global environment of globals.
+func File(f *cst.File, globals *builtins.Runtime) *Result {
+	r := &resolver{
+		Result:   &Result{Assigned: make(map[string]bool)},
+		globals:  globals,
+		declared: make(map[*cst.Node]*Binding),
+	}
+	for _, n := range f.Nodes {
+		r.collectAssigned(n)
+	}
+	r.Top = r.newScope(nil, nil)
+	r.walkBody(f.Nodes, r.Top)
+	return r.Result
+}
+
+// Elements returns the elements of a list before its dot, and the datum after it.
+func Elements(n *cst.Node) ([]*cst.Node, *cst.Node) {
+	for i, child := range n.Children {
+		if child.Kind == cst.Dot {
+			if i+1 < len(n.Children) {
+				return n.Children[:i], n.Children[i+1]
+			}
+			return n.Children[:i], nil
+		}
+	}
+	return n.Children, nil
+}
+
+// formalsArity returns the arity of a procedure with the formals formals, which are
+// either a list of identifiers, possibly dotted, or a single identifier.
+func formalsArity(formals *cst.Node) *builtins.Arity {
+	var arity builtins.Arity
+	switch formals.Kind {
+	case cst.List:
+		items, tail := Elements(formals)
+		arity = builtins.Exactly(len(items))
+		if tail != nil {
+			arity = builtins.AtLeast(len(items))
+		}
+	case cst.Atom:
+		arity = builtins.AtLeast(0)
+	default:
+		return nil
+	}
+	return &arity
+}
+
+// collectAssigned records the names n assigns with set!.
+func (r *resolver) collectAssigned(n *cst.Node) {
+	if n.Kind == cst.Quote {
+		return
+	}
+	if n.Kind == cst.List && len(n.Children) > 1 {
+		if head, _ := n.Children[0].Identifier(); head == "set!" {
+			if name, ok := n.Children[1].Identifier(); ok {
+				r.Assigned[name] = true
+			}
+		}
+	}
+	for _, child := range n.Children {
+		r.collectAssigned(child)
+	}
+}
+
+func (r *resolver) newScope(parent *Scope, node *cst.Node) *Scope {
+	sc := &Scope{Parent: parent, Node: node, Names: make(map[string]*Binding)}
+	r.Scopes = append(r.Scopes, sc)
+	return sc
+}
+
+// bind binds the identifier id in sc. It returns nil when id is not an identifier.
+func (r *resolver) bind(sc *Scope, id, form *cst.Node, kind Kind) *Binding {
+	name, ok := id.Identifier()
+	if !ok {
+		return nil
+	}
+	b := &Binding{Name: name, Kind: kind, ID: id, Form: form, Scope: sc, Replaces: sc.Names[name]}
+	sc.Names[name] = b
+	r.Bindings = append(r.Bindings, b)
+	return b
+}
+
+// procedure records that b is a procedure with the formals formals and the body body.
+func procedure(b *Binding, formals *cst.Node, body []*cst.Node) {
+	b.Formals, b.Body, b.Arity = formals, body, formalsArity(formals)
+}
+
+// special returns the name of the special form n is an application of, or "" when n is
+// not one. A special form shadowed by a binding of the source is not special.
+func (r *resolver) special(n *cst.Node, sc *Scope) string {
+	if n.Kind != cst.List || len(n.Children) == 0 {
+		return ""
+	}
+	name, ok := n.Children[0].Identifier()
+	if !ok {
+		return ""
+	}
+	if _, ok := sc.Lookup(name); ok {
+		return ""
+	}
+	if v, ok := r.globals.Env.Lookup(name); ok {
+		if _, ok := v.(builtins.Syntax); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// declareAll binds the names defined by the forms of a body, including those within
+// begin, so that they can be referred to before their definition.
+func (r *resolver) declareAll(forms []*cst.Node, sc *Scope) {
+	for _, form := range forms {
+		var b *Binding
+		switch r.special(form, sc) {
+		case "begin":
+			r.declareAll(form.Children[1:], sc)
+		case "define":
+			b = r.declareDefine(form, sc)
+		case "define-record-type":
+			b = r.declareRecordType(form, sc)
+		}
+		if b != nil {
+			sc.Definitions = append(sc.Definitions, b)
+		}
+	}
+}
+
+// declareDefine binds the name of (define name expr) or (define (name . formals) body ...).
+func (r *resolver) declareDefine(form *cst.Node, sc *Scope) *Binding {
+	if len(form.Children) < 2 {
+		return nil
+	}
+	var b *Binding
+	switch target := form.Children[1]; {
+	case target.Kind != cst.List:
+		b = r.bind(sc, target, form, Define)
+		if b != nil && len(form.Children) > 2 && r.special(form.Children[2], sc) == "lambda" && len(form.Children[2].Children) > 1 {
+			lambda := form.Children[2]
+			procedure(b, lambda.Children[1], lambda.Children[2:])
+		}
+	case len(target.Children) > 0:
+		b = r.bind(sc, target.Children[0], form, Define)
+		if b != nil {
+			procedure(b, &cst.Node{Kind: cst.List, Children: target.Children[1:]}, form.Children[2:])
+		}
+	}
+	r.declared[form] = b
+	return b
+}
+
+// declareRecordType binds the type, constructor, predicate, accessors and modifiers of
+// (define-record-type name (constructor field ...) predicate (field accessor [modifier]) ...).
+func (r *resolver) declareRecordType(form *cst.Node, sc *Scope) *Binding {
+	if len(form.Children) < 4 {
+		return nil
+	}
+	b := r.bind(sc, form.Children[1], form, RecordType)
+	if b == nil {
+		return nil
+	}
+	r.declared[form] = b
+	member := func(id *cst.Node, arity builtins.Arity) {
+		if m := r.bind(sc, id, form, Define); m != nil {
+			m.Arity = &arity
+			b.Members = append(b.Members, m)
+		}
+	}
+	if ctor := form.Children[2]; ctor.Kind == cst.List && len(ctor.Children) > 0 {
+		member(ctor.Children[0], builtins.Exactly(len(ctor.Children)-1))
+	}
+	member(form.Children[3], builtins.Exactly(1))
+	for _, field := range form.Children[4:] {
+		if field.Kind != cst.List {
+			continue
+		}
+		if len(field.Children) > 1 {
+			member(field.Children[1], builtins.Exactly(1))
+		}
+		if len(field.Children) > 2 {
+			member(field.Children[2], builtins.Exactly(2))
+		}
+	}
+	return b
+}
+
+// walk resolves the identifiers of the expression n evaluated in sc.
+func (r *resolver) walk(n *cst.Node, sc *Scope) {
+	switch n.Kind {
+	case cst.Quote, cst.Vector, cst.Dot:
+		return
+	case cst.Atom:
+		r.refer(n, sc)
+		return
+	}
+	special := r.special(n, sc)
+	if special == "" {
+		r.walkCall(n, sc)
+		return
+	}
+	r.refer(n.Children[0], sc)
+	args := n.Children[1:]
+	switch special {
+	case "quote", "define-library":
+	case "define":
+		r.walkDefine(n, sc)
+	case "define-record-type":
+		if _, ok := r.declared[n]; !ok {
+			r.declareRecordType(n, sc)
+		}
+	case "set!":
+		if len(args) > 0 {
+			r.refer(args[0], sc)
+			r.walkValues(args[1:], sc)
+		}
+	case "lambda":
+		if len(args) > 0 {
+			r.walkProcedure(n, args[0], args[1:], sc)
+		}
+	case "case-lambda":
+		for _, clause := range args {
+			if clause.Kind == cst.List && len(clause.Children) > 0 {
+				r.walkProcedure(clause, clause.Children[0], clause.Children[1:], sc)
+			}
+		}
+	case "let":
+		r.walkLet(n, args, sc)
+	case "cond":
+		r.walkClauses(args, sc)
+	case "guard":
+		r.walkGuard(n, args, sc)
+	case "select":
+		r.walkSelect(args, sc)
+	case "parameterize":
+		if len(args) == 0 {
+			return
+		}
+		if args[0].Kind == cst.List {
+			for _, binding := range args[0].Children {
+				r.walkValues(binding.Children, sc)
+			}
+		}
+		r.walkBody(args[1:], r.newScope(sc, n))
+	case "import":
+		r.walkImport(args)
+	default:
+		r.walkAll(args, sc)
+	}
+}
+
+func (r *resolver) walkAll(nodes []*cst.Node, sc *Scope) {
+	for _, n := range nodes {
+		r.walk(n, sc)
+	}
+}
+
+// walkValues walks expressions whose values are used.
+func (r *resolver) walkValues(nodes []*cst.Node, sc *Scope) {
+	for _, n := range nodes {
+		r.Values = append(r.Values, Value{Node: n, Special: r.special(n, sc)})
+		r.walk(n, sc)
+	}
+}
+
+// walkCall walks the application of a procedure. Loading or including a file makes the
+// source opaque.
+func (r *resolver) walkCall(n *cst.Node, sc *Scope) {
+	if len(n.Children) == 0 {
+		return
+	}
+	head := n.Children[0]
+	name, isName := head.Identifier()
+	if isName && (name == "load" || name == "include") {
+		r.Opaque = true
+	}
+	r.walk(head, sc)
+	args, tail := Elements(n)
+	args = args[1:]
+	r.walkValues(args, sc)
+	if tail != nil {
+		r.walk(tail, sc)
+	}
+	call := Call{Form: n, Args: len(args), Dotted: tail != nil}
+	if isName {
+		call.Binding, _ = sc.Lookup(name)
+	}
+	r.Calls = append(r.Calls, call)
+}
+
+// refer resolves the identifier id, recording it as unbound when neither the source nor
+// the runtime binds it.
+func (r *resolver) refer(id *cst.Node, sc *Scope) {
+	name, ok := id.Identifier()
+	if !ok {
+		return
+	}
+	b, _ := sc.Lookup(name)
+	r.References = append(r.References, Reference{ID: id, Binding: b})
+	if b != nil {
+		b.Used = true
+		return
+	}
+	if _, ok := r.globals.Env.Lookup(name); !ok {
+		r.Unbound = append(r.Unbound, id)
+	}
+}
+
+// walkDefine walks a define form, binding its name in sc unless it was already declared
+// with the body it belongs to.
+func (r *resolver) walkDefine(form *cst.Node, sc *Scope) {
+	b, ok := r.declared[form]
+	if !ok {
+		b = r.declareDefine(form, sc)
+	}
+	if len(form.Children) < 2 {
+		return
+	}
+	target := form.Children[1]
+	if target.Kind == cst.List {
+		inner := r.walkProcedure(form, &cst.Node{Kind: cst.List, Children: target.Children[min(1, len(target.Children)):]}, form.Children[2:], sc)
+		if b != nil {
+			b.Inner = inner
+		}
+		return
+	}
+	if b != nil && b.Formals != nil {
+		lambda := form.Children[2]
+		r.refer(lambda.Children[0], sc)
+		b.Inner = r.walkProcedure(lambda, b.Formals, b.Body, sc)
+		r.walkValues(form.Children[3:], sc)
+		return
+	}
+	r.walkValues(form.Children[2:], sc)
+}
+
+// walkProcedure walks the body of the procedure form in a new scope binding its formals,
+// and returns the scope.
+func (r *resolver) walkProcedure(form, formals *cst.Node, body []*cst.Node, sc *Scope) *Scope {
+	inner := r.newScope(sc, form)
+	if formals.Kind == cst.List {
+		items, tail := Elements(formals)
+		for _, formal := range items {
+			r.bind(inner, formal, formal, Parameter)
+		}
+		if tail != nil {
+			r.bind(inner, tail, tail, Parameter)
+		}
+	} else {
+		r.bind(inner, formals, formals, Parameter)
+	}
+	r.walkBody(body, inner)
+	return inner
+}
+
+// walkBody walks the expressions of a body in sc, after declaring its internal definitions.
+func (r *resolver) walkBody(body []*cst.Node, sc *Scope) {
+	r.declareAll(body, sc)
+	r.walkAll(body, sc)
+}
+
+// walkLet walks (let ((name expr) ...) body ...) and the named let (let loop ((name expr) ...) body ...).
+func (r *resolver) walkLet(form *cst.Node, args []*cst.Node, sc *Scope) {
+	if len(args) == 0 {
+		return
+	}
+	inner := r.newScope(sc, form)
+	if _, ok := args[0].Identifier(); ok && len(args) > 1 {
+		loop := r.bind(inner, args[0], args[0], Local)
+		var names []*cst.Node
+		if args[1].Kind == cst.List {
+			for _, binding := range args[1].Children {
+				if binding.Kind == cst.List && len(binding.Children) > 0 {
+					names = append(names, binding.Children[0])
+				}
+			}
+		}
+		procedure(loop, &cst.Node{Kind: cst.List, Children: names}, nil)
+		args = args[1:]
+	}
+	if args[0].Kind == cst.List {
+		for _, binding := range args[0].Children {
+			if binding.Kind != cst.List || len(binding.Children) == 0 {
+				continue
+			}
+			r.walkValues(binding.Children[1:], sc)
+			r.bind(inner, binding.Children[0], binding.Children[0], Let)
+		}
+	}
+	r.walkBody(args[1:], inner)
+}
+
+// walkClauses walks the clauses of cond, (test expr ...), (test => receiver) and (else expr ...).
+func (r *resolver) walkClauses(clauses []*cst.Node, sc *Scope) {
+	for _, clause := range clauses {
+		if clause.Kind != cst.List {
+			continue
+		}
+		for i, part := range clause.Children {
+			if name, _ := part.Identifier(); i == 0 && name == "else" || i == 1 && name == "=>" {
+				continue
+			}
+			r.walk(part, sc)
+		}
+	}
+}
+
+// walkGuard walks (guard (var clause ...) body ...), whose clauses are walked in a scope binding var.
+func (r *resolver) walkGuard(form *cst.Node, args []*cst.Node, sc *Scope) {
+	if len(args) == 0 {
+		return
+	}
+	r.walkBody(args[1:], r.newScope(sc, form))
+	if args[0].Kind != cst.List || len(args[0].Children) == 0 {
+		return
+	}
+	handler := r.newScope(sc, args[0])
+	r.bind(handler, args[0].Children[0], args[0].Children[0], Local)
+	r.walkClauses(args[0].Children[1:], handler)
+}
+
+// walkSelect walks the clauses of select, where (recv channel var body ...) binds var in its body.
+func (r *resolver) walkSelect(clauses []*cst.Node, sc *Scope) {
+	for _, clause := range clauses {
+		if clause.Kind != cst.List || len(clause.Children) == 0 {
+			continue
+		}
+		kind, _ := clause.Children[0].Identifier()
+		parts := clause.Children[1:]
+		switch {
+		case kind == "recv" && len(parts) > 1:
+			r.walk(parts[0], sc)
+			inner := r.newScope(sc, clause)
+			r.bind(inner, parts[1], parts[1], Local)
+			r.walkBody(parts[2:], inner)
+		case kind == "else":
+			r.walkBody(parts, r.newScope(sc, clause))
+		default:
+			r.walkAll(parts, sc)
+		}
+	}
+}
+
+// walkImport makes the source opaque when an import set is anything but the name of a
+// library the runtime knows, as the names it binds are then unknown.
+func (r *resolver) walkImport(sets []*cst.Node) {
+	for _, set := range sets {
+		if set.Kind != cst.List {
+			continue
+		}
+		name := make(builtins.LibraryName, 0, len(set.Children))
+		for _, part := range set.Children {
+			name = append(name, part.Token.Literal)
+		}
+		if _, ok := r.globals.Libraries().Lookup(name); !ok {
+			r.Opaque = true
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package resolve
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/cst"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// want describes each reference as name@line:column, followed by the position of
+		// the binding it refers to, global or unbound
+		want []string
+	}{
+		{
+			name: "formals and globals",
+			src:  "(define (f x) (+ x y))",
+			want: []string{"define@1:2 global", "+@1:16 global", "x@1:18 1:12", "y@1:20 unbound"},
+		},
+		{
+			name: "definitions are visible before them",
+			src:  "(define (f) (g))\n(define (g) 1)",
+			want: []string{"define@1:2 global", "g@1:14 2:10", "define@2:2 global"},
+		},
+		{
+			name: "let inits are outside the let",
+			src:  "(let ((x 1)) (let ((x x)) x))",
+			want: []string{"let@1:2 global", "let@1:15 global", "x@1:23 1:8", "x@1:27 1:21"},
+		},
+		{
+			name: "named let",
+			src:  "(let loop ((i 0)) (loop i))",
+			want: []string{"let@1:2 global", "loop@1:20 1:6", "i@1:25 1:13"},
+		},
+		{
+			name: "shadowed special form",
+			src:  "(define (f if) (if 1))",
+			want: []string{"define@1:2 global", "if@1:17 1:12"},
+		},
+		{
+			name: "quoted data",
+			src:  "'(a b) (quote c)",
+			want: []string{"quote@1:9 global"},
+		},
+		{
+			name: "guard and select",
+			src:  "(guard (e (#t e)) (select (recv ch v v)))",
+			want: []string{"guard@1:2 global", "select@1:20 global", "ch@1:33 unbound", "v@1:38 1:36", "e@1:15 1:9"},
+		},
+		{
+			name: "format to t",
+			src:  `(format t "~a" x)`,
+			want: []string{"format@1:2 global", "t@1:9 global", "x@1:16 unbound"},
+		},
+	}
+	globals := builtins.NewRuntime()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := File(read(t, tt.src), globals)
+			var got []string
+			for _, ref := range res.References {
+				to := "global"
+				switch {
+				case ref.Binding != nil:
+					to = fmt.Sprintf("%d:%d", ref.Binding.ID.Pos.Line, ref.Binding.ID.Pos.Column)
+				case contains(res.Unbound, ref.ID):
+					to = "unbound"
+				}
+				got = append(got, fmt.Sprintf("%s@%d:%d %s", ref.ID.Token.Literal, ref.ID.Pos.Line, ref.ID.Pos.Column, to))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("References = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	src := `(define (area r) "the area of a circle" (define pi 3.14) (* pi r r))
+(define-record-type point (make-point x y) point? (x point-x set-point-x!))
+(define add (lambda (a . rest) (apply + a rest)))
+(load "lib.scm")`
+	res := File(read(t, src), builtins.NewRuntime())
+	var got []string
+	for _, b := range res.Top.Definitions {
+		desc := fmt.Sprintf("%s %v", b.Name, b.Arity)
+		if b.Inner != nil {
+			for _, inner := range b.Inner.Definitions {
+				desc += " " + inner.Name
+			}
+		}
+		for _, m := range b.Members {
+			desc += fmt.Sprintf(" %s %v", m.Name, m.Arity)
+		}
+		got = append(got, desc)
+	}
+	want := []string{
+		"area 1 pi",
+		"point <nil> make-point 2 point? 1 point-x 1 set-point-x! 2",
+		"add at least 1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Definitions = %q, want %q", got, want)
+	}
+	if !res.Opaque {
+		t.Errorf("Opaque = false after load")
+	}
+}
+
+func read(t *testing.T, src string) *cst.File {
+	t.Helper()
+	f, err := cst.Read(strings.NewReader(src), "test.scm")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	return f
+}
+
+func contains(nodes []*cst.Node, n *cst.Node) bool {
+	for _, node := range nodes {
+		if node == n {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,10 @@
 package tui
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"os"
 	"time"
 
@@ -34,9 +35,11 @@ type Model struct {
 	err            error
 	ctx            context.Context
 	cancelFunc     context.CancelFunc
-	runtime        *builtins.Runtime
-	readFromParser io.Reader
-	openParens     int
+	// running holds the cancel functions of the evaluations in progress by their id
+	running    map[int]context.CancelFunc
+	nextEvalID int
+	runtime    *builtins.Runtime
+	openParens int
 }
 
 func (m Model) Init() tea.Cmd {
@@ -55,13 +58,12 @@ func InitialModel(prompt string) Model {
 	vp := viewport.New(30, 5)
 	vp.SetContent("welcome to scheme")
 
-	//pr, pw := io.Pipe()
-
 	ctx, cancel := context.WithCancel(context.Background())
 
 	runtime := builtins.NewRuntime(
 		builtins.WithOut(os.Stdout),
-		builtins.WithEvaluatorCallback(parser.DefaultExpressionEvaluator()))
+		builtins.WithEvaluatorCallback(parser.DefaultExpressionEvaluator()),
+		builtins.WithDatumReader(parser.DefaultDatumReader()))
 
 	// styles
 	inputStyle := lipgloss.NewStyle().
@@ -91,26 +93,22 @@ func InitialModel(prompt string) Model {
 		openParens:     0,
 		ctx:            ctx,
 		cancelFunc:     cancel,
-		//readFromParser: pr,
+		running:        make(map[int]context.CancelFunc),
 	}
 }
 
+// EvalCompleteMsg reports the end of the evaluation dispatched with id.
 type EvalCompleteMsg struct {
+	id     int
 	result values.Interface
+	output string
 	err    error
 }
 
-type ParserIoAvailable struct {
-	content  string
-	canceled bool
-}
-
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var (
-		tiCmd                tea.Cmd
-		vpCmd                tea.Cmd
-		runParserCmd         tea.Cmd
-		updateParseResultCmd tea.Cmd
+		tiCmd tea.Cmd
+		vpCmd tea.Cmd
 	)
 
 	m.textarea, tiCmd = m.textarea.Update(msg)
@@ -132,11 +130,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case EvalCompleteMsg:
 		return m.handleEvalComplete(msg, tiCmd, vpCmd)
-	case ParserIoAvailable:
-		return m.handleParserIoAvailable(msg, tiCmd, vpCmd)
 	case tea.KeyMsg:
 		switch msg.Type {
-		case tea.KeyEsc, tea.KeyCtrlC:
+		case tea.KeyCtrlC:
+			// interrupt the running evaluations, quit when idle
+			if len(m.running) > 0 {
+				for _, cancel := range m.running {
+					cancel()
+				}
+				return m, tea.Batch(tiCmd, vpCmd)
+			}
+			if m.cancelFunc != nil {
+				m.cancelFunc()
+			}
+			return m, tea.Quit
+		case tea.KeyEsc:
 			if m.cancelFunc != nil {
 				m.cancelFunc()
 			}
@@ -173,15 +181,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case errMsg:
 		m.err = msg
 	}
-	if runParserCmd != nil {
-		return m, tea.Batch(tiCmd, vpCmd, runParserCmd, updateParseResultCmd)
-	}
 	return m, tea.Batch(tiCmd, vpCmd)
 }
 
 func (m Model) handleEvalComplete(msg EvalCompleteMsg, tiCmd, vpCmd tea.Cmd) (tea.Model, tea.Cmd) {
 	now := time.Now()
-	if msg.err != nil {
+	if cancel, ok := m.running[msg.id]; ok {
+		cancel()
+		delete(m.running, msg.id)
+	}
+	if msg.output != "" {
+		m.outputs = append(m.outputs,
+			fmt.Sprintf("%s Output: %s",
+				now.Format(time.Kitchen), msg.output))
+	}
+	if errors.Is(msg.err, context.Canceled) {
+		m.outputs = append(m.outputs,
+			fmt.Sprintf("%s Interrupted", now.Format(time.Kitchen)))
+	} else if msg.err != nil {
 		m.outputs = append(m.outputs,
 			fmt.Sprintf("%s Error: %s", now.Format(time.Kitchen),
 				msg.err.Error()))
@@ -210,49 +227,17 @@ func (m Model) handleLineOfCommandEntered(msg string, tiCmd, vpCmd tea.Cmd) (tea
 	return m, tea.Batch(tiCmd, vpCmd)
 }
 
-func (m Model) handleParserIoAvailable(msg ParserIoAvailable, tiCmd, vpCmd tea.Cmd) (tea.Model, tea.Cmd) {
-	now := time.Now()
-	if msg.content != "" {
-		m.outputs = append(m.outputs,
-			fmt.Sprintf("%s Output: %s",
-				now.Format(time.Kitchen), msg.content))
-		m.viewport.SetContent(lipgloss.NewStyle().
-			Width(m.viewport.Width).
-			Render(strings.Join(m.outputs, "\n")))
-		m.viewport.GotoBottom()
-	}
-	m.input = ""
-	m.cursor = -1
-	return m, tea.Batch(tiCmd, vpCmd)
-}
-
 func (m Model) handleParserDispatch(msg string, tiCmd, vpCmd tea.Cmd) (tea.Model, tea.Cmd) {
-	var (
-		runParserCmd, updateParseResultCmd tea.Cmd
-	)
-	pr, pw := io.Pipe()
-	runParserCmd = func() tea.Msg {
-		m.runtime.Out = pw
-		val, err := parser.EvalString(m.ctx, msg, m.runtime)
-		return EvalCompleteMsg{result: val, err: err}
-	}
-	updateParseResultCmd = func() tea.Msg {
-		var content string
-		select {
-		case <-m.ctx.Done():
-			_ = pr.Close()
-			return ParserIoAvailable{canceled: true}
-		default:
-			buf := make([]byte, 1024)
-			for {
-				n, err := pr.Read(buf)
-				if err != nil {
-				}
-				content = string(buf[:n])
-				return ParserIoAvailable{content: content}
-
-			}
-		}
+	// each evaluation writes to its own buffer and can be interrupted with Ctrl-C
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.nextEvalID++
+	id := m.nextEvalID
+	m.running[id] = cancel
+	out := bytes.NewBuffer(nil)
+	rt := m.runtime.WithOutput(out)
+	runParserCmd := func() tea.Msg {
+		val, err := parser.EvalString(ctx, msg, rt)
+		return EvalCompleteMsg{id: id, result: val, output: out.String(), err: err}
 	}
 	now := time.Now()
 	m.history = append(m.history, m.input)
@@ -263,7 +248,7 @@ func (m Model) handleParserDispatch(msg string, tiCmd, vpCmd tea.Cmd) (tea.Model
 			Render(strings.Join(m.outputs, "\n")))
 	m.textarea.Reset()
 	m.viewport.GotoBottom()
-	return m, tea.Batch(tiCmd, vpCmd, runParserCmd, updateParseResultCmd)
+	return m, tea.Batch(tiCmd, vpCmd, runParserCmd)
 }
 
 func (m Model) handleScrollUpHistory(tiCmd, vpCmd tea.Cmd) (tea.Model, tea.Cmd) {
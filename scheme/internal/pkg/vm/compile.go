@@ -0,0 +1,727 @@
+package vm
+
+import (
+	"fmt"
+	"text/scanner"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+type opcode uint8
+
+const (
+	// opConst pushes consts[a]
+	opConst opcode = iota
+	// opVoid pushes the unspecified value
+	opVoid
+	// opLocal pushes slot b of the frame a levels out
+	opLocal
+	// opGlobal pushes the global names[a], which must be a procedure when b is set
+	opGlobal
+	// opStoreLocal pops a value into slot b of the frame a levels out
+	opStoreLocal
+	// opSetGlobal pops a value into the existing global names[a] and pushes the unspecified value
+	opSetGlobal
+	// opDefineGlobal pops a value, binds the global names[a] to it and pushes the unspecified value
+	opDefineGlobal
+	opPop
+	opDup
+	opSwap
+	// opJump continues at instruction a
+	opJump
+	// opJumpIfFalse pops a value and continues at instruction a when it is false
+	opJumpIfFalse
+	// opClosure pushes a closure of the function functions[a] over the current frame
+	opClosure
+	// opCall calls the procedure below the a arguments on top of the stack
+	opCall
+	// opTailCall calls like opCall and returns its result, replacing the current call
+	opTailCall
+	// opReturn pops a value and returns it to the caller
+	opReturn
+	// opEval pushes the value of consts[a] evaluated by the evaluator of the runtime
+	opEval
+	// opPrim calls the global names[a] with the b arguments on top of the stack, which
+	// the machine computes itself when the global is a primitive
+	opPrim
+)
+
+// instr is an instruction of a function: an opcode and its operands.
+type instr struct {
+	op   opcode
+	a, b int32
+}
+
+// code is a compiled function, or the body of a compiled program.
+type code struct {
+	name   string
+	source scanner.Position
+	// anonymous describes the procedure in stack traces when it has no name
+	anonymous string
+	instrs    []instr
+	// calls holds the source positions of the calls made by the call instructions
+	calls     map[int]scanner.Position
+	consts    []values.Interface
+	functions []*code
+	// params is the number of formals before the rest formal, if rest is set. The
+	// formals take the first slots of a frame, followed by the variables of the body.
+	params int
+	rest   bool
+	arity  builtins.Arity
+	// slots names the slots of the frame of a call
+	slots []string
+	// program is set for the body of a program, which is not a procedure
+	program bool
+}
+
+func (c *code) emit(op opcode, a, b int) int {
+	c.instrs = append(c.instrs, instr{op: op, a: int32(a), b: int32(b)})
+	return len(c.instrs) - 1
+}
+
+// patch sets the target of the jump at pc to the next instruction.
+func (c *code) patch(pc int) {
+	c.instrs[pc].a = int32(len(c.instrs))
+}
+
+// position records the source position of form as that of the call made at pc. A nil
+// form makes a call without a position, as those made by special forms.
+func (c *code) position(pc int, form values.Interface) {
+	if form == nil {
+		return
+	}
+	if pos, ok := values.SourcePosition(form); ok {
+		if c.calls == nil {
+			c.calls = make(map[int]scanner.Position)
+		}
+		c.calls[pc] = pos
+	}
+}
+
+func (c *code) constant(v values.Interface) int {
+	c.consts = append(c.consts, v)
+	return len(c.consts) - 1
+}
+
+func (c *code) slot(name string) int {
+	c.slots = append(c.slots, name)
+	return len(c.slots) - 1
+}
+
+// scope is a region of compiled code in which names are bound to slots of the frame of
+// fn, together with the scope enclosing it.
+type scope struct {
+	parent *scope
+	fn     *code
+	names  map[string]int
+}
+
+func newScope(parent *scope, fn *code) *scope {
+	return &scope{parent: parent, fn: fn, names: make(map[string]int)}
+}
+
+// resolve returns the frame, counted outwards from that of sc, and the slot of the
+// local variable name, or false for a global.
+func (sc *scope) resolve(name string) (depth, slot int, ok bool) {
+	for s := sc; s != nil; s = s.parent {
+		if slot, ok := s.names[name]; ok {
+			return depth, slot, true
+		}
+		if s.parent != nil && s.parent.fn != s.fn {
+			depth++
+		}
+	}
+	return 0, 0, false
+}
+
+// top reports whether sc is the outermost scope of a program, where definitions are global.
+func (sc *scope) top() bool {
+	return sc.parent == nil && sc.fn.program
+}
+
+// compiler translates expressions, resolving special forms against the global
+// environment of its runtime.
+type compiler struct {
+	rt *builtins.Runtime
+	// names are the globals referred to by the code compiled, indexed by its instructions
+	names []string
+}
+
+func (c *compiler) global(name string) int {
+	for i, n := range c.names {
+		if n == name {
+			return i
+		}
+	}
+	c.names = append(c.names, name)
+	return len(c.names) - 1
+}
+
+// notCompilable reports a form the compiler does not translate.
+func notCompilable(form values.Interface) error {
+	return fmt.Errorf("%w: %s", ErrNotCompilable, form.WriteString())
+}
+
+func symbolName(v values.Interface) (string, bool) {
+	switch sym := v.(type) {
+	case values.Operator:
+		return sym.GetName(), true
+	case values.Identifier:
+		return sym.GetName(), true
+	}
+	return "", false
+}
+
+// special returns the name of the special form expr is an application of, or "" when
+// it is not one. A special form shadowed by a local variable is not special.
+func (c *compiler) special(expr values.Interface, sc *scope) string {
+	pair, ok := expr.(values.Pair)
+	if !ok {
+		return ""
+	}
+	name, ok := symbolName(pair.Car())
+	if !ok {
+		return ""
+	}
+	if _, _, ok := sc.resolve(name); ok {
+		return ""
+	}
+	if v, ok := c.rt.Env.Lookup(name); ok {
+		if syntax, ok := v.(builtins.Syntax); ok {
+			return syntax.Name
+		}
+	}
+	return ""
+}
+
+// compile emits the instructions evaluating expr in sc, which leave its value on the
+// stack, or return it when expr is in tail position.
+func (c *compiler) compile(expr values.Interface, sc *scope, tail bool) error {
+	fn := sc.fn
+	switch v := expr.(type) {
+	case values.Operator:
+		c.reference(v.GetName(), true, sc)
+	case values.Identifier:
+		c.reference(v.GetName(), false, sc)
+	case values.Pair:
+		return c.compilePair(v, sc, tail)
+	default:
+		// literals evaluate to themselves
+		fn.emit(opConst, fn.constant(expr), 0)
+	}
+	c.ret(sc, tail)
+	return nil
+}
+
+// ret returns the value on top of the stack when in tail position.
+func (c *compiler) ret(sc *scope, tail bool) {
+	if tail {
+		sc.fn.emit(opReturn, 0, 0)
+	}
+}
+
+func (c *compiler) reference(name string, operator bool, sc *scope) {
+	if depth, slot, ok := sc.resolve(name); ok {
+		sc.fn.emit(opLocal, depth, slot)
+		return
+	}
+	b := 0
+	if operator {
+		b = 1
+	}
+	sc.fn.emit(opGlobal, c.global(name), b)
+}
+
+func (c *compiler) compilePair(form values.Pair, sc *scope, tail bool) error {
+	operands, err := values.ToSlice(form.Cdr())
+	if err != nil {
+		return notCompilable(form)
+	}
+	fn := sc.fn
+	switch name := c.special(form, sc); name {
+	case "":
+		return c.compileCall(form, operands, sc, tail)
+	case "quote":
+		if len(operands) != 1 {
+			return notCompilable(form)
+		}
+		fn.emit(opConst, fn.constant(values.NewQuot(operands[0])), 0)
+	case "if":
+		return c.compileIf(form, operands, sc, tail)
+	case "define":
+		if err := c.compileDefine(form, operands, sc); err != nil {
+			return err
+		}
+	case "set!":
+		if err := c.compileSet(form, operands, sc); err != nil {
+			return err
+		}
+	case "lambda":
+		if len(operands) < 2 {
+			return notCompilable(form)
+		}
+		if err := c.compileLambda("", form, operands[0], operands[1:], sc); err != nil {
+			return err
+		}
+	case "begin":
+		if len(operands) == 0 {
+			fn.emit(opVoid, 0, 0)
+			break
+		}
+		return c.compileSequence(operands, sc, tail)
+	case "let":
+		return c.compileLet(form, operands, sc, tail)
+	case "cond":
+		return c.compileCond(form, operands, sc, tail)
+	default:
+		if err := c.delegate(form, name, sc); err != nil {
+			return err
+		}
+	}
+	c.ret(sc, tail)
+	return nil
+}
+
+// definitions are the special forms binding names in the environment they are evaluated in.
+var definitions = map[string]bool{
+	"define":             true,
+	"define-record-type": true,
+	"define-library":     true,
+	"import":             true,
+}
+
+// delegate emits the evaluation of a special form the compiler does not translate by
+// the evaluator of the runtime. That evaluation sees the global environment only, so
+// the form may neither refer to local variables nor define names within a body.
+func (c *compiler) delegate(form values.Interface, name string, sc *scope) error {
+	if !sc.top() && (definitions[name] || c.refersToLocals(form, sc)) {
+		return notCompilable(form)
+	}
+	sc.fn.emit(opEval, sc.fn.constant(form), 0)
+	return nil
+}
+
+// refersToLocals reports whether expr, including its quoted data, holds a symbol naming
+// a local variable of sc.
+func (c *compiler) refersToLocals(expr values.Interface, sc *scope) bool {
+	for expr.Type() == types.Pair {
+		if c.refersToLocals(values.Car(expr), sc) {
+			return true
+		}
+		expr = values.Cdr(expr)
+	}
+	if name, ok := symbolName(expr); ok {
+		_, _, local := sc.resolve(name)
+		return local
+	}
+	if v, ok := expr.(values.Vector); ok {
+		for _, item := range v.Items() {
+			if c.refersToLocals(item, sc) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compileCall emits the call of the procedure form applies to operands.
+func (c *compiler) compileCall(form values.Pair, operands []values.Interface, sc *scope, tail bool) error {
+	if name, ok := symbolName(form.Car()); ok {
+		if builtins.PrimitiveCall(name, len(operands)) {
+			if _, _, local := sc.resolve(name); !local {
+				return c.compilePrimitive(form, name, operands, sc, tail)
+			}
+		}
+	}
+	if err := c.compile(form.Car(), sc, false); err != nil {
+		return err
+	}
+	for _, operand := range operands {
+		if err := c.compile(operand, sc, false); err != nil {
+			return err
+		}
+	}
+	c.call(form, len(operands), sc, tail)
+	return nil
+}
+
+// compilePrimitive emits the call of the global name, which may be a primitive, with operands.
+func (c *compiler) compilePrimitive(form values.Pair, name string, operands []values.Interface, sc *scope, tail bool) error {
+	for _, operand := range operands {
+		if err := c.compile(operand, sc, false); err != nil {
+			return err
+		}
+	}
+	pc := sc.fn.emit(opPrim, c.global(name), len(operands))
+	sc.fn.position(pc, form)
+	c.ret(sc, tail)
+	return nil
+}
+
+// call emits the call of a procedure with n arguments on the stack. Calls in tail
+// position replace the activation of a procedure, but not that of a program, so that the
+// frames of its calls stay on the stack like those of the evaluator.
+func (c *compiler) call(form values.Interface, n int, sc *scope, tail bool) {
+	op := opCall
+	if tail && !sc.fn.program {
+		op = opTailCall
+	}
+	pc := sc.fn.emit(op, n, 0)
+	sc.fn.position(pc, form)
+	if op == opCall {
+		c.ret(sc, tail)
+	}
+}
+
+// compileIf emits (if test consequent [alternate]).
+func (c *compiler) compileIf(form values.Interface, operands []values.Interface, sc *scope, tail bool) error {
+	if len(operands) < 2 || len(operands) > 3 {
+		return notCompilable(form)
+	}
+	fn := sc.fn
+	if err := c.compile(operands[0], sc, false); err != nil {
+		return err
+	}
+	otherwise := fn.emit(opJumpIfFalse, 0, 0)
+	if err := c.compile(operands[1], sc, tail); err != nil {
+		return err
+	}
+	end := -1
+	if !tail {
+		end = fn.emit(opJump, 0, 0)
+	}
+	fn.patch(otherwise)
+	if len(operands) == 3 {
+		if err := c.compile(operands[2], sc, tail); err != nil {
+			return err
+		}
+	} else {
+		fn.emit(opVoid, 0, 0)
+		c.ret(sc, tail)
+	}
+	if end >= 0 {
+		fn.patch(end)
+	}
+	return nil
+}
+
+// compileDefine emits (define name [expr]) and (define (name . formals) body ...). At
+// the top of a program they define globals, and within a body they store the value in
+// the slot declared for name by compileBody.
+func (c *compiler) compileDefine(form values.Interface, operands []values.Interface, sc *scope) error {
+	if len(operands) == 0 {
+		return notCompilable(form)
+	}
+	fn := sc.fn
+	var name string
+	if target, ok := operands[0].(values.Pair); ok {
+		var named bool
+		if name, named = symbolName(target.Car()); !named || len(operands) < 2 {
+			return notCompilable(form)
+		}
+		if err := c.compileLambda(name, form, target.Cdr(), operands[1:], sc); err != nil {
+			return err
+		}
+	} else {
+		var named bool
+		if name, named = symbolName(operands[0]); !named || len(operands) > 2 {
+			return notCompilable(form)
+		}
+		if len(operands) == 1 {
+			fn.emit(opVoid, 0, 0)
+		} else if err := c.compileNamed(name, operands[1], sc); err != nil {
+			return err
+		}
+	}
+	if sc.top() {
+		fn.emit(opDefineGlobal, c.global(name), 0)
+		return nil
+	}
+	slot, ok := sc.names[name]
+	if !ok {
+		return notCompilable(form)
+	}
+	fn.emit(opStoreLocal, 0, slot)
+	fn.emit(opVoid, 0, 0)
+	return nil
+}
+
+// compileNamed emits expr, the value of a definition of name, naming it after the
+// definition when it is a lambda expression.
+func (c *compiler) compileNamed(name string, expr values.Interface, sc *scope) error {
+	if c.special(expr, sc) == "lambda" {
+		operands, err := values.ToSlice(values.Cdr(expr))
+		if err == nil && len(operands) >= 2 {
+			return c.compileLambda(name, expr, operands[0], operands[1:], sc)
+		}
+	}
+	return c.compile(expr, sc, false)
+}
+
+// compileSet emits (set! name expr).
+func (c *compiler) compileSet(form values.Interface, operands []values.Interface, sc *scope) error {
+	if len(operands) != 2 {
+		return notCompilable(form)
+	}
+	name, ok := symbolName(operands[0])
+	if !ok {
+		return notCompilable(form)
+	}
+	if err := c.compile(operands[1], sc, false); err != nil {
+		return err
+	}
+	fn := sc.fn
+	if depth, slot, ok := sc.resolve(name); ok {
+		fn.emit(opStoreLocal, depth, slot)
+		fn.emit(opVoid, 0, 0)
+		return nil
+	}
+	fn.emit(opSetGlobal, c.global(name), 0)
+	return nil
+}
+
+// compileLambda emits the closure of a procedure named name, defined by form, taking
+// formals and running body.
+func (c *compiler) compileLambda(name string, form, formals values.Interface, body []values.Interface, sc *scope) error {
+	fn := &code{name: name, anonymous: "anonymous procedure"}
+	if pos, ok := values.SourcePosition(form); ok {
+		fn.source = pos
+		fn.anonymous += " at " + values.Location(pos)
+	}
+	inner := newScope(sc, fn)
+	for formals.Type() == types.Pair {
+		formal, ok := symbolName(values.Car(formals))
+		if !ok {
+			return notCompilable(form)
+		}
+		inner.names[formal] = fn.slot(formal)
+		fn.params++
+		formals = values.Cdr(formals)
+	}
+	fn.arity = builtins.Exactly(fn.params)
+	if formals.Type() != types.Nil {
+		rest, ok := symbolName(formals)
+		if !ok {
+			return notCompilable(form)
+		}
+		inner.names[rest] = fn.slot(rest)
+		fn.rest = true
+		fn.arity = builtins.AtLeast(fn.params)
+	}
+	if err := c.compileBody(body, inner, true); err != nil {
+		return err
+	}
+	sc.fn.functions = append(sc.fn.functions, fn)
+	sc.fn.emit(opClosure, len(sc.fn.functions)-1, 0)
+	return nil
+}
+
+// compileBody emits the expressions of a body in sc, after declaring slots in sc for
+// its internal definitions.
+func (c *compiler) compileBody(body []values.Interface, sc *scope, tail bool) error {
+	if len(body) == 0 {
+		return ErrNotCompilable
+	}
+	c.declare(body, sc)
+	return c.compileSequence(body, sc, tail)
+}
+
+// declare declares slots for the names defined by the forms of a body, including those
+// within begin, so that they can be referred to before their definition.
+func (c *compiler) declare(forms []values.Interface, sc *scope) {
+	for _, form := range forms {
+		switch c.special(form, sc) {
+		case "begin":
+			if inner, err := values.ToSlice(values.Cdr(form)); err == nil {
+				c.declare(inner, sc)
+			}
+		case "define":
+			target := values.Cdr(form)
+			if target.Type() != types.Pair {
+				continue
+			}
+			target = values.Car(target)
+			if target.Type() == types.Pair {
+				target = values.Car(target)
+			}
+			if name, ok := symbolName(target); ok {
+				if _, declared := sc.names[name]; !declared {
+					sc.names[name] = sc.fn.slot(name)
+				}
+			}
+		}
+	}
+}
+
+// compileSequence emits expressions evaluated in order, keeping the value of the last one.
+func (c *compiler) compileSequence(exprs []values.Interface, sc *scope, tail bool) error {
+	for i, expr := range exprs {
+		last := i == len(exprs)-1
+		if err := c.compile(expr, sc, tail && last); err != nil {
+			return err
+		}
+		if !last {
+			sc.fn.emit(opPop, 0, 0)
+		}
+	}
+	return nil
+}
+
+// compileLet emits (let ((name expr) ...) body ...), whose variables take slots of the
+// frame of the enclosing procedure, and the named let (let loop ((name expr) ...) body ...),
+// which calls a procedure bound to loop.
+func (c *compiler) compileLet(form values.Interface, operands []values.Interface, sc *scope, tail bool) error {
+	if len(operands) < 2 {
+		return notCompilable(form)
+	}
+	loop, named := symbolName(operands[0])
+	if named {
+		operands = operands[1:]
+		if len(operands) < 2 {
+			return notCompilable(form)
+		}
+	}
+	bindings, err := values.ToSlice(operands[0])
+	if err != nil {
+		return notCompilable(form)
+	}
+	var (
+		formals = make([]values.Interface, 0, len(bindings))
+		inits   = make([]values.Interface, 0, len(bindings))
+	)
+	for _, binding := range bindings {
+		parts, err := values.ToSlice(binding)
+		if err != nil || len(parts) < 1 || len(parts) > 2 {
+			return notCompilable(form)
+		}
+		if _, ok := symbolName(parts[0]); !ok {
+			return notCompilable(form)
+		}
+		formals = append(formals, parts[0])
+		if len(parts) == 2 {
+			inits = append(inits, parts[1])
+		} else {
+			inits = append(inits, nil)
+		}
+	}
+	fn := sc.fn
+	if named {
+		loopScope := newScope(sc, fn)
+		slot := fn.slot(loop)
+		loopScope.names[loop] = slot
+		if err := c.compileLambda(loop, values.Cdr(form), values.List(formals...), operands[1:], loopScope); err != nil {
+			return err
+		}
+		fn.emit(opStoreLocal, 0, slot)
+		fn.emit(opLocal, 0, slot)
+		if err := c.compileInits(inits, sc); err != nil {
+			return err
+		}
+		c.call(form, len(inits), sc, tail)
+		return nil
+	}
+	if err := c.compileInits(inits, sc); err != nil {
+		return err
+	}
+	inner := newScope(sc, fn)
+	slots := make([]int, len(formals))
+	for i, formal := range formals {
+		name, _ := symbolName(formal)
+		slots[i] = fn.slot(name)
+		inner.names[name] = slots[i]
+	}
+	for i := len(slots) - 1; i >= 0; i-- {
+		fn.emit(opStoreLocal, 0, slots[i])
+	}
+	return c.compileBody(operands[1:], inner, tail)
+}
+
+// compileInits emits the values of the bindings of a let, the unspecified value for
+// those without an expression.
+func (c *compiler) compileInits(inits []values.Interface, sc *scope) error {
+	for _, init := range inits {
+		if init == nil {
+			sc.fn.emit(opVoid, 0, 0)
+			continue
+		}
+		if err := c.compile(init, sc, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileCond emits (cond (test expr ...) ... [(else expr ...)]), where a clause
+// (test) yields the value of test and (test => receiver) calls receiver with it.
+func (c *compiler) compileCond(form values.Interface, clauses []values.Interface, sc *scope, tail bool) error {
+	fn := sc.fn
+	var ends []int
+	end := func() {
+		if !tail {
+			ends = append(ends, fn.emit(opJump, 0, 0))
+		}
+	}
+	for _, clause := range clauses {
+		parts, err := values.ToSlice(clause)
+		if err != nil || len(parts) == 0 {
+			return notCompilable(form)
+		}
+		if name, ok := symbolName(parts[0]); ok && name == "else" {
+			if len(parts) == 1 {
+				fn.emit(opVoid, 0, 0)
+				c.ret(sc, tail)
+			} else if err := c.compileSequence(parts[1:], sc, tail); err != nil {
+				return err
+			}
+			end()
+			for _, pc := range ends {
+				fn.patch(pc)
+			}
+			return nil
+		}
+		if err := c.compile(parts[0], sc, false); err != nil {
+			return err
+		}
+		receiver := false
+		if len(parts) > 1 {
+			name, _ := symbolName(parts[1])
+			receiver = name == "=>"
+		}
+		switch {
+		case len(parts) == 1 || receiver:
+			if receiver && len(parts) != 3 {
+				return notCompilable(form)
+			}
+			// the value of the test is kept for the clause
+			fn.emit(opDup, 0, 0)
+			next := fn.emit(opJumpIfFalse, 0, 0)
+			if receiver {
+				if err := c.compile(parts[2], sc, false); err != nil {
+					return err
+				}
+				fn.emit(opSwap, 0, 0)
+				c.call(nil, 1, sc, tail)
+			} else {
+				c.ret(sc, tail)
+			}
+			end()
+			fn.patch(next)
+			fn.emit(opPop, 0, 0)
+		default:
+			next := fn.emit(opJumpIfFalse, 0, 0)
+			if err := c.compileSequence(parts[1:], sc, tail); err != nil {
+				return err
+			}
+			end()
+			fn.patch(next)
+		}
+	}
+	fn.emit(opVoid, 0, 0)
+	c.ret(sc, tail)
+	for _, pc := range ends {
+		fn.patch(pc)
+	}
+	return nil
+}
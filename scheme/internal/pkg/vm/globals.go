@@ -0,0 +1,45 @@
+package vm
+
+import (
+	"sync/atomic"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// globals is the global environment of a run of a program, with the bindings of the
+// globals its code refers to cached until the environment changes. The closures created
+// by the run share it, and may be called concurrently.
+type globals struct {
+	env   builtins.Environment
+	names []string
+	cache []atomic.Pointer[binding]
+}
+
+// binding is the value of a global at a version of the environment.
+type binding struct {
+	version uint64
+	value   values.Interface
+	// primitive is the builtin the value is, or NoPrimitive
+	primitive builtins.Primitive
+}
+
+func newGlobals(env builtins.Environment, names []string) *globals {
+	return &globals{env: env, names: names, cache: make([]atomic.Pointer[binding], len(names))}
+}
+
+// lookup returns the binding of the global names[i], or false when it is unbound.
+func (g *globals) lookup(i int32) (*binding, bool) {
+	version := g.env.Version()
+	if b := g.cache[i].Load(); b != nil && b.version == version {
+		return b, true
+	}
+	name := g.names[i]
+	v, ok := g.env.Lookup(name)
+	if !ok {
+		return nil, false
+	}
+	b := &binding{version: version, value: v, primitive: builtins.PrimitiveOf(name, v)}
+	g.cache[i].Store(b)
+	return b, true
+}
@@ -0,0 +1,445 @@
+// Package vm compiles Scheme expressions to bytecode and runs it on a stack machine,
+// as a faster alternative to the tree-walking evaluator of the parser.
+//
+// The compiler resolves the variables bound by lambda, let and internal definitions to
+// slots of heap allocated frames, addressed by the number of frames out and the slot
+// within, so that local variables are not looked up by name. Globals are looked up in the
+// environment the code was compiled for, and cached until a binding of it changes. Calls
+// between compiled procedures run on the stack of the machine, with proper tail calls, and
+// are recorded on the call stack of the runtime for stack traces. Builtins and procedures
+// of the evaluator are called as from the evaluator, except that the machine computes the
+// arithmetic and comparisons of numbers itself while their names are bound to the builtins.
+//
+// The special forms quote, if, define, set!, lambda, begin, let and cond are compiled.
+// Other special forms are evaluated by the evaluator of the runtime when they neither
+// refer to local variables nor define names within a body; Compile returns ErrNotCompilable
+// for expressions holding any other form, which are left to the evaluator. Compiled code
+// is accounted for by the step and depth limits of its runtime, but does not run the hook
+// of the runtime, so a debugger does not stop in it.
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+var (
+	ErrNotCompilable = errors.New("not compilable")
+)
+
+// Program is a compiled top level expression.
+type Program struct {
+	code  *code
+	names []string
+}
+
+// Compile compiles expr for evaluation in the global environment of rt, in which its
+// special forms are recognised.
+func Compile(expr values.Interface, rt *builtins.Runtime) (*Program, error) {
+	c := &compiler{rt: rt}
+	fn := &code{program: true}
+	if err := c.compile(expr, newScope(nil, fn), true); err != nil {
+		return nil, err
+	}
+	return &Program{code: fn, names: c.names}, nil
+}
+
+// Run evaluates the program in the global environment of rt.
+func (p *Program) Run(rt *builtins.Runtime) (values.Interface, error) {
+	m := &machine{rt: rt}
+	return m.run(&Closure{code: p.code, globals: newGlobals(rt.Env, p.names), rt: rt}, nil)
+}
+
+// Eval compiles and runs expr in rt, or evaluates it with the evaluator of rt when it
+// cannot be compiled.
+func Eval(expr values.Interface, rt *builtins.Runtime) (values.Interface, error) {
+	p, err := Compile(expr, rt)
+	if err != nil {
+		return rt.Evaluate(expr)
+	}
+	return p.Run(rt)
+}
+
+// frame holds the formals and local variables of a call of the compiled code. Frames
+// of up to four slots keep them inline.
+type frame struct {
+	code   *code
+	slots  []values.Interface
+	parent *frame
+	inline [4]values.Interface
+}
+
+func newFrame(code *code, parent *frame) *frame {
+	f := &frame{code: code, parent: parent}
+	if size := len(code.slots); size <= len(f.inline) {
+		f.slots = f.inline[:size]
+	} else {
+		f.slots = make([]values.Interface, size)
+	}
+	return f
+}
+
+// Closure is a compiled procedure with the frame it was created in and the global
+// environment its code was compiled for.
+type Closure struct {
+	code    *code
+	parent  *frame
+	globals *globals
+	rt      *builtins.Runtime
+	name    string
+}
+
+func (c *Closure) Equal(p values.Interface) bool {
+	other, ok := p.(*Closure)
+	return ok && other == c
+}
+
+func (c *Closure) Type() types.Type {
+	return types.Lambda
+}
+
+func (c *Closure) IsTruthy() bool {
+	return true
+}
+
+// WriteString returns the printed form of the procedure, such as #<procedure fact at lib.scm:12>.
+func (c *Closure) WriteString() string {
+	var sb strings.Builder
+	sb.WriteString("#<procedure")
+	if c.name != "" {
+		sb.WriteString(" " + c.name)
+	}
+	if c.code.source.IsValid() {
+		sb.WriteString(" at " + values.Location(c.code.source))
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
+
+func (c *Closure) DisplayString() string {
+	return c.WriteString()
+}
+
+func (c *Closure) String() string {
+	return c.WriteString()
+}
+
+// ProcedureName describes the procedure in stack traces.
+func (c *Closure) ProcedureName() string {
+	if c.name != "" {
+		return c.name
+	}
+	return c.code.anonymous
+}
+
+// Arity returns the number of arguments the procedure accepts.
+func (c *Closure) Arity() builtins.Arity {
+	return c.code.arity
+}
+
+// Apply calls the procedure in the runtime it was created in.
+func (c *Closure) Apply(args values.Interface) (values.Interface, error) {
+	return c.Call(args, c.rt)
+}
+
+// Call calls the procedure in rt, the runtime of the call site.
+func (c *Closure) Call(args values.Interface, rt *builtins.Runtime) (values.Interface, error) {
+	items, err := values.ToSlice(args)
+	if err != nil {
+		return values.NewVoidType(), builtins.ErrInvalidFormat
+	}
+	m := &machine{rt: rt}
+	return m.run(c, items)
+}
+
+// ApplyContext calls the procedure from Go as a new evaluation bounded by ctx.
+func (c *Closure) ApplyContext(ctx context.Context, args values.Interface) (values.Interface, error) {
+	return c.rt.NewEvaluation(ctx).Apply(c, args)
+}
+
+// named returns the closure called name, for an anonymous closure being defined.
+func (c *Closure) named(name string) *Closure {
+	named := *c
+	named.name = name
+	return &named
+}
+
+// bind returns the frame of a call of the closure with args.
+func (c *Closure) bind(args []values.Interface) (*frame, error) {
+	code := c.code
+	if !code.arity.Accepts(len(args)) {
+		err := fmt.Errorf("%w: expected %s, got %d", builtins.ErrWrongNumberOfArguments, code.arity, len(args))
+		if c.name != "" {
+			err = fmt.Errorf("%s: %w", c.name, err)
+		}
+		return nil, err
+	}
+	f := newFrame(code, c.parent)
+	copy(f.slots, args[:code.params])
+	if code.rest {
+		f.slots[code.params] = values.List(args[code.params:]...)
+	}
+	return f, nil
+}
+
+// activation is a call of a compiled procedure in progress on a machine.
+type activation struct {
+	closure *Closure
+	frame   *frame
+	pc      int
+	// base is the height of the stack below the call, where its result is left
+	base int
+	// depth is the depth of the call stack of the runtime to unwind to once the call
+	// returns, or -1 for a call recorded by the caller of the machine
+	depth int
+}
+
+// machine runs compiled code, keeping the values being computed on its stack and the
+// calls of compiled procedures in progress on its activations. The calls are recorded
+// on the call stack of the runtime like those of the evaluator.
+type machine struct {
+	rt          *builtins.Runtime
+	stack       []values.Interface
+	activations []activation
+}
+
+func (m *machine) push(v values.Interface) {
+	m.stack = append(m.stack, v)
+}
+
+func (m *machine) pop() values.Interface {
+	v := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return v
+}
+
+// run calls the closure c with args and runs until it returns.
+func (m *machine) run(c *Closure, args []values.Interface) (values.Interface, error) {
+	f := newFrame(c.code, nil)
+	if !c.code.program {
+		var err error
+		if f, err = c.bind(args); err != nil {
+			return values.NewVoidType(), err
+		}
+	}
+	if err := m.rt.Enter(); err != nil {
+		return values.NewVoidType(), err
+	}
+	depth := m.rt.StackDepth()
+	m.activations = append(m.activations, activation{closure: c, frame: f, depth: -1})
+	v, err := m.loop()
+	if err != nil {
+		err = m.rt.Traced(err)
+		m.rt.Unwind(depth)
+		for range m.activations {
+			m.rt.Leave()
+		}
+		m.activations = m.activations[:0]
+		return values.NewVoidType(), err
+	}
+	return v, nil
+}
+
+// loop executes instructions until the outermost activation returns. On error, the
+// calls in progress are left on the call stack of the runtime for run to trace.
+func (m *machine) loop() (values.Interface, error) {
+	act := &m.activations[len(m.activations)-1]
+	fn := act.closure.code
+	for {
+		in := fn.instrs[act.pc]
+		act.pc++
+		switch in.op {
+		case opConst:
+			m.push(fn.consts[in.a])
+		case opVoid:
+			m.push(values.NewVoidType())
+		case opLocal:
+			f := act.frame
+			for range in.a {
+				f = f.parent
+			}
+			v := f.slots[in.b]
+			if v == nil {
+				// an internal definition referred to before it is evaluated
+				return nil, fmt.Errorf("%w: %s", builtins.ErrUndefinedIdent, f.code.slots[in.b])
+			}
+			m.push(v)
+		case opGlobal:
+			b, ok := act.closure.globals.lookup(in.a)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", builtins.ErrUndefinedIdent, act.closure.globals.names[in.a])
+			}
+			if _, ok := b.value.(builtins.Lambda); in.b != 0 && !ok {
+				return nil, builtins.ErrOperatorIsNotAProcedure
+			}
+			m.push(b.value)
+		case opStoreLocal:
+			f := act.frame
+			for range in.a {
+				f = f.parent
+			}
+			f.slots[in.b] = values.Unquote(m.pop())
+		case opSetGlobal:
+			name := act.closure.globals.names[in.a]
+			if !act.closure.globals.env.Set(name, values.Unquote(m.pop())) {
+				return nil, fmt.Errorf("%w: %s", builtins.ErrUndefinedIdent, name)
+			}
+			m.push(values.NewVoidType())
+		case opDefineGlobal:
+			name := act.closure.globals.names[in.a]
+			v := values.Unquote(m.pop())
+			// an anonymous procedure takes the name it is first defined with
+			switch proc := v.(type) {
+			case *Closure:
+				if proc.name == "" {
+					v = proc.named(name)
+				}
+			case builtins.LambdaExpr:
+				if proc.Name == "" {
+					proc.Name = name
+					v = proc
+				}
+			}
+			act.closure.globals.env.Define(name, v)
+			m.push(values.NewVoidType())
+		case opPop:
+			m.stack = m.stack[:len(m.stack)-1]
+		case opDup:
+			m.push(m.stack[len(m.stack)-1])
+		case opSwap:
+			n := len(m.stack)
+			m.stack[n-1], m.stack[n-2] = m.stack[n-2], m.stack[n-1]
+		case opJump:
+			act.pc = int(in.a)
+		case opJumpIfFalse:
+			if !m.pop().IsTruthy() {
+				act.pc = int(in.a)
+			}
+		case opClosure:
+			m.push(&Closure{code: fn.functions[in.a], parent: act.frame, globals: act.closure.globals, rt: act.closure.rt, name: fn.functions[in.a].name})
+		case opEval:
+			v, err := m.rt.WithEnvironment(act.closure.globals.env).Evaluate(fn.consts[in.a])
+			if err != nil {
+				return nil, err
+			}
+			m.push(v)
+		case opPrim:
+			b, ok := act.closure.globals.lookup(in.a)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", builtins.ErrUndefinedIdent, act.closure.globals.names[in.a])
+			}
+			base := len(m.stack) - int(in.b)
+			if b.primitive != builtins.NoPrimitive {
+				args := m.stack[base:]
+				for i, arg := range args {
+					args[i] = values.Unquote(arg)
+				}
+				if v, ok := b.primitive.Apply(args); ok {
+					if err := m.rt.Step(); err != nil {
+						return nil, err
+					}
+					clear(args)
+					m.stack = append(m.stack[:base], v)
+					continue
+				}
+			}
+			// call the global like any other procedure, from below its arguments
+			m.stack = append(m.stack, nil)
+			copy(m.stack[base+1:], m.stack[base:])
+			m.stack[base] = b.value
+			in = instr{op: opCall, a: in.b}
+			fallthrough
+		case opCall, opTailCall:
+			base := len(m.stack) - int(in.a) - 1
+			callee := m.stack[base]
+			if err := m.rt.Step(); err != nil {
+				return nil, err
+			}
+			args := m.stack[base+1:]
+			for i, arg := range args {
+				args[i] = values.Unquote(arg)
+			}
+			call := values.Frame{Procedure: procedureName(callee), Call: fn.calls[act.pc-1]}
+			if c, ok := callee.(*Closure); ok {
+				depth := act.depth
+				if in.op == opTailCall {
+					m.rt.TailCall(call)
+				} else {
+					depth = m.rt.PushCall(call)
+				}
+				f, err := c.bind(args)
+				if err != nil {
+					return nil, err
+				}
+				clear(m.stack[base:])
+				m.stack = m.stack[:base]
+				if in.op == opTailCall {
+					*act = activation{closure: c, frame: f, base: act.base, depth: depth}
+				} else {
+					if err := m.rt.Enter(); err != nil {
+						return nil, err
+					}
+					m.activations = append(m.activations, activation{closure: c, frame: f, base: base, depth: depth})
+					act = &m.activations[len(m.activations)-1]
+				}
+				fn = c.code
+				continue
+			}
+			depth := m.rt.PushCall(call)
+			v, err := m.apply(callee, args)
+			if err != nil {
+				return nil, err
+			}
+			m.rt.Unwind(depth)
+			clear(m.stack[base:])
+			m.stack = append(m.stack[:base], v)
+			if in.op == opCall {
+				continue
+			}
+			fallthrough
+		case opReturn:
+			v := m.pop()
+			m.stack = m.stack[:act.base]
+			if act.depth >= 0 {
+				m.rt.Unwind(act.depth)
+			}
+			m.activations = m.activations[:len(m.activations)-1]
+			m.rt.Leave()
+			if len(m.activations) == 0 {
+				return v, nil
+			}
+			m.push(v)
+			act = &m.activations[len(m.activations)-1]
+			fn = act.closure.code
+		}
+	}
+}
+
+// apply calls callee, which is not a compiled procedure, with args.
+func (m *machine) apply(callee values.Interface, args []values.Interface) (values.Interface, error) {
+	switch proc := callee.(type) {
+	case builtins.Procedure:
+		return m.rt.Trampoline(proc.Call(values.List(args...), m.rt))
+	case builtins.Lambda:
+		return proc.Apply(values.List(args...))
+	}
+	// a value in operator position without operands resolves to itself, e.g. (1234)
+	if len(args) == 0 {
+		return callee, nil
+	}
+	return nil, fmt.Errorf("%w: %s", builtins.ErrOperatorIsNotAProcedure, callee.WriteString())
+}
+
+// procedureName describes proc in stack traces.
+func procedureName(proc values.Interface) string {
+	if p, ok := proc.(interface{ ProcedureName() string }); ok {
+		return p.ProcedureName()
+	}
+	return proc.WriteString()
+}
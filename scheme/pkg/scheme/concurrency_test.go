@@ -0,0 +1,90 @@
+package scheme
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// These tests are meant to be run with go test -race.
+
+func TestInterpreter_ConcurrentEval(t *testing.T) {
+	ctx := context.Background()
+	interp := New(WithStdout(&lockedBuffer{}))
+	if _, err := interp.Eval(ctx, "(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))"); err != nil {
+		t.Fatalf("Eval() error = %v", err)
+	}
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("x%d", i)
+			src := fmt.Sprintf("(define %s (fib 15)) (display %s) (+ %s %d)", name, name, name, i)
+			got, err := interp.Eval(ctx, src)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if n, ok := got.Int(); !ok || n != int64(610+i) {
+				errs <- fmt.Errorf("worker %d: got %v, want %d", i, got, 610+i)
+			}
+			if _, err := interp.Call(ctx, "fib", Int(10)); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	for i := 0; i < workers; i++ {
+		if _, ok := interp.Lookup(fmt.Sprintf("x%d", i)); !ok {
+			t.Errorf("definition x%d made by a goroutine is not visible", i)
+		}
+	}
+}
+
+func TestInterpreter_ConcurrentRegisterAndImport(t *testing.T) {
+	ctx := context.Background()
+	interp := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("add%d", i)
+			if err := interp.RegisterFunc(name, func(n int) int { return n + i }); err != nil {
+				t.Error(err)
+				return
+			}
+			src := fmt.Sprintf("(import (prefix (scheme base) b:)) (b:+ (%s 1) 1)", name)
+			got, err := interp.Eval(ctx, src)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if n, ok := got.Int(); !ok || n != int64(i+2) {
+				t.Errorf("%s: got %v, want %d", name, got, i+2)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// lockedBuffer is a writer that is safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
@@ -0,0 +1,24 @@
+package scheme
+
+import (
+	"errors"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+)
+
+// Errors returned by the interpreter. Use errors.Is to test for them,
+// as they are usually wrapped with details such as the offending name.
+var (
+	ErrUndefined              = builtins.ErrUndefinedIdent
+	ErrNotAProcedure          = builtins.ErrOperatorIsNotAProcedure
+	ErrWrongNumberOfArguments = builtins.ErrWrongNumberOfArguments
+	ErrTypeMismatch           = builtins.ErrTypeMismatch
+	ErrBadArgument            = builtins.ErrBadArgument
+	ErrDivideByZero           = builtins.ErrDivideByZero
+	ErrSyntax                 = builtins.ErrInvalidFormat
+	ErrLibraryNotFound        = builtins.ErrLibraryNotFound
+	ErrResourceLimitExceeded  = builtins.ErrResourceLimitExceeded
+)
+
+// ErrPanic is raised when a Go function registered with RegisterFunc panics.
+var ErrPanic = errors.New("go function panicked")
@@ -0,0 +1,269 @@
+// Package scheme embeds the go-lisp Scheme interpreter in Go programs.
+//
+// An Interpreter owns a global environment holding the builtins and every
+// definition evaluated by it. Scheme values cross the API boundary as Value,
+// which hides the interpreter's internal representation:
+//
+//	interp := scheme.New(scheme.WithStdout(os.Stdout))
+//	_ = interp.Register("greeting", func(args []scheme.Value) (scheme.Value, error) {
+//		return scheme.String("hello " + args[0].Display()), nil
+//	})
+//	v, err := interp.Eval(ctx, `(greeting "world")`)
+//
+// An Interpreter is safe for concurrent use. Goroutines evaluating in the same Interpreter
+// share its global environment, so a definition made by one is visible to the others, while
+// context cancellation and resource limits apply to each call to Eval or Call separately.
+// Mutable Scheme data, such as a vector shared between goroutines, is not synchronized.
+package scheme
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Func is a Go function callable from Scheme. It receives the evaluated arguments of the call.
+type Func func(args []Value) (Value, error)
+
+// Interpreter evaluates Scheme source in a persistent global environment.
+type Interpreter struct {
+	rt       *builtins.Runtime
+	bytecode bool
+	analysis bool
+}
+
+type config struct {
+	stdout      io.Writer
+	stderr      io.Writer
+	stdin       io.Reader
+	libraryPath []string
+	limits      []builtins.OptionRuntime
+	bytecode    bool
+	analysis    bool
+}
+
+type Option func(*config)
+
+// WithStdout sets the writer receiving the output of display, write and friends.
+func WithStdout(w io.Writer) Option {
+	return func(c *config) {
+		c.stdout = w
+	}
+}
+
+// WithStderr sets the writer receiving diagnostics.
+func WithStderr(w io.Writer) Option {
+	return func(c *config) {
+		c.stderr = w
+	}
+}
+
+// WithStdin sets the reader of the initial current-input-port.
+func WithStdin(r io.Reader) Option {
+	return func(c *config) {
+		c.stdin = r
+	}
+}
+
+// WithLibraryPath sets the directories searched for libraries named in import.
+func WithLibraryPath(paths ...string) Option {
+	return func(c *config) {
+		c.libraryPath = paths
+	}
+}
+
+// WithMaxSteps limits the number of procedure calls and special forms evaluated by each call to Eval or Call.
+func WithMaxSteps(n int64) Option {
+	return func(c *config) {
+		c.limits = append(c.limits, builtins.WithMaxSteps(n))
+	}
+}
+
+// WithMaxDepth limits the nesting of non-tail evaluations, bounding non-tail recursion.
+func WithMaxDepth(n int64) Option {
+	return func(c *config) {
+		c.limits = append(c.limits, builtins.WithMaxDepth(n))
+	}
+}
+
+// WithMaxConsCells limits the pairs and vector elements allocated by each call to Eval or Call.
+func WithMaxConsCells(n int64) Option {
+	return func(c *config) {
+		c.limits = append(c.limits, builtins.WithMaxConsCells(n))
+	}
+}
+
+// WithMaxStringBytes limits the bytes of the strings created by each call to Eval or Call.
+func WithMaxStringBytes(n int64) Option {
+	return func(c *config) {
+		c.limits = append(c.limits, builtins.WithMaxStringBytes(n))
+	}
+}
+
+// WithAllowedBuiltins restricts the global environment to the named builtins and special forms,
+// for example to run untrusted scripts without load. Procedures added with Define and Register are not affected.
+func WithAllowedBuiltins(names ...string) Option {
+	return func(c *config) {
+		c.limits = append(c.limits, builtins.WithAllowedBuiltins(names...))
+	}
+}
+
+// WithBytecode compiles the expressions evaluated to bytecode run by a virtual machine,
+// which is faster than the default tree-walking evaluator but not seen by debugger hooks.
+// Expressions the compiler does not handle are still evaluated by the evaluator.
+func WithBytecode() Option {
+	return func(c *config) {
+		c.bytecode = true
+	}
+}
+
+// WithAnalysis analyzes the expressions evaluated once before evaluating them, resolving
+// their local variables to frame slots, which makes the procedures they define faster to
+// call but not seen by debugger hooks. Expressions the analyzer does not handle are still
+// evaluated by the evaluator. WithBytecode takes precedence over it.
+func WithAnalysis() Option {
+	return func(c *config) {
+		c.analysis = true
+	}
+}
+
+// New creates an Interpreter whose global environment holds the standard builtins.
+func New(opts ...Option) *Interpreter {
+	cfg := config{
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		stdin:       os.Stdin,
+		libraryPath: []string{"."},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	rtOpts := []builtins.OptionRuntime{
+		builtins.WithOut(cfg.stdout),
+		builtins.WithErr(cfg.stderr),
+		builtins.WithIn(cfg.stdin),
+		builtins.WithEvaluatorCallback(parser.DefaultExpressionEvaluator()),
+		builtins.WithDatumReader(parser.DefaultDatumReader()),
+		builtins.WithLibraryPath(cfg.libraryPath...),
+	}
+	return &Interpreter{
+		rt:       builtins.NewRuntime(append(rtOpts, cfg.limits...)...),
+		bytecode: cfg.bytecode,
+		analysis: cfg.analysis,
+	}
+}
+
+// Eval evaluates every expression in src and returns the value of the last one.
+func (interp *Interpreter) Eval(ctx context.Context, src string) (Value, error) {
+	return interp.EvalReader(ctx, strings.NewReader(src))
+}
+
+// EvalReader evaluates every expression read from r and returns the value of the last one.
+func (interp *Interpreter) EvalReader(ctx context.Context, r io.Reader) (Value, error) {
+	v, err := parser.EvalReader(ctx, r, interp.rt, parser.WithBytecode(interp.bytecode), parser.WithAnalysis(interp.analysis))
+	if err != nil {
+		return Void(), err
+	}
+	return newValue(v), nil
+}
+
+// Define binds name to value in the global environment, replacing any previous binding.
+func (interp *Interpreter) Define(name string, value Value) {
+	interp.rt.Env.Define(name, value.value())
+}
+
+// Lookup returns the value bound to name in the global environment.
+func (interp *Interpreter) Lookup(name string) (Value, bool) {
+	v, ok := interp.rt.Env.Lookup(name)
+	if !ok {
+		return Void(), false
+	}
+	return newValue(v), true
+}
+
+// Register binds name to a procedure implemented by fn.
+func (interp *Interpreter) Register(name string, fn Func) error {
+	if name == "" || fn == nil {
+		return ErrBadArgument
+	}
+	interp.rt.Env.Define(name, goProcedure(interp.rt, name, builtins.AtLeast(0), func(_ context.Context, args []Value) (Value, error) {
+		return fn(args)
+	}))
+	return nil
+}
+
+// goProcedure wraps fn as a Scheme procedure called name, accepting the arguments of arity
+// and passing it the context of the calling runtime.
+// Go procedures never return tail calls, so rt is only used when the procedure is applied
+// from Go and may be nil.
+func goProcedure(rt *builtins.Runtime, name string, arity builtins.Arity, fn func(ctx context.Context, args []Value) (Value, error)) values.Interface {
+	return builtins.NewNamedLambda(rt, name, arity, func(args values.Interface, rt *builtins.Runtime) (values.Interface, error) {
+		items, err := values.ToSlice(args)
+		if err != nil {
+			return values.NewVoidType(), ErrBadArgument
+		}
+		converted := make([]Value, 0, len(items))
+		for _, item := range items {
+			converted = append(converted, newValue(item))
+		}
+		result, err := fn(rt.Context(), converted)
+		if err != nil {
+			return values.NewVoidType(), err
+		}
+		return result.value(), nil
+	})
+}
+
+// Call applies the procedure bound to name to args and returns its result.
+func (interp *Interpreter) Call(ctx context.Context, name string, args ...Value) (Value, error) {
+	if err := ctx.Err(); err != nil {
+		return Void(), err
+	}
+	v, ok := interp.rt.Env.Lookup(name)
+	if !ok {
+		return Void(), fmt.Errorf("%w: %s", ErrUndefined, name)
+	}
+	return apply(ctx, newValue(v), args...)
+}
+
+// Apply applies the procedure proc to args and returns its result.
+func Apply(proc Value, args ...Value) (Value, error) {
+	return apply(context.Background(), proc, args...)
+}
+
+// contextApplier is a procedure that can be applied from Go as a new evaluation bounded
+// by a context, such as a lambda of the evaluator or a compiled procedure.
+type contextApplier interface {
+	ApplyContext(ctx context.Context, args values.Interface) (values.Interface, error)
+}
+
+// apply applies proc to args with evaluation bounded by ctx.
+func apply(ctx context.Context, proc Value, args ...Value) (Value, error) {
+	lambda, ok := proc.value().(builtins.Lambda)
+	if !ok {
+		return Void(), fmt.Errorf("%w: %s", ErrNotAProcedure, proc)
+	}
+	items := make([]values.Interface, 0, len(args))
+	for _, arg := range args {
+		items = append(items, arg.value())
+	}
+	var (
+		result values.Interface
+		err    error
+	)
+	if expr, ok := lambda.(contextApplier); ok {
+		result, err = expr.ApplyContext(ctx, values.List(items...))
+	} else {
+		result, err = lambda.Apply(values.List(items...))
+	}
+	if err != nil {
+		return Void(), err
+	}
+	return newValue(result), nil
+}
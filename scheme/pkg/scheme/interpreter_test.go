@@ -0,0 +1,243 @@
+package scheme
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterpreter_Eval(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    Value
+		wantOut string
+		wantErr error
+	}{
+		{
+			name: "arithmetic",
+			src:  "(+ 1 2 3)",
+			want: Int(6),
+		},
+		{
+			name: "definitions persist between expressions",
+			src:  "(define (square x) (* x x)) (square 12)",
+			want: Int(144),
+		},
+		{
+			name: "quoted data is returned unquoted",
+			src:  "'(a 1)",
+			want: List(Symbol("a"), Int(1)),
+		},
+		{
+			name:    "output goes to stdout",
+			src:     `(display "hello")`,
+			want:    Void(),
+			wantOut: "hello",
+		},
+		{
+			name:    "parameterize redirects output for its body",
+			src:     `(define port (open-output-string)) (parameterize ((current-output-port port)) (display "inner")) (display "outer") (get-output-string port)`,
+			want:    String("inner"),
+			wantOut: "outer",
+		},
+		{
+			name:    "undefined identifier",
+			src:     "(no-such-procedure 1)",
+			wantErr: ErrUndefined,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.NewBuffer(nil)
+			interp := New(WithStdout(out))
+			got, err := interp.Eval(context.Background(), tt.src)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Eval() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Eval() = %v, want %v", got, tt.want)
+			}
+			if out.String() != tt.wantOut {
+				t.Errorf("Eval() output = %q, want %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestInterpreter_EvalReader(t *testing.T) {
+	interp := New()
+	got, err := interp.EvalReader(context.Background(), strings.NewReader("(define x 20)\n(+ x 1)"))
+	if err != nil {
+		t.Fatalf("EvalReader() error = %v", err)
+	}
+	if i, ok := got.Int(); !ok || i != 21 {
+		t.Errorf("EvalReader() = %v, want 21", got)
+	}
+}
+
+func TestInterpreter_DefineAndCall(t *testing.T) {
+	ctx := context.Background()
+	interp := New()
+	interp.Define("limit", Int(3))
+	if _, err := interp.Eval(ctx, "(define (over? n) (> n limit))"); err != nil {
+		t.Fatalf("Eval() error = %v", err)
+	}
+	got, err := interp.Call(ctx, "over?", Int(5))
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if b, ok := got.Bool(); !ok || !b {
+		t.Errorf("Call() = %v, want #t", got)
+	}
+	if _, err := interp.Call(ctx, "limit"); !errors.Is(err, ErrNotAProcedure) {
+		t.Errorf("Call() error = %v, want %v", err, ErrNotAProcedure)
+	}
+	if _, err := interp.Call(ctx, "missing"); !errors.Is(err, ErrUndefined) {
+		t.Errorf("Call() error = %v, want %v", err, ErrUndefined)
+	}
+}
+
+func TestInterpreter_Register(t *testing.T) {
+	ctx := context.Background()
+	interp := New()
+	errOdd := errors.New("odd length")
+	err := interp.Register("join", func(args []Value) (Value, error) {
+		if len(args)%2 == 1 {
+			return Void(), errOdd
+		}
+		parts := make([]string, 0, len(args))
+		for _, arg := range args {
+			parts = append(parts, arg.Display())
+		}
+		return String(strings.Join(parts, "-")), nil
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	got, err := interp.Eval(ctx, `(join "a" 'b 1 (+ 1 1))`)
+	if err != nil {
+		t.Fatalf("Eval() error = %v", err)
+	}
+	if s, ok := got.Str(); !ok || s != "a-b-1-2" {
+		t.Errorf("Eval() = %v, want \"a-b-1-2\"", got)
+	}
+	if _, err := interp.Eval(ctx, `(join "a")`); !errors.Is(err, errOdd) {
+		t.Errorf("Eval() error = %v, want %v", err, errOdd)
+	}
+}
+
+func TestValue_List(t *testing.T) {
+	v := List(Int(1), String("two"), Cons(Char('x'), Nil()))
+	items, ok := v.List()
+	if !ok || len(items) != 3 {
+		t.Fatalf("List() = %v, %v", items, ok)
+	}
+	if items[0].Kind() != KindInt || items[1].Kind() != KindString || items[2].Kind() != KindPair {
+		t.Errorf("List() kinds = %v %v %v", items[0].Kind(), items[1].Kind(), items[2].Kind())
+	}
+	if got := v.String(); got != `(1 "two" (#\x))` {
+		t.Errorf("String() = %s", got)
+	}
+	if _, ok := Int(1).List(); ok {
+		t.Errorf("List() of an integer should fail")
+	}
+}
+
+func TestInterpreter_Context(t *testing.T) {
+	interp := New()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := interp.Eval(ctx, "(define (spin) (spin)) (spin)"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Eval() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := interp.Eval(context.Background(), "(define (inc n) (+ n 1))"); err != nil {
+		t.Fatalf("Eval() error = %v", err)
+	}
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if _, err := interp.Call(canceled, "inc", Int(1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Call() error = %v, want %v", err, context.Canceled)
+	}
+	type key struct{}
+	err := interp.RegisterFunc("from-context", func(ctx context.Context, suffix string) string {
+		return ctx.Value(key{}).(string) + suffix
+	})
+	if err != nil {
+		t.Fatalf("RegisterFunc() error = %v", err)
+	}
+	got, err := interp.Eval(context.WithValue(context.Background(), key{}, "ctx"), `(from-context "!")`)
+	if err != nil {
+		t.Fatalf("Eval() error = %v", err)
+	}
+	if s, ok := got.Str(); !ok || s != "ctx!" {
+		t.Errorf("Eval() = %v, want \"ctx!\"", got)
+	}
+}
+
+func TestInterpreter_Compiled(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opt  Option
+	}{{"bytecode", WithBytecode()}, {"analysis", WithAnalysis()}} {
+		t.Run(tt.name, func(t *testing.T) {
+			interp := New(tt.opt)
+			if err := interp.Register("twice", func(args []Value) (Value, error) {
+				i, _ := args[0].Int()
+				return Int(2 * i), nil
+			}); err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+			got, err := interp.Eval(context.Background(), `
+				(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))
+				(define (spin) (spin))
+				(twice (fib 20))`)
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if i, ok := got.Int(); !ok || i != 13530 {
+				t.Errorf("Eval() = %v, want 13530", got)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if _, err := interp.Call(ctx, "spin"); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Call() error = %v, want %v", err, context.DeadlineExceeded)
+			}
+		})
+	}
+}
+
+func TestInterpreter_Limits(t *testing.T) {
+	ctx := context.Background()
+	interp := New(WithMaxSteps(500), WithAllowedBuiltins("define", "if", "=", "-"))
+	if _, err := interp.Eval(ctx, "(define (spin n) (if (= n 0) n (spin (- n 1)))) (spin 10)"); err != nil {
+		t.Fatalf("Eval() error = %v", err)
+	}
+	if _, err := interp.Eval(ctx, "(spin 1000)"); !errors.Is(err, ErrResourceLimitExceeded) {
+		t.Errorf("Eval() error = %v, want %v", err, ErrResourceLimitExceeded)
+	}
+	if _, err := interp.Call(ctx, "spin", Int(1000)); !errors.Is(err, ErrResourceLimitExceeded) {
+		t.Errorf("Call() error = %v, want %v", err, ErrResourceLimitExceeded)
+	}
+	if _, err := interp.Eval(ctx, `(load "script.scm")`); !errors.Is(err, ErrUndefined) {
+		t.Errorf("Eval() error = %v, want %v", err, ErrUndefined)
+	}
+
+	// the limits apply to every expression of a call of Eval together
+	interp = New(WithMaxConsCells(100))
+	if _, err := interp.Eval(ctx, "(iota 60)"); err != nil {
+		t.Fatalf("Eval() error = %v", err)
+	}
+	if _, err := interp.Eval(ctx, "(iota 60) (iota 60)"); !errors.Is(err, ErrResourceLimitExceeded) {
+		t.Errorf("Eval() error = %v, want %v", err, ErrResourceLimitExceeded)
+	}
+}
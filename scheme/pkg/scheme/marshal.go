@@ -0,0 +1,673 @@
+package scheme
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Conversion between Go and Scheme values follows these rules:
+//
+//	Go                          Scheme
+//	bool                        boolean
+//	int*, uint*                 exact integer
+//	float32, float64            inexact number
+//	string                      string
+//	slice                       list
+//	array                       vector
+//	map                         hash table
+//	struct                      hash table keyed by field name symbols
+//	error                       error object (condition)
+//	func                        procedure
+//	nil pointer or interface    #f
+//
+// Struct fields are named by their `scheme:"name"` tag, or by the field name when untagged;
+// fields tagged `scheme:"-"` and unexported fields are skipped. Converting back to Go,
+// lists and vectors fill slices and arrays, and hash tables and association lists fill maps and structs.
+
+var (
+	valueType   = reflect.TypeOf(Value{})
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// ToValue converts a Go value to a Scheme value.
+func ToValue(x any) (Value, error) {
+	v, err := toValue(reflect.ValueOf(x))
+	if err != nil {
+		return Void(), err
+	}
+	return newValue(v), nil
+}
+
+// FromValue stores the Go representation of v in the value pointed to by target.
+// When target points to an empty interface v is converted to its natural Go type:
+// bool, int64, float64, string, rune, []any, map[string]any, error or, for anything else, Value.
+func FromValue(v Value, target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%w: target must be a non-nil pointer", ErrBadArgument)
+	}
+	return fromValue(context.Background(), v.value(), rv.Elem())
+}
+
+// RegisterFunc binds name to a procedure calling the Go function fn.
+// Arguments are converted to the parameter types of fn, and a variadic fn accepts any number of
+// trailing arguments. When the first parameter of fn is a context.Context it receives the
+// context of the evaluation instead of an argument. fn may return nothing, a value, an error, or a value and an error; a non-nil
+// error is raised in Scheme as an error object. A panic in fn is recovered and raised as an error.
+func (interp *Interpreter) RegisterFunc(name string, fn any) error {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func || rv.IsNil() {
+		return fmt.Errorf("%w: %s is not a function", ErrBadArgument, name)
+	}
+	if !validResults(rv.Type()) {
+		return fmt.Errorf("%w: %s must return (), (T), (error) or (T, error)", ErrBadArgument, name)
+	}
+	interp.rt.Env.Define(name, goProcedure(interp.rt, name, funcArity(rv.Type()), func(ctx context.Context, args []Value) (Value, error) {
+		return callFunc(ctx, name, rv, args)
+	}))
+	return nil
+}
+
+// funcArity returns the arity of a procedure calling a Go function of type t. A leading
+// context.Context parameter is not an argument, and a variadic t accepts any number of
+// trailing arguments.
+func funcArity(t reflect.Type) builtins.Arity {
+	n := t.NumIn()
+	if n > 0 && t.In(0) == contextType {
+		n--
+	}
+	if t.IsVariadic() {
+		return builtins.AtLeast(n - 1)
+	}
+	return builtins.Exactly(n)
+}
+
+// validResults reports whether the results of a function type can be returned to Scheme.
+func validResults(t reflect.Type) bool {
+	switch t.NumOut() {
+	case 0, 1:
+		return true
+	case 2:
+		return t.Out(1) == errorType
+	}
+	return false
+}
+
+// callFunc converts args to the parameters of fn, calls it and converts its results.
+func callFunc(ctx context.Context, name string, fn reflect.Value, args []Value) (result Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = Void(), fmt.Errorf("%w: %s: %v", ErrPanic, name, r)
+		}
+	}()
+	t := fn.Type()
+	var in []reflect.Value
+	if t.NumIn() > 0 && t.In(0) == contextType {
+		in = append(in, reflect.ValueOf(&ctx).Elem())
+	}
+	arity := funcArity(t)
+	if !arity.Accepts(len(args)) {
+		return Void(), fmt.Errorf("%w: %s expected %s, got %d", ErrWrongNumberOfArguments, name, arity, len(args))
+	}
+	offset, fixed := len(in), arity.Min
+	for i, arg := range args {
+		paramType := t.In(min(i+offset, t.NumIn()-1))
+		if i >= fixed && t.IsVariadic() {
+			paramType = paramType.Elem()
+		}
+		param := reflect.New(paramType).Elem()
+		if err := fromValue(ctx, arg.value(), param); err != nil {
+			return Void(), fmt.Errorf("argument %d of %s: %w", i+1, name, err)
+		}
+		in = append(in, param)
+	}
+	return fromResults(fn.Call(in))
+}
+
+// fromResults converts the results of a Go function call to a Scheme value and error.
+func fromResults(out []reflect.Value) (Value, error) {
+	if len(out) == 0 {
+		return Void(), nil
+	}
+	last := out[len(out)-1]
+	if last.Type() == errorType {
+		if !last.IsNil() {
+			return Void(), values.ConditionFromError(last.Interface().(error))
+		}
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return Void(), nil
+	}
+	v, err := toValue(out[0])
+	if err != nil {
+		return Void(), err
+	}
+	return newValue(v), nil
+}
+
+// toValue converts the Go value rv to a Scheme value. It returns ErrTypeMismatch for a
+// value that refers to itself, such as a struct holding a pointer to itself, which has no
+// finite conversion.
+func toValue(rv reflect.Value) (values.Interface, error) {
+	return make(converter).toValue(rv)
+}
+
+// converter holds the pointers, maps and slices being converted, whose appearance within
+// themselves is a cycle.
+type converter map[reference]bool
+
+// reference identifies the pointer, map or slice of a type that refers to data at ptr.
+type reference struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// enter records rv as being converted, returning the function that ends its conversion.
+// It returns ErrTypeMismatch when rv is already being converted.
+func (c converter) enter(rv reflect.Value) (func(), error) {
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if rv.IsNil() || (rv.Kind() == reflect.Slice && rv.Len() == 0) {
+			return func() {}, nil
+		}
+	default:
+		return func() {}, nil
+	}
+	ref := reference{ptr: rv.Pointer(), typ: rv.Type()}
+	if rv.Kind() == reflect.Slice {
+		ref.len = rv.Len()
+	}
+	if c[ref] {
+		return nil, fmt.Errorf("%w: cyclic value of type %s", ErrTypeMismatch, rv.Type())
+	}
+	c[ref] = true
+	return func() { delete(c, ref) }, nil
+}
+
+func (c converter) toValue(rv reflect.Value) (values.Interface, error) {
+	if !rv.IsValid() {
+		return values.NewBool(false), nil
+	}
+	if rv.Type() == valueType {
+		return rv.Interface().(Value).value(), nil
+	}
+	if rv.Type().Implements(errorType) && rv.Kind() != reflect.Interface {
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return values.NewBool(false), nil
+		}
+		return values.ConditionFromError(rv.Interface().(error)), nil
+	}
+	leave, err := c.enter(rv)
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+	switch rv.Kind() {
+	case reflect.Bool:
+		return values.NewBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return values.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if u > 1<<63-1 {
+			return nil, fmt.Errorf("%w: %d overflows an exact integer", ErrTypeMismatch, u)
+		}
+		return values.NewInt(int64(u)), nil
+	case reflect.Float32, reflect.Float64:
+		return values.NewFloat(rv.Float()), nil
+	case reflect.String:
+		return values.NewString(rv.String()), nil
+	case reflect.Slice:
+		items, err := c.toValues(rv)
+		if err != nil {
+			return nil, err
+		}
+		return values.List(items...), nil
+	case reflect.Array:
+		items, err := c.toValues(rv)
+		if err != nil {
+			return nil, err
+		}
+		return values.NewVector(items...), nil
+	case reflect.Map:
+		table := values.NewHashTable()
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := c.toValue(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			value, err := c.toValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			table.Set(key, value)
+		}
+		return table, nil
+	case reflect.Struct:
+		table := values.NewHashTable()
+		for _, field := range structFields(rv.Type()) {
+			value, err := c.toValue(rv.FieldByIndex(field.index))
+			if err != nil {
+				return nil, err
+			}
+			table.Set(values.NewIdentifier(field.name), value)
+		}
+		return table, nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return values.NewBool(false), nil
+		}
+		return c.toValue(rv.Elem())
+	case reflect.Func:
+		if rv.IsNil() {
+			return values.NewBool(false), nil
+		}
+		if !validResults(rv.Type()) {
+			return nil, fmt.Errorf("%w: unsupported function type %s", ErrTypeMismatch, rv.Type())
+		}
+		return goProcedure(nil, "", funcArity(rv.Type()), func(ctx context.Context, args []Value) (Value, error) {
+			return callFunc(ctx, rv.Type().String(), rv, args)
+		}), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported Go type %s", ErrTypeMismatch, rv.Type())
+}
+
+func (c converter) toValues(rv reflect.Value) ([]values.Interface, error) {
+	items := make([]values.Interface, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		item, err := c.toValue(rv.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// fromValue stores the Go representation of the Scheme value sv in rv. Procedures stored in
+// functions are applied with ctx. It returns ErrTypeMismatch for a value that contains
+// itself, such as a vector holding itself, which has no finite conversion.
+func fromValue(ctx context.Context, sv values.Interface, rv reflect.Value) error {
+	return (&extractor{ctx: ctx, active: make(map[uintptr]bool)}).fromValue(sv, rv)
+}
+
+// extractor holds the pairs, vectors, records and hash tables being converted to Go,
+// whose appearance within themselves is a cycle.
+type extractor struct {
+	ctx    context.Context
+	active map[uintptr]bool
+}
+
+// enter records sv as being converted, returning the function that ends its conversion.
+// It returns ErrTypeMismatch when sv is already being converted.
+func (e *extractor) enter(sv values.Interface) (func(), error) {
+	switch sv.Type() {
+	case types.Pair, types.Vector, types.Record, types.Map:
+	default:
+		return func() {}, nil
+	}
+	ptr, ok := values.Identity(sv)
+	if !ok || ptr == 0 {
+		return func() {}, nil
+	}
+	if e.active[ptr] {
+		return nil, fmt.Errorf("%w: cyclic value %s", ErrTypeMismatch, sv.WriteString())
+	}
+	e.active[ptr] = true
+	return func() { delete(e.active, ptr) }, nil
+}
+
+func (e *extractor) fromValue(sv values.Interface, rv reflect.Value) error {
+	sv = values.Unquote(sv)
+	t := rv.Type()
+	if t == valueType {
+		rv.Set(reflect.ValueOf(newValue(sv)))
+		return nil
+	}
+	mismatch := func() error {
+		return fmt.Errorf("%w: cannot convert %s to %s", ErrTypeMismatch, sv.WriteString(), t)
+	}
+	switch rv.Kind() {
+	case reflect.Interface:
+		if c, ok := sv.(*values.Condition); ok && t == errorType {
+			rv.Set(reflect.ValueOf(error(c)))
+			return nil
+		}
+		natural, err := e.naturalValue(sv)
+		if err != nil {
+			return err
+		}
+		if natural == nil {
+			rv.SetZero()
+			return nil
+		}
+		nv := reflect.ValueOf(natural)
+		if !nv.Type().AssignableTo(t) {
+			return mismatch()
+		}
+		rv.Set(nv)
+	case reflect.Bool:
+		b, ok := sv.(values.Boolean)
+		if !ok {
+			return mismatch()
+		}
+		rv.SetBool(b.GetLiteral())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if c, ok := sv.(values.Char); ok && rv.Kind() == reflect.Int32 {
+			i = int64(c.Rune())
+		} else if n, ok := sv.(values.Numeric); ok && n.IsInteger() {
+			i, _ = n.AsInt()
+		} else {
+			return mismatch()
+		}
+		if rv.OverflowInt(i) {
+			return fmt.Errorf("%w: %d overflows %s", ErrTypeMismatch, i, t)
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := sv.(values.Numeric)
+		if !ok || !n.IsInteger() {
+			return mismatch()
+		}
+		i, _ := n.AsInt()
+		if i < 0 || rv.OverflowUint(uint64(i)) {
+			return fmt.Errorf("%w: %d overflows %s", ErrTypeMismatch, i, t)
+		}
+		rv.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		n, ok := sv.(values.Numeric)
+		if !ok {
+			return mismatch()
+		}
+		f, _ := n.AsFloat()
+		rv.SetFloat(f)
+	case reflect.String:
+		switch sv.Type() {
+		case types.String:
+			rv.SetString(sv.(values.String).String())
+		case types.Identifier, types.RelationalOperator, types.ArithmeticOperator, types.BooleanOperator:
+			rv.SetString(sv.DisplayString())
+		default:
+			return mismatch()
+		}
+	case reflect.Slice:
+		items, ok := sequenceItems(sv)
+		if !ok {
+			return mismatch()
+		}
+		leave, err := e.enter(sv)
+		if err != nil {
+			return err
+		}
+		defer leave()
+		slice := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := e.fromValue(item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(slice)
+	case reflect.Array:
+		items, ok := sequenceItems(sv)
+		if !ok || len(items) != rv.Len() {
+			return mismatch()
+		}
+		leave, err := e.enter(sv)
+		if err != nil {
+			return err
+		}
+		defer leave()
+		for i, item := range items {
+			if err := e.fromValue(item, rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		entries, ok := tableEntries(sv)
+		if !ok {
+			return mismatch()
+		}
+		leave, err := e.enter(sv)
+		if err != nil {
+			return err
+		}
+		defer leave()
+		m := reflect.MakeMapWithSize(t, len(entries))
+		for _, entry := range entries {
+			key := reflect.New(t.Key()).Elem()
+			if err := e.fromValue(entry[0], key); err != nil {
+				return err
+			}
+			value := reflect.New(t.Elem()).Elem()
+			if err := e.fromValue(entry[1], value); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, value)
+		}
+		rv.Set(m)
+	case reflect.Struct:
+		entries, ok := tableEntries(sv)
+		if !ok {
+			return mismatch()
+		}
+		leave, err := e.enter(sv)
+		if err != nil {
+			return err
+		}
+		defer leave()
+		fields := make(map[string][]int)
+		for _, field := range structFields(t) {
+			fields[field.name] = field.index
+		}
+		for _, entry := range entries {
+			index, ok := fields[keyName(entry[0])]
+			if !ok {
+				continue
+			}
+			if err := e.fromValue(entry[1], rv.FieldByIndex(index)); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer:
+		if sv.Type() == types.Bool && !sv.IsTruthy() && t.Elem().Kind() != reflect.Bool {
+			rv.SetZero()
+			return nil
+		}
+		elem := reflect.New(t.Elem())
+		if err := e.fromValue(sv, elem.Elem()); err != nil {
+			return err
+		}
+		rv.Set(elem)
+	case reflect.Func:
+		proc := newValue(sv)
+		if !proc.IsProcedure() {
+			return mismatch()
+		}
+		rv.Set(reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+			return callProcedure(e.ctx, proc, t, in)
+		}))
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+// callProcedure applies a Scheme procedure on behalf of a Go function of type t.
+// Errors are returned through a trailing error result, or panic when t has none.
+func callProcedure(ctx context.Context, proc Value, t reflect.Type, in []reflect.Value) []reflect.Value {
+	out := make([]reflect.Value, t.NumOut())
+	for i := range out {
+		out[i] = reflect.New(t.Out(i)).Elem()
+	}
+	fail := func(err error) []reflect.Value {
+		if t.NumOut() == 0 || t.Out(t.NumOut()-1) != errorType {
+			panic(err)
+		}
+		out[len(out)-1] = reflect.ValueOf(&err).Elem()
+		return out
+	}
+	if t.IsVariadic() && len(in) > 0 {
+		rest := in[len(in)-1]
+		in = in[:len(in)-1]
+		for i := 0; i < rest.Len(); i++ {
+			in = append(in, rest.Index(i))
+		}
+	}
+	args := make([]Value, 0, len(in))
+	for _, arg := range in {
+		v, err := toValue(arg)
+		if err != nil {
+			return fail(err)
+		}
+		args = append(args, newValue(v))
+	}
+	result, err := apply(ctx, proc, args...)
+	if err != nil {
+		return fail(err)
+	}
+	if t.NumOut() > 0 && t.Out(0) != errorType {
+		if err := fromValue(ctx, result.value(), out[0]); err != nil {
+			return fail(err)
+		}
+	}
+	return out
+}
+
+// naturalValue converts a Scheme value to the Go value it most naturally corresponds to.
+func (e *extractor) naturalValue(sv values.Interface) (any, error) {
+	leave, err := e.enter(sv)
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+	switch sv.Type() {
+	case types.Void:
+		return nil, nil
+	case types.Bool:
+		return sv.(values.Boolean).GetLiteral(), nil
+	case types.Int:
+		i, _ := sv.(values.Numeric).AsInt()
+		return i, nil
+	case types.Float:
+		f, _ := sv.(values.Numeric).AsFloat()
+		return f, nil
+	case types.String:
+		return sv.(values.String).String(), nil
+	case types.Char:
+		return sv.(values.Char).Rune(), nil
+	case types.Identifier, types.RelationalOperator, types.ArithmeticOperator, types.BooleanOperator:
+		return sv.DisplayString(), nil
+	case types.Condition:
+		return error(sv.(*values.Condition)), nil
+	case types.Nil, types.Pair, types.Vector:
+		items, ok := sequenceItems(sv)
+		if !ok {
+			return newValue(sv), nil
+		}
+		natural := make([]any, 0, len(items))
+		for _, item := range items {
+			v, err := e.naturalValue(item)
+			if err != nil {
+				return nil, err
+			}
+			natural = append(natural, v)
+		}
+		return natural, nil
+	case types.Map, types.Record:
+		entries, _ := tableEntries(sv)
+		natural := make(map[string]any, len(entries))
+		for _, entry := range entries {
+			v, err := e.naturalValue(entry[1])
+			if err != nil {
+				return nil, err
+			}
+			natural[keyName(entry[0])] = v
+		}
+		return natural, nil
+	}
+	return newValue(sv), nil
+}
+
+// sequenceItems returns the elements of a proper list or vector.
+func sequenceItems(sv values.Interface) ([]values.Interface, bool) {
+	if vec, ok := sv.(values.Vector); ok {
+		return vec.Items(), true
+	}
+	items, err := values.ToSlice(sv)
+	return items, err == nil
+}
+
+// tableEntries returns the key/value pairs of a hash table or association list, or the
+// field names and values of a record.
+func tableEntries(sv values.Interface) ([][2]values.Interface, bool) {
+	if record, ok := sv.(*values.Record); ok {
+		fields := record.RecordType().Fields()
+		entries := make([][2]values.Interface, len(fields))
+		for i, field := range fields {
+			entries[i] = [2]values.Interface{values.NewIdentifier(field), record.Field(i)}
+		}
+		return entries, true
+	}
+	if table, ok := sv.(values.HashTable); ok {
+		entries := make([][2]values.Interface, 0, table.Len())
+		for _, key := range table.Keys() {
+			value, _ := table.Ref(key)
+			entries = append(entries, [2]values.Interface{key, value})
+		}
+		return entries, true
+	}
+	items, err := values.ToSlice(sv)
+	if err != nil {
+		return nil, false
+	}
+	entries := make([][2]values.Interface, 0, len(items))
+	for _, item := range items {
+		if item.Type() != types.Pair {
+			return nil, false
+		}
+		entries = append(entries, [2]values.Interface{values.Car(item), values.Cdr(item)})
+	}
+	return entries, true
+}
+
+// keyName returns the name of a symbol or string used as a struct field or map key.
+func keyName(key values.Interface) string {
+	if s, ok := key.(values.String); ok {
+		return s.String()
+	}
+	return key.DisplayString()
+}
+
+type structField struct {
+	name  string
+	index []int
+}
+
+// structFields lists the exported fields of a struct type with their Scheme names.
+func structFields(t reflect.Type) []structField {
+	fields := make([]structField, 0, t.NumField())
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("scheme"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+		fields = append(fields, structField{name: name, index: field.Index})
+	}
+	return fields
+}
@@ -0,0 +1,260 @@
+package scheme
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X     int    `scheme:"x"`
+	Y     int    `scheme:"y"`
+	Label string `scheme:"label"`
+	Skip  string `scheme:"-"`
+}
+
+func TestRegisterFunc(t *testing.T) {
+	ctx := context.Background()
+	errNegative := errors.New("negative")
+	funcs := map[string]any{
+		"ratio": func(a int, b string) (float64, error) {
+			if a < 0 {
+				return 0, errNegative
+			}
+			return float64(a) / float64(len(b)), nil
+		},
+		"sum":    func(xs ...int) int { return eachSum(xs) },
+		"join":   func(sep string, parts []string) string { return strings.Join(parts, sep) },
+		"origin": func() point { return point{Label: "origin"} },
+		"norm1":  func(p point) int { return abs(p.X) + abs(p.Y) },
+		"boom":   func() { panic("boom") },
+		"twice":  func(f func(int) int, x int) int { return f(f(x)) },
+		"scaled": func(_ context.Context, x int) int { return 2 * x },
+		"same":   func(x any) any { return x },
+	}
+	interp := New()
+	for name, fn := range funcs {
+		if err := interp.RegisterFunc(name, fn); err != nil {
+			t.Fatalf("RegisterFunc(%s) error = %v", name, err)
+		}
+	}
+	tests := []struct {
+		name    string
+		src     string
+		want    Value
+		wantErr error
+	}{
+		{name: "converts arguments and results", src: `(ratio 3 "ab")`, want: Float(1.5)},
+		{name: "variadic", src: "(sum 1 2 3 4)", want: Int(10)},
+		{name: "variadic without arguments", src: "(sum)", want: Int(0)},
+		{name: "list to slice", src: `(join "," '("a" "b" c))`, want: String("a,b,c")},
+		{name: "wrong arity", src: `(ratio 1)`, wantErr: ErrWrongNumberOfArguments},
+		{name: "argument type mismatch", src: `(ratio "1" "a")`, wantErr: ErrTypeMismatch},
+		{name: "go error", src: `(ratio -1 "a")`, wantErr: errNegative},
+		{
+			name: "go error becomes an error object",
+			src:  `(guard (e ((error-object? e) (error-object-message e))) (ratio -1 "a"))`,
+			want: String("negative"),
+		},
+		{name: "panics are recovered", src: "(boom)", wantErr: ErrPanic},
+		{name: "struct from alist", src: "(norm1 '((x . -3) (y . 4)))", want: Int(7)},
+		{name: "struct round trip", src: "(norm1 (origin))", want: Int(0)},
+		{name: "procedure to func", src: "(twice (lambda (n) (* n 3)) 2)", want: Int(18)},
+		{
+			name: "arity of the function",
+			src:  "(equal? (map procedure-arity (list ratio sum join scaled)) '(2 (0 . #f) 2 1))",
+			want: Bool(true),
+		},
+		{name: "context is not an argument", src: "(scaled 1 2)", wantErr: ErrWrongNumberOfArguments},
+		{name: "cyclic argument", src: "(define v (vector 1 2)) (vector-set! v 0 v) (same v)", wantErr: ErrTypeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := interp.Eval(ctx, tt.src)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Eval() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if err := interp.RegisterFunc("bad", 42); !errors.Is(err, ErrBadArgument) {
+		t.Errorf("RegisterFunc() error = %v, want %v", err, ErrBadArgument)
+	}
+	if err := interp.RegisterFunc("bad", func() (int, int) { return 0, 0 }); !errors.Is(err, ErrBadArgument) {
+		t.Errorf("RegisterFunc() error = %v, want %v", err, ErrBadArgument)
+	}
+}
+
+func TestToValue(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want string
+	}{
+		{name: "nil", in: nil, want: "#f"},
+		{name: "integer", in: uint8(7), want: "7"},
+		{name: "slice", in: []any{1, "a", true}, want: `(1 "a" #t)`},
+		{name: "array", in: [2]float64{1.5, 2}, want: "#(1.5 2)"},
+		{name: "nil pointer", in: (*point)(nil), want: "#f"},
+		{name: "error", in: errors.New("oops"), want: "#<condition oops>"},
+		{name: "shared pointer", in: func() any { p := &point{X: 1}; return []*point{p, p} }(), want: "(#<hash-table 3> #<hash-table 3>)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToValue(tt.in)
+			if err != nil {
+				t.Fatalf("ToValue() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ToValue() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+	if _, err := ToValue(make(chan int)); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("ToValue() error = %v, want %v", err, ErrTypeMismatch)
+	}
+
+	type node struct{ Self *node }
+	r := &node{}
+	r.Self = r
+	s := []any{nil}
+	s[0] = s
+	m := map[string]any{}
+	m["m"] = m
+	for _, cyclic := range []any{r, s, m} {
+		if _, err := ToValue(cyclic); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("ToValue(%T) error = %v, want %v", cyclic, err, ErrTypeMismatch)
+		}
+	}
+}
+
+func TestFromValue(t *testing.T) {
+	ctx := context.Background()
+	interp := New()
+	eval := func(src string) Value {
+		t.Helper()
+		v, err := interp.Eval(ctx, src)
+		if err != nil {
+			t.Fatalf("Eval(%s) error = %v", src, err)
+		}
+		return v
+	}
+
+	var p point
+	in := point{X: 1, Y: -2, Label: "p", Skip: "ignored"}
+	v, err := ToValue(&in)
+	if err != nil {
+		t.Fatalf("ToValue() error = %v", err)
+	}
+	if v.Kind() != KindHashTable {
+		t.Errorf("ToValue() kind = %v, want %v", v.Kind(), KindHashTable)
+	}
+	if err := FromValue(v, &p); err != nil {
+		t.Fatalf("FromValue() error = %v", err)
+	}
+	if want := (point{X: 1, Y: -2, Label: "p"}); p != want {
+		t.Errorf("FromValue() = %+v, want %+v", p, want)
+	}
+
+	var fromRecord point
+	record := eval(`(define-record-type <point> (make-point x y label) point? (x point-x) (y point-y) (label point-label)) (make-point 3 4 "r")`)
+	if record.Kind() != KindRecord {
+		t.Errorf("Eval() kind = %v, want %v", record.Kind(), KindRecord)
+	}
+	if err := FromValue(record, &fromRecord); err != nil {
+		t.Fatalf("FromValue() error = %v", err)
+	}
+	if want := (point{X: 3, Y: 4, Label: "r"}); fromRecord != want {
+		t.Errorf("FromValue() = %+v, want %+v", fromRecord, want)
+	}
+
+	var m map[string][]int
+	if err := FromValue(eval(`'(("a" 1 2) (b 3))`), &m); err != nil {
+		t.Fatalf("FromValue() error = %v", err)
+	}
+	if want := map[string][]int{"a": {1, 2}, "b": {3}}; !reflect.DeepEqual(m, want) {
+		t.Errorf("FromValue() = %v, want %v", m, want)
+	}
+
+	var natural any
+	if err := FromValue(eval(`(list 1 2.5 "s" #\c #(#t))`), &natural); err != nil {
+		t.Fatalf("FromValue() error = %v", err)
+	}
+	if want := []any{int64(1), 2.5, "s", 'c', []any{true}}; !reflect.DeepEqual(natural, want) {
+		t.Errorf("FromValue() = %#v, want %#v", natural, want)
+	}
+
+	var small int8
+	if err := FromValue(Int(300), &small); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("FromValue() error = %v, want %v", err, ErrTypeMismatch)
+	}
+
+	var add func(a, b int) (int, error)
+	if err := FromValue(eval("(lambda (a b) (+ a b))"), &add); err != nil {
+		t.Fatalf("FromValue() error = %v", err)
+	}
+	if sum, err := add(2, 3); err != nil || sum != 5 {
+		t.Errorf("add() = %d, %v, want 5", sum, err)
+	}
+	var fail func() error
+	if err := FromValue(eval(`(lambda () (error "failed" 1))`), &fail); err != nil {
+		t.Fatalf("FromValue() error = %v", err)
+	}
+	if err := fail(); err == nil || err.Error() != "failed 1" {
+		t.Errorf("fail() = %v, want failed 1", err)
+	}
+	cyclic := []struct {
+		name   string
+		src    string
+		target any
+	}{
+		{"vector", "(define v (vector 1 2)) (vector-set! v 0 (list v)) v", new(any)},
+		{"list", "(define l (list 1 2)) (set-car! l l) l", new([]any)},
+		{"hash table", "(define h (make-hash-table)) (hash-table-set! h 'self h) h", new(map[string]any)},
+		{"record", "(define-record-type node (make-node x) node? (x node-x set-node-x!)) (define n (make-node 1)) (set-node-x! n n) n", new(any)},
+	}
+	for _, tt := range cyclic {
+		if err := FromValue(eval(tt.src), tt.target); !errors.Is(err, ErrTypeMismatch) {
+			t.Errorf("FromValue(cyclic %s) error = %v, want %v", tt.name, err, ErrTypeMismatch)
+		}
+	}
+	var shared any
+	if err := FromValue(eval("(define s (vector 1)) (list s s)"), &shared); err != nil {
+		t.Errorf("FromValue(shared vector) error = %v", err)
+	}
+	var cycle func() (any, error)
+	if err := FromValue(eval("(lambda () v)"), &cycle); err != nil {
+		t.Fatalf("FromValue() error = %v", err)
+	}
+	if _, err := cycle(); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("cycle() error = %v, want %v", err, ErrTypeMismatch)
+	}
+	if err := FromValue(Int(1), p); !errors.Is(err, ErrBadArgument) {
+		t.Errorf("FromValue() error = %v, want %v", err, ErrBadArgument)
+	}
+}
+
+func eachSum(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
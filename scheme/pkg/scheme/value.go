@@ -0,0 +1,251 @@
+package scheme
+
+import (
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/builtins"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/types"
+	"github.com/bchisham/go-lisp/scheme/internal/pkg/parser/values"
+)
+
+// Kind classifies a Value.
+type Kind int
+
+const (
+	KindVoid Kind = iota
+	KindNil
+	KindBool
+	KindInt
+	KindFloat
+	KindString
+	KindChar
+	KindSymbol
+	KindPair
+	KindProcedure
+	KindVector
+	KindHashTable
+	KindError
+	KindRecord
+	KindOther
+)
+
+var kindNames = map[Kind]string{
+	KindVoid:      "void",
+	KindNil:       "nil",
+	KindBool:      "bool",
+	KindInt:       "int",
+	KindFloat:     "float",
+	KindString:    "string",
+	KindChar:      "char",
+	KindSymbol:    "symbol",
+	KindPair:      "pair",
+	KindProcedure: "procedure",
+	KindVector:    "vector",
+	KindHashTable: "hash-table",
+	KindError:     "error",
+	KindRecord:    "record",
+	KindOther:     "other",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Value is an immutable Scheme value. The zero Value is the unspecified (void) value.
+type Value struct {
+	v values.Interface
+}
+
+func newValue(v values.Interface) Value {
+	return Value{v: values.Unquote(v)}
+}
+
+func (v Value) value() values.Interface {
+	if v.v == nil {
+		return values.NewVoidType()
+	}
+	return v.v
+}
+
+// Void returns the unspecified value returned by definitions and side effects.
+func Void() Value {
+	return Value{}
+}
+
+// Nil returns the empty list.
+func Nil() Value {
+	return newValue(values.NewNil())
+}
+
+func Bool(b bool) Value {
+	return newValue(values.NewBool(b))
+}
+
+func Int(i int64) Value {
+	return newValue(values.NewInt(i))
+}
+
+func Float(f float64) Value {
+	return newValue(values.NewFloat(f))
+}
+
+func String(s string) Value {
+	return newValue(values.NewString(s))
+}
+
+func Char(r rune) Value {
+	return newValue(values.NewChar(r))
+}
+
+func Symbol(name string) Value {
+	return newValue(values.NewIdentifier(name))
+}
+
+// Cons returns a new pair.
+func Cons(car, cdr Value) Value {
+	return newValue(values.Cons(car.value(), cdr.value()))
+}
+
+// List returns a proper list of items.
+func List(items ...Value) Value {
+	converted := make([]values.Interface, 0, len(items))
+	for _, item := range items {
+		converted = append(converted, item.value())
+	}
+	return newValue(values.List(converted...))
+}
+
+// Kind reports the kind of the value.
+func (v Value) Kind() Kind {
+	switch v.value().Type() {
+	case types.Void:
+		return KindVoid
+	case types.Nil:
+		return KindNil
+	case types.Bool:
+		return KindBool
+	case types.Int:
+		return KindInt
+	case types.Float:
+		return KindFloat
+	case types.String:
+		return KindString
+	case types.Char:
+		return KindChar
+	case types.Identifier, types.RelationalOperator, types.ArithmeticOperator, types.BooleanOperator:
+		return KindSymbol
+	case types.Pair:
+		return KindPair
+	case types.Lambda:
+		return KindProcedure
+	case types.Vector:
+		return KindVector
+	case types.Map:
+		return KindHashTable
+	case types.Condition:
+		return KindError
+	case types.Record:
+		return KindRecord
+	}
+	return KindOther
+}
+
+// String returns the external representation of the value as produced by write.
+func (v Value) String() string {
+	return v.value().WriteString()
+}
+
+// Display returns the representation of the value as produced by display.
+func (v Value) Display() string {
+	return v.value().DisplayString()
+}
+
+// Equal reports whether v and other are equal in the sense of equal?.
+func (v Value) Equal(other Value) bool {
+	return v.value().Equal(other.value())
+}
+
+// IsTruthy reports whether the value counts as true in a conditional; only #f is false.
+func (v Value) IsTruthy() bool {
+	return v.value().IsTruthy()
+}
+
+// Bool returns the value of a boolean.
+func (v Value) Bool() (bool, bool) {
+	b, ok := v.value().(values.Boolean)
+	if !ok {
+		return false, false
+	}
+	return b.GetLiteral(), true
+}
+
+// Int returns the value of an exact integer.
+func (v Value) Int() (int64, bool) {
+	n, ok := v.value().(values.Numeric)
+	if !ok || !n.IsInteger() {
+		return 0, false
+	}
+	i, _ := n.AsInt()
+	return i, true
+}
+
+// Float returns the value of any number as a float64.
+func (v Value) Float() (float64, bool) {
+	n, ok := v.value().(values.Numeric)
+	if !ok {
+		return 0, false
+	}
+	f, _ := n.AsFloat()
+	return f, true
+}
+
+// Str returns the contents of a string.
+func (v Value) Str() (string, bool) {
+	s, ok := v.value().(values.String)
+	if !ok {
+		return "", false
+	}
+	return s.String(), true
+}
+
+// Char returns the rune of a character.
+func (v Value) Char() (rune, bool) {
+	c, ok := v.value().(values.Char)
+	if !ok {
+		return 0, false
+	}
+	return c.Rune(), true
+}
+
+// Symbol returns the name of a symbol.
+func (v Value) Symbol() (string, bool) {
+	if v.Kind() != KindSymbol {
+		return "", false
+	}
+	return v.value().DisplayString(), true
+}
+
+// Pair returns the car and cdr of a pair.
+func (v Value) Pair() (Value, Value, bool) {
+	if v.Kind() != KindPair {
+		return Void(), Void(), false
+	}
+	return newValue(values.Car(v.value())), newValue(values.Cdr(v.value())), true
+}
+
+// List returns the elements of a proper list.
+func (v Value) List() ([]Value, bool) {
+	items, err := values.ToSlice(v.value())
+	if err != nil {
+		return nil, false
+	}
+	converted := make([]Value, 0, len(items))
+	for _, item := range items {
+		converted = append(converted, newValue(item))
+	}
+	return converted, true
+}
+
+// IsProcedure reports whether the value can be applied with Apply.
+func (v Value) IsProcedure() bool {
+	_, ok := v.value().(builtins.Lambda)
+	return ok
+}